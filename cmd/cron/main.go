@@ -40,6 +40,18 @@ func cronJobs(c *cron.Cron, ctx *infra.ServiceContext) error {
 		return err
 	}
 
+	if err := holdReservedTables(c, ctx); err != nil {
+		return err
+	}
+
+	if err := sendReservationReminders(c, ctx); err != nil {
+		return err
+	}
+
+	if err := expireLoyaltyPoints(c, ctx); err != nil {
+		return err
+	}
+
 	return nil
 }
 
@@ -75,3 +87,36 @@ func addBestSeller(c *cron.Cron, ctx *infra.ServiceContext) error {
 
 	return nil
 }
+
+func holdReservedTables(c *cron.Cron, ctx *infra.ServiceContext) error {
+	if _, err := c.AddFunc("* * * * *", func() {
+		ctx.Ctl.ReservationHandler.HoldTables()
+	}); err != nil {
+		fmt.Println("Error holding reserved tables from cron:", err)
+		return err
+	}
+
+	return nil
+}
+
+func sendReservationReminders(c *cron.Cron, ctx *infra.ServiceContext) error {
+	if _, err := c.AddFunc("*/5 * * * *", func() {
+		ctx.Ctl.ReservationHandler.SendReminders()
+	}); err != nil {
+		fmt.Println("Error sending reservation reminders from cron:", err)
+		return err
+	}
+
+	return nil
+}
+
+func expireLoyaltyPoints(c *cron.Cron, ctx *infra.ServiceContext) error {
+	if _, err := c.AddFunc("30 0 * * *", func() {
+		ctx.Ctl.LoyaltyHandler.ExpirePoints()
+	}); err != nil {
+		fmt.Println("Error expiring loyalty points from cron:", err)
+		return err
+	}
+
+	return nil
+}
@@ -1,21 +1,44 @@
 package config
 
 import (
+	"errors"
 	"flag"
+	"fmt"
 	"github.com/spf13/viper"
 	"log"
+	"time"
 )
 
 type Config struct {
 	AppDebug        bool
 	DB              DatabaseConfig
 	Email           EmailConfig
+	OrderCode       OrderCodeConfig
+	TaxRounding     TaxRoundingConfig
+	Receipt         ReceiptConfig
+	Reservation     ReservationConfig
+	Loyalty         LoyaltyConfig
 	RedisConfig     RedisConfig
 	ServerPort      string
 	ShutdownTimeout int
 
 	ProfitMargin float64
 
+	// voids and refunds above this amount need a manager PIN
+	ManagerApprovalAmount float64
+
+	// tip pool points per role, e.g. "staff:1,admin:1.5"
+	TipPoolPoints string
+
+	// days a gift card stays usable after it is issued, 0 never expires
+	GiftCardExpiryDays int
+
+	// guest ratings at or below this notify the admins, 0 turns the alerts off
+	FeedbackLowRating int
+
+	// seconds the public menu stays in the cache, it is also dropped whenever the menu changes
+	MenuCacheTTL int
+
 	PrivateKey string
 	PublicKey  string
 }
@@ -37,6 +60,70 @@ type EmailConfig struct {
 	FromEmail string
 }
 
+type OrderCodeConfig struct {
+	Prefix     string
+	OutletCode string
+	DateLayout string
+	Separator  string
+	Padding    int
+	DailyReset bool
+}
+
+type TaxRoundingConfig struct {
+	Scope string
+	Mode  string
+}
+
+type ReceiptConfig struct {
+	// header and footer lines are separated by |
+	Header string
+	Footer string
+	Logo   string
+	// thermal paper width in mm, 58 or 80
+	PaperWidth int
+	// base URL of the public digital receipt, the signed token is appended
+	Url string
+	// signs the public receipt links, falls back to the JWT private key
+	Secret string
+	// days a public receipt link stays valid
+	LinkTTL int
+	// base URL of the guest feedback page of completed orders, the signed token is appended
+	FeedbackUrl string
+}
+
+type ReservationConfig struct {
+	// e.g. "11:00-22:00" every day or "mon-fri=11:00-15:00,17:00-22:00;sat,sun=10:00-23:00"
+	OpeningHours string
+	// minutes between two bookable start times
+	SlotInterval int
+	// minutes a table needs between two bookings
+	TurnTime int
+	// minutes before the booking the reminder email is sent
+	ReminderLeadTime int
+	// hours before the booking a guest cancels to get the deposit back in full
+	DepositRefundNotice int
+	// percent of the deposit kept on a cancellation within the notice and on a no-show
+	DepositLateCancelForfeit float64
+	DepositNoShowForfeit     float64
+	// base URL of the guest confirm and cancel links, the signed token is appended
+	Url string
+	// signs the guest links, falls back to the JWT private key
+	Secret string
+	// base URL of the subscribed calendar feeds, the feed token is appended
+	CalendarUrl string
+}
+
+type LoyaltyConfig struct {
+	// points earned per currency unit paid, categories can override it
+	EarnRate float64
+	// currency value of one point when redeemed
+	PointValue float64
+	// days earned points stay redeemable
+	ExpiryDays int
+	// days of earned points that count towards the membership tier
+	TierPeriodDays int
+}
+
 type RedisConfig struct {
 	Url      string
 	Password string
@@ -64,10 +151,31 @@ func LoadConfig() (Config, error) {
 
 	readFlags()
 
+	orderCode, err := loadOrderCodeConfig()
+	if err != nil {
+		return Config{}, err
+	}
+	receipt, err := loadReceiptConfig()
+	if err != nil {
+		return Config{}, err
+	}
+	reservation, err := loadReservationConfig()
+	if err != nil {
+		return Config{}, err
+	}
+
 	// add value to the config
 	config := Config{
-		DB:    loadDatabaseConfig(),
-		Email: loadEmailConfig(),
+		DB:          loadDatabaseConfig(),
+		Email:       loadEmailConfig(),
+		OrderCode:   orderCode,
+		Receipt:     receipt,
+		Reservation: reservation,
+		Loyalty:     loadLoyaltyConfig(),
+		TaxRounding: TaxRoundingConfig{
+			Scope: viper.GetString("TAX_ROUNDING_SCOPE"),
+			Mode:  viper.GetString("TAX_ROUNDING_MODE"),
+		},
 
 		AppDebug:        viper.GetBool("APP_DEBUG"),
 		ServerPort:      viper.GetString("SERVER_PORT"),
@@ -77,6 +185,12 @@ func LoadConfig() (Config, error) {
 
 		ProfitMargin: viper.GetFloat64("PROFIT_MARGIN"),
 
+		ManagerApprovalAmount: viper.GetFloat64("MANAGER_APPROVAL_AMOUNT"),
+		TipPoolPoints:         viper.GetString("TIP_POOL_POINTS"),
+		GiftCardExpiryDays:    viper.GetInt("GIFT_CARD_EXPIRY_DAYS"),
+		FeedbackLowRating:     viper.GetInt("FEEDBACK_LOW_RATING"),
+		MenuCacheTTL:          viper.GetInt("MENU_CACHE_TTL"),
+
 		RedisConfig: loadRedisConfig(),
 	}
 	return config, nil
@@ -102,6 +216,81 @@ func loadEmailConfig() EmailConfig {
 	}
 }
 
+func loadOrderCodeConfig() (OrderCodeConfig, error) {
+	cfg := OrderCodeConfig{
+		Prefix:     viper.GetString("ORDER_CODE_PREFIX"),
+		OutletCode: viper.GetString("ORDER_CODE_OUTLET"),
+		DateLayout: viper.GetString("ORDER_CODE_DATE_LAYOUT"),
+		Separator:  viper.GetString("ORDER_CODE_SEPARATOR"),
+		Padding:    viper.GetInt("ORDER_CODE_PADDING"),
+		DailyReset: viper.GetBool("ORDER_CODE_DAILY_RESET"),
+	}
+	// numbers restart every day, without the date in the code they would repeat
+	if cfg.DailyReset && !datedLayout(cfg.DateLayout) {
+		return cfg, fmt.Errorf("ORDER_CODE_DAILY_RESET needs an ORDER_CODE_DATE_LAYOUT with the year, month and day, got %q", cfg.DateLayout)
+	}
+	return cfg, nil
+}
+
+// datedLayout reports whether a time layout gives every day its own text
+func datedLayout(layout string) bool {
+	day := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	for _, other := range []time.Time{day.AddDate(0, 0, 1), day.AddDate(0, 1, 0), day.AddDate(1, 0, 0)} {
+		if other.Format(layout) == day.Format(layout) {
+			return false
+		}
+	}
+	return true
+}
+
+func loadReceiptConfig() (ReceiptConfig, error) {
+	// anyone holding the key can forge receipt links, so it is never shared or left empty
+	secret := viper.GetString("RECEIPT_SECRET")
+	if secret == "" {
+		return ReceiptConfig{}, errors.New("RECEIPT_SECRET is required to sign the public receipt links")
+	}
+	return ReceiptConfig{
+		Header:      viper.GetString("RECEIPT_HEADER"),
+		Footer:      viper.GetString("RECEIPT_FOOTER"),
+		Logo:        viper.GetString("RECEIPT_LOGO"),
+		PaperWidth:  viper.GetInt("RECEIPT_PAPER_WIDTH"),
+		Url:         viper.GetString("RECEIPT_URL"),
+		Secret:      secret,
+		LinkTTL:     viper.GetInt("RECEIPT_LINK_TTL_DAYS"),
+		FeedbackUrl: viper.GetString("RECEIPT_FEEDBACK_URL"),
+	}, nil
+}
+
+func loadReservationConfig() (ReservationConfig, error) {
+	// the guest links confirm and cancel bookings, so they get a key of their own
+	secret := viper.GetString("RESERVATION_SECRET")
+	if secret == "" {
+		return ReservationConfig{}, errors.New("RESERVATION_SECRET is required to sign the guest reservation links")
+	}
+	return ReservationConfig{
+		OpeningHours:     viper.GetString("RESERVATION_OPENING_HOURS"),
+		SlotInterval:     viper.GetInt("RESERVATION_SLOT_INTERVAL"),
+		TurnTime:         viper.GetInt("RESERVATION_TURN_TIME"),
+		ReminderLeadTime: viper.GetInt("RESERVATION_REMINDER_LEAD_TIME"),
+		Url:              viper.GetString("RESERVATION_URL"),
+		Secret:           secret,
+		CalendarUrl:      viper.GetString("RESERVATION_CALENDAR_URL"),
+
+		DepositRefundNotice:      viper.GetInt("RESERVATION_DEPOSIT_REFUND_NOTICE"),
+		DepositLateCancelForfeit: viper.GetFloat64("RESERVATION_DEPOSIT_LATE_CANCEL_FORFEIT"),
+		DepositNoShowForfeit:     viper.GetFloat64("RESERVATION_DEPOSIT_NO_SHOW_FORFEIT"),
+	}, nil
+}
+
+func loadLoyaltyConfig() LoyaltyConfig {
+	return LoyaltyConfig{
+		EarnRate:       viper.GetFloat64("LOYALTY_EARN_RATE"),
+		PointValue:     viper.GetFloat64("LOYALTY_POINT_VALUE"),
+		ExpiryDays:     viper.GetInt("LOYALTY_POINTS_EXPIRY_DAYS"),
+		TierPeriodDays: viper.GetInt("LOYALTY_TIER_PERIOD_DAYS"),
+	}
+}
+
 func loadRedisConfig() RedisConfig {
 	return RedisConfig{
 		Url:      viper.GetString("REDIS_URL"),
@@ -122,6 +311,36 @@ func setDefaultValues() {
 	viper.SetDefault("SHUTDOWN_TIMEOUT", 5)
 
 	viper.SetDefault("PROFIT_MARGIN", 10.00)
+	viper.SetDefault("MANAGER_APPROVAL_AMOUNT", 50.00)
+	viper.SetDefault("TIP_POOL_POINTS", "staff:1,admin:1,super admin:1")
+	viper.SetDefault("GIFT_CARD_EXPIRY_DAYS", 365)
+	viper.SetDefault("FEEDBACK_LOW_RATING", 2)
+	viper.SetDefault("MENU_CACHE_TTL", 300)
+
+	viper.SetDefault("ORDER_CODE_PREFIX", "ORD")
+	viper.SetDefault("ORDER_CODE_PADDING", 4)
+	viper.SetDefault("ORDER_CODE_DAILY_RESET", false)
+
+	viper.SetDefault("TAX_ROUNDING_SCOPE", "line")
+	viper.SetDefault("TAX_ROUNDING_MODE", "half_up")
+
+	viper.SetDefault("RECEIPT_HEADER", "")
+	viper.SetDefault("RECEIPT_FOOTER", "Thank you for your visit!")
+	viper.SetDefault("RECEIPT_PAPER_WIDTH", 80)
+	viper.SetDefault("RECEIPT_LINK_TTL_DAYS", 30)
+
+	viper.SetDefault("RESERVATION_OPENING_HOURS", "10:00-22:00")
+	viper.SetDefault("RESERVATION_SLOT_INTERVAL", 15)
+	viper.SetDefault("RESERVATION_TURN_TIME", 15)
+	viper.SetDefault("RESERVATION_REMINDER_LEAD_TIME", 1440)
+	viper.SetDefault("RESERVATION_DEPOSIT_REFUND_NOTICE", 24)
+	viper.SetDefault("RESERVATION_DEPOSIT_LATE_CANCEL_FORFEIT", 100)
+	viper.SetDefault("RESERVATION_DEPOSIT_NO_SHOW_FORFEIT", 100)
+
+	viper.SetDefault("LOYALTY_EARN_RATE", 1)
+	viper.SetDefault("LOYALTY_POINT_VALUE", 0.01)
+	viper.SetDefault("LOYALTY_POINTS_EXPIRY_DAYS", 365)
+	viper.SetDefault("LOYALTY_TIER_PERIOD_DAYS", 365)
 
 	viper.SetDefault("DB_MIGRATE", false)
 	viper.SetDefault("DB_SEEDING", false)
@@ -5,6 +5,7 @@ import (
 	"log"
 	"os"
 	"project/config"
+	"project/domain"
 	"time"
 
 	"gorm.io/driver/postgres"
@@ -46,7 +47,7 @@ func ConnectDB(cfg config.Config) (*gorm.DB, error) {
 
 	// Call See function to auto-migrate database schemas
 	if cfg.DB.Seeding {
-		err = SeedAll(db)
+		err = SeedAll(db, OrderCodeFormat(cfg.OrderCode))
 	}
 	if err != nil {
 		return nil, err
@@ -55,6 +56,18 @@ func ConnectDB(cfg config.Config) (*gorm.DB, error) {
 	return db, nil
 }
 
+// OrderCodeFormat is the order code format set in the configuration
+func OrderCodeFormat(cfg config.OrderCodeConfig) domain.OrderCodeFormat {
+	return domain.OrderCodeFormat{
+		Prefix:     cfg.Prefix,
+		OutletCode: cfg.OutletCode,
+		DateLayout: cfg.DateLayout,
+		Separator:  cfg.Separator,
+		Padding:    cfg.Padding,
+		DailyReset: cfg.DailyReset,
+	}
+}
+
 // makePostgresString creates the PostgreSQL DSN (Data Source Name)
 func makePostgresString(cfg config.Config) string {
 	return fmt.Sprintf("host=%s port=%s user=%s dbname=%s password=%s sslmode=disable",
@@ -85,6 +98,14 @@ func createCustomDBTypes(db *gorm.DB) error {
 		return err
 	}
 
+	if err = db.Exec(`ALTER TYPE status_kitchen ADD VALUE IF NOT EXISTS 'Served'`).Error; err != nil {
+		return err
+	}
+
+	if err = db.Exec(`CREATE SEQUENCE IF NOT EXISTS order_code_seq`).Error; err != nil {
+		return err
+	}
+
 	return nil
 
 }
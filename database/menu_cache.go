@@ -0,0 +1,50 @@
+package database
+
+import "gorm.io/gorm"
+
+// MenuCacheKey is where the public menu is cached
+const MenuCacheKey = "menu"
+
+// menuTables are the tables the public menu is built from
+var menuTables = map[string]bool{"products": true, "categories": true, "product_modifiers": true}
+
+// stockColumns are what a sale or a cancellation changes on a product. The cached menu holds the
+// products whatever their stock, sold out ones are taken off when it is served.
+var stockColumns = map[string]bool{"stock": true, "availability": true, "version": true, "updated_at": true}
+
+// RegisterMenuInvalidation drops the cached menu whenever a product, category or modifier is written,
+// except for stock changes. The callbacks run before the write is committed, so a request in between
+// may cache the old menu again; repositories writing the menu drop it once more after commit.
+func RegisterMenuInvalidation(db *gorm.DB, cacher Cacher) error {
+	invalidate := func(tx *gorm.DB) {
+		if tx.Error != nil || !menuTables[tx.Statement.Table] || stockOnly(tx.Statement) {
+			return
+		}
+		if err := cacher.Delete(MenuCacheKey); err != nil {
+			tx.Logger.Warn(tx.Statement.Context, "failed to drop cached menu: %v", err)
+		}
+	}
+
+	callbacks := db.Callback()
+	if err := callbacks.Create().After("gorm:create").Register("menu:invalidate", invalidate); err != nil {
+		return err
+	}
+	if err := callbacks.Update().After("gorm:update").Register("menu:invalidate", invalidate); err != nil {
+		return err
+	}
+	return callbacks.Delete().After("gorm:delete").Register("menu:invalidate", invalidate)
+}
+
+// stockOnly reports whether an update writes nothing but stock columns
+func stockOnly(stmt *gorm.Statement) bool {
+	columns, ok := stmt.Dest.(map[string]interface{})
+	if !ok || len(columns) == 0 {
+		return false
+	}
+	for column := range columns {
+		if !stockColumns[column] {
+			return false
+		}
+	}
+	return true
+}
@@ -28,15 +28,41 @@ func autoMigrates(db *gorm.DB) error {
 	return db.AutoMigrate(
 		&domain.Permission{},
 		&domain.User{},
-		&domain.Reservation{},
 		&domain.Notification{},
+		&domain.TaxRate{},
 		&domain.Category{},
 		&domain.Product{},
 		// &domain.Inventory{},
 		&domain.Table{},
+		&domain.Customer{},
+		&domain.Reservation{},
+		&domain.ReservationTable{},
+		&domain.ReservationStatusHistory{},
+		&domain.CalendarFeed{},
 		&domain.PaymentMethod{},
 		&domain.Order{},
+		&domain.OrderTable{},
+		&domain.WaitlistEntry{},
 		&domain.OrderItem{},
+		&domain.OrderSequence{},
+		&domain.OrderStatusHistory{},
+		&domain.Payment{},
+		&domain.OrderVoid{},
+		&domain.Refund{},
+		&domain.RefundItem{},
+		&domain.PromoCode{},
+		&domain.PricingRule{},
+		&domain.OrderDiscount{},
+		&domain.OrderTaxLine{},
+		&domain.ServiceChargeRule{},
+		&domain.LoyaltyTier{},
+		&domain.LoyaltyEntry{},
+		&domain.GiftCard{},
+		&domain.GiftCardTransaction{},
+		&domain.Feedback{},
+		&domain.FeedbackItem{},
+		&domain.ProductModifier{},
+		&domain.TimeClock{},
 		&domain.PasswordResetToken{},
 		&domain.BestSeller{},
 	)
@@ -47,13 +73,41 @@ func dropTables(db *gorm.DB) error {
 		&domain.PasswordResetToken{},
 		&domain.User{},
 		&domain.Reservation{},
+		&domain.ReservationTable{},
+		&domain.ReservationStatusHistory{},
+		&domain.CalendarFeed{},
+		&domain.LoyaltyEntry{},
+		&domain.LoyaltyTier{},
+		&domain.Customer{},
 		&domain.Notification{},
 		&domain.Category{},
+		&domain.ProductModifier{},
 		&domain.Product{},
 		&domain.Table{},
 		&domain.PaymentMethod{},
 		&domain.Order{},
+		&domain.OrderTable{},
+		&domain.WaitlistEntry{},
 		&domain.OrderItem{},
+		&domain.OrderSequence{},
+		&domain.OrderStatusHistory{},
+		&domain.Payment{},
+		&domain.OrderVoid{},
+		&domain.Refund{},
+		&domain.RefundItem{},
+		&domain.GiftCardTransaction{},
+		&domain.GiftCard{},
+		&domain.FeedbackItem{},
+		&domain.Feedback{},
+		&domain.PromoCode{},
+		&domain.PricingRule{},
+		&domain.OrderDiscount{},
+		&domain.OrderTaxLine{},
+		&domain.ServiceChargeRule{},
+		&domain.TimeClock{},
+		"category_tax_rates",
+		"product_tax_rates",
+		&domain.TaxRate{},
 		&domain.Permission{},
 		"user_permissions",
 		&domain.UserNotification{},
@@ -89,7 +143,8 @@ func queryOrderDetail(db *gorm.DB) error {
 
 	query := db.Raw(`
 	SELECT 
-    o.id AS order_id, o.name, o.code_order, o.status_payment, o.status_kitchen, t.name AS table_name, pm.name AS payment_method_name,
+    o.id AS order_id, o.name, o.type, o.party_size, o.customer_phone, o.delivery_address, o.promised_at, o.created_at, o.code_order, o.queue_number, o.status_payment, o.status_kitchen, o.receipt_prints, o.version, t.name AS table_name, pm.name AS payment_method_name,
+	COALESCE((SELECT string_agg(jt.name, ', ' ORDER BY jt.name) FROM order_tables ot JOIN tables jt ON jt.id = ot.table_id WHERE ot.order_id = o.id), '') AS joined_tables,
 	to_char(o.created_at, 'FMDay, DD-Mon-YYYY') as date_order,
     to_char(o.created_at, 'FMHH12:MI AM') as time_order,
    	jsonb_agg(
@@ -98,10 +153,30 @@ func queryOrderDetail(db *gorm.DB) error {
             'product_name', p.name,
             'product_price', p.price,
             'quantity', oi.quantity,
-            'sub_total', (oi.quantity * p.price)
+            'sub_total', (oi.quantity * p.price),
+            'discount', COALESCE((SELECT SUM(d.amount) FROM order_discounts d WHERE d.order_item_id = oi.id), 0)
         )
     ) AS order_items,
-    SUM(oi.quantity * p.price) as total
+    SUM(oi.quantity * p.price) as total,
+	COALESCE((SELECT jsonb_agg(jsonb_build_object('id', d.id, 'order_item_id', d.order_item_id, 'source', d.source, 'name', d.name, 'type', d.type, 'value', d.value, 'amount', d.amount) ORDER BY d.id)
+		FROM order_discounts d WHERE d.order_id = o.id), '[]'::jsonb) AS discounts,
+	COALESCE((SELECT SUM(d.amount) FROM order_discounts d WHERE d.order_id = o.id), 0) AS discount,
+	COALESCE((SELECT jsonb_agg(jsonb_build_object('code', tl.code, 'name', tl.name, 'rate', tl.rate, 'inclusive', tl.inclusive, 'taxable_amount', tl.taxable_amount, 'amount', tl.amount) ORDER BY tl.tax_rate_id)
+		FROM order_tax_lines tl WHERE tl.order_id = o.id), '[]'::jsonb) AS tax_lines,
+	COALESCE((SELECT SUM(tl.amount) FROM order_tax_lines tl WHERE tl.order_id = o.id), 0) AS tax,
+	o.service_charge,
+	o.delivery_fee,
+	COALESCE(SUM(oi.quantity * p.price), 0)
+		- COALESCE((SELECT SUM(d.amount) FROM order_discounts d WHERE d.order_id = o.id), 0)
+		+ COALESCE((SELECT SUM(tl.amount) FROM order_tax_lines tl WHERE tl.order_id = o.id AND NOT tl.inclusive), 0)
+		+ o.service_charge + o.delivery_fee AS grand_total,
+	COALESCE((SELECT SUM(rf.amount) FROM refunds rf WHERE rf.order_id = o.id), 0) AS refunded,
+	COALESCE(SUM(oi.quantity * p.price), 0)
+		- COALESCE((SELECT SUM(d.amount) FROM order_discounts d WHERE d.order_id = o.id), 0)
+		+ COALESCE((SELECT SUM(tl.amount) FROM order_tax_lines tl WHERE tl.order_id = o.id AND NOT tl.inclusive), 0)
+		+ o.service_charge + o.delivery_fee
+		- COALESCE((SELECT SUM(rf.amount) FROM refunds rf WHERE rf.order_id = o.id), 0) AS net_total,
+	COALESCE((SELECT SUM(pay.tip) FROM payments pay WHERE pay.order_id = o.id AND pay.type = 'payment'), 0) AS tips
 	FROM orders o
 	LEFT JOIN tables t ON o.table_id = t.id
 	LEFT JOIN payment_methods pm ON o.payment_method_id = pm.id
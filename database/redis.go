@@ -57,6 +57,11 @@ func (c *Cacher) Set(name string, value string) error {
 	return c.rdb.Set(context.Background(), c.prefix+"_"+name, value, c.expiry).Err()
 }
 
+// SetFor stores a value that expires after ttl instead of the default expiry
+func (c *Cacher) SetFor(name string, value string, ttl time.Duration) error {
+	return c.rdb.Set(context.Background(), c.prefix+"_"+name, value, ttl).Err()
+}
+
 func (c *Cacher) SaveToken(name string, value string) error {
 	return c.rdb.Set(context.Background(), c.prefix+"_"+name, value, 24*time.Hour).Err()
 }
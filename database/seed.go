@@ -10,8 +10,9 @@ import (
 	"gorm.io/gorm/clause"
 )
 
-func SeedAll(db *gorm.DB) error {
-	return db.Transaction(func(tx *gorm.DB) error {
+// SeedAll inserts the seed data, seeded orders get their codes in codeFormat
+func SeedAll(db *gorm.DB, codeFormat domain.OrderCodeFormat) error {
+	return db.Set(domain.OrderCodeFormatKey, codeFormat).Transaction(func(tx *gorm.DB) error {
 		seeds := dataSeeds()
 		for _, seed := range seeds {
 			err := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(seed).Error
@@ -27,13 +28,15 @@ func SeedAll(db *gorm.DB) error {
 
 func dataSeeds() []interface{} {
 	return []interface{}{
-		domain.ReservationSeed(),
 		domain.NotificationSeed(),
+		seeder.TaxRateSeed(),
 		seeder.CategorySeed(),
 		seeder.ProductSeed(),
 		// domain.InventorySeed(),
 		seeder.TableSeed(),
+		domain.ReservationSeed(),
 		seeder.PaymentMethodSeed(),
+		seeder.LoyaltyTierSeed(),
 		seeder.OrderSeed(),
 		seeder.Permission(),
 		seeder.User(),
@@ -0,0 +1,306 @@
+package domain
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+var ErrInvalidOpeningHours = errors.New("invalid opening hours")
+
+// TimeRange is a period of the day from midnight. To is past 24h when it closes after midnight.
+type TimeRange struct {
+	From time.Duration
+	To   time.Duration
+}
+
+// OpeningHours are the periods guests can be seated on every weekday, a day without periods is closed
+type OpeningHours map[time.Weekday][]TimeRange
+
+// ReservationPolicy is how the restaurant takes bookings
+type ReservationPolicy struct {
+	Hours OpeningHours
+	// SlotInterval is the minutes between two bookable start times
+	SlotInterval int
+	// TurnTime is the minutes a table needs between two bookings to be cleared and laid again
+	TurnTime int
+	Deposit  DepositPolicy
+}
+
+var DefaultReservationPolicy = ReservationPolicy{
+	Hours:        everyDay(TimeRange{From: 10 * time.Hour, To: 22 * time.Hour}),
+	SlotInterval: 15,
+	TurnTime:     15,
+	Deposit:      DefaultDepositPolicy,
+}
+
+// AvailabilityQuery is a search for tables for a party on a day, optionally only in a section or
+// only inside or outside
+type AvailabilityQuery struct {
+	Date     time.Time
+	Pax      uint
+	Duration int
+	Section  string
+	Outdoor  *bool
+}
+
+// TableOption is a table, or tables pushed together for a large party, that is free for a slot
+type TableOption struct {
+	TableIDs []uint   `json:"table_ids" example:"3"`
+	Names    []string `json:"names" example:"Table C"`
+	Section  string   `json:"section" example:"Main Hall"`
+	Capacity int      `json:"capacity" example:"4"`
+}
+
+type AvailableSlot struct {
+	Time   string        `json:"time" example:"19:00"`
+	Start  time.Time     `json:"start"`
+	Tables []TableOption `json:"tables"`
+}
+
+var weekdays = map[string]time.Weekday{
+	"sun": time.Sunday, "mon": time.Monday, "tue": time.Tuesday, "wed": time.Wednesday,
+	"thu": time.Thursday, "fri": time.Friday, "sat": time.Saturday,
+}
+
+// ParseOpeningHours reads opening hours such as "11:00-22:00" for every day, or days followed by
+// their periods separated by semicolons: "mon-fri=11:00-15:00,17:00-22:00;sat,sun=10:00-23:00".
+// Days left out are closed.
+func ParseOpeningHours(s string) (OpeningHours, error) {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return nil, fmt.Errorf("%w: empty", ErrInvalidOpeningHours)
+	}
+
+	hours := OpeningHours{}
+	for _, entry := range strings.Split(s, ";") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+
+		days := []time.Weekday{time.Sunday, time.Monday, time.Tuesday, time.Wednesday, time.Thursday, time.Friday, time.Saturday}
+		periods := entry
+		if dayList, rest, ok := strings.Cut(entry, "="); ok {
+			var err error
+			if days, err = parseWeekdays(dayList); err != nil {
+				return nil, err
+			}
+			periods = rest
+		}
+
+		for _, period := range strings.Split(periods, ",") {
+			r, err := parseTimeRange(strings.TrimSpace(period))
+			if err != nil {
+				return nil, err
+			}
+			for _, day := range days {
+				hours[day] = append(hours[day], r)
+			}
+		}
+	}
+
+	for day := range hours {
+		sort.Slice(hours[day], func(i, j int) bool { return hours[day][i].From < hours[day][j].From })
+	}
+	return hours, nil
+}
+
+func parseWeekdays(s string) ([]time.Weekday, error) {
+	var days []time.Weekday
+	for _, part := range strings.Split(s, ",") {
+		part = strings.ToLower(strings.TrimSpace(part))
+		first, last, isRange := strings.Cut(part, "-")
+		from, ok := weekdays[first]
+		if !ok {
+			return nil, fmt.Errorf("%w: unknown day %q", ErrInvalidOpeningHours, first)
+		}
+		to := from
+		if isRange {
+			if to, ok = weekdays[last]; !ok {
+				return nil, fmt.Errorf("%w: unknown day %q", ErrInvalidOpeningHours, last)
+			}
+		}
+		// ranges wrap around the week, so fri-mon is friday to monday
+		for day := from; ; day = (day + 1) % 7 {
+			days = append(days, day)
+			if day == to {
+				break
+			}
+		}
+	}
+	return days, nil
+}
+
+func parseTimeRange(s string) (TimeRange, error) {
+	from, to, ok := strings.Cut(s, "-")
+	if !ok {
+		return TimeRange{}, fmt.Errorf("%w: %q is not a period like 11:00-22:00", ErrInvalidOpeningHours, s)
+	}
+	start, err := parseClock(from)
+	if err != nil {
+		return TimeRange{}, err
+	}
+	end, err := parseClock(to)
+	if err != nil {
+		return TimeRange{}, err
+	}
+	if end <= start {
+		end += 24 * time.Hour
+	}
+	return TimeRange{From: start, To: end}, nil
+}
+
+func parseClock(s string) (time.Duration, error) {
+	t, err := time.Parse("15:04", strings.TrimSpace(s))
+	if err != nil {
+		return 0, fmt.Errorf("%w: %q is not a time like 17:30", ErrInvalidOpeningHours, s)
+	}
+	return time.Duration(t.Hour())*time.Hour + time.Duration(t.Minute())*time.Minute, nil
+}
+
+func everyDay(r TimeRange) OpeningHours {
+	hours := OpeningHours{}
+	for day := time.Sunday; day <= time.Saturday; day++ {
+		hours[day] = []TimeRange{r}
+	}
+	return hours
+}
+
+// Slots are the start times a booking of duration minutes can take on the day of date, finishing
+// before the restaurant closes
+func (p ReservationPolicy) Slots(date time.Time, duration int) []time.Time {
+	day := time.Date(date.Year(), date.Month(), date.Day(), 0, 0, 0, 0, date.Location())
+	interval := time.Duration(p.SlotInterval) * time.Minute
+	if interval <= 0 {
+		interval = time.Duration(DefaultReservationPolicy.SlotInterval) * time.Minute
+	}
+	length := time.Duration(duration) * time.Minute
+
+	var slots []time.Time
+	for _, period := range p.Hours[day.Weekday()] {
+		for at := period.From; at+length <= period.To; at += interval {
+			slots = append(slots, day.Add(at))
+		}
+	}
+	return slots
+}
+
+// Bookable checks that the reservation starts on one of the slots of its day and finishes before
+// the restaurant closes. A start after midnight may fall in a period opened the day before.
+func (p ReservationPolicy) Bookable(r *Reservation) error {
+	start, err := r.Start()
+	if err != nil {
+		return err
+	}
+	duration := r.Duration
+	if duration <= 0 {
+		duration = DefaultReservationDuration
+	}
+	for _, day := range []time.Time{start, start.AddDate(0, 0, -1)} {
+		for _, slot := range p.Slots(day, duration) {
+			if slot.Equal(start) {
+				return nil
+			}
+		}
+	}
+	return fmt.Errorf("%w: %s for %d minutes is outside the opening hours or between slots",
+		ErrInvalidReservation, start.Format("Mon 15:04"), duration)
+}
+
+// Availability lists the slots of the day where the party can be seated, with the free tables for
+// each. A table is free when none of the reservations, widened by the turn time, overlaps the slot.
+// Single tables that seat the party come first, smallest first. Only when none is free are tables
+// of one section combined, largest first, until they seat the party.
+func (p ReservationPolicy) Availability(q AvailabilityQuery, tables []Table, reservations []Reservation, now time.Time) []AvailableSlot {
+	duration := q.Duration
+	if duration <= 0 {
+		duration = DefaultReservationDuration
+	}
+	turn := time.Duration(p.TurnTime) * time.Minute
+
+	slots := []AvailableSlot{}
+	for _, start := range p.Slots(q.Date, duration) {
+		if start.Before(now) {
+			continue
+		}
+		end := start.Add(time.Duration(duration) * time.Minute)
+
+		var free []Table
+		for _, table := range tables {
+			if !tableBooked(table.ID, reservations, start.Add(-turn), end.Add(turn)) {
+				free = append(free, table)
+			}
+		}
+
+		options := singleTables(free, q.Pax)
+		if len(options) == 0 {
+			options = combinedTables(free, q.Pax)
+		}
+		if len(options) > 0 {
+			slots = append(slots, AvailableSlot{Time: start.Format("15:04"), Start: start, Tables: options})
+		}
+	}
+	return slots
+}
+
+func tableBooked(tableID uint, reservations []Reservation, start, end time.Time) bool {
+	for i := range reservations {
+		if reservations[i].Status.Holds() && reservations[i].Books(tableID) && reservations[i].Overlaps(start, end) {
+			return true
+		}
+	}
+	return false
+}
+
+func singleTables(free []Table, pax uint) []TableOption {
+	var fitting []Table
+	for _, table := range free {
+		if table.Capacity >= int(pax) {
+			fitting = append(fitting, table)
+		}
+	}
+	sort.SliceStable(fitting, func(i, j int) bool { return fitting[i].Capacity < fitting[j].Capacity })
+
+	options := make([]TableOption, 0, len(fitting))
+	for _, table := range fitting {
+		options = append(options, TableOption{
+			TableIDs: []uint{table.ID},
+			Names:    []string{table.Name},
+			Section:  table.Section,
+			Capacity: table.Capacity,
+		})
+	}
+	return options
+}
+
+func combinedTables(free []Table, pax uint) []TableOption {
+	sections := map[string][]Table{}
+	var order []string
+	for _, table := range free {
+		if _, ok := sections[table.Section]; !ok {
+			order = append(order, table.Section)
+		}
+		sections[table.Section] = append(sections[table.Section], table)
+	}
+
+	var options []TableOption
+	for _, section := range order {
+		candidates := sections[section]
+		sort.SliceStable(candidates, func(i, j int) bool { return candidates[i].Capacity > candidates[j].Capacity })
+
+		option := TableOption{Section: section}
+		for _, table := range candidates {
+			option.TableIDs = append(option.TableIDs, table.ID)
+			option.Names = append(option.Names, table.Name)
+			option.Capacity += table.Capacity
+			if option.Capacity >= int(pax) {
+				options = append(options, option)
+				break
+			}
+		}
+	}
+	return options
+}
@@ -0,0 +1,129 @@
+package domain_test
+
+import (
+	"project/domain"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/datatypes"
+)
+
+func TestParseOpeningHours(t *testing.T) {
+	hours, err := domain.ParseOpeningHours("mon-fri=11:00-15:00,17:00-22:00;sat,sun=18:00-02:00")
+	require.NoError(t, err)
+
+	assert.Equal(t, []domain.TimeRange{
+		{From: 11 * time.Hour, To: 15 * time.Hour},
+		{From: 17 * time.Hour, To: 22 * time.Hour},
+	}, hours[time.Wednesday])
+	assert.Equal(t, []domain.TimeRange{{From: 18 * time.Hour, To: 26 * time.Hour}}, hours[time.Sunday], "closing after midnight")
+
+	hours, err = domain.ParseOpeningHours("12:00-23:00")
+	require.NoError(t, err)
+	assert.Len(t, hours, 7)
+
+	for _, invalid := range []string{"", "noon-23:00", "funday=12:00-23:00", "12:00"} {
+		_, err := domain.ParseOpeningHours(invalid)
+		assert.ErrorIs(t, err, domain.ErrInvalidOpeningHours, invalid)
+	}
+}
+
+func TestReservationPolicy_Slots(t *testing.T) {
+	hours, err := domain.ParseOpeningHours("mon=17:00-19:00")
+	require.NoError(t, err)
+	policy := domain.ReservationPolicy{Hours: hours, SlotInterval: 30}
+
+	monday := time.Date(2024, 12, 16, 0, 0, 0, 0, time.Local)
+	var slots []string
+	for _, slot := range policy.Slots(monday, 60) {
+		slots = append(slots, slot.Format("15:04"))
+	}
+	assert.Equal(t, []string{"17:00", "17:30", "18:00"}, slots, "the last booking ends at closing")
+	assert.Empty(t, policy.Slots(monday.AddDate(0, 0, 1), 60), "closed on tuesday")
+}
+
+func TestReservationPolicy_Bookable(t *testing.T) {
+	hours, err := domain.ParseOpeningHours("mon=17:00-19:00;sat=22:00-02:00")
+	require.NoError(t, err)
+	policy := domain.ReservationPolicy{Hours: hours, SlotInterval: 30}
+
+	tests := []struct {
+		name     string
+		date     string
+		at       datatypes.Time
+		duration int
+		bookable bool
+	}{
+		{"On a slot", "2024-12-16", datatypes.NewTime(17, 30, 0, 0), 60, true},
+		{"Between slots", "2024-12-16", datatypes.NewTime(17, 15, 0, 0), 60, false},
+		{"Ends after closing", "2024-12-16", datatypes.NewTime(18, 30, 0, 0), 60, false},
+		{"Before opening", "2024-12-16", datatypes.NewTime(16, 30, 0, 0), 60, false},
+		{"Closed day", "2024-12-17", datatypes.NewTime(17, 0, 0, 0), 60, false},
+		{"After midnight of the day before", "2024-12-22", datatypes.NewTime(0, 30, 0, 0), 60, true},
+		{"Default duration", "2024-12-16", datatypes.NewTime(17, 30, 0, 0), 0, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := &domain.Reservation{ReservationDate: tt.date, ReservationTime: tt.at, Duration: tt.duration}
+			err := policy.Bookable(r)
+			if tt.bookable {
+				assert.NoError(t, err)
+			} else {
+				assert.ErrorIs(t, err, domain.ErrInvalidReservation)
+			}
+		})
+	}
+}
+
+func TestReservationPolicy_Availability(t *testing.T) {
+	hours, err := domain.ParseOpeningHours("18:00-21:00")
+	require.NoError(t, err)
+	policy := domain.ReservationPolicy{Hours: hours, SlotInterval: 60, TurnTime: 30}
+
+	date := time.Date(2024, 12, 16, 0, 0, 0, 0, time.Local)
+	tables := []domain.Table{
+		{ID: 1, Name: "Table A", Capacity: 2, Section: "Main Hall"},
+		{ID: 2, Name: "Table C", Capacity: 4, Section: "Main Hall"},
+		{ID: 3, Name: "Table E", Capacity: 6, Section: "Main Hall"},
+		{ID: 4, Name: "Table H", Capacity: 4, Section: "Terrace"},
+	}
+	reservations := []domain.Reservation{
+		{TableID: 2, Status: domain.ReservationConfirmed, ReservationDate: "2024-12-16", ReservationTime: datatypes.NewTime(18, 0, 0, 0), Duration: 60},
+		{TableID: 4, Status: domain.ReservationCanceled, ReservationDate: "2024-12-16", ReservationTime: datatypes.NewTime(18, 0, 0, 0), Duration: 60},
+	}
+
+	t.Run("Single tables", func(t *testing.T) {
+		slots := policy.Availability(domain.AvailabilityQuery{Date: date, Pax: 4, Duration: 60}, tables, reservations, date)
+		require.Len(t, slots, 3)
+
+		// table C is booked until 19:00 and needs the turn time before the next booking
+		assert.Equal(t, "18:00", slots[0].Time)
+		assert.Equal(t, []uint{4}, slots[0].Tables[0].TableIDs, "the cancelled booking does not hold table H")
+		assert.Equal(t, []uint{3}, slots[0].Tables[1].TableIDs)
+		assert.Len(t, slots[1].Tables, 2)
+		assert.Len(t, slots[2].Tables, 3)
+		assert.Equal(t, []uint{2}, slots[2].Tables[0].TableIDs, "smallest fitting table first")
+	})
+
+	t.Run("Combined tables", func(t *testing.T) {
+		slots := policy.Availability(domain.AvailabilityQuery{Date: date, Pax: 11, Duration: 60}, tables, reservations, date)
+		// until table C is cleared after its booking the main hall seats 8
+		require.Len(t, slots, 1)
+		assert.Equal(t, "20:00", slots[0].Time)
+		assert.Equal(t, []domain.TableOption{{
+			TableIDs: []uint{3, 2, 1},
+			Names:    []string{"Table E", "Table C", "Table A"},
+			Section:  "Main Hall",
+			Capacity: 12,
+		}}, slots[0].Tables)
+	})
+
+	t.Run("Past slots", func(t *testing.T) {
+		now := date.Add(19*time.Hour + 30*time.Minute)
+		slots := policy.Availability(domain.AvailabilityQuery{Date: date, Pax: 2, Duration: 60}, tables, reservations, now)
+		require.Len(t, slots, 1)
+		assert.Equal(t, "20:00", slots[0].Time)
+	})
+}
@@ -9,6 +9,10 @@ type Category struct {
 	Icon        string    `gorm:"size:255;not null" json:"icon,omitempty" example:"/icon/category.png"`
 	Name        string    `gorm:"size:100;unique" json:"name"`
 	Description string    `gorm:"type:text" example:"lorem" json:"description,omitempty"`
-	CreatedAt   time.Time `gorm:"autoCreateTime" json:"created_at" swaggerignore:"true"`
-	UpdatedAt   time.Time `gorm:"autoUpdateTime" json:"updated_at" swaggerignore:"true"`
+	Station     string    `gorm:"size:50;not null;default:'kitchen'" json:"station" example:"grill"`
+	TaxRates    []TaxRate `gorm:"many2many:category_tax_rates" json:"tax_rates,omitempty" swaggerignore:"true"`
+	// LoyaltyEarnRate is the points per currency unit earned on the category, nil for the default rate
+	LoyaltyEarnRate *float64  `gorm:"type:decimal(6,2)" json:"loyalty_earn_rate" swaggerignore:"true"`
+	CreatedAt       time.Time `gorm:"autoCreateTime" json:"created_at" swaggerignore:"true"`
+	UpdatedAt       time.Time `gorm:"autoUpdateTime" json:"updated_at" swaggerignore:"true"`
 }
@@ -0,0 +1,217 @@
+package domain
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+	"unicode"
+
+	"gorm.io/gorm"
+)
+
+var (
+	ErrCustomerNotFound  = errors.New("customer not found")
+	ErrInvalidCustomer   = errors.New("invalid customer")
+	ErrDuplicateCustomer = errors.New("another customer has this phone number or email address")
+)
+
+// Customer is a guest known by phone number or email address. Reservations and orders of the same
+// guest link to one customer, so spend and visits add up across them.
+type Customer struct {
+	ID   uint   `gorm:"primaryKey" json:"id" swaggerignore:"true"`
+	Name string `gorm:"size:100;not null" json:"name" binding:"required,max=100" example:"John Doe"`
+	// Phone and Email are stored normalized, each belongs to one customer at most
+	Phone     string `gorm:"size:20;index:idx_customers_phone,unique,where:phone <> '' AND deleted_at IS NULL" json:"phone" binding:"max=20" example:"+62895123456"`
+	Email     string `gorm:"size:100;index:idx_customers_email,unique,where:email <> '' AND deleted_at IS NULL" json:"email" binding:"omitempty,email,max=100" example:"john@example.com"`
+	Notes     string `gorm:"type:text" json:"notes" example:"Prefers a window table"`
+	Allergies string `gorm:"type:text" json:"allergies" example:"Peanuts"`
+	// MergedIntoID is the customer a merged duplicate was folded into
+	MergedIntoID *uint          `gorm:"index" json:"merged_into_id,omitempty" swaggerignore:"true"`
+	Version      uint           `gorm:"not null;default:1" json:"version" swaggerignore:"true"`
+	CreatedAt    time.Time      `gorm:"autoCreateTime" json:"created_at" swaggerignore:"true"`
+	UpdatedAt    time.Time      `gorm:"autoUpdateTime" json:"updated_at" swaggerignore:"true"`
+	DeletedAt    gorm.DeletedAt `gorm:"index" json:"-" swaggerignore:"true"`
+
+	CustomerStats `gorm:"-" swaggerignore:"true"`
+}
+
+// CustomerStats are read from the completed orders and the reservations of a customer
+type CustomerStats struct {
+	LifetimeSpend float64      `json:"lifetime_spend" example:"412.50"`
+	Visits        int          `json:"visits" example:"7"`
+	LastVisit     *time.Time   `json:"last_visit,omitempty"`
+	Reservations  int          `json:"reservations" example:"5"`
+	NoShows       int          `json:"no_shows" example:"1"`
+	Favourites    []Favourite  `json:"favourites,omitempty"`
+	RecentVisits  []VisitEntry `json:"recent_visits,omitempty"`
+}
+
+// Favourite is a product a customer ordered, by how many were kept after refunds
+type Favourite struct {
+	ProductID uint   `json:"product_id" example:"4"`
+	Name      string `json:"name" example:"Chicken Parmesan"`
+	Quantity  int    `json:"quantity" example:"6"`
+}
+
+// VisitEntry is a completed order of a customer, with the reservation it was seated from
+type VisitEntry struct {
+	OrderID       uint      `json:"order_id" example:"12"`
+	CodeOrder     string    `json:"code_order" example:"ORD-20241216-0012"`
+	Type          OrderType `json:"type" example:"dine_in"`
+	PartySize     int       `json:"party_size" example:"4"`
+	Spend         float64   `json:"spend" example:"86.40"`
+	ReservationID *uint     `json:"reservation_id,omitempty"`
+	VisitedAt     time.Time `json:"visited_at"`
+}
+
+// MergeCustomersRequest folds the duplicates into the customer of the path
+type MergeCustomersRequest struct {
+	DuplicateIDs []uint `json:"duplicate_ids" binding:"required,min=1,dive,gt=0" example:"8"`
+}
+
+// NormalizePhone keeps the digits of a phone number and a leading plus, so "+62 895-123" and
+// "+62895123" are the same guest
+func NormalizePhone(phone string) string {
+	phone = strings.TrimSpace(phone)
+	var b strings.Builder
+	for i, r := range phone {
+		if unicode.IsDigit(r) || (r == '+' && i == 0) {
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
+
+func NormalizeEmail(email string) string {
+	return strings.ToLower(strings.TrimSpace(email))
+}
+
+// Normalize cleans the contact details and checks the customer can be told apart from others
+func (c *Customer) Normalize() error {
+	c.Name = strings.TrimSpace(c.Name)
+	c.Phone = NormalizePhone(c.Phone)
+	c.Email = NormalizeEmail(c.Email)
+	if c.Name == "" {
+		return fmt.Errorf("%w: name is required", ErrInvalidCustomer)
+	}
+	if c.Phone == "" && c.Email == "" {
+		return fmt.Errorf("%w: a phone number or email address is required", ErrInvalidCustomer)
+	}
+	return nil
+}
+
+// Absorb takes over the details of a duplicate the customer does not have yet, notes and
+// allergies of both are kept
+func (c *Customer) Absorb(duplicate Customer) {
+	if c.Phone == "" {
+		c.Phone = duplicate.Phone
+	}
+	if c.Email == "" {
+		c.Email = duplicate.Email
+	}
+	c.Notes = joinDistinct(c.Notes, duplicate.Notes)
+	c.Allergies = joinDistinct(c.Allergies, duplicate.Allergies)
+}
+
+func joinDistinct(text, more string) string {
+	more = strings.TrimSpace(more)
+	if more == "" || strings.Contains(text, more) {
+		return text
+	}
+	if strings.TrimSpace(text) == "" {
+		return more
+	}
+	return text + "\n" + more
+}
+
+// MatchCustomer returns the customer with the phone number or email address of a guest, creating
+// one when the guest is new. The phone number wins when it and the email address belong to
+// different customers. Nothing is matched without either.
+func MatchCustomer(tx *gorm.DB, name, phone, email string) (*Customer, error) {
+	guest := Customer{Name: name, Phone: phone, Email: email}
+	if strings.TrimSpace(guest.Name) == "" {
+		guest.Name = "Guest"
+	}
+	if err := guest.Normalize(); err != nil {
+		return nil, nil
+	}
+
+	// Concurrent bookings of a new guest wait here so only one of them creates the customer
+	if err := LockCustomerContacts(tx, guest.Phone, guest.Email); err != nil {
+		return nil, err
+	}
+
+	var matches []Customer
+	query := tx.Where("phone <> '' AND phone = ?", guest.Phone).Or("email <> '' AND email = ?", guest.Email)
+	if err := tx.Where(query).Order("id").Find(&matches).Error; err != nil {
+		return nil, err
+	}
+	var matched *Customer
+	for i := range matches {
+		if guest.Phone != "" && matches[i].Phone == guest.Phone {
+			matched = &matches[i]
+			break
+		}
+	}
+	if matched == nil && len(matches) > 0 {
+		matched = &matches[0]
+	}
+
+	if matched != nil {
+		// details the customer lacks are taken from the guest unless another customer has them
+		updates := map[string]interface{}{}
+		if matched.Phone == "" && guest.Phone != "" {
+			updates["phone"] = guest.Phone
+		}
+		if matched.Email == "" && guest.Email != "" && len(matches) == 1 {
+			updates["email"] = guest.Email
+		}
+		if len(updates) > 0 {
+			if err := tx.Model(matched).UpdateColumns(updates).Error; err != nil {
+				return nil, err
+			}
+		}
+		return matched, nil
+	}
+
+	if err := tx.Create(&guest).Error; err != nil {
+		return nil, err
+	}
+	return &guest, nil
+}
+
+// LockCustomerContacts holds a lock on the normalized phone number and email address until the
+// transaction ends, so two requests cannot give the same contact to different customers
+func LockCustomerContacts(tx *gorm.DB, phone, email string) error {
+	for _, key := range []string{phone, email} {
+		if key == "" {
+			continue
+		}
+		if err := tx.Exec("SELECT pg_advisory_xact_lock(hashtext(?))", "customer:"+key).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// LinkCustomer is the customer a reservation or order belongs to: the one chosen by id, or else
+// the one matched by the contact details of the guest
+func LinkCustomer(tx *gorm.DB, customerID *uint, name, phone, email string) (*uint, error) {
+	if customerID != nil {
+		var count int64
+		if err := tx.Model(&Customer{}).Where("id = ?", *customerID).Count(&count).Error; err != nil {
+			return nil, err
+		}
+		if count == 0 {
+			return nil, fmt.Errorf("%w: customer %d not found", ErrInvalidCustomer, *customerID)
+		}
+		return customerID, nil
+	}
+
+	customer, err := MatchCustomer(tx, name, phone, email)
+	if err != nil || customer == nil {
+		return nil, err
+	}
+	return &customer.ID, nil
+}
@@ -0,0 +1,52 @@
+package domain_test
+
+import (
+	"project/domain"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNormalizePhone(t *testing.T) {
+	assert.Equal(t, "+62895123456", domain.NormalizePhone(" +62 895-123-456 "))
+	assert.Equal(t, "0895123456", domain.NormalizePhone("(0895) 123 456"))
+	assert.Equal(t, "0895123456", domain.NormalizePhone("0895+123456"))
+	assert.Equal(t, "", domain.NormalizePhone("n/a"))
+}
+
+func TestCustomer_Normalize(t *testing.T) {
+	t.Run("Contact details cleaned", func(t *testing.T) {
+		customer := domain.Customer{Name: " John Doe ", Phone: "+62 895-123", Email: " John@Example.COM "}
+		assert.NoError(t, customer.Normalize())
+		assert.Equal(t, "John Doe", customer.Name)
+		assert.Equal(t, "+62895123", customer.Phone)
+		assert.Equal(t, "john@example.com", customer.Email)
+	})
+
+	t.Run("Email only", func(t *testing.T) {
+		customer := domain.Customer{Name: "Jane", Email: "jane@example.com"}
+		assert.NoError(t, customer.Normalize())
+	})
+
+	t.Run("No contact details", func(t *testing.T) {
+		customer := domain.Customer{Name: "Jane", Phone: "-"}
+		assert.ErrorIs(t, customer.Normalize(), domain.ErrInvalidCustomer)
+	})
+
+	t.Run("No name", func(t *testing.T) {
+		customer := domain.Customer{Name: " ", Phone: "0895123456"}
+		assert.ErrorIs(t, customer.Normalize(), domain.ErrInvalidCustomer)
+	})
+}
+
+func TestCustomer_Absorb(t *testing.T) {
+	customer := domain.Customer{Name: "John Doe", Phone: "0895123456", Notes: "Prefers a window table"}
+	customer.Absorb(domain.Customer{Name: "J. Doe", Phone: "0811111111", Email: "john@example.com", Notes: "Prefers a window table", Allergies: "Peanuts"})
+	customer.Absorb(domain.Customer{Name: "Johnny", Email: "johnny@example.com", Notes: "Birthday in May", Allergies: "Shellfish"})
+
+	assert.Equal(t, "John Doe", customer.Name)
+	assert.Equal(t, "0895123456", customer.Phone)
+	assert.Equal(t, "john@example.com", customer.Email)
+	assert.Equal(t, "Prefers a window table\nBirthday in May", customer.Notes)
+	assert.Equal(t, "Peanuts\nShellfish", customer.Allergies)
+}
@@ -0,0 +1,200 @@
+package domain
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+var ErrInvalidDeposit = errors.New("invalid reservation deposit")
+
+type DepositStatus string
+
+const (
+	DepositNone DepositStatus = "none"
+	// the reservation asks for a deposit that has not been paid yet
+	DepositRequested DepositStatus = "requested"
+	DepositPaid      DepositStatus = "paid"
+	// the deposit was credited to the order the party was seated with
+	DepositApplied   DepositStatus = "applied"
+	DepositRefunded  DepositStatus = "refunded"
+	DepositForfeited DepositStatus = "forfeited"
+)
+
+// DepositPolicy is what happens to a paid deposit when the party cancels, does not come or leaves
+// without paying
+type DepositPolicy struct {
+	// RefundNotice is how long before the booking a guest cancels to get the deposit back in full
+	RefundNotice time.Duration
+	// LateCancelForfeit and NoShowForfeit are the percentages of the deposit kept on a cancellation
+	// within the notice and on a no-show, the rest is refunded
+	LateCancelForfeit float64
+	NoShowForfeit     float64
+}
+
+var DefaultDepositPolicy = DepositPolicy{
+	RefundNotice:      24 * time.Hour,
+	LateCancelForfeit: 100,
+	NoShowForfeit:     100,
+}
+
+// DepositInput is a deposit taken for a reservation, the requested deposit fee when Amount is zero
+type DepositInput struct {
+	PaymentMethodID uint
+	Amount          float64
+	UserID          *uint
+}
+
+// DepositRevenue sums the reservation deposits of a period: collected, the part of it applied to
+// orders, and the refunds and forfeits made. Outstanding is what is paid and still held for upcoming
+// bookings, whenever it was paid.
+type DepositRevenue struct {
+	Collected   float64 `json:"collected" example:"250.00"`
+	Applied     float64 `json:"applied" example:"180.00"`
+	Refunded    float64 `json:"refunded" example:"20.00"`
+	Forfeited   float64 `json:"forfeited" example:"30.00"`
+	Outstanding float64 `json:"outstanding" example:"40.00"`
+}
+
+// CheckDeposit checks that a deposit of amount can be taken for the reservation
+func (r *Reservation) CheckDeposit(amount float64) error {
+	if !r.Status.Holds() || r.Status == ReservationSeated {
+		return fmt.Errorf("%w: cannot take a deposit for a reservation that is %s", ErrInvalidDeposit, r.Status)
+	}
+	if r.DepositStatus == DepositPaid {
+		return fmt.Errorf("%w: the deposit is already paid", ErrInvalidDeposit)
+	}
+	if amount <= 0 {
+		return fmt.Errorf("%w: amount must be positive", ErrInvalidDeposit)
+	}
+	return nil
+}
+
+// Settle splits the paid deposit of a reservation that was cancelled or not shown up for into what
+// is refunded and what is forfeited. Nothing is settled for a reservation that is still booked.
+func (p DepositPolicy) Settle(r *Reservation, paid float64, at time.Time) (refund, forfeit float64) {
+	var percent float64
+	switch r.Status {
+	case ReservationCanceled:
+		start, err := r.Start()
+		if err == nil && start.Sub(at) < p.RefundNotice {
+			percent = p.LateCancelForfeit
+		}
+	case ReservationNoShow:
+		percent = p.NoShowForfeit
+	default:
+		return 0, 0
+	}
+
+	percent = math.Min(math.Max(percent, 0), 100)
+	forfeit = math.Round(paid*percent) / 100
+	return math.Round((paid-forfeit)*100) / 100, forfeit
+}
+
+// SettleDeposit refunds and forfeits what was paid as the deposit of a reservation that ended, as
+// the policy says, and sets its deposit status
+func SettleDeposit(tx *gorm.DB, r *Reservation, paid float64, paymentMethodID *uint, policy DepositPolicy, userID *uint, at time.Time) error {
+	refund, forfeit := policy.Settle(r, paid, at)
+	for _, settlement := range []Payment{
+		{Type: PaymentTypeRefund, Amount: refund},
+		{Type: PaymentTypeForfeit, Amount: forfeit},
+	} {
+		if settlement.Amount <= 0 {
+			continue
+		}
+		settlement.ReservationID = &r.ID
+		settlement.PaymentMethodID = paymentMethodID
+		settlement.UserID = userID
+		if err := tx.Create(&settlement).Error; err != nil {
+			return fmt.Errorf("failed to settle deposit: %v", err)
+		}
+	}
+
+	r.DepositStatus = DepositRefunded
+	if forfeit > 0 {
+		r.DepositStatus = DepositForfeited
+	}
+	return nil
+}
+
+// CancelSeatedReservations cancels the reservations seated with an order that is cancelled. The
+// deposits credited to the order are taken off it and settled like a late cancellation.
+func CancelSeatedReservations(tx *gorm.DB, orderID uint, policy DepositPolicy, input OrderTransitionInput, at time.Time) error {
+	var reservations []Reservation
+	if err := tx.Where("order_id = ? AND status = ?", orderID, ReservationSeated).Order("id").Find(&reservations).Error; err != nil {
+		return fmt.Errorf("failed to retrieve reservation of order: %v", err)
+	}
+
+	for i := range reservations {
+		reservation := &reservations[i]
+		history := reservation.cancelSeated(ReservationTransitionInput{UserID: input.UserID, Reason: input.Reason}, at)
+
+		var deposits []Payment
+		err := tx.Where("order_id = ? AND reservation_id = ? AND type = ?", orderID, reservation.ID, PaymentTypeDeposit).
+			Order("id").Find(&deposits).Error
+		if err != nil {
+			return fmt.Errorf("failed to retrieve deposit: %v", err)
+		}
+		if len(deposits) > 0 {
+			var paid float64
+			ids := make([]uint, len(deposits))
+			for i, deposit := range deposits {
+				paid += deposit.Amount
+				ids[i] = deposit.ID
+			}
+			if err := tx.Model(&Payment{}).Where("id IN ?", ids).UpdateColumn("order_id", nil).Error; err != nil {
+				return fmt.Errorf("failed to take the deposit off the order: %v", err)
+			}
+			if err := SettleDeposit(tx, reservation, paid, deposits[0].PaymentMethodID, policy, input.UserID, at); err != nil {
+				return err
+			}
+		}
+
+		err = tx.Model(&Reservation{}).Where("id = ?", reservation.ID).UpdateColumns(map[string]interface{}{
+			"status":         reservation.Status,
+			"closed_at":      reservation.ClosedAt,
+			"deposit_status": reservation.DepositStatus,
+			"version":        gorm.Expr("version + 1"),
+			"updated_at":     at,
+		}).Error
+		if err != nil {
+			return fmt.Errorf("failed to cancel reservation: %v", err)
+		}
+		if err := tx.Create(history).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// refundExcessDeposit refunds the part of the deposits credited to an order that the bill did not
+// use, latest deposit first
+func refundExcessDeposit(tx *gorm.DB, orderID uint, excess float64, userID *uint) error {
+	var deposits []Payment
+	if err := tx.Where("order_id = ? AND type = ?", orderID, PaymentTypeDeposit).Order("id DESC").Find(&deposits).Error; err != nil {
+		return fmt.Errorf("failed to retrieve deposit: %v", err)
+	}
+
+	for _, deposit := range deposits {
+		amount := math.Round(math.Min(excess, deposit.Amount)*100) / 100
+		if amount <= 0 {
+			break
+		}
+		refund := Payment{
+			OrderID:         &orderID,
+			ReservationID:   deposit.ReservationID,
+			PaymentMethodID: deposit.PaymentMethodID,
+			Type:            PaymentTypeRefund,
+			Amount:          amount,
+			UserID:          userID,
+		}
+		if err := tx.Create(&refund).Error; err != nil {
+			return fmt.Errorf("failed to refund deposit: %v", err)
+		}
+		excess -= amount
+	}
+	return nil
+}
@@ -0,0 +1,59 @@
+package domain_test
+
+import (
+	"project/domain"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"gorm.io/datatypes"
+)
+
+func TestDepositPolicy_Settle(t *testing.T) {
+	policy := domain.DepositPolicy{RefundNotice: 24 * time.Hour, LateCancelForfeit: 50, NoShowForfeit: 100}
+	start := time.Date(2024, 12, 16, 19, 0, 0, 0, time.Local)
+
+	tests := []struct {
+		name    string
+		status  domain.ReservationStatus
+		at      time.Time
+		refund  float64
+		forfeit float64
+	}{
+		{"Cancel before the notice", domain.ReservationCanceled, start.Add(-48 * time.Hour), 25, 0},
+		{"Cancel within the notice", domain.ReservationCanceled, start.Add(-2 * time.Hour), 12.5, 12.5},
+		{"No-show", domain.ReservationNoShow, start.Add(time.Hour), 0, 25},
+		{"Still booked", domain.ReservationConfirmed, start.Add(-2 * time.Hour), 0, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			reservation := domain.Reservation{Status: tt.status, ReservationDate: "2024-12-16", ReservationTime: datatypes.NewTime(19, 0, 0, 0)}
+			refund, forfeit := policy.Settle(&reservation, 25, tt.at)
+			assert.Equal(t, tt.refund, refund)
+			assert.Equal(t, tt.forfeit, forfeit)
+		})
+	}
+}
+
+func TestReservation_CheckDeposit(t *testing.T) {
+	t.Run("Confirmed reservation", func(t *testing.T) {
+		reservation := domain.Reservation{Status: domain.ReservationConfirmed, DepositStatus: domain.DepositRequested}
+		assert.NoError(t, reservation.CheckDeposit(25))
+	})
+
+	t.Run("Already paid", func(t *testing.T) {
+		reservation := domain.Reservation{Status: domain.ReservationConfirmed, DepositStatus: domain.DepositPaid}
+		assert.ErrorIs(t, reservation.CheckDeposit(25), domain.ErrInvalidDeposit)
+	})
+
+	t.Run("Seated reservation", func(t *testing.T) {
+		reservation := domain.Reservation{Status: domain.ReservationSeated}
+		assert.ErrorIs(t, reservation.CheckDeposit(25), domain.ErrInvalidDeposit)
+	})
+
+	t.Run("Zero amount", func(t *testing.T) {
+		reservation := domain.Reservation{Status: domain.ReservationPending}
+		assert.ErrorIs(t, reservation.CheckDeposit(0), domain.ErrInvalidDeposit)
+	})
+}
@@ -0,0 +1,513 @@
+package domain
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var (
+	ErrInvalidDiscount       = errors.New("invalid discount")
+	ErrPromoCodeNotFound     = errors.New("promo code not found")
+	ErrPromoCodeInvalid      = errors.New("promo code cannot be used")
+	ErrDiscountNotAllowed    = errors.New("discounts can only change on orders in process")
+	ErrOrderDiscountNotFound = errors.New("order discount not found")
+)
+
+type DiscountType string
+
+const (
+	DiscountPercentage DiscountType = "percentage"
+	DiscountFixed      DiscountType = "fixed"
+)
+
+type DiscountSource string
+
+const (
+	DiscountManual DiscountSource = "manual"
+	DiscountPromo  DiscountSource = "promo"
+	DiscountRule   DiscountSource = "rule"
+)
+
+type PricingRuleKind string
+
+const (
+	PricingHappyHour PricingRuleKind = "happy_hour"
+	PricingBuyXGetY  PricingRuleKind = "buy_x_get_y"
+)
+
+// Amount returns the discount on base, rounded to cents and never more than base
+func (t DiscountType) Amount(value, base float64) float64 {
+	var amount float64
+	switch t {
+	case DiscountPercentage:
+		amount = base * value / 100
+	case DiscountFixed:
+		amount = value
+	}
+	return math.Round(math.Min(math.Max(amount, 0), base)*100) / 100
+}
+
+func (t DiscountType) Validate(value float64) error {
+	switch {
+	case t != DiscountPercentage && t != DiscountFixed:
+		return fmt.Errorf("%w: type must be percentage or fixed", ErrInvalidDiscount)
+	case value <= 0:
+		return fmt.Errorf("%w: value must be greater than zero", ErrInvalidDiscount)
+	case t == DiscountPercentage && value > 100:
+		return fmt.Errorf("%w: percentage cannot be above 100", ErrInvalidDiscount)
+	}
+	return nil
+}
+
+type PromoCode struct {
+	ID          uint           `gorm:"primaryKey" json:"id" swaggerignore:"true"`
+	Code        string         `gorm:"size:50;unique;not null" json:"code" binding:"required,min=3" example:"WELCOME10"`
+	Description string         `gorm:"size:255" json:"description" example:"10% off for new guests"`
+	Type        DiscountType   `gorm:"size:20;not null" json:"type" binding:"required,oneof=percentage fixed" example:"percentage"`
+	Value       float64        `gorm:"type:decimal(10,2);not null" json:"value" binding:"required,gt=0" example:"10"`
+	MinSpend    float64        `gorm:"type:decimal(10,2);not null;default:0" json:"min_spend" binding:"gte=0" example:"20"`
+	StartsAt    *time.Time     `json:"starts_at"`
+	EndsAt      *time.Time     `json:"ends_at"`
+	UsageLimit  int            `gorm:"not null;default:0" json:"usage_limit" binding:"gte=0" example:"100"`
+	UsedCount   int            `gorm:"not null;default:0" json:"used_count" swaggerignore:"true"`
+	Active      bool           `gorm:"not null;default:true" json:"active" example:"true"`
+	CreatedAt   time.Time      `gorm:"autoCreateTime" json:"created_at" swaggerignore:"true"`
+	UpdatedAt   time.Time      `gorm:"autoUpdateTime" json:"updated_at" swaggerignore:"true"`
+	DeletedAt   gorm.DeletedAt `gorm:"index" json:"-" swaggerignore:"true"`
+}
+
+// Check reports why the promo code cannot be used on an order of subtotal at the given time.
+// A usage limit of zero means unlimited.
+func (p *PromoCode) Check(subtotal float64, at time.Time) error {
+	switch {
+	case !p.Active:
+		return fmt.Errorf("%w: %s is not active", ErrPromoCodeInvalid, p.Code)
+	case p.StartsAt != nil && at.Before(*p.StartsAt):
+		return fmt.Errorf("%w: %s is not valid yet", ErrPromoCodeInvalid, p.Code)
+	case p.EndsAt != nil && at.After(*p.EndsAt):
+		return fmt.Errorf("%w: %s has expired", ErrPromoCodeInvalid, p.Code)
+	case p.UsageLimit > 0 && p.UsedCount >= p.UsageLimit:
+		return fmt.Errorf("%w: %s has reached its usage limit", ErrPromoCodeInvalid, p.Code)
+	case subtotal < p.MinSpend:
+		return fmt.Errorf("%w: %s needs a minimum spend of %.2f", ErrPromoCodeInvalid, p.Code, p.MinSpend)
+	}
+	return nil
+}
+
+// PricingRule is evaluated automatically on every order. Happy hour discounts matching items
+// inside the daily time window, buy X get Y discounts Y of every X+Y matching units.
+// An empty product and category matches every item, empty weekdays match every day.
+type PricingRule struct {
+	ID          uint            `gorm:"primaryKey" json:"id" swaggerignore:"true"`
+	Name        string          `gorm:"size:100;not null" json:"name" binding:"required" example:"Happy hour drinks"`
+	Kind        PricingRuleKind `gorm:"size:20;not null" json:"kind" binding:"required,oneof=happy_hour buy_x_get_y" example:"happy_hour"`
+	Type        DiscountType    `gorm:"size:20;not null" json:"type" binding:"required,oneof=percentage fixed" example:"percentage"`
+	Value       float64         `gorm:"type:decimal(10,2);not null" json:"value" binding:"required,gt=0" example:"20"`
+	ProductID   *uint           `json:"product_id" example:"1"`
+	CategoryID  *int            `json:"category_id" example:"2"`
+	BuyQuantity int             `gorm:"not null;default:0" json:"buy_quantity" binding:"gte=0" example:"2"`
+	GetQuantity int             `gorm:"not null;default:0" json:"get_quantity" binding:"gte=0" example:"1"`
+	StartTime   string          `gorm:"size:5" json:"start_time" example:"16:00"`
+	EndTime     string          `gorm:"size:5" json:"end_time" example:"18:00"`
+	Weekdays    string          `gorm:"size:20" json:"weekdays" example:"1,2,3,4,5"`
+	StartsAt    *time.Time      `json:"starts_at"`
+	EndsAt      *time.Time      `json:"ends_at"`
+	Active      bool            `gorm:"not null;default:true" json:"active" example:"true"`
+	CreatedAt   time.Time       `gorm:"autoCreateTime" json:"created_at" swaggerignore:"true"`
+	UpdatedAt   time.Time       `gorm:"autoUpdateTime" json:"updated_at" swaggerignore:"true"`
+	DeletedAt   gorm.DeletedAt  `gorm:"index" json:"-" swaggerignore:"true"`
+}
+
+func (r *PricingRule) Validate() error {
+	if err := r.Type.Validate(r.Value); err != nil {
+		return err
+	}
+	if r.Kind == PricingBuyXGetY && (r.BuyQuantity <= 0 || r.GetQuantity <= 0) {
+		return fmt.Errorf("%w: buy_x_get_y needs buy_quantity and get_quantity", ErrInvalidDiscount)
+	}
+	if (r.StartTime == "") != (r.EndTime == "") {
+		return fmt.Errorf("%w: start_time and end_time must be set together", ErrInvalidDiscount)
+	}
+	for _, clock := range []string{r.StartTime, r.EndTime} {
+		if _, err := time.Parse("15:04", clock); clock != "" && err != nil {
+			return fmt.Errorf("%w: times must use HH:MM", ErrInvalidDiscount)
+		}
+	}
+	for _, day := range strings.Split(r.Weekdays, ",") {
+		if n, err := strconv.Atoi(strings.TrimSpace(day)); r.Weekdays != "" && (err != nil || n < 0 || n > 6) {
+			return fmt.Errorf("%w: weekdays must be numbers from 0 (Sunday) to 6", ErrInvalidDiscount)
+		}
+	}
+	return nil
+}
+
+// ActiveAt reports whether the rule applies at t, checking the date range, weekday and daily time window
+func (r *PricingRule) ActiveAt(t time.Time) bool {
+	if !r.Active || (r.StartsAt != nil && t.Before(*r.StartsAt)) || (r.EndsAt != nil && t.After(*r.EndsAt)) {
+		return false
+	}
+
+	if r.Weekdays != "" && !strings.Contains(","+strings.ReplaceAll(r.Weekdays, " ", "")+",", ","+strconv.Itoa(int(t.Weekday()))+",") {
+		return false
+	}
+
+	if r.StartTime == "" {
+		return true
+	}
+	clock := t.Format("15:04")
+	if r.StartTime <= r.EndTime {
+		return clock >= r.StartTime && clock < r.EndTime
+	}
+	// windows such as 22:00-02:00 run past midnight
+	return clock >= r.StartTime || clock < r.EndTime
+}
+
+func (r *PricingRule) Matches(product Product) bool {
+	if r.ProductID != nil && *r.ProductID != product.ID {
+		return false
+	}
+	if r.CategoryID != nil && *r.CategoryID != product.CategoryID {
+		return false
+	}
+	return true
+}
+
+// Discount returns what the rule takes off an order item, zero when it does not apply
+func (r *PricingRule) Discount(item OrderItem) float64 {
+	if !r.Matches(item.Product) {
+		return 0
+	}
+
+	switch r.Kind {
+	case PricingHappyHour:
+		return r.Type.Amount(r.Value, float64(item.Quantity)*item.Product.Price)
+	case PricingBuyXGetY:
+		if r.BuyQuantity <= 0 || r.GetQuantity <= 0 {
+			return 0
+		}
+		freeUnits := item.Quantity / (r.BuyQuantity + r.GetQuantity) * r.GetQuantity
+		return float64(freeUnits) * r.Type.Amount(r.Value, item.Product.Price)
+	}
+	return 0
+}
+
+// OrderDiscount is a discount applied to an order, on a single item when OrderItemID is set.
+// Amount is recalculated by PriceOrder whenever the items change.
+type OrderDiscount struct {
+	ID            uint           `gorm:"primaryKey" json:"id"`
+	OrderID       uint           `gorm:"not null;index" json:"order_id"`
+	OrderItemID   *uint          `json:"order_item_id"`
+	Source        DiscountSource `gorm:"size:20;not null" json:"source" example:"promo"`
+	PromoCodeID   *uint          `json:"promo_code_id"`
+	PricingRuleID *uint          `json:"pricing_rule_id"`
+	Name          string         `gorm:"size:100" json:"name" example:"WELCOME10"`
+	Type          DiscountType   `gorm:"size:20;not null" json:"type" example:"percentage"`
+	Value         float64        `gorm:"type:decimal(10,2);not null" json:"value" example:"10"`
+	Amount        float64        `gorm:"type:decimal(10,2);not null" json:"amount" example:"3.20"`
+	UserID        *uint          `json:"user_id"`
+	CreatedAt     time.Time      `gorm:"autoCreateTime" json:"created_at"`
+}
+
+// EvaluatePricingRules picks, for every item, the active rule that gives the biggest discount
+func EvaluatePricingRules(rules []PricingRule, items []OrderItem, at time.Time) []OrderDiscount {
+	var discounts []OrderDiscount
+	for _, item := range items {
+		var best *PricingRule
+		var bestAmount float64
+		for i := range rules {
+			if !rules[i].ActiveAt(at) {
+				continue
+			}
+			if amount := rules[i].Discount(item); amount > bestAmount {
+				best, bestAmount = &rules[i], amount
+			}
+		}
+
+		if best != nil {
+			discounts = append(discounts, best.orderDiscount(item, bestAmount))
+		}
+	}
+	return discounts
+}
+
+// RepricePricingRules keeps the rule each item was already discounted with, recalculated for its
+// current quantity, so an item rung up in a happy hour keeps its price after the window closes.
+// Items without one, or whose rule no longer gives anything, are evaluated at the given time.
+func RepricePricingRules(rules []PricingRule, applied map[uint]PricingRule, items []OrderItem, at time.Time) []OrderDiscount {
+	var discounts []OrderDiscount
+	var unpriced []OrderItem
+	for _, item := range items {
+		if rule, ok := applied[item.ID]; ok {
+			if amount := rule.Discount(item); amount > 0 {
+				discounts = append(discounts, rule.orderDiscount(item, amount))
+				continue
+			}
+		}
+		unpriced = append(unpriced, item)
+	}
+	return append(discounts, EvaluatePricingRules(rules, unpriced, at)...)
+}
+
+func (r *PricingRule) orderDiscount(item OrderItem, amount float64) OrderDiscount {
+	itemID := item.ID
+	ruleID := r.ID
+	return OrderDiscount{
+		OrderID:       item.OrderID,
+		OrderItemID:   &itemID,
+		Source:        DiscountRule,
+		PricingRuleID: &ruleID,
+		Name:          r.Name,
+		Type:          r.Type,
+		Value:         r.Value,
+		Amount:        amount,
+	}
+}
+
+// PriceOrder reprices the automatic pricing rules of an open order, recalculates the amount of
+// every manual and promo discount against the current items and replaces the order taxes.
+// Item discounts come first and order discounts are taken off what is left, so the total
+// discount never exceeds the subtotal. A promo code whose minimum spend is no longer reached is
+// taken off the order. The bill may not go below what points and gift cards paid towards it.
+func PriceOrder(tx *gorm.DB, orderID uint, at time.Time, rounding TaxRounding) error {
+	var order Order
+	if err := tx.First(&order, orderID).Error; err != nil {
+		return fmt.Errorf("failed to retrieve order: %v", err)
+	}
+
+	var items []OrderItem
+	if err := tx.Preload("Product.TaxRates").Preload("Product.Category.TaxRates").
+		Where("order_id = ?", orderID).Find(&items).Error; err != nil {
+		return fmt.Errorf("failed to retrieve order items: %v", err)
+	}
+
+	var rules []PricingRule
+	if err := tx.Where("active = ?", true).Order("id").Find(&rules).Error; err != nil {
+		return fmt.Errorf("failed to retrieve pricing rules: %v", err)
+	}
+
+	applied, err := appliedPricingRules(tx, orderID)
+	if err != nil {
+		return err
+	}
+	if err := tx.Where("order_id = ? AND source = ?", orderID, DiscountRule).Delete(&OrderDiscount{}).Error; err != nil {
+		return fmt.Errorf("failed to clear pricing rule discounts: %v", err)
+	}
+
+	var discounts []OrderDiscount
+	if err := tx.Where("order_id = ?", orderID).Order("id").Find(&discounts).Error; err != nil {
+		return fmt.Errorf("failed to retrieve order discounts: %v", err)
+	}
+
+	lines := make(map[uint]float64, len(items))
+	for _, item := range items {
+		lines[item.ID] = float64(item.Quantity) * item.Product.Price
+	}
+
+	ruleDiscounts := RepricePricingRules(rules, applied, items, at)
+	for i := range ruleDiscounts {
+		lines[*ruleDiscounts[i].OrderItemID] -= ruleDiscounts[i].Amount
+	}
+	if len(ruleDiscounts) > 0 {
+		if err := tx.Create(&ruleDiscounts).Error; err != nil {
+			return fmt.Errorf("failed to save pricing rule discounts: %v", err)
+		}
+	}
+
+	priced := ruleDiscounts
+	// item discounts first, their items may have been removed since they were applied
+	for i := range discounts {
+		discount := &discounts[i]
+		if discount.OrderItemID == nil {
+			continue
+		}
+		remaining, ok := lines[*discount.OrderItemID]
+		if !ok {
+			if err := tx.Delete(discount).Error; err != nil {
+				return fmt.Errorf("failed to remove discount of deleted item: %v", err)
+			}
+			discount.Amount = 0
+			continue
+		}
+		discount.Amount = discount.Type.Amount(discount.Value, remaining)
+		lines[*discount.OrderItemID] = remaining - discount.Amount
+		if err := tx.Model(discount).UpdateColumn("amount", discount.Amount).Error; err != nil {
+			return err
+		}
+	}
+
+	var remaining float64
+	for _, line := range lines {
+		remaining += line
+	}
+	for i := range discounts {
+		discount := &discounts[i]
+		if discount.OrderItemID != nil {
+			continue
+		}
+		if discount.PromoCodeID != nil {
+			var promo PromoCode
+			if err := tx.Unscoped().First(&promo, *discount.PromoCodeID).Error; err != nil {
+				return fmt.Errorf("failed to retrieve promo code: %v", err)
+			}
+			if remaining < promo.MinSpend {
+				if err := RemoveOrderDiscount(tx, discount); err != nil {
+					return err
+				}
+				discount.Amount = 0
+				continue
+			}
+		}
+		discount.Amount = discount.Type.Amount(discount.Value, remaining)
+		remaining -= discount.Amount
+		if err := tx.Model(discount).UpdateColumn("amount", discount.Amount).Error; err != nil {
+			return err
+		}
+	}
+
+	priced = append(priced, discounts...)
+	if err := taxOrder(tx, &order, items, priced, rounding); err != nil {
+		return err
+	}
+	if err := chargeService(tx, &order, remaining); err != nil {
+		return err
+	}
+	return order.checkRedeemed(tx)
+}
+
+// appliedPricingRules are the rules the items of an order are discounted with, by item. Rules
+// switched off or deleted since still count for the items they were applied to.
+func appliedPricingRules(tx *gorm.DB, orderID uint) (map[uint]PricingRule, error) {
+	var discounts []OrderDiscount
+	if err := tx.Where("order_id = ? AND source = ?", orderID, DiscountRule).Find(&discounts).Error; err != nil {
+		return nil, fmt.Errorf("failed to retrieve pricing rule discounts: %v", err)
+	}
+
+	var ruleIDs []uint
+	for _, discount := range discounts {
+		if discount.OrderItemID != nil && discount.PricingRuleID != nil {
+			ruleIDs = append(ruleIDs, *discount.PricingRuleID)
+		}
+	}
+	applied := make(map[uint]PricingRule, len(ruleIDs))
+	if len(ruleIDs) == 0 {
+		return applied, nil
+	}
+
+	var rules []PricingRule
+	if err := tx.Unscoped().Where("id IN ?", ruleIDs).Find(&rules).Error; err != nil {
+		return nil, fmt.Errorf("failed to retrieve pricing rules: %v", err)
+	}
+	byID := make(map[uint]PricingRule, len(rules))
+	for _, rule := range rules {
+		byID[rule.ID] = rule
+	}
+	for _, discount := range discounts {
+		if discount.OrderItemID == nil || discount.PricingRuleID == nil {
+			continue
+		}
+		if rule, ok := byID[*discount.PricingRuleID]; ok {
+			applied[*discount.OrderItemID] = rule
+		}
+	}
+	return applied, nil
+}
+
+// releasePromoCodes gives the promo codes used on a cancelled order their uses back, the discounts
+// stay on the order as a record
+func releasePromoCodes(tx *gorm.DB, orderID uint) error {
+	var promoIDs []uint
+	err := tx.Model(&OrderDiscount{}).Where("order_id = ? AND promo_code_id IS NOT NULL", orderID).
+		Pluck("promo_code_id", &promoIDs).Error
+	if err != nil {
+		return fmt.Errorf("failed to retrieve promo codes of order: %v", err)
+	}
+	for _, promoID := range promoIDs {
+		err := tx.Model(&PromoCode{}).Where("id = ? AND used_count > 0", promoID).
+			UpdateColumn("used_count", gorm.Expr("used_count - 1")).Error
+		if err != nil {
+			return fmt.Errorf("failed to release promo code: %v", err)
+		}
+	}
+	return nil
+}
+
+// ApplyPromoCode locks the promo code, checks it against the discounted subtotal and adds it to the order.
+// An order takes a single promo code.
+func ApplyPromoCode(tx *gorm.DB, orderID uint, code string, userID *uint, at time.Time) (*OrderDiscount, error) {
+	var promo PromoCode
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where("UPPER(code) = UPPER(?)", code).First(&promo).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrPromoCodeNotFound
+		}
+		return nil, err
+	}
+
+	var applied int64
+	if err := tx.Model(&OrderDiscount{}).Where("order_id = ? AND source = ?", orderID, DiscountPromo).Count(&applied).Error; err != nil {
+		return nil, err
+	}
+	if applied > 0 {
+		return nil, fmt.Errorf("%w: the order already has a promo code", ErrPromoCodeInvalid)
+	}
+
+	subtotal, err := OrderSubtotal(tx, orderID)
+	if err != nil {
+		return nil, err
+	}
+	discounted, err := OrderDiscountTotal(tx, orderID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := promo.Check(subtotal-discounted, at); err != nil {
+		return nil, err
+	}
+
+	discount := OrderDiscount{
+		OrderID:     orderID,
+		Source:      DiscountPromo,
+		PromoCodeID: &promo.ID,
+		Name:        promo.Code,
+		Type:        promo.Type,
+		Value:       promo.Value,
+		Amount:      promo.Type.Amount(promo.Value, subtotal-discounted),
+		UserID:      userID,
+	}
+	if err := tx.Create(&discount).Error; err != nil {
+		return nil, fmt.Errorf("failed to apply promo code: %v", err)
+	}
+
+	if err := tx.Model(&promo).UpdateColumn("used_count", gorm.Expr("used_count + 1")).Error; err != nil {
+		return nil, fmt.Errorf("failed to update promo code usage: %v", err)
+	}
+	return &discount, nil
+}
+
+// RemoveOrderDiscount deletes a discount and gives a promo code its use back
+func RemoveOrderDiscount(tx *gorm.DB, discount *OrderDiscount) error {
+	if err := tx.Delete(discount).Error; err != nil {
+		return fmt.Errorf("failed to remove discount: %v", err)
+	}
+	if discount.PromoCodeID != nil {
+		return tx.Model(&PromoCode{}).Where("id = ? AND used_count > 0", *discount.PromoCodeID).
+			UpdateColumn("used_count", gorm.Expr("used_count - 1")).Error
+	}
+	return nil
+}
+
+// OrderDiscountTotal sums every discount applied to the order
+func OrderDiscountTotal(tx *gorm.DB, orderID uint) (float64, error) {
+	var total float64
+	err := tx.Model(&OrderDiscount{}).
+		Select("COALESCE(SUM(amount), 0)").
+		Where("order_id = ?", orderID).
+		Scan(&total).Error
+	return total, err
+}
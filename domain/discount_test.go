@@ -0,0 +1,121 @@
+package domain_test
+
+import (
+	"project/domain"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDiscountType_Amount(t *testing.T) {
+	assert.Equal(t, 2.5, domain.DiscountPercentage.Amount(10, 25))
+	assert.Equal(t, 5.0, domain.DiscountFixed.Amount(5, 25))
+	assert.Equal(t, 25.0, domain.DiscountFixed.Amount(40, 25), "fixed discount is capped at the base")
+	assert.Equal(t, 0.33, domain.DiscountPercentage.Amount(33.333, 1))
+}
+
+func TestPromoCode_Check(t *testing.T) {
+	now := time.Date(2024, 5, 10, 12, 0, 0, 0, time.UTC)
+	yesterday, tomorrow := now.AddDate(0, 0, -1), now.AddDate(0, 0, 1)
+
+	tests := []struct {
+		name  string
+		promo domain.PromoCode
+		total float64
+		ok    bool
+	}{
+		{"Valid", domain.PromoCode{Active: true, StartsAt: &yesterday, EndsAt: &tomorrow, MinSpend: 20}, 25, true},
+		{"Inactive", domain.PromoCode{Active: false}, 25, false},
+		{"Not started", domain.PromoCode{Active: true, StartsAt: &tomorrow}, 25, false},
+		{"Expired", domain.PromoCode{Active: true, EndsAt: &yesterday}, 25, false},
+		{"Usage limit reached", domain.PromoCode{Active: true, UsageLimit: 3, UsedCount: 3}, 25, false},
+		{"Unlimited usage", domain.PromoCode{Active: true, UsedCount: 300}, 25, true},
+		{"Below minimum spend", domain.PromoCode{Active: true, MinSpend: 30}, 25, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.promo.Check(tt.total, now)
+			if tt.ok {
+				assert.NoError(t, err)
+			} else {
+				assert.ErrorIs(t, err, domain.ErrPromoCodeInvalid)
+			}
+		})
+	}
+}
+
+func TestPricingRule_ActiveAt(t *testing.T) {
+	// 10 May 2024 is a Friday
+	friday := func(clock string) time.Time {
+		at, _ := time.Parse("2006-01-02 15:04", "2024-05-10 "+clock)
+		return at
+	}
+
+	happyHour := domain.PricingRule{Active: true, StartTime: "16:00", EndTime: "18:00", Weekdays: "1,2,3,4,5"}
+	assert.True(t, happyHour.ActiveAt(friday("16:30")))
+	assert.False(t, happyHour.ActiveAt(friday("18:00")))
+	assert.False(t, happyHour.ActiveAt(friday("16:30").AddDate(0, 0, 1)), "saturday is not a listed weekday")
+
+	lateNight := domain.PricingRule{Active: true, StartTime: "22:00", EndTime: "02:00"}
+	assert.True(t, lateNight.ActiveAt(friday("23:15")))
+	assert.True(t, lateNight.ActiveAt(friday("01:00")))
+	assert.False(t, lateNight.ActiveAt(friday("12:00")))
+}
+
+func TestEvaluatePricingRules(t *testing.T) {
+	at := time.Date(2024, 5, 10, 17, 0, 0, 0, time.UTC)
+	drinks := 2
+
+	rules := []domain.PricingRule{
+		{ID: 1, Name: "Happy hour", Kind: domain.PricingHappyHour, Type: domain.DiscountPercentage, Value: 20, CategoryID: &drinks, Active: true, StartTime: "16:00", EndTime: "18:00"},
+		{ID: 2, Name: "Buy 2 get 1", Kind: domain.PricingBuyXGetY, Type: domain.DiscountPercentage, Value: 100, CategoryID: &drinks, BuyQuantity: 2, GetQuantity: 1, Active: true},
+	}
+	items := []domain.OrderItem{
+		{ID: 10, OrderID: 1, Quantity: 3, Product: domain.Product{ID: 5, CategoryID: 2, Price: 4}},
+		{ID: 11, OrderID: 1, Quantity: 1, Product: domain.Product{ID: 6, CategoryID: 2, Price: 4}},
+		{ID: 12, OrderID: 1, Quantity: 2, Product: domain.Product{ID: 7, CategoryID: 1, Price: 15}},
+	}
+
+	discounts := domain.EvaluatePricingRules(rules, items, at)
+
+	assert.Len(t, discounts, 2)
+	// three drinks: one free (4.00) beats 20% off (2.40)
+	assert.Equal(t, uint(10), *discounts[0].OrderItemID)
+	assert.Equal(t, uint(2), *discounts[0].PricingRuleID)
+	assert.Equal(t, 4.0, discounts[0].Amount)
+	// a single drink only gets happy hour
+	assert.Equal(t, uint(11), *discounts[1].OrderItemID)
+	assert.Equal(t, uint(1), *discounts[1].PricingRuleID)
+	assert.Equal(t, 0.8, discounts[1].Amount)
+}
+
+func TestRepricePricingRules(t *testing.T) {
+	afterHappyHour := time.Date(2024, 5, 10, 19, 0, 0, 0, time.UTC)
+	drinks := 2
+	happyHour := domain.PricingRule{ID: 1, Name: "Happy hour", Kind: domain.PricingHappyHour, Type: domain.DiscountPercentage, Value: 20, CategoryID: &drinks, Active: true, StartTime: "16:00", EndTime: "18:00"}
+	buyTwo := domain.PricingRule{ID: 2, Name: "Buy 2 get 1", Kind: domain.PricingBuyXGetY, Type: domain.DiscountPercentage, Value: 100, CategoryID: &drinks, BuyQuantity: 2, GetQuantity: 1, Active: true}
+
+	items := []domain.OrderItem{
+		// rung up in the happy hour, the quantity changed since
+		{ID: 10, OrderID: 1, Quantity: 2, Product: domain.Product{ID: 5, CategoryID: 2, Price: 4}},
+		// added after the happy hour
+		{ID: 11, OrderID: 1, Quantity: 1, Product: domain.Product{ID: 6, CategoryID: 2, Price: 4}},
+		// had a free unit, not any more
+		{ID: 12, OrderID: 1, Quantity: 1, Product: domain.Product{ID: 7, CategoryID: 2, Price: 4}},
+	}
+	applied := map[uint]domain.PricingRule{10: happyHour, 12: buyTwo}
+
+	discounts := domain.RepricePricingRules([]domain.PricingRule{happyHour, buyTwo}, applied, items, afterHappyHour)
+
+	assert.Len(t, discounts, 1)
+	assert.Equal(t, uint(10), *discounts[0].OrderItemID)
+	assert.Equal(t, uint(1), *discounts[0].PricingRuleID)
+	assert.Equal(t, 1.6, discounts[0].Amount, "happy hour kept for the new quantity")
+
+	inHappyHour := time.Date(2024, 5, 10, 17, 0, 0, 0, time.UTC)
+	discounts = domain.RepricePricingRules([]domain.PricingRule{happyHour}, nil, items[1:2], inHappyHour)
+	assert.Len(t, discounts, 1)
+	assert.Equal(t, 0.8, discounts[0].Amount, "items without a rule are evaluated at the given time")
+}
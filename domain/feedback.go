@@ -0,0 +1,231 @@
+package domain
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+var (
+	ErrInvalidFeedback = errors.New("invalid feedback")
+	// ErrFeedbackNotOpen is returned for orders that are not paid yet or were cancelled
+	ErrFeedbackNotOpen = errors.New("feedback can only be given on a completed order")
+	ErrFeedbackGiven   = errors.New("feedback has already been given for this order")
+)
+
+// Feedback is what a guest thought of a completed order, rated from 1 to 5. ServedByID is the staff
+// member who served the order, or took its payment when it was never marked served.
+type Feedback struct {
+	ID         uint           `gorm:"primaryKey" json:"id"`
+	OrderID    uint           `gorm:"not null;uniqueIndex" json:"order_id"`
+	CustomerID *uint          `gorm:"index" json:"customer_id,omitempty"`
+	ServedByID *uint          `gorm:"index" json:"served_by_id,omitempty"`
+	Overall    int            `gorm:"not null;check:overall BETWEEN 1 AND 5" json:"overall" example:"4"`
+	Food       int            `gorm:"not null;check:food BETWEEN 1 AND 5" json:"food" example:"5"`
+	Service    int            `gorm:"not null;check:service BETWEEN 1 AND 5" json:"service" example:"3"`
+	Comment    string         `gorm:"type:text" json:"comment,omitempty" example:"Lovely soup, but we waited a while for the bill"`
+	CreatedAt  time.Time      `gorm:"autoCreateTime;index" json:"created_at"`
+	Items      []FeedbackItem `gorm:"foreignKey:FeedbackID" json:"items,omitempty"`
+}
+
+func (Feedback) TableName() string {
+	return "feedback"
+}
+
+// FeedbackItem is the rating of one product of the order
+type FeedbackItem struct {
+	ID         uint   `gorm:"primaryKey" json:"id"`
+	FeedbackID uint   `gorm:"not null;index" json:"feedback_id"`
+	ProductID  uint   `gorm:"not null;index" json:"product_id" example:"1"`
+	Rating     int    `gorm:"not null;check:rating BETWEEN 1 AND 5" json:"rating" example:"5"`
+	Comment    string `gorm:"size:255" json:"comment,omitempty" example:"Perfectly cooked"`
+}
+
+// FeedbackRequest is the form a guest sends from the link on the receipt
+type FeedbackRequest struct {
+	Overall int                   `json:"overall" binding:"required,min=1,max=5" example:"4"`
+	Food    int                   `json:"food" binding:"required,min=1,max=5" example:"5"`
+	Service int                   `json:"service" binding:"required,min=1,max=5" example:"3"`
+	Comment string                `json:"comment" binding:"max=1000" example:"Lovely soup, but we waited a while for the bill"`
+	Items   []FeedbackItemRequest `json:"items" binding:"omitempty,dive"`
+}
+
+type FeedbackItemRequest struct {
+	ProductID uint   `json:"product_id" binding:"required" example:"1"`
+	Rating    int    `json:"rating" binding:"required,min=1,max=5" example:"5"`
+	Comment   string `json:"comment" binding:"max=255" example:"Perfectly cooked"`
+}
+
+// FeedbackForm is what the guest sees before rating: the products of the order, and the
+// feedback already given when there is one
+type FeedbackForm struct {
+	CodeOrder string            `json:"code_order" example:"ORD-20240101-0001"`
+	Date      time.Time         `json:"date"`
+	Products  []FeedbackProduct `json:"products"`
+	Feedback  *Feedback         `json:"feedback,omitempty"`
+}
+
+type FeedbackProduct struct {
+	ProductID uint   `json:"product_id" example:"1"`
+	Name      string `json:"name" example:"Tomato Soup"`
+	Image     string `json:"image,omitempty"`
+}
+
+// FeedbackProductRating is one row of the ratings per product report
+type FeedbackProductRating struct {
+	ProductID uint    `json:"product_id" example:"1"`
+	Name      string  `json:"name" example:"Tomato Soup"`
+	Ratings   int64   `json:"ratings" example:"18"`
+	Average   float64 `json:"average" example:"4.39"`
+	Low       int64   `json:"low" example:"1"`
+}
+
+// FeedbackStaffRating is one row of the ratings per serving staff report, orders with nobody
+// recorded as serving them have no user
+type FeedbackStaffRating struct {
+	UserID   *uint   `json:"user_id" example:"2"`
+	FullName string  `json:"full_name" example:"John Doe"`
+	Ratings  int64   `json:"ratings" example:"27"`
+	Overall  float64 `json:"overall" example:"4.26"`
+	Food     float64 `json:"food" example:"4.41"`
+	Service  float64 `json:"service" example:"4.04"`
+	Low      int64   `json:"low" example:"2"`
+}
+
+// FeedbackTrend is the average ratings of a day, week or month
+type FeedbackTrend struct {
+	Period  time.Time `json:"period"`
+	Ratings int64     `json:"ratings" example:"12"`
+	Overall float64   `json:"overall" example:"4.25"`
+	Food    float64   `json:"food" example:"4.50"`
+	Service float64   `json:"service" example:"3.92"`
+	Low     int64     `json:"low" example:"1"`
+}
+
+// FeedbackInterval groups the ratings over time
+type FeedbackInterval string
+
+const (
+	FeedbackDaily   FeedbackInterval = "day"
+	FeedbackWeekly  FeedbackInterval = "week"
+	FeedbackMonthly FeedbackInterval = "month"
+)
+
+func (i FeedbackInterval) Valid() bool {
+	return i == FeedbackDaily || i == FeedbackWeekly || i == FeedbackMonthly
+}
+
+// Low tells whether any rating of the feedback is at or below threshold, never when threshold is 0
+func (f Feedback) Low(threshold int) bool {
+	if f.Overall <= threshold || f.Food <= threshold || f.Service <= threshold {
+		return true
+	}
+	for _, item := range f.Items {
+		if item.Rating <= threshold {
+			return true
+		}
+	}
+	return false
+}
+
+// NewFeedback checks a feedback form against the products of the order, each may be rated once
+func NewFeedback(order Order, request FeedbackRequest) (*Feedback, error) {
+	ordered := make(map[uint]bool, len(order.OrderItems))
+	for _, item := range order.OrderItems {
+		ordered[item.ProductID] = true
+	}
+
+	feedback := &Feedback{
+		OrderID:    order.ID,
+		CustomerID: order.CustomerID,
+		Overall:    request.Overall,
+		Food:       request.Food,
+		Service:    request.Service,
+		Comment:    strings.TrimSpace(request.Comment),
+	}
+	rated := make(map[uint]bool, len(request.Items))
+	for _, item := range request.Items {
+		if !ordered[item.ProductID] {
+			return nil, fmt.Errorf("%w: product %d is not on the order", ErrInvalidFeedback, item.ProductID)
+		}
+		if rated[item.ProductID] {
+			return nil, fmt.Errorf("%w: product %d is rated twice", ErrInvalidFeedback, item.ProductID)
+		}
+		rated[item.ProductID] = true
+		feedback.Items = append(feedback.Items, FeedbackItem{
+			ProductID: item.ProductID,
+			Rating:    item.Rating,
+			Comment:   strings.TrimSpace(item.Comment),
+		})
+	}
+	return feedback, nil
+}
+
+// SubmitFeedback saves the feedback of a locked, completed order once, and notifies the admins when
+// a rating is at or below lowRating
+func SubmitFeedback(tx *gorm.DB, order *Order, request FeedbackRequest, lowRating int) (*Feedback, error) {
+	if order.StatusPayment != OrderCompleted {
+		return nil, ErrFeedbackNotOpen
+	}
+
+	var count int64
+	if err := tx.Model(&Feedback{}).Where("order_id = ?", order.ID).Count(&count).Error; err != nil {
+		return nil, err
+	}
+	if count > 0 {
+		return nil, ErrFeedbackGiven
+	}
+
+	if err := tx.Where("order_id = ?", order.ID).Find(&order.OrderItems).Error; err != nil {
+		return nil, err
+	}
+	feedback, err := NewFeedback(*order, request)
+	if err != nil {
+		return nil, err
+	}
+	if feedback.ServedByID, err = servedBy(tx, order.ID); err != nil {
+		return nil, err
+	}
+	if err := tx.Create(feedback).Error; err != nil {
+		return nil, err
+	}
+
+	if feedback.Low(lowRating) {
+		notification := Notification{
+			Title:   "Low Guest Rating",
+			Content: feedback.Summary(order.CodeOrder),
+		}
+		if err := NotifyAdmins(tx, &notification); err != nil {
+			return nil, err
+		}
+	}
+	return feedback, nil
+}
+
+// Summary describes the ratings of the feedback for the low rating notification
+func (f Feedback) Summary(codeOrder string) string {
+	summary := fmt.Sprintf("Order %s was rated %d/5 overall, %d/5 for food and %d/5 for service.", codeOrder, f.Overall, f.Food, f.Service)
+	if f.Comment != "" {
+		summary += ` "` + f.Comment + `"`
+	}
+	return summary
+}
+
+// servedBy is the last user who served the order, or who took its payment
+func servedBy(tx *gorm.DB, orderID uint) (*uint, error) {
+	for _, action := range []OrderAction{OrderActionServe, OrderActionPay} {
+		var history OrderStatusHistory
+		err := tx.Where("order_id = ? AND action = ? AND user_id IS NOT NULL", orderID, action).
+			Order("id DESC").Limit(1).Find(&history).Error
+		if err != nil {
+			return nil, err
+		}
+		if history.ID != 0 {
+			return history.UserID, nil
+		}
+	}
+	return nil, nil
+}
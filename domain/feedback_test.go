@@ -0,0 +1,70 @@
+package domain_test
+
+import (
+	"project/domain"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewFeedback(t *testing.T) {
+	customerID := uint(7)
+	order := domain.Order{
+		ID:         3,
+		CustomerID: &customerID,
+		OrderItems: []domain.OrderItem{{ProductID: 1}, {ProductID: 2}, {ProductID: 1}},
+	}
+
+	t.Run("Ratings and items", func(t *testing.T) {
+		feedback, err := domain.NewFeedback(order, domain.FeedbackRequest{
+			Overall: 4, Food: 5, Service: 3, Comment: "  Lovely soup ",
+			Items: []domain.FeedbackItemRequest{{ProductID: 1, Rating: 5}, {ProductID: 2, Rating: 4, Comment: " Too salty "}},
+		})
+		assert.NoError(t, err)
+		assert.Equal(t, uint(3), feedback.OrderID)
+		assert.Equal(t, &customerID, feedback.CustomerID)
+		assert.Equal(t, "Lovely soup", feedback.Comment)
+		assert.Len(t, feedback.Items, 2)
+		assert.Equal(t, "Too salty", feedback.Items[1].Comment)
+	})
+
+	t.Run("Product not on the order", func(t *testing.T) {
+		_, err := domain.NewFeedback(order, domain.FeedbackRequest{
+			Overall: 4, Food: 5, Service: 3,
+			Items: []domain.FeedbackItemRequest{{ProductID: 9, Rating: 5}},
+		})
+		assert.ErrorIs(t, err, domain.ErrInvalidFeedback)
+	})
+
+	t.Run("Product rated twice", func(t *testing.T) {
+		_, err := domain.NewFeedback(order, domain.FeedbackRequest{
+			Overall: 4, Food: 5, Service: 3,
+			Items: []domain.FeedbackItemRequest{{ProductID: 1, Rating: 5}, {ProductID: 1, Rating: 2}},
+		})
+		assert.ErrorIs(t, err, domain.ErrInvalidFeedback)
+	})
+}
+
+func TestFeedbackLow(t *testing.T) {
+	feedback := domain.Feedback{Overall: 4, Food: 3, Service: 5}
+	assert.False(t, feedback.Low(2))
+	assert.True(t, feedback.Low(3))
+	assert.False(t, feedback.Low(0))
+
+	feedback.Items = []domain.FeedbackItem{{ProductID: 1, Rating: 1}}
+	assert.True(t, feedback.Low(2))
+	assert.False(t, feedback.Low(0))
+}
+
+func TestFeedbackSummary(t *testing.T) {
+	feedback := domain.Feedback{Overall: 2, Food: 3, Service: 1}
+	assert.Equal(t, "Order ORD-0001 was rated 2/5 overall, 3/5 for food and 1/5 for service.", feedback.Summary("ORD-0001"))
+
+	feedback.Comment = "We waited an hour"
+	assert.Equal(t, `Order ORD-0001 was rated 2/5 overall, 3/5 for food and 1/5 for service. "We waited an hour"`, feedback.Summary("ORD-0001"))
+}
+
+func TestFeedbackInterval(t *testing.T) {
+	assert.True(t, domain.FeedbackWeekly.Valid())
+	assert.False(t, domain.FeedbackInterval("year").Valid())
+}
@@ -0,0 +1,341 @@
+package domain
+
+import (
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"math"
+	"math/big"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var (
+	ErrGiftCardNotFound = errors.New("gift card not found")
+	ErrInvalidGiftCard  = errors.New("invalid gift card")
+	// ErrGiftCardUnusable is returned for voided, expired and empty cards
+	ErrGiftCardUnusable = errors.New("gift card cannot be used")
+	ErrGiftCardBalance  = errors.New("not enough gift card balance")
+)
+
+// giftCardAlphabet leaves out letters and digits that are easily mistaken for each other
+const giftCardAlphabet = "ABCDEFGHJKLMNPQRSTUVWXYZ23456789"
+
+type GiftCardStatus string
+
+const (
+	GiftCardActive GiftCardStatus = "active"
+	GiftCardVoided GiftCardStatus = "voided"
+)
+
+// GiftCard is a stored-value voucher sold over the counter. Its balance is owed to whoever holds
+// the code until it is spent on orders or the card expires.
+type GiftCard struct {
+	ID             uint           `gorm:"primaryKey" json:"id"`
+	Code           string         `gorm:"size:20;not null;uniqueIndex" json:"code" example:"GC-7KQM-X2RP-9HTD"`
+	InitialBalance float64        `gorm:"type:decimal(10,2);not null" json:"initial_balance" example:"50.00"`
+	Balance        float64        `gorm:"type:decimal(10,2);not null;check:chk_gift_cards_balance,balance >= 0" json:"balance" example:"32.50"`
+	Status         GiftCardStatus `gorm:"size:20;not null;default:active;check:status IN ('active', 'voided')" json:"status" example:"active"`
+	ExpiresAt      *time.Time     `gorm:"index" json:"expires_at,omitempty"`
+	RecipientName  string         `gorm:"size:100" json:"recipient_name,omitempty" example:"Jane Doe"`
+	RecipientEmail string         `gorm:"size:100" json:"recipient_email,omitempty" example:"jane@example.com"`
+	Message        string         `gorm:"type:text" json:"message,omitempty" example:"Happy birthday!"`
+	// PaymentMethodID is how the card was paid for
+	PaymentMethodID *uint                 `json:"payment_method_id"`
+	IssuedByID      *uint                 `json:"issued_by_id,omitempty"`
+	Version         uint                  `gorm:"not null;default:1" json:"version"`
+	CreatedAt       time.Time             `gorm:"autoCreateTime" json:"created_at"`
+	UpdatedAt       time.Time             `gorm:"autoUpdateTime" json:"updated_at"`
+	Transactions    []GiftCardTransaction `gorm:"foreignKey:GiftCardID" json:"transactions,omitempty"`
+}
+
+type GiftCardTransactionType string
+
+const (
+	GiftCardIssue  GiftCardTransactionType = "issue"
+	GiftCardRedeem GiftCardTransactionType = "redeem"
+	// a refund credits back what was paid with the card on a cancelled or refunded order
+	GiftCardRefund GiftCardTransactionType = "refund"
+	GiftCardVoid   GiftCardTransactionType = "void"
+)
+
+// GiftCardTransaction is a change of the balance of a card, Amount is negative when it is spent
+type GiftCardTransaction struct {
+	ID           uint                    `gorm:"primaryKey" json:"id"`
+	GiftCardID   uint                    `gorm:"not null;index" json:"gift_card_id"`
+	Type         GiftCardTransactionType `gorm:"size:20;not null;check:type IN ('issue', 'redeem', 'refund', 'void')" json:"type" example:"redeem"`
+	Amount       float64                 `gorm:"type:decimal(10,2);not null" json:"amount" example:"-17.50"`
+	BalanceAfter float64                 `gorm:"type:decimal(10,2);not null" json:"balance_after" example:"32.50"`
+	OrderID      *uint                   `gorm:"index" json:"order_id,omitempty"`
+	PaymentID    *uint                   `json:"payment_id,omitempty"`
+	UserID       *uint                   `json:"user_id,omitempty"`
+	Note         string                  `gorm:"size:255" json:"note,omitempty"`
+	CreatedAt    time.Time               `gorm:"autoCreateTime" json:"created_at"`
+}
+
+// IssueGiftCardRequest sells a gift card, it expires after the configured days unless ExpiresOn is given
+type IssueGiftCardRequest struct {
+	Amount          float64 `json:"amount" binding:"required,gt=0,lte=10000" example:"50.00"`
+	PaymentMethodID uint    `json:"payment_method_id" binding:"required" example:"1"`
+	ExpiresOn       string  `json:"expires_on" binding:"omitempty,datetime=2006-01-02" example:"2025-12-31"`
+	RecipientName   string  `json:"recipient_name" binding:"max=100" example:"Jane Doe"`
+	RecipientEmail  string  `json:"recipient_email" binding:"omitempty,email,max=100" example:"jane@example.com"`
+	Message         string  `json:"message" binding:"max=500" example:"Happy birthday!"`
+}
+
+// RedeemGiftCardRequest pays an order with a gift card, as much as the card and the bill allow when Amount is zero
+type RedeemGiftCardRequest struct {
+	Code   string  `json:"code" binding:"required" example:"GC-7KQM-X2RP-9HTD"`
+	Amount float64 `json:"amount" binding:"gte=0" example:"17.50"`
+}
+
+// GiftCardLiability is what is owed on gift cards: the balances of usable cards, and the balances
+// left on expired cards. Issued, redeemed, refunded and voided are the movements of a period.
+type GiftCardLiability struct {
+	Outstanding      float64 `json:"outstanding" example:"1250.00"`
+	OutstandingCards int     `json:"outstanding_cards" example:"42"`
+	Expired          float64 `json:"expired" example:"85.00"`
+	ExpiredCards     int     `json:"expired_cards" example:"4"`
+	Issued           float64 `json:"issued" example:"600.00"`
+	Redeemed         float64 `json:"redeemed" example:"410.50"`
+	Refunded         float64 `json:"refunded" example:"12.00"`
+	Voided           float64 `json:"voided" example:"25.00"`
+}
+
+// NewGiftCardCode is a random code like GC-7KQM-X2RP-9HTD
+func NewGiftCardCode() (string, error) {
+	var b strings.Builder
+	b.WriteString("GC")
+	size := big.NewInt(int64(len(giftCardAlphabet)))
+	for i := 0; i < 12; i++ {
+		if i%4 == 0 {
+			b.WriteByte('-')
+		}
+		n, err := rand.Int(rand.Reader, size)
+		if err != nil {
+			return "", err
+		}
+		b.WriteByte(giftCardAlphabet[n.Int64()])
+	}
+	return b.String(), nil
+}
+
+// NormalizeGiftCardCode reads a code as typed, in any case and with or without its dashes
+func NormalizeGiftCardCode(code string) string {
+	var b strings.Builder
+	for _, r := range strings.ToUpper(code) {
+		if (r >= 'A' && r <= 'Z') || (r >= '0' && r <= '9') {
+			b.WriteRune(r)
+		}
+	}
+	plain := b.String()
+	if len(plain) != 14 || !strings.HasPrefix(plain, "GC") {
+		return plain
+	}
+	return "GC-" + plain[2:6] + "-" + plain[6:10] + "-" + plain[10:14]
+}
+
+// GiftCardExpiry is when a card issued at a time expires, the given date wins over the default days
+func GiftCardExpiry(expiresOn string, days int, at time.Time) (*time.Time, error) {
+	if expiresOn != "" {
+		date, err := time.ParseInLocation("2006-01-02", expiresOn, at.Location())
+		if err != nil {
+			return nil, fmt.Errorf("%w: expiry date must be YYYY-MM-DD", ErrInvalidGiftCard)
+		}
+		// the card can be used for the whole of its last day
+		expiresAt := date.AddDate(0, 0, 1)
+		if !expiresAt.After(at) {
+			return nil, fmt.Errorf("%w: expiry date must be in the future", ErrInvalidGiftCard)
+		}
+		return &expiresAt, nil
+	}
+	if days <= 0 {
+		return nil, nil
+	}
+	expiresAt := at.AddDate(0, 0, days)
+	return &expiresAt, nil
+}
+
+func (g *GiftCard) Expired(at time.Time) bool {
+	return g.ExpiresAt != nil && !at.Before(*g.ExpiresAt)
+}
+
+// LastDay is the last date the card can be used on, as printed for the holder
+func (g GiftCard) LastDay() string {
+	if g.ExpiresAt == nil {
+		return ""
+	}
+	return g.ExpiresAt.Add(-time.Nanosecond).Format("2 January 2006")
+}
+
+// Usable checks the card can pay for an order at a time
+func (g *GiftCard) Usable(at time.Time) error {
+	switch {
+	case g.Status == GiftCardVoided:
+		return fmt.Errorf("%w: the card was voided", ErrGiftCardUnusable)
+	case g.Expired(at):
+		return fmt.Errorf("%w: the card has expired", ErrGiftCardUnusable)
+	case g.Balance <= 0:
+		return fmt.Errorf("%w: the card has no balance left", ErrGiftCardUnusable)
+	}
+	return nil
+}
+
+// IssueGiftCard saves a new card with a code no other card has and the issue transaction
+func IssueGiftCard(tx *gorm.DB, card *GiftCard) error {
+	for attempt := 0; ; attempt++ {
+		code, err := NewGiftCardCode()
+		if err != nil {
+			return fmt.Errorf("failed to generate gift card code: %v", err)
+		}
+		var count int64
+		if err := tx.Model(&GiftCard{}).Where("code = ?", code).Count(&count).Error; err != nil {
+			return err
+		}
+		if count == 0 {
+			card.Code = code
+			break
+		}
+		if attempt == 4 {
+			return errors.New("failed to generate a unique gift card code")
+		}
+	}
+
+	card.Balance = card.InitialBalance
+	card.Status = GiftCardActive
+	card.Transactions = []GiftCardTransaction{{
+		Type:         GiftCardIssue,
+		Amount:       card.InitialBalance,
+		BalanceAfter: card.InitialBalance,
+		UserID:       card.IssuedByID,
+	}}
+	return tx.Create(card).Error
+}
+
+// LockGiftCard loads a card by its code with a row lock
+func LockGiftCard(tx *gorm.DB, card *GiftCard, code string) error {
+	err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where("code = ?", NormalizeGiftCardCode(code)).First(card).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return ErrGiftCardNotFound
+	}
+	return err
+}
+
+// RedeemGiftCard pays part of an open order from a gift card, at most what is left to pay
+func RedeemGiftCard(tx *gorm.DB, order *Order, code string, amount float64, userID *uint, at time.Time) (*Payment, error) {
+	if order.StatusPayment != OrderInProcess {
+		return nil, fmt.Errorf("%w: gift cards pay open orders only", ErrInvalidGiftCard)
+	}
+
+	var card GiftCard
+	if err := LockGiftCard(tx, &card, code); err != nil {
+		return nil, err
+	}
+	if err := card.Usable(at); err != nil {
+		return nil, err
+	}
+
+	amountDue, err := order.AmountDue(tx)
+	if err != nil {
+		return nil, err
+	}
+	tendered, err := OrderTendered(tx, order.ID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to calculate order tenders: %v", err)
+	}
+	remaining := math.Round((amountDue-tendered)*100) / 100
+	if remaining <= 0 {
+		return nil, fmt.Errorf("%w: nothing is left to pay", ErrInvalidGiftCard)
+	}
+
+	amount = math.Round(amount*100) / 100
+	switch {
+	case amount == 0:
+		amount = math.Min(card.Balance, remaining)
+	case amount > card.Balance:
+		return nil, fmt.Errorf("%w: %.2f left on the card", ErrGiftCardBalance, card.Balance)
+	case amount > remaining:
+		return nil, fmt.Errorf("%w: %.2f is left to pay", ErrInvalidGiftCard, remaining)
+	}
+
+	payment := Payment{OrderID: &order.ID, Type: PaymentTypeGiftCard, Amount: amount, UserID: userID}
+	if err := tx.Create(&payment).Error; err != nil {
+		return nil, fmt.Errorf("failed to record gift card payment: %v", err)
+	}
+	err = changeGiftCardBalance(tx, &card, GiftCardTransaction{
+		Type:      GiftCardRedeem,
+		Amount:    -amount,
+		OrderID:   &order.ID,
+		PaymentID: &payment.ID,
+		UserID:    userID,
+		Note:      order.CodeOrder,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &payment, nil
+}
+
+// ReturnGiftCardTenders credits the share of what gift cards paid on an order back to the cards,
+// 1 for all of it. Voided and expired cards are credited too, the balance stays owed to the holder.
+func ReturnGiftCardTenders(tx *gorm.DB, orderID uint, share float64, userID *uint) error {
+	var rows []struct {
+		GiftCardID uint
+		Amount     float64
+	}
+	err := tx.Model(&GiftCardTransaction{}).Select("gift_card_id, SUM(amount) AS amount").
+		Where("order_id = ? AND type IN ?", orderID, []GiftCardTransactionType{GiftCardRedeem, GiftCardRefund}).
+		Group("gift_card_id").
+		Order("gift_card_id").
+		Scan(&rows).Error
+	if err != nil {
+		return fmt.Errorf("failed to retrieve gift card tenders: %v", err)
+	}
+
+	for _, row := range rows {
+		amount := math.Round(-row.Amount*math.Min(share, 1)*100) / 100
+		if amount <= 0 {
+			continue
+		}
+		var card GiftCard
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&card, row.GiftCardID).Error; err != nil {
+			return err
+		}
+		err := changeGiftCardBalance(tx, &card, GiftCardTransaction{Type: GiftCardRefund, Amount: amount, OrderID: &orderID, UserID: userID})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// VoidGiftCard takes the balance off a card that was sold by mistake or reported stolen
+func VoidGiftCard(tx *gorm.DB, card *GiftCard, note string, userID *uint) error {
+	if card.Status == GiftCardVoided {
+		return fmt.Errorf("%w: the card is already voided", ErrInvalidGiftCard)
+	}
+	card.Status = GiftCardVoided
+	return changeGiftCardBalance(tx, card, GiftCardTransaction{Type: GiftCardVoid, Amount: -card.Balance, UserID: userID, Note: note})
+}
+
+// changeGiftCardBalance applies a transaction to a locked card and records it
+func changeGiftCardBalance(tx *gorm.DB, card *GiftCard, transaction GiftCardTransaction) error {
+	card.Balance = math.Round((card.Balance+transaction.Amount)*100) / 100
+	card.Version++
+	err := tx.Model(card).UpdateColumns(map[string]interface{}{
+		"balance":    card.Balance,
+		"status":     card.Status,
+		"version":    card.Version,
+		"updated_at": time.Now(),
+	}).Error
+	if err != nil {
+		return fmt.Errorf("failed to update gift card balance: %v", err)
+	}
+
+	transaction.GiftCardID = card.ID
+	transaction.BalanceAfter = card.Balance
+	return tx.Create(&transaction).Error
+}
@@ -0,0 +1,67 @@
+package domain_test
+
+import (
+	"project/domain"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewGiftCardCode(t *testing.T) {
+	code, err := domain.NewGiftCardCode()
+	assert.NoError(t, err)
+	assert.Regexp(t, regexp.MustCompile(`^GC(-[A-HJ-NP-Z2-9]{4}){3}$`), code)
+
+	other, err := domain.NewGiftCardCode()
+	assert.NoError(t, err)
+	assert.NotEqual(t, code, other)
+}
+
+func TestNormalizeGiftCardCode(t *testing.T) {
+	assert.Equal(t, "GC-7KQM-X2RP-9HTD", domain.NormalizeGiftCardCode(" gc7kqmx2rp9htd "))
+	assert.Equal(t, "GC-7KQM-X2RP-9HTD", domain.NormalizeGiftCardCode("GC 7KQM X2RP 9HTD"))
+	assert.Equal(t, "GC-7KQM-X2RP-9HTD", domain.NormalizeGiftCardCode("GC-7KQM-X2RP-9HTD"))
+	assert.Equal(t, "7KQM", domain.NormalizeGiftCardCode("7kqm"))
+}
+
+func TestGiftCardExpiry(t *testing.T) {
+	issued := time.Date(2024, 12, 16, 15, 30, 0, 0, time.UTC)
+
+	t.Run("Default days", func(t *testing.T) {
+		expiresAt, err := domain.GiftCardExpiry("", 365, issued)
+		assert.NoError(t, err)
+		assert.Equal(t, time.Date(2025, 12, 16, 15, 30, 0, 0, time.UTC), *expiresAt)
+	})
+
+	t.Run("Never expires", func(t *testing.T) {
+		expiresAt, err := domain.GiftCardExpiry("", 0, issued)
+		assert.NoError(t, err)
+		assert.Nil(t, expiresAt)
+	})
+
+	t.Run("Usable for the whole last day", func(t *testing.T) {
+		expiresAt, err := domain.GiftCardExpiry("2025-06-30", 365, issued)
+		assert.NoError(t, err)
+		assert.Equal(t, time.Date(2025, 7, 1, 0, 0, 0, 0, time.UTC), *expiresAt)
+		assert.Equal(t, "30 June 2025", domain.GiftCard{ExpiresAt: expiresAt}.LastDay())
+	})
+
+	t.Run("Date in the past", func(t *testing.T) {
+		_, err := domain.GiftCardExpiry("2024-12-15", 365, issued)
+		assert.ErrorIs(t, err, domain.ErrInvalidGiftCard)
+	})
+}
+
+func TestGiftCard_Usable(t *testing.T) {
+	now := time.Date(2024, 12, 16, 12, 0, 0, 0, time.UTC)
+	tomorrow := now.AddDate(0, 0, 1)
+	yesterday := now.AddDate(0, 0, -1)
+
+	assert.NoError(t, (&domain.GiftCard{Status: domain.GiftCardActive, Balance: 20, ExpiresAt: &tomorrow}).Usable(now))
+	assert.NoError(t, (&domain.GiftCard{Status: domain.GiftCardActive, Balance: 20}).Usable(now))
+	assert.ErrorIs(t, (&domain.GiftCard{Status: domain.GiftCardActive, Balance: 20, ExpiresAt: &yesterday}).Usable(now), domain.ErrGiftCardUnusable)
+	assert.ErrorIs(t, (&domain.GiftCard{Status: domain.GiftCardVoided, Balance: 20}).Usable(now), domain.ErrGiftCardUnusable)
+	assert.ErrorIs(t, (&domain.GiftCard{Status: domain.GiftCardActive, Balance: 0}).Usable(now), domain.ErrGiftCardUnusable)
+}
@@ -0,0 +1,531 @@
+package domain
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+var (
+	ErrInvalidLoyalty      = errors.New("invalid loyalty points")
+	ErrInsufficientPoints  = errors.New("not enough loyalty points")
+	ErrLoyaltyTierNotFound = errors.New("loyalty tier not found")
+)
+
+// LoyaltyExpiringWindow is how far ahead an account shows the points about to expire
+const LoyaltyExpiringWindow = 30 * 24 * time.Hour
+
+// LoyaltyPolicy is how points are earned, what they are worth and how long they last
+type LoyaltyPolicy struct {
+	// EarnRate is the points per currency unit paid for items of categories without a rate of their own
+	EarnRate float64
+	// PointValue is the currency value of one redeemed point, zero turns redemption off
+	PointValue float64
+	// Expiry is how long earned points stay redeemable, zero keeps them forever
+	Expiry time.Duration
+	// TierPeriod is how far back the earned points count towards the tier, zero counts all of them
+	TierPeriod time.Duration
+}
+
+var DefaultLoyaltyPolicy = LoyaltyPolicy{
+	EarnRate:   1,
+	PointValue: 0.01,
+	Expiry:     365 * 24 * time.Hour,
+	TierPeriod: 365 * 24 * time.Hour,
+}
+
+type LoyaltyEntryType string
+
+const (
+	LoyaltyEarn   LoyaltyEntryType = "earn"
+	LoyaltyRedeem LoyaltyEntryType = "redeem"
+	// a reverse takes back earned points when the order is refunded
+	LoyaltyReverse LoyaltyEntryType = "reverse"
+	// a restore gives back redeemed points when the order is cancelled or refunded
+	LoyaltyRestore LoyaltyEntryType = "restore"
+	LoyaltyExpire  LoyaltyEntryType = "expire"
+	LoyaltyAdjust  LoyaltyEntryType = "adjust"
+)
+
+// LoyaltyEntry is a line of the points ledger of a customer, Points is negative for debits.
+// Credits keep what is left to spend in Remaining and are spent soonest expiry first. A debit the
+// balance cannot cover, like the reverse of points already spent, keeps the shortfall as a negative
+// Remaining that the next credits pay off.
+type LoyaltyEntry struct {
+	ID         uint             `gorm:"primaryKey" json:"id"`
+	CustomerID uint             `gorm:"not null;index" json:"customer_id"`
+	OrderID    *uint            `gorm:"index" json:"order_id,omitempty"`
+	Type       LoyaltyEntryType `gorm:"size:20;not null;check:type IN ('earn', 'redeem', 'reverse', 'restore', 'expire', 'adjust')" json:"type" example:"earn"`
+	Points     int              `gorm:"not null" json:"points" example:"86"`
+	Remaining  int              `gorm:"not null;default:0" json:"remaining" example:"86"`
+	ExpiresAt  *time.Time       `gorm:"index" json:"expires_at,omitempty"`
+	Note       string           `gorm:"size:255" json:"note,omitempty"`
+	UserID     *uint            `json:"user_id,omitempty"`
+	CreatedAt  time.Time        `gorm:"autoCreateTime" json:"created_at"`
+}
+
+// LoyaltyTier is a membership level reached with the points earned over the tier period
+type LoyaltyTier struct {
+	ID        uint   `gorm:"primaryKey" json:"id" swaggerignore:"true"`
+	Name      string `gorm:"size:50;not null;unique" json:"name" binding:"required,max=50" example:"Silver"`
+	MinPoints int    `gorm:"not null;default:0;unique" json:"min_points" binding:"gte=0" example:"1000"`
+	// EarnMultiplier scales the points earned by members of the tier
+	EarnMultiplier float64   `gorm:"type:decimal(4,2);not null;default:1" json:"earn_multiplier" binding:"required,gte=1,lte=10" example:"1.25"`
+	Perks          string    `gorm:"type:text" json:"perks" example:"Free dessert on your birthday"`
+	CreatedAt      time.Time `gorm:"autoCreateTime" json:"created_at" swaggerignore:"true"`
+	UpdatedAt      time.Time `gorm:"autoUpdateTime" json:"updated_at" swaggerignore:"true"`
+}
+
+// LoyaltyAccount is the points balance and tier of a customer
+type LoyaltyAccount struct {
+	CustomerID uint    `json:"customer_id" example:"3"`
+	Balance    int     `json:"balance" example:"1240"`
+	Value      float64 `json:"value" example:"12.40"`
+	// TierPoints are the points earned over the tier period, less the reversed ones
+	TierPoints       int          `json:"tier_points" example:"1520"`
+	Tier             *LoyaltyTier `json:"tier,omitempty"`
+	NextTier         *LoyaltyTier `json:"next_tier,omitempty"`
+	PointsToNextTier int          `json:"points_to_next_tier" example:"3480"`
+	// Expiring are the points of the balance that expire within LoyaltyExpiringWindow
+	Expiring   int        `json:"expiring" example:"120"`
+	ExpiringAt *time.Time `json:"expiring_at,omitempty"`
+}
+
+// LoyaltyAdjustment adds or takes away points by hand, e.g. as a goodwill gesture
+type LoyaltyAdjustment struct {
+	Points int    `json:"points" binding:"required" example:"200"`
+	Note   string `json:"note" binding:"required,max=255" example:"Apology for the long wait"`
+}
+
+// LoyaltyEarning is an amount at list prices with the earn rate of its category, nil for the default
+type LoyaltyEarning struct {
+	ListAmount float64
+	EarnRate   *float64
+}
+
+// Value is what the points are worth when redeemed
+func (p LoyaltyPolicy) Value(points int) float64 {
+	return math.Round(float64(points)*p.PointValue*100) / 100
+}
+
+// Earned is the whole points for the amounts, each at the rate of its category, scaled by the
+// share of the list prices paid in money and by the tier multiplier
+func (p LoyaltyPolicy) Earned(earnings []LoyaltyEarning, ratio, multiplier float64) int {
+	var points float64
+	for _, earning := range earnings {
+		rate := p.EarnRate
+		if earning.EarnRate != nil {
+			rate = *earning.EarnRate
+		}
+		points += earning.ListAmount * rate
+	}
+	if multiplier <= 0 {
+		multiplier = 1
+	}
+	// the epsilon keeps 99.99999 from rounding down a whole point
+	return int(math.Max(math.Floor(points*ratio*multiplier+1e-6), 0))
+}
+
+func (p LoyaltyPolicy) expiresAt(at time.Time) *time.Time {
+	if p.Expiry <= 0 {
+		return nil
+	}
+	expiresAt := at.Add(p.Expiry)
+	return &expiresAt
+}
+
+// TierFor is the highest tier the points reach and the one after it
+func TierFor(tiers []LoyaltyTier, points int) (tier, next *LoyaltyTier) {
+	sorted := append([]LoyaltyTier(nil), tiers...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].MinPoints < sorted[j].MinPoints })
+	for i := range sorted {
+		if points >= sorted[i].MinPoints {
+			tier = &sorted[i]
+			continue
+		}
+		next = &sorted[i]
+		break
+	}
+	return tier, next
+}
+
+// LockLoyaltyAccount holds a lock on the points of a customer until the transaction ends, so
+// concurrent credits and debits see each other
+func LockLoyaltyAccount(tx *gorm.DB, customerID uint) error {
+	return tx.Exec("SELECT pg_advisory_xact_lock(hashtext(?))", fmt.Sprintf("loyalty:%d", customerID)).Error
+}
+
+// LoyaltyBalance is the points a customer can spend at a time, expired points not swept yet excluded
+func LoyaltyBalance(tx *gorm.DB, customerID uint, at time.Time) (int, error) {
+	var balance int
+	err := tx.Model(&LoyaltyEntry{}).Select("COALESCE(SUM(remaining), 0)").
+		Where("customer_id = ? AND remaining <> 0", customerID).
+		Where("remaining < 0 OR expires_at IS NULL OR expires_at > ?", at).
+		Scan(&balance).Error
+	return balance, err
+}
+
+// CustomerLoyalty reads the balance and tier of a customer
+func CustomerLoyalty(tx *gorm.DB, customerID uint, policy LoyaltyPolicy, at time.Time) (*LoyaltyAccount, error) {
+	account := LoyaltyAccount{CustomerID: customerID}
+
+	balance, err := LoyaltyBalance(tx, customerID, at)
+	if err != nil {
+		return nil, fmt.Errorf("failed to calculate loyalty balance: %v", err)
+	}
+	account.Balance = balance
+	account.Value = policy.Value(balance)
+
+	if account.TierPoints, err = tierPoints(tx, customerID, policy, at); err != nil {
+		return nil, err
+	}
+	var tiers []LoyaltyTier
+	if err := tx.Find(&tiers).Error; err != nil {
+		return nil, fmt.Errorf("failed to retrieve loyalty tiers: %v", err)
+	}
+	account.Tier, account.NextTier = TierFor(tiers, account.TierPoints)
+	if account.NextTier != nil {
+		account.PointsToNextTier = account.NextTier.MinPoints - account.TierPoints
+	}
+
+	var expiring struct {
+		Points int
+		First  *time.Time
+	}
+	err = tx.Model(&LoyaltyEntry{}).Select("COALESCE(SUM(remaining), 0) AS points, MIN(expires_at) AS first").
+		Where("customer_id = ? AND remaining > 0 AND expires_at > ? AND expires_at <= ?", customerID, at, at.Add(LoyaltyExpiringWindow)).
+		Scan(&expiring).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to calculate expiring points: %v", err)
+	}
+	account.Expiring = min(expiring.Points, max(balance, 0))
+	if account.Expiring > 0 {
+		account.ExpiringAt = expiring.First
+	}
+	return &account, nil
+}
+
+// tierPoints are the points earned within the tier period less the ones reversed for refunds
+func tierPoints(tx *gorm.DB, customerID uint, policy LoyaltyPolicy, at time.Time) (int, error) {
+	var points int
+	query := tx.Model(&LoyaltyEntry{}).Select("COALESCE(SUM(points), 0)").
+		Where("customer_id = ? AND type IN ?", customerID, []LoyaltyEntryType{LoyaltyEarn, LoyaltyReverse})
+	if policy.TierPeriod > 0 {
+		query = query.Where("created_at > ?", at.Add(-policy.TierPeriod))
+	}
+	if err := query.Scan(&points).Error; err != nil {
+		return 0, fmt.Errorf("failed to calculate tier points: %v", err)
+	}
+	return max(points, 0), nil
+}
+
+// EarnLoyaltyPoints credits the customer of a paid order. Every category earns at its own rate on
+// what was charged for its items, the part paid with points earns nothing, and the tier of the
+// customer multiplies the result.
+func EarnLoyaltyPoints(tx *gorm.DB, order *Order, policy LoyaltyPolicy, at time.Time) error {
+	if order.CustomerID == nil {
+		return nil
+	}
+
+	var earnings []LoyaltyEarning
+	err := tx.Model(&OrderItem{}).
+		Select("SUM(order_items.quantity * products.price) AS list_amount, categories.loyalty_earn_rate AS earn_rate").
+		Joins("JOIN products ON products.id = order_items.product_id").
+		Joins("LEFT JOIN categories ON categories.id = products.category_id").
+		Where("order_items.order_id = ?", order.ID).
+		Group("categories.loyalty_earn_rate").
+		Scan(&earnings).Error
+	if err != nil {
+		return fmt.Errorf("failed to read order earnings: %v", err)
+	}
+
+	subtotal, err := OrderSubtotal(tx, order.ID)
+	if err != nil {
+		return fmt.Errorf("failed to calculate order total: %v", err)
+	}
+	if subtotal == 0 {
+		return nil
+	}
+	amountDue, err := order.AmountDue(tx)
+	if err != nil {
+		return err
+	}
+	redeemed, err := OrderPoints(tx, order.ID)
+	if err != nil {
+		return fmt.Errorf("failed to calculate redeemed points: %v", err)
+	}
+	ratio := math.Max(amountDue-redeemed, 0) / subtotal
+
+	if err := LockLoyaltyAccount(tx, *order.CustomerID); err != nil {
+		return err
+	}
+	multiplier := 1.0
+	points, err := tierPoints(tx, *order.CustomerID, policy, at)
+	if err != nil {
+		return err
+	}
+	var tiers []LoyaltyTier
+	if err := tx.Find(&tiers).Error; err != nil {
+		return fmt.Errorf("failed to retrieve loyalty tiers: %v", err)
+	}
+	if tier, _ := TierFor(tiers, points); tier != nil {
+		multiplier = tier.EarnMultiplier
+	}
+
+	earned := policy.Earned(earnings, ratio, multiplier)
+	if earned == 0 {
+		return nil
+	}
+	return creditPoints(tx, &LoyaltyEntry{
+		CustomerID: *order.CustomerID,
+		OrderID:    &order.ID,
+		Type:       LoyaltyEarn,
+		Points:     earned,
+		ExpiresAt:  policy.expiresAt(at),
+		Note:       order.CodeOrder,
+		CreatedAt:  at,
+	}, at)
+}
+
+// RedeemLoyaltyPoints pays part of an open order with the points of its customer
+func RedeemLoyaltyPoints(tx *gorm.DB, order *Order, points int, policy LoyaltyPolicy, userID *uint, at time.Time) (*Payment, error) {
+	if policy.PointValue <= 0 {
+		return nil, fmt.Errorf("%w: points cannot be redeemed", ErrInvalidLoyalty)
+	}
+	if points <= 0 {
+		return nil, fmt.Errorf("%w: points must be more than zero", ErrInvalidLoyalty)
+	}
+	if order.StatusPayment != OrderInProcess {
+		return nil, fmt.Errorf("%w: points are redeemed on open orders only", ErrInvalidLoyalty)
+	}
+	if order.CustomerID == nil {
+		return nil, fmt.Errorf("%w: the order has no customer", ErrInvalidLoyalty)
+	}
+
+	amountDue, err := order.AmountDue(tx)
+	if err != nil {
+		return nil, err
+	}
+	tendered, err := OrderTendered(tx, order.ID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to calculate order tenders: %v", err)
+	}
+	value := policy.Value(points)
+	if remaining := math.Round((amountDue-tendered)*100) / 100; value > remaining+0.005 {
+		return nil, fmt.Errorf("%w: %.2f is left to pay", ErrInvalidLoyalty, math.Max(remaining, 0))
+	}
+
+	if err := LockLoyaltyAccount(tx, *order.CustomerID); err != nil {
+		return nil, err
+	}
+	err = debitPoints(tx, &LoyaltyEntry{
+		CustomerID: *order.CustomerID,
+		OrderID:    &order.ID,
+		Type:       LoyaltyRedeem,
+		Points:     -points,
+		Note:       order.CodeOrder,
+		UserID:     userID,
+		CreatedAt:  at,
+	}, false, at)
+	if err != nil {
+		return nil, err
+	}
+
+	payment := Payment{OrderID: &order.ID, Type: PaymentTypePoints, Amount: value, UserID: userID}
+	if err := tx.Create(&payment).Error; err != nil {
+		return nil, fmt.Errorf("failed to record points payment: %v", err)
+	}
+	return &payment, nil
+}
+
+// ReverseLoyaltyPoints takes back the share of the points an order earned, 1 for all of them.
+// Points already spent leave the balance negative until more are earned.
+func ReverseLoyaltyPoints(tx *gorm.DB, orderID uint, share float64, at time.Time) error {
+	net, err := orderLoyalty(tx, orderID, LoyaltyEarn, LoyaltyReverse)
+	if err != nil {
+		return err
+	}
+	for customerID, points := range net {
+		points = int(math.Round(float64(points) * math.Min(share, 1)))
+		if points <= 0 {
+			continue
+		}
+		if err := LockLoyaltyAccount(tx, customerID); err != nil {
+			return err
+		}
+		err := debitPoints(tx, &LoyaltyEntry{CustomerID: customerID, OrderID: &orderID, Type: LoyaltyReverse, Points: -points, CreatedAt: at}, true, at)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// RestoreRedeemedPoints gives back the share of the points redeemed on an order, 1 for all of them
+func RestoreRedeemedPoints(tx *gorm.DB, orderID uint, share float64, policy LoyaltyPolicy, at time.Time) error {
+	net, err := orderLoyalty(tx, orderID, LoyaltyRedeem, LoyaltyRestore)
+	if err != nil {
+		return err
+	}
+	for customerID, points := range net {
+		points = int(math.Round(float64(-points) * math.Min(share, 1)))
+		if points <= 0 {
+			continue
+		}
+		if err := LockLoyaltyAccount(tx, customerID); err != nil {
+			return err
+		}
+		err := creditPoints(tx, &LoyaltyEntry{
+			CustomerID: customerID,
+			OrderID:    &orderID,
+			Type:       LoyaltyRestore,
+			Points:     points,
+			ExpiresAt:  policy.expiresAt(at),
+			CreatedAt:  at,
+		}, at)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// AdjustLoyaltyPoints adds points to a customer, or takes away at most the balance
+func AdjustLoyaltyPoints(tx *gorm.DB, customerID uint, adjustment LoyaltyAdjustment, policy LoyaltyPolicy, userID *uint, at time.Time) (*LoyaltyEntry, error) {
+	if adjustment.Points == 0 {
+		return nil, fmt.Errorf("%w: points must not be zero", ErrInvalidLoyalty)
+	}
+	if err := LockLoyaltyAccount(tx, customerID); err != nil {
+		return nil, err
+	}
+
+	entry := LoyaltyEntry{
+		CustomerID: customerID,
+		Type:       LoyaltyAdjust,
+		Points:     adjustment.Points,
+		Note:       adjustment.Note,
+		UserID:     userID,
+		CreatedAt:  at,
+	}
+	if adjustment.Points > 0 {
+		entry.ExpiresAt = policy.expiresAt(at)
+		return &entry, creditPoints(tx, &entry, at)
+	}
+	return &entry, debitPoints(tx, &entry, false, at)
+}
+
+// ExpireLoyaltyPoints writes off what is left of the credits expired by now and returns the points
+func ExpireLoyaltyPoints(tx *gorm.DB, now time.Time) (int, error) {
+	var lots []LoyaltyEntry
+	if err := tx.Where("remaining > 0 AND expires_at <= ?", now).Order("id").Find(&lots).Error; err != nil {
+		return 0, fmt.Errorf("failed to retrieve expired points: %v", err)
+	}
+
+	expired := 0
+	for _, lot := range lots {
+		if err := LockLoyaltyAccount(tx, lot.CustomerID); err != nil {
+			return expired, err
+		}
+		// a redemption may have spent the credit while the lock was awaited
+		result := tx.Model(&LoyaltyEntry{}).Where("id = ? AND remaining = ?", lot.ID, lot.Remaining).UpdateColumn("remaining", 0)
+		if result.Error != nil {
+			return expired, result.Error
+		}
+		if result.RowsAffected == 0 {
+			continue
+		}
+		err := tx.Create(&LoyaltyEntry{
+			CustomerID: lot.CustomerID,
+			OrderID:    lot.OrderID,
+			Type:       LoyaltyExpire,
+			Points:     -lot.Remaining,
+			CreatedAt:  now,
+		}).Error
+		if err != nil {
+			return expired, err
+		}
+		expired += lot.Remaining
+	}
+	return expired, nil
+}
+
+// orderLoyalty nets the entries of the given types on an order by customer
+func orderLoyalty(tx *gorm.DB, orderID uint, types ...LoyaltyEntryType) (map[uint]int, error) {
+	var rows []struct {
+		CustomerID uint
+		Points     int
+	}
+	err := tx.Model(&LoyaltyEntry{}).Select("customer_id, SUM(points) AS points").
+		Where("order_id = ? AND type IN ?", orderID, types).
+		Group("customer_id").
+		Scan(&rows).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to retrieve order points: %v", err)
+	}
+	net := make(map[uint]int, len(rows))
+	for _, row := range rows {
+		net[row.CustomerID] = row.Points
+	}
+	return net, nil
+}
+
+// creditPoints pays off earlier shortfalls of the customer and saves the rest as a new credit
+func creditPoints(tx *gorm.DB, entry *LoyaltyEntry, at time.Time) error {
+	entry.Remaining = entry.Points
+
+	var debts []LoyaltyEntry
+	if err := tx.Where("customer_id = ? AND remaining < 0", entry.CustomerID).Order("id").Find(&debts).Error; err != nil {
+		return fmt.Errorf("failed to retrieve points owed: %v", err)
+	}
+	for _, debt := range debts {
+		if entry.Remaining == 0 {
+			break
+		}
+		paid := min(-debt.Remaining, entry.Remaining)
+		if err := tx.Model(&debt).UpdateColumn("remaining", debt.Remaining+paid).Error; err != nil {
+			return err
+		}
+		entry.Remaining -= paid
+	}
+	return tx.Create(entry).Error
+}
+
+// debitPoints spends the credits of the customer soonest expiry first. Without allowDebt a debit
+// larger than the balance fails, with it the shortfall is kept to be paid off by later credits.
+func debitPoints(tx *gorm.DB, entry *LoyaltyEntry, allowDebt bool, at time.Time) error {
+	owed := -entry.Points
+
+	var lots []LoyaltyEntry
+	err := tx.Where("customer_id = ? AND remaining > 0 AND (expires_at IS NULL OR expires_at > ?)", entry.CustomerID, at).
+		Order("expires_at IS NULL, expires_at, id").
+		Find(&lots).Error
+	if err != nil {
+		return fmt.Errorf("failed to retrieve loyalty points: %v", err)
+	}
+	if !allowDebt {
+		balance, err := LoyaltyBalance(tx, entry.CustomerID, at)
+		if err != nil {
+			return fmt.Errorf("failed to calculate loyalty balance: %v", err)
+		}
+		if owed > balance {
+			return fmt.Errorf("%w: %d available", ErrInsufficientPoints, max(balance, 0))
+		}
+	}
+
+	for _, lot := range lots {
+		if owed == 0 {
+			break
+		}
+		spent := min(lot.Remaining, owed)
+		if err := tx.Model(&lot).UpdateColumn("remaining", lot.Remaining-spent).Error; err != nil {
+			return err
+		}
+		owed -= spent
+	}
+	entry.Remaining = -owed
+	return tx.Create(entry).Error
+}
@@ -0,0 +1,74 @@
+package domain_test
+
+import (
+	"project/domain"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLoyaltyPolicy_Earned(t *testing.T) {
+	policy := domain.LoyaltyPolicy{EarnRate: 1, PointValue: 0.01}
+	double := 2.0
+	none := 0.0
+
+	t.Run("Default rate", func(t *testing.T) {
+		earnings := []domain.LoyaltyEarning{{ListAmount: 42.50}}
+		assert.Equal(t, 42, policy.Earned(earnings, 1, 1))
+	})
+
+	t.Run("Category rates", func(t *testing.T) {
+		earnings := []domain.LoyaltyEarning{
+			{ListAmount: 30, EarnRate: &double},
+			{ListAmount: 20},
+			{ListAmount: 15, EarnRate: &none},
+		}
+		assert.Equal(t, 80, policy.Earned(earnings, 1, 1))
+	})
+
+	t.Run("Discounts and points payments earn nothing", func(t *testing.T) {
+		earnings := []domain.LoyaltyEarning{{ListAmount: 100}}
+		assert.Equal(t, 72, policy.Earned(earnings, 0.72, 1))
+	})
+
+	t.Run("Tier multiplier", func(t *testing.T) {
+		earnings := []domain.LoyaltyEarning{{ListAmount: 100}}
+		assert.Equal(t, 125, policy.Earned(earnings, 1, 1.25))
+		assert.Equal(t, 100, policy.Earned(earnings, 1, 0))
+	})
+
+	t.Run("Whole points are not lost to rounding", func(t *testing.T) {
+		earnings := []domain.LoyaltyEarning{{ListAmount: 0.1}, {ListAmount: 0.2}, {ListAmount: 0.7}}
+		assert.Equal(t, 3, policy.Earned(earnings, 3, 1))
+	})
+}
+
+func TestLoyaltyPolicy_Value(t *testing.T) {
+	policy := domain.LoyaltyPolicy{PointValue: 0.01}
+	assert.Equal(t, 12.34, policy.Value(1234))
+	assert.Equal(t, 0.0, policy.Value(0))
+}
+
+func TestTierFor(t *testing.T) {
+	tiers := []domain.LoyaltyTier{
+		{Name: "Gold", MinPoints: 5000},
+		{Name: "Member", MinPoints: 0},
+		{Name: "Silver", MinPoints: 1000},
+	}
+
+	tier, next := domain.TierFor(tiers, 0)
+	assert.Equal(t, "Member", tier.Name)
+	assert.Equal(t, "Silver", next.Name)
+
+	tier, next = domain.TierFor(tiers, 1000)
+	assert.Equal(t, "Silver", tier.Name)
+	assert.Equal(t, "Gold", next.Name)
+
+	tier, next = domain.TierFor(tiers, 7200)
+	assert.Equal(t, "Gold", tier.Name)
+	assert.Nil(t, next)
+
+	tier, next = domain.TierFor(tiers[:1], 300)
+	assert.Nil(t, tier)
+	assert.Equal(t, "Gold", next.Name)
+}
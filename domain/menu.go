@@ -0,0 +1,154 @@
+package domain
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+var ErrInvalidModifier = errors.New("invalid modifier")
+
+// ProductModifier is an option ordering channels offer with a product, like a size or an extra
+// topping, at an extra price
+type ProductModifier struct {
+	ID        uint    `gorm:"primaryKey" json:"id" swaggerignore:"true"`
+	ProductID uint    `gorm:"not null;index" json:"-"`
+	Group     string  `gorm:"size:50;not null" json:"group" binding:"required,max=50" example:"Extras"`
+	Name      string  `gorm:"size:100;not null" json:"name" binding:"required,max=100" example:"Extra cheese"`
+	Price     float64 `gorm:"type:decimal(10,2);not null;default:0" json:"price" binding:"gte=0" example:"1.50"`
+	Position  int     `gorm:"not null;default:0" json:"-"`
+}
+
+// ModifiersRequest replaces the modifiers of a product, in the order they are shown
+type ModifiersRequest struct {
+	Modifiers []ProductModifier `json:"modifiers" binding:"dive"`
+}
+
+// Menu is the public menu: the active products in stock per category
+type Menu struct {
+	Categories []MenuCategory `json:"categories"`
+}
+
+type MenuCategory struct {
+	ID          uint          `json:"id" example:"1"`
+	Name        string        `json:"name" example:"Soups"`
+	Description string        `json:"description,omitempty" example:"Made fresh every morning"`
+	Icon        string        `json:"icon,omitempty" example:"/icon/category.png"`
+	Products    []MenuProduct `json:"products"`
+}
+
+type MenuProduct struct {
+	ID          uint              `json:"id" example:"1"`
+	Name        string            `json:"name" example:"Tomato Soup"`
+	Description string            `json:"description,omitempty" example:"Roasted tomatoes with basil"`
+	Image       string            `json:"image" example:"/image/product.png"`
+	Price       float64           `json:"price" example:"6.50"`
+	Allergens   []string          `json:"allergens" example:"celery,milk"`
+	Modifiers   []ProductModifier `json:"modifiers"`
+}
+
+// NormalizeAllergens lowercases a comma separated list of allergens and drops blanks and repeats
+func NormalizeAllergens(allergens string) string {
+	return strings.Join(AllergenList(allergens), ",")
+}
+
+// AllergenList splits a comma separated list of allergens
+func AllergenList(allergens string) []string {
+	list := []string{}
+	seen := map[string]bool{}
+	for _, allergen := range strings.Split(allergens, ",") {
+		allergen = strings.ToLower(strings.TrimSpace(allergen))
+		if allergen == "" || seen[allergen] {
+			continue
+		}
+		seen[allergen] = true
+		list = append(list, allergen)
+	}
+	return list
+}
+
+// CheckModifiers trims the modifiers of a product and numbers them in order, a name may be used
+// once per group
+func CheckModifiers(modifiers []ProductModifier) error {
+	seen := map[string]bool{}
+	for i := range modifiers {
+		modifier := &modifiers[i]
+		modifier.Group = strings.TrimSpace(modifier.Group)
+		modifier.Name = strings.TrimSpace(modifier.Name)
+		if modifier.Group == "" || modifier.Name == "" {
+			return fmt.Errorf("%w: group and name are required", ErrInvalidModifier)
+		}
+		key := strings.ToLower(modifier.Group + "\x00" + modifier.Name)
+		if seen[key] {
+			return fmt.Errorf("%w: %s is listed twice in %s", ErrInvalidModifier, modifier.Name, modifier.Group)
+		}
+		seen[key] = true
+		modifier.ID = 0
+		modifier.Position = i
+	}
+	return nil
+}
+
+// NewMenu groups the products under their categories in the given order, categories without
+// products are left out
+func NewMenu(categories []Category, products []Product) Menu {
+	byCategory := map[uint][]MenuProduct{}
+	for _, product := range products {
+		modifiers := product.Modifiers
+		if modifiers == nil {
+			modifiers = []ProductModifier{}
+		}
+		byCategory[uint(product.CategoryID)] = append(byCategory[uint(product.CategoryID)], MenuProduct{
+			ID:          product.ID,
+			Name:        product.Name,
+			Description: product.Description,
+			Image:       product.Image,
+			Price:       product.Price,
+			Allergens:   AllergenList(product.Allergens),
+			Modifiers:   modifiers,
+		})
+	}
+
+	menu := Menu{Categories: []MenuCategory{}}
+	for _, category := range categories {
+		if len(byCategory[category.ID]) == 0 {
+			continue
+		}
+		menu.Categories = append(menu.Categories, MenuCategory{
+			ID:          category.ID,
+			Name:        category.Name,
+			Description: category.Description,
+			Icon:        category.Icon,
+			Products:    byCategory[category.ID],
+		})
+	}
+	return menu
+}
+
+// Without takes products off the menu, like those sold out since it was cached, and leaves out
+// the categories that have no products left
+func (m Menu) Without(productIDs []uint) Menu {
+	if len(productIDs) == 0 {
+		return m
+	}
+	removed := map[uint]bool{}
+	for _, id := range productIDs {
+		removed[id] = true
+	}
+
+	menu := Menu{Categories: []MenuCategory{}}
+	for _, category := range m.Categories {
+		products := make([]MenuProduct, 0, len(category.Products))
+		for _, product := range category.Products {
+			if !removed[product.ID] {
+				products = append(products, product)
+			}
+		}
+		if len(products) == 0 {
+			continue
+		}
+		category.Products = products
+		menu.Categories = append(menu.Categories, category)
+	}
+	return menu
+}
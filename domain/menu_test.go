@@ -0,0 +1,80 @@
+package domain_test
+
+import (
+	"project/domain"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAllergens(t *testing.T) {
+	assert.Equal(t, []string{"gluten", "milk", "nuts"}, domain.AllergenList(" Gluten, milk,,NUTS , milk"))
+	assert.Equal(t, []string{}, domain.AllergenList(""))
+	assert.Equal(t, "gluten,milk", domain.NormalizeAllergens("Gluten , Milk, gluten"))
+}
+
+func TestCheckModifiers(t *testing.T) {
+	t.Run("Trimmed and numbered", func(t *testing.T) {
+		modifiers := []domain.ProductModifier{
+			{ID: 9, Group: " Size ", Name: "Large", Price: 1},
+			{Group: "Extras", Name: " Extra cheese ", Price: 1.5},
+		}
+		assert.NoError(t, domain.CheckModifiers(modifiers))
+		assert.Equal(t, uint(0), modifiers[0].ID)
+		assert.Equal(t, "Size", modifiers[0].Group)
+		assert.Equal(t, "Extra cheese", modifiers[1].Name)
+		assert.Equal(t, 1, modifiers[1].Position)
+	})
+
+	t.Run("Name twice in a group", func(t *testing.T) {
+		modifiers := []domain.ProductModifier{{Group: "Size", Name: "Large"}, {Group: "size", Name: "large"}}
+		assert.ErrorIs(t, domain.CheckModifiers(modifiers), domain.ErrInvalidModifier)
+	})
+
+	t.Run("Same name in another group", func(t *testing.T) {
+		modifiers := []domain.ProductModifier{{Group: "Size", Name: "Large"}, {Group: "Side", Name: "Large"}}
+		assert.NoError(t, domain.CheckModifiers(modifiers))
+	})
+
+	t.Run("Blank name", func(t *testing.T) {
+		modifiers := []domain.ProductModifier{{Group: "Size", Name: "  "}}
+		assert.ErrorIs(t, domain.CheckModifiers(modifiers), domain.ErrInvalidModifier)
+	})
+}
+
+func TestNewMenu(t *testing.T) {
+	categories := []domain.Category{{ID: 1, Name: "Soups"}, {ID: 2, Name: "Desserts"}, {ID: 3, Name: "Drinks"}}
+	products := []domain.Product{
+		{ID: 5, CategoryID: 3, Name: "Lemonade", Price: 3},
+		{ID: 4, CategoryID: 1, Name: "Tomato Soup", Price: 6.5, Allergens: "celery,milk",
+			Modifiers: []domain.ProductModifier{{Group: "Size", Name: "Large", Price: 2}}},
+	}
+
+	menu := domain.NewMenu(categories, products)
+	assert.Len(t, menu.Categories, 2)
+	assert.Equal(t, "Soups", menu.Categories[0].Name)
+	assert.Equal(t, "Drinks", menu.Categories[1].Name)
+
+	soup := menu.Categories[0].Products[0]
+	assert.Equal(t, []string{"celery", "milk"}, soup.Allergens)
+	assert.Len(t, soup.Modifiers, 1)
+	assert.Equal(t, []domain.ProductModifier{}, menu.Categories[1].Products[0].Modifiers)
+}
+
+func TestMenu_Without(t *testing.T) {
+	categories := []domain.Category{{ID: 1, Name: "Soups"}, {ID: 3, Name: "Drinks"}}
+	products := []domain.Product{
+		{ID: 5, CategoryID: 3, Name: "Lemonade", Price: 3},
+		{ID: 4, CategoryID: 1, Name: "Tomato Soup", Price: 6.5},
+		{ID: 6, CategoryID: 1, Name: "Onion Soup", Price: 7},
+	}
+	menu := domain.NewMenu(categories, products)
+
+	soldOut := menu.Without([]uint{5, 4})
+	assert.Len(t, soldOut.Categories, 1, "a category with nothing left is left out")
+	assert.Len(t, soldOut.Categories[0].Products, 1)
+	assert.Equal(t, "Onion Soup", soldOut.Categories[0].Products[0].Name)
+	assert.Len(t, menu.Categories[0].Products, 2, "the cached menu is not changed")
+
+	assert.Equal(t, menu, menu.Without(nil))
+}
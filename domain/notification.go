@@ -54,6 +54,24 @@ func (u *UserNotification) BeforeCreate(db *gorm.DB) error {
 	return nil
 }
 
+// NotifyAdmins creates a notification and delivers it unread to every admin
+func NotifyAdmins(tx *gorm.DB, notification *Notification) error {
+	var admins []User
+	if err := tx.Where("role = ?", Admin).Find(&admins).Error; err != nil {
+		return err
+	}
+	if err := tx.Create(notification).Error; err != nil {
+		return err
+	}
+	for _, admin := range admins {
+		userNotif := UserNotification{UserID: admin.ID, NotificationID: notification.ID, Status: "unread"}
+		if err := tx.Create(&userNotif).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
 type BatchUpdateNotifRequest struct {
 	NotificationIDs []uint `json:"notification_ids"`
 	Status          string `json:"status"`
@@ -1,7 +1,8 @@
 package domain
 
 import (
-
+	"errors"
+	"fmt"
 	"time"
 
 	"gorm.io/gorm"
@@ -21,34 +22,98 @@ const (
 	OrderInTheKitchen StatusKitchen = "In The Kitchen"
 	OrderCookingNow   StatusKitchen = "Cooking Now"
 	OrderReadyToServe StatusKitchen = "Ready To Serve"
+	OrderServed       StatusKitchen = "Served"
 )
 
-type Order struct {
-	ID              uint           `gorm:"primaryKey" json:"id" swaggerignore:"true"`
-	TableID         uint           `gorm:"not null" json:"table_id" example:"1"`
-	Table           Table          `gorm:"foreignKey:TableID;references:ID"`
-	Name            string         `gorm:"size:100" json:"name"`
-	CodeOrder       string         `gorm:"size:50;unique" json:"code_order"`
-	Tax             float64        `gorm:"type:decimal(4,2);not null;default:10.0" json:"tax"`
-	PaymentMethodID *uint          `gorm:"default:null" json:"payment_method_id" example:"1"`
-	PaymentMethod   PaymentMethod  `gorm:"foreignKey:PaymentMethodID;references:ID"`
-	StatusPayment   StatusPayment  `gorm:"type:status_payment;default:'In Process'" json:"status_payment" example:"In Process"`
-	StatusKitchen   StatusKitchen  `gorm:"type:status_kitchen;default:'In The Kitchen'" json:"status_kitchen" example:"In The Kitchen"`
-	OrderItems      []OrderItem    `gorm:"foreignKey:OrderID;references:ID"`
-	CreatedAt       time.Time      `gorm:"autoCreateTime" json:"created_at" swaggerignore:"true"`
-	UpdatedAt       time.Time      `gorm:"autoUpdateTime" json:"updated_at" swaggerignore:"true"`
-	DeletedAt       gorm.DeletedAt `gorm:"index" json:"-" swaggerignore:"true"`
+type OrderType string
+
+const (
+	OrderDineIn   OrderType = "dine_in"
+	OrderTakeaway OrderType = "takeaway"
+	OrderDelivery OrderType = "delivery"
+	OrderPickup   OrderType = "pickup"
+)
+
+var ErrInvalidOrder = errors.New("invalid order")
+
+// NeedsTable tells whether orders of this type are served at a table
+func (t OrderType) NeedsTable() bool {
+	return t == OrderDineIn
 }
 
+// Validate checks the rules of the order's type: dine-in orders sit at a table, the other types
+// have none, and only delivery orders carry an address and a delivery fee
+func (o *Order) Validate() error {
+	switch o.Type {
+	case OrderDineIn, OrderTakeaway, OrderDelivery, OrderPickup:
+	default:
+		return fmt.Errorf("%w: unknown order type %q", ErrInvalidOrder, o.Type)
+	}
 
-type OrderItem struct {
-	ID        uint      `gorm:"primaryKey" json:"id" swaggerignore:"true"`
-	OrderID   uint      `gorm:"not null" json:"order_id" example:"1" swaggerignore:"true"`
-	Order     Order     `gorm:"foreignKey:OrderID;references:ID" swaggerignore:"true"`
-	ProductID uint      `gorm:"not null" json:"product_id" binding:"required" example:"1"`
-	Product   Product   `gorm:"foreignKey:ProductID;references:ID" binding:"-" swaggerignore:"true"`
-	Quantity  int       `gorm:"not null" json:"quantity" binding:"gt=0" example:"2"`
-	CreatedAt time.Time `gorm:"autoCreateTime" json:"created_at" swaggerignore:"true"`
-	UpdatedAt time.Time `gorm:"autoUpdateTime" json:"updated_at" swaggerignore:"true"`
+	if o.Type.NeedsTable() && o.TableID == nil {
+		return fmt.Errorf("%w: a dine-in order needs a table", ErrInvalidOrder)
+	}
+	if !o.Type.NeedsTable() && o.TableID != nil {
+		return fmt.Errorf("%w: a %s order cannot take a table", ErrInvalidOrder, o.Type)
+	}
+
+	if o.Type == OrderDelivery {
+		if o.DeliveryAddress == "" {
+			return fmt.Errorf("%w: a delivery order needs an address", ErrInvalidOrder)
+		}
+		if o.DeliveryFee < 0 {
+			return fmt.Errorf("%w: delivery fee cannot be negative", ErrInvalidOrder)
+		}
+	} else if o.DeliveryAddress != "" || o.DeliveryFee != 0 {
+		return fmt.Errorf("%w: only delivery orders have an address and a delivery fee", ErrInvalidOrder)
+	}
+
+	if o.PromisedAt != nil && o.Type == OrderDineIn {
+		return fmt.Errorf("%w: a promised time is for takeaway, pickup and delivery orders", ErrInvalidOrder)
+	}
+	return nil
+}
+
+type Order struct {
+	ID                  uint           `gorm:"primaryKey" json:"id" swaggerignore:"true"`
+	TableID             *uint          `gorm:"default:null" json:"table_id" example:"1"`
+	Table               *Table         `gorm:"foreignKey:TableID;references:ID"`
+	Name                string         `gorm:"size:100" json:"name"`
+	Type                OrderType      `gorm:"size:20;not null;default:'dine_in'" json:"type" example:"dine_in"`
+	PartySize           int            `gorm:"not null;default:1" json:"party_size" example:"2"`
+	CustomerPhone       string         `gorm:"size:20" json:"customer_phone" example:"+1 (23) 123 4567"`
+	CustomerID          *uint          `gorm:"index" json:"customer_id" example:"1"`
+	DeliveryAddress     string         `gorm:"size:255" json:"delivery_address" example:"1st Street 12"`
+	DeliveryFee         float64        `gorm:"type:decimal(10,2);not null;default:0" json:"delivery_fee" example:"3.50"`
+	PromisedAt          *time.Time     `json:"promised_at"`
+	CodeOrder           string         `gorm:"size:50;unique" json:"code_order"`
+	QueueNumber         int            `gorm:"not null;default:0" json:"queue_number"`
+	PaymentMethodID     *uint          `gorm:"default:null" json:"payment_method_id" example:"1"`
+	PaymentMethod       PaymentMethod  `gorm:"foreignKey:PaymentMethodID;references:ID"`
+	StatusPayment       StatusPayment  `gorm:"type:status_payment;default:'In Process'" json:"status_payment" example:"In Process"`
+	StatusKitchen       StatusKitchen  `gorm:"type:status_kitchen;default:'In The Kitchen'" json:"status_kitchen" example:"In The Kitchen"`
+	OrderItems          []OrderItem    `gorm:"foreignKey:OrderID;references:ID"`
+	ServiceChargeRuleID *uint          `gorm:"default:null" json:"service_charge_rule_id" swaggerignore:"true"`
+	ServiceCharge       float64        `gorm:"type:decimal(10,2);not null;default:0" json:"service_charge" swaggerignore:"true"`
+	ReceiptPrints       int            `gorm:"not null;default:0" json:"receipt_prints" swaggerignore:"true"`
+	Version             uint           `gorm:"not null;default:1" json:"version" swaggerignore:"true"`
+	CreatedAt           time.Time      `gorm:"autoCreateTime" json:"created_at" swaggerignore:"true"`
+	UpdatedAt           time.Time      `gorm:"autoUpdateTime" json:"updated_at" swaggerignore:"true"`
+	DeletedAt           gorm.DeletedAt `gorm:"index" json:"-" swaggerignore:"true"`
+
+	// ReservationID is the booking whose party the order seats, only set while seating it. The
+	// tables held for that booking can be taken, any other order is refused a reserved table.
+	ReservationID uint `gorm:"-" json:"-" swaggerignore:"true"`
 }
 
+type OrderItem struct {
+	ID               uint      `gorm:"primaryKey" json:"id" swaggerignore:"true"`
+	OrderID          uint      `gorm:"not null" json:"order_id" example:"1" swaggerignore:"true"`
+	Order            Order     `gorm:"foreignKey:OrderID;references:ID" swaggerignore:"true"`
+	ProductID        uint      `gorm:"not null" json:"product_id" binding:"required" example:"1"`
+	Product          Product   `gorm:"foreignKey:ProductID;references:ID" binding:"-" swaggerignore:"true"`
+	Quantity         int       `gorm:"not null" json:"quantity" binding:"gt=0" example:"2"`
+	RefundedQuantity int       `gorm:"not null;default:0" json:"refunded_quantity" swaggerignore:"true"`
+	CreatedAt        time.Time `gorm:"autoCreateTime" json:"created_at" swaggerignore:"true"`
+	UpdatedAt        time.Time `gorm:"autoUpdateTime" json:"updated_at" swaggerignore:"true"`
+}
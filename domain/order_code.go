@@ -0,0 +1,96 @@
+package domain
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+const (
+	orderCodeSequence = "order_code_seq"
+	orderCodeCounter  = "order_code"
+	orderQueueCounter = "order_queue"
+)
+
+// OrderCodeFormatKey is the gorm setting that hands the configured format to the order hook,
+// for orders saved without a code
+const OrderCodeFormatKey = "order_code_format"
+
+// OrderSequence stores the per-day counters used for daily order codes and queue numbers.
+// Rows are incremented with a single upsert so concurrent orders never read the same value.
+type OrderSequence struct {
+	Name      string    `gorm:"primaryKey;size:50"`
+	Day       time.Time `gorm:"primaryKey;type:date"`
+	LastValue int       `gorm:"not null;default:0"`
+}
+
+// OrderCodeFormat describes how order codes are built, e.g. ORD-JKT-20241216-0001
+type OrderCodeFormat struct {
+	Prefix     string
+	OutletCode string
+	DateLayout string
+	Separator  string
+	Padding    int
+	DailyReset bool
+}
+
+var DefaultOrderCodeFormat = OrderCodeFormat{Prefix: "ORD", Padding: 4}
+
+// orderCodeFormat is the format set on tx with OrderCodeFormatKey, the default otherwise
+func orderCodeFormat(tx *gorm.DB) OrderCodeFormat {
+	if value, ok := tx.Get(OrderCodeFormatKey); ok {
+		if format, ok := value.(OrderCodeFormat); ok {
+			return format
+		}
+	}
+	return DefaultOrderCodeFormat
+}
+
+func (f OrderCodeFormat) Format(number int, at time.Time) string {
+	parts := make([]string, 0, 4)
+	if f.Prefix != "" {
+		parts = append(parts, f.Prefix)
+	}
+	if f.OutletCode != "" {
+		parts = append(parts, f.OutletCode)
+	}
+	if f.DateLayout != "" {
+		parts = append(parts, at.Format(f.DateLayout))
+	}
+	parts = append(parts, fmt.Sprintf("%0*d", f.Padding, number))
+
+	return strings.Join(parts, f.Separator)
+}
+
+// Next reserves a new order code and the daily queue number within the given transaction
+func (f OrderCodeFormat) Next(tx *gorm.DB, at time.Time) (string, int, error) {
+	var number int
+	var err error
+	if f.DailyReset {
+		number, err = nextDailySequence(tx, orderCodeCounter, at)
+	} else {
+		err = tx.Raw("SELECT nextval(?)", orderCodeSequence).Scan(&number).Error
+	}
+	if err != nil {
+		return "", 0, fmt.Errorf("failed to reserve order code number: %v", err)
+	}
+
+	queueNumber, err := nextDailySequence(tx, orderQueueCounter, at)
+	if err != nil {
+		return "", 0, fmt.Errorf("failed to reserve queue number: %v", err)
+	}
+
+	return f.Format(number, at), queueNumber, nil
+}
+
+func nextDailySequence(tx *gorm.DB, name string, at time.Time) (int, error) {
+	var value int
+	err := tx.Raw(`
+		INSERT INTO order_sequences (name, day, last_value) VALUES (?, ?, 1)
+		ON CONFLICT (name, day) DO UPDATE SET last_value = order_sequences.last_value + 1
+		RETURNING last_value
+	`, name, at.Format("2006-01-02")).Scan(&value).Error
+	return value, err
+}
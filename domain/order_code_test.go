@@ -0,0 +1,57 @@
+package domain_test
+
+import (
+	"project/domain"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestOrderCodeFormat_Format(t *testing.T) {
+	at := time.Date(2024, 12, 16, 10, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name     string
+		format   domain.OrderCodeFormat
+		number   int
+		expected string
+	}{
+		{
+			name:     "Default format",
+			format:   domain.DefaultOrderCodeFormat,
+			number:   7,
+			expected: "ORD0007",
+		},
+		{
+			name:     "Number wider than padding",
+			format:   domain.DefaultOrderCodeFormat,
+			number:   10000,
+			expected: "ORD10000",
+		},
+		{
+			name: "Outlet and date with separator",
+			format: domain.OrderCodeFormat{
+				Prefix:     "ORD",
+				OutletCode: "JKT",
+				DateLayout: "20060102",
+				Separator:  "-",
+				Padding:    3,
+			},
+			number:   12,
+			expected: "ORD-JKT-20241216-012",
+		},
+		{
+			name:     "Without prefix",
+			format:   domain.OrderCodeFormat{DateLayout: "060102", Padding: 2},
+			number:   5,
+			expected: "24121605",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, tt.format.Format(tt.number, at))
+		})
+	}
+}
@@ -1,17 +1,52 @@
 package domain
 
-import "encoding/json"
+import (
+	"encoding/json"
+	"time"
+)
 
 type OrderDetail struct {
 	OrderID           int             `json:"order_id"`
 	DateOrder         string          `json:"date_order"`
 	TimeOrder         string          `json:"time_order"`
 	CodeOrder         string          `json:"code_order"`
+	QueueNumber       int             `json:"queue_number"`
 	Name              string          `json:"name"`
+	Type              string          `json:"type"`
+	PartySize         int             `json:"party_size"`
+	CustomerPhone     string          `json:"customer_phone"`
+	DeliveryAddress   string          `json:"delivery_address"`
+	PromisedAt        *time.Time      `json:"promised_at"`
+	CreatedAt         time.Time       `json:"created_at"`
 	TableName         string          `json:"table_name"`
+	JoinedTables      string          `json:"joined_tables"`
 	PaymentMethodName string          `json:"payment_method_name"`
 	OrderItems        json.RawMessage `json:"order_items"`
 	StatusPayment     string          `json:"status_payment"`
 	StatusKitchen     string          `json:"status_kitchen"`
 	Total             float64         `json:"total"`
+	Discounts         json.RawMessage `json:"discounts"`
+	Discount          float64         `json:"discount"`
+	TaxLines          json.RawMessage `json:"tax_lines"`
+	Tax               float64         `json:"tax"`
+	ServiceCharge     float64         `json:"service_charge"`
+	DeliveryFee       float64         `json:"delivery_fee"`
+	GrandTotal        float64         `json:"grand_total"`
+	Refunded          float64         `json:"refunded"`
+	NetTotal          float64         `json:"net_total"`
+	Tips              float64         `json:"tips"`
+	ReceiptPrints     int             `json:"receipt_prints"`
+	Version           uint            `json:"version"`
+}
+
+// OrderTypeRevenue is one row of the revenue per order type report
+type OrderTypeRevenue struct {
+	Type          string  `json:"type" example:"delivery"`
+	Orders        int64   `json:"orders" example:"42"`
+	Revenue       float64 `json:"revenue" example:"1250.40"`
+	Discount      float64 `json:"discount" example:"35.00"`
+	Tax           float64 `json:"tax" example:"112.80"`
+	ServiceCharge float64 `json:"service_charge" example:"0"`
+	DeliveryFee   float64 `json:"delivery_fee" example:"126.00"`
+	AverageOrder  float64 `json:"average_order" example:"29.77"`
 }
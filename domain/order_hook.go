@@ -1,80 +1,82 @@
 package domain
 
 import (
+	"errors"
 	"fmt"
 	"log"
-	"strconv"
+	"time"
 
 	"gorm.io/gorm"
 )
 
 // Utility Functions
-func generateCodeOrder(db *gorm.DB) (string, error) {
-	var lastOrder Order
-	err := db.Unscoped().Order("id desc").First(&lastOrder).Error
-	if err != nil && err != gorm.ErrRecordNotFound {
-		return "", fmt.Errorf("failed to retrieve last order: %v", err)
+func updateTableStatus(tx *gorm.DB, tableID *uint, status TableStatus) error {
+	if tableID == nil {
+		return nil
 	}
 
-	lastCode := "ORD0000"
-	if lastOrder.CodeOrder != "" {
-		lastCode = lastOrder.CodeOrder
-	}
-
-	codeNum, err := strconv.Atoi(lastCode[3:])
-	if err != nil {
-		return "", fmt.Errorf("failed to parse last order code number: %v", err)
-	}
-
-	newCodeNum := codeNum + 1
-	newCode := fmt.Sprintf("ORD%04d", newCodeNum)
-
-	return newCode, nil
-}
-
-func updateTableStatus(tx *gorm.DB, tableID uint, status bool) error {
 	var table Table
-	if err := tx.First(&table, tableID).Error; err != nil {
+	if err := tx.First(&table, *tableID).Error; err != nil {
 		return fmt.Errorf("failed to retrieve table: %v", err)
 	}
 
-	table.Status = status
-	if err := tx.Save(&table).Error; err != nil {
+	table.setStatus(status, time.Now())
+	if err := tx.Select("status", "status_changed_at", "seated_at", "updated_at").Save(&table).Error; err != nil {
 		return fmt.Errorf("failed to update table status: %v", err)
 	}
 	return nil
 }
 
-func validateTable(tx *gorm.DB, tableID uint) error {
-	var table Table
+// validateTable checks that an order can be seated at the table, a reserved table only takes the
+// party of the reservation holding it
+func validateTable(tx *gorm.DB, tableID *uint, reservationID uint) error {
+	if tableID == nil {
+		return nil
+	}
 
-	if err := tx.First(&table, tableID).Error; err != nil {
+	var table Table
+	if err := tx.First(&table, *tableID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ErrTableNotFound
+		}
 		return fmt.Errorf("failed to retrieve table: %v", err)
 	}
 
-	if !table.Status {
-		return fmt.Errorf(table.Name + " is already reserved")
+	if table.Status == TableReserved && reservationID != 0 {
+		var booked int64
+		err := tx.Model(&Reservation{}).Where("id = ?", reservationID).
+			Where("table_id = ? OR id IN (?)", table.ID, tx.Model(&ReservationTable{}).Select("reservation_id").Where("table_id = ?", table.ID)).
+			Count(&booked).Error
+		if err != nil {
+			return fmt.Errorf("failed to check reservation of table: %v", err)
+		}
+		if booked > 0 {
+			return nil
+		}
+	}
+	if table.Status != TableAvailable {
+		return fmt.Errorf("%w: %s is %s", ErrTableUnavailable, table.Name, table.Status)
 	}
 
 	return nil
 }
-func changeTable(tx *gorm.DB, orderID, tableID uint) error {
+func changeTable(tx *gorm.DB, orderID uint, tableID *uint) error {
 	var oldOrder Order
 	if err := tx.Unscoped().First(&oldOrder, orderID).Error; err != nil {
 		return fmt.Errorf("failed to retrieve old order: %v", err)
 	}
 
-	if oldOrder.TableID != tableID {
+	if !sameTable(oldOrder.TableID, tableID) {
 		log.Println("masuk before update oldtable != table")
 
-		if err := validateTable(tx, tableID); err != nil {
+		if err := validateTable(tx, tableID, 0); err != nil {
 			return err
 		}
-		if err := updateTableStatus(tx, oldOrder.TableID, true); err != nil {
+		if err := releaseTable(tx, oldOrder.TableID, TableAvailable, orderID); err != nil {
 			return err
 		}
 
-		if err := updateTableStatus(tx, tableID, false); err != nil {
+		if err := updateTableStatus(tx, tableID, TableOccupied); err != nil {
 			return err
 		}
 
@@ -82,6 +84,14 @@ func changeTable(tx *gorm.DB, orderID, tableID uint) error {
 
 	return nil
 }
+
+func sameTable(a, b *uint) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return *a == *b
+}
+
 func restoreStockForCancelledOrder(tx *gorm.DB, orderID uint) error {
 	var oldOrder Order
 	if err := tx.Unscoped().First(&oldOrder, orderID).Error; err != nil {
@@ -95,7 +105,7 @@ func restoreStockForCancelledOrder(tx *gorm.DB, orderID uint) error {
 
 	if oldOrder.StatusPayment != OrderCancelled {
 		for _, item := range orderItems {
-			if err := adjustProductStock(tx, item.ProductID, item.Quantity); err != nil {
+			if err := AdjustProductStock(tx, item.ProductID, item.Quantity); err != nil {
 				return err
 			}
 		}
@@ -103,6 +113,30 @@ func restoreStockForCancelledOrder(tx *gorm.DB, orderID uint) error {
 
 	return nil
 }
+// SaveOrderItems stores the items of an edited order, new ones are added and kept ones take their
+// product and quantity. Stock follows through the item hooks.
+func SaveOrderItems(tx *gorm.DB, orderID uint, orderItems []OrderItem) error {
+	for i := range orderItems {
+		item := &orderItems[i]
+		item.OrderID = orderID
+		if item.ID == 0 {
+			if err := tx.Create(item).Error; err != nil {
+				return fmt.Errorf("failed to add order item: %v", err)
+			}
+			continue
+		}
+
+		result := tx.Model(item).Where("order_id = ?", orderID).Select("product_id", "quantity", "updated_at").Updates(item)
+		if result.Error != nil {
+			return fmt.Errorf("failed to update order item: %v", result.Error)
+		}
+		if result.RowsAffected == 0 {
+			return fmt.Errorf("%w: item %d is not on the order", ErrInvalidOrder, item.ID)
+		}
+	}
+	return nil
+}
+
 func overWriteOrderItem(tx *gorm.DB, orderID uint, orderItems []OrderItem) error {
 	var existingItems []OrderItem
 	if err := tx.Where("order_id = ?", orderID).Find(&existingItems).Error; err != nil {
@@ -125,68 +159,76 @@ func overWriteOrderItem(tx *gorm.DB, orderID uint, orderItems []OrderItem) error
 	return nil
 }
 
-func adjustProductStock(tx *gorm.DB, productID uint, quantityChange int) error {
-	var product Product
-	if err := tx.First(&product, productID).Error; err != nil {
-		return fmt.Errorf("failed to retrieve product: %v", err)
-	}
-
-	newStock := product.Stock + quantityChange
-	if newStock < 0 {
+// AdjustProductStock changes stock with a single conditional UPDATE so concurrent sales
+// cannot oversell or overwrite each other's decrements
+func AdjustProductStock(tx *gorm.DB, productID uint, quantityChange int) error {
+	result := tx.Model(&Product{}).
+		Where("id = ? AND stock + ? >= 0", productID, quantityChange).
+		UpdateColumns(map[string]interface{}{
+			"stock":        gorm.Expr("stock + ?", quantityChange),
+			"availability": gorm.Expr("CASE WHEN stock + ? <= 0 THEN 'Out Of Stock' WHEN stock + ? <= 5 THEN 'Low Stock' ELSE 'In Stock' END", quantityChange, quantityChange),
+			"version":      gorm.Expr("version + 1"),
+			"updated_at":   time.Now(),
+		})
+	if result.Error != nil {
+		return fmt.Errorf("failed to update product stock: %v", result.Error)
+	}
+
+	if result.RowsAffected == 0 {
+		var product Product
+		if err := tx.First(&product, productID).Error; err != nil {
+			return fmt.Errorf("failed to retrieve product: %v", err)
+		}
 		return fmt.Errorf("insufficient stock for product %s", product.Name)
 	}
-
-	product.Stock = newStock
-	if err := tx.Save(&product).Error; err != nil {
-		return fmt.Errorf("failed to update product stock: %v", err)
-	}
 	return nil
 }
 
 // Hook Order
 func (o *Order) BeforeSave(tx *gorm.DB) (err error) {
-	if o.ID == 0 {
-		codeOrder, err := generateCodeOrder(tx)
+	if o.ID == 0 && o.CodeOrder == "" {
+		codeOrder, queueNumber, err := orderCodeFormat(tx).Next(tx, time.Now())
 		if err != nil {
 			return fmt.Errorf("failed to generate code_order: %v", err)
 		}
 		o.CodeOrder = codeOrder
+		o.QueueNumber = queueNumber
 	}
 	return nil
 }
 
 func (o *Order) BeforeCreate(tx *gorm.DB) (err error) {
+	if o.Type == "" {
+		o.Type = OrderDineIn
+	}
+	if err := o.Validate(); err != nil {
+		return err
+	}
 
-	if err := validateTable(tx, o.TableID); err != nil {
+	if err := validateTable(tx, o.TableID, o.ReservationID); err != nil {
 		return err
 	}
-	if err := updateTableStatus(tx, o.TableID, false); err != nil {
+	if err := updateTableStatus(tx, o.TableID, TableOccupied); err != nil {
 		return err
 	}
 	return nil
 }
 
+// Status changes go through Order.Transition, these hooks only cover edits of an open order
 func (o *Order) BeforeUpdate(tx *gorm.DB) (err error) {
-	if o.PaymentMethodID != nil {
-		o.StatusPayment = OrderCompleted
-		o.StatusKitchen = OrderReadyToServe
-	}
-
 	var order Order
 	if err := tx.First(&order, o.ID).Error; err != nil {
 		return fmt.Errorf("failed to retrieve table: %v", err)
 	}
 
-	if order.StatusPayment == OrderInProcess && o.PaymentMethodID == nil {
+	if order.StatusPayment == OrderInProcess {
 		log.Println(order.StatusPayment, "masuk before update")
 
-		if err := changeTable(tx, o.ID, o.TableID); err != nil {
+		if err := o.Validate(); err != nil {
 			return err
 		}
-	}
 
-	if o.StatusPayment == OrderCancelled {
-		if err := restoreStockForCancelledOrder(tx, o.ID); err != nil {
+		if err := changeTable(tx, o.ID, o.TableID); err != nil {
 			return err
 		}
 	}
@@ -195,18 +237,6 @@ func (o *Order) BeforeUpdate(tx *gorm.DB) (err error) {
 }
 
 func (o *Order) AfterUpdate(tx *gorm.DB) (err error) {
-	var order Order
-	if err := tx.First(&order, o.ID).Error; err != nil {
-		return fmt.Errorf("failed to retrieve table: %v", err)
-	}
-	if order.StatusPayment != OrderInProcess {
-		log.Println(order.StatusPayment, "masuk after update")
-
-		if err := updateTableStatus(tx, o.TableID, true); err != nil {
-			return fmt.Errorf("failed to update table status: %v", err)
-		}
-	}
-
 	if err := overWriteOrderItem(tx, o.ID, o.OrderItems); err != nil {
 		return err
 	}
@@ -221,14 +251,14 @@ func (o *Order) AfterDelete(tx *gorm.DB) (err error) {
 	}
 
 	for _, item := range orderItems {
-		if err := adjustProductStock(tx, item.ProductID, item.Quantity); err != nil {
+		if err := AdjustProductStock(tx, item.ProductID, item.Quantity); err != nil {
 			return fmt.Errorf("failed to restore stock for product ID %d: %v", item.ProductID, err)
 		}
 	}
 
 	if o.StatusPayment == OrderInProcess {
 		log.Println(o.StatusPayment, "masuk after delete")
-		if err := updateTableStatus(tx, o.TableID, true); err != nil {
+		if err := releaseOrderTables(tx, o, TableAvailable); err != nil {
 			return fmt.Errorf("failed to update table status: %v", err)
 		}
 	}
@@ -237,20 +267,31 @@ func (o *Order) AfterDelete(tx *gorm.DB) (err error) {
 
 // Hook Order Item
 func (oi *OrderItem) AfterCreate(tx *gorm.DB) (err error) {
-	if err := adjustProductStock(tx, oi.ProductID, -oi.Quantity); err != nil {
+	if err := AdjustProductStock(tx, oi.ProductID, -oi.Quantity); err != nil {
 		return fmt.Errorf("failed: %v", err)
 	}
 	return nil
 }
 
-func (oi *OrderItem) AfterUpdate(tx *gorm.DB) (err error) {
+// BeforeUpdate moves stock by the change of the item, read from the stored row before it is overwritten
+func (oi *OrderItem) BeforeUpdate(tx *gorm.DB) (err error) {
 	var old OrderItem
 	if err := tx.First(&old, oi.ID).Error; err != nil {
 		return fmt.Errorf("failed to retrieve old order item: %v", err)
 	}
 
+	if old.ProductID != oi.ProductID {
+		if err := AdjustProductStock(tx, old.ProductID, old.Quantity); err != nil {
+			return fmt.Errorf("failed to restore product stock: %v", err)
+		}
+		if err := AdjustProductStock(tx, oi.ProductID, -oi.Quantity); err != nil {
+			return fmt.Errorf("failed to adjust product stock: %v", err)
+		}
+		return nil
+	}
+
 	stockDifference := oi.Quantity - old.Quantity
-	if err := adjustProductStock(tx, oi.ProductID, -stockDifference); err != nil {
+	if err := AdjustProductStock(tx, oi.ProductID, -stockDifference); err != nil {
 		return fmt.Errorf("failed to adjust product stock: %v", err)
 	}
 
@@ -258,7 +299,7 @@ func (oi *OrderItem) AfterUpdate(tx *gorm.DB) (err error) {
 }
 
 func (oi *OrderItem) AfterDelete(tx *gorm.DB) (err error) {
-	if err := adjustProductStock(tx, oi.ProductID, oi.Quantity); err != nil {
+	if err := AdjustProductStock(tx, oi.ProductID, oi.Quantity); err != nil {
 		return fmt.Errorf("failed to restore product stock: %v", err)
 	}
 	return nil
@@ -0,0 +1,325 @@
+package domain
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+var (
+	ErrOrderMoveNotAllowed = errors.New("only orders in process can be merged, split or moved")
+	ErrInvalidOrderMove    = errors.New("invalid order move")
+)
+
+// OrderTable is an extra table joined to an order when a large party pushes tables together.
+// The order keeps its own table, joined tables are released with it.
+type OrderTable struct {
+	OrderID   uint      `gorm:"primaryKey" json:"order_id"`
+	TableID   uint      `gorm:"primaryKey" json:"table_id"`
+	Table     Table     `gorm:"foreignKey:TableID;references:ID" json:"table"`
+	CreatedAt time.Time `gorm:"autoCreateTime" json:"created_at"`
+}
+
+// OrderItemMove takes Quantity of an order item to another order, the whole item when Quantity is zero
+type OrderItemMove struct {
+	OrderItemID uint `json:"order_item_id" binding:"required" example:"1"`
+	Quantity    int  `json:"quantity" binding:"gte=0" example:"1"`
+}
+
+func (o *Order) checkMovable() error {
+	if o.StatusPayment != OrderInProcess {
+		return fmt.Errorf("%w: %s is %s", ErrOrderMoveNotAllowed, o.CodeOrder, o.StatusPayment)
+	}
+	return nil
+}
+
+// Split is a new order for the same guests and table, to bill part of the items apart
+func (o *Order) Split() *Order {
+	return &Order{
+		Name:            o.Name,
+		Type:            o.Type,
+		TableID:         o.TableID,
+		PartySize:       1,
+		CustomerPhone:   o.CustomerPhone,
+		DeliveryAddress: o.DeliveryAddress,
+		PromisedAt:      o.PromisedAt,
+	}
+}
+
+// MergeSources lists the orders to merge into target once each, in the order given
+func MergeSources(targetID uint, sourceIDs []uint) ([]uint, error) {
+	var ids []uint
+	seen := map[uint]bool{}
+	for _, id := range sourceIDs {
+		if id == targetID {
+			return nil, fmt.Errorf("%w: an order cannot be merged into itself", ErrInvalidOrderMove)
+		}
+		if seen[id] {
+			continue
+		}
+		seen[id] = true
+		ids = append(ids, id)
+	}
+	if len(ids) == 0 {
+		return nil, fmt.Errorf("%w: no orders to merge", ErrInvalidOrderMove)
+	}
+	return ids, nil
+}
+
+// MergeOrders moves the items, discounts, tenders, deposits, reservations, party and tables of the
+// sources into the target and cancels the sources. Items change order without hooks, so stock is
+// untouched, and the tables of the sources stay occupied as tables joined to the target.
+func MergeOrders(tx *gorm.DB, target *Order, sources []*Order) error {
+	ids := make([]uint, len(sources))
+	for i, source := range sources {
+		ids[i] = source.ID
+	}
+	ids, err := MergeSources(target.ID, ids)
+	if err != nil {
+		return err
+	}
+	if err := target.checkMovable(); err != nil {
+		return err
+	}
+
+	// a source listed twice is merged once, its party is only counted once
+	byID := make(map[uint]*Order, len(sources))
+	for _, source := range sources {
+		if _, ok := byID[source.ID]; !ok {
+			byID[source.ID] = source
+		}
+	}
+	for _, id := range ids {
+		if err := byID[id].checkMovable(); err != nil {
+			return err
+		}
+	}
+
+	for _, id := range ids {
+		source := byID[id]
+		if err := tx.Model(&OrderItem{}).Where("order_id = ?", source.ID).UpdateColumn("order_id", target.ID).Error; err != nil {
+			return fmt.Errorf("failed to move order items: %v", err)
+		}
+		// pricing rule discounts are calculated again for the target
+		if err := tx.Model(&OrderDiscount{}).Where("order_id = ? AND source <> ?", source.ID, DiscountRule).
+			UpdateColumn("order_id", target.ID).Error; err != nil {
+			return fmt.Errorf("failed to move order discounts: %v", err)
+		}
+		// deposits, points and gift cards used on the merged order pay the target, and come back if the
+		// target is cancelled
+		if err := tx.Model(&Payment{}).Where("order_id = ? AND type IN ?", source.ID, []PaymentType{PaymentTypeDeposit, PaymentTypePoints, PaymentTypeGiftCard}).
+			UpdateColumn("order_id", target.ID).Error; err != nil {
+			return fmt.Errorf("failed to move order tenders: %v", err)
+		}
+		if err := tx.Model(&LoyaltyEntry{}).Where("order_id = ? AND type IN ?", source.ID, []LoyaltyEntryType{LoyaltyRedeem, LoyaltyRestore}).
+			UpdateColumn("order_id", target.ID).Error; err != nil {
+			return fmt.Errorf("failed to move redeemed points: %v", err)
+		}
+		if err := tx.Model(&GiftCardTransaction{}).Where("order_id = ?", source.ID).UpdateColumn("order_id", target.ID).Error; err != nil {
+			return fmt.Errorf("failed to move gift card tenders: %v", err)
+		}
+		// the reservation seated at the source is completed when the target is paid
+		if err := tx.Model(&Reservation{}).Where("order_id = ?", source.ID).UpdateColumn("order_id", target.ID).Error; err != nil {
+			return fmt.Errorf("failed to move reservations: %v", err)
+		}
+
+		tables, err := orderTableIDs(tx, source)
+		if err != nil {
+			return err
+		}
+		if err := tx.Where("order_id = ?", source.ID).Delete(&OrderTable{}).Error; err != nil {
+			return fmt.Errorf("failed to move joined tables: %v", err)
+		}
+		for _, tableID := range tables {
+			if err := joinTable(tx, target, tableID); err != nil {
+				return err
+			}
+		}
+
+		target.PartySize += source.PartySize
+		source.StatusPayment = OrderCancelled
+		if err := tx.Model(&Order{}).Where("id = ?", source.ID).UpdateColumn("status_payment", source.StatusPayment).Error; err != nil {
+			return fmt.Errorf("failed to close merged order: %v", err)
+		}
+	}
+
+	return tx.Model(&Order{}).Where("id = ?", target.ID).UpdateColumn("party_size", target.PartySize).Error
+}
+
+// MoveItems moves whole items, or part of their quantity, from one open order to another
+func MoveItems(tx *gorm.DB, from, to *Order, moves []OrderItemMove) error {
+	if len(moves) == 0 {
+		return fmt.Errorf("%w: no items to move", ErrInvalidOrderMove)
+	}
+	if from.ID == to.ID {
+		return fmt.Errorf("%w: items cannot move to the same order", ErrInvalidOrderMove)
+	}
+	if err := from.checkMovable(); err != nil {
+		return err
+	}
+	if err := to.checkMovable(); err != nil {
+		return err
+	}
+
+	for _, move := range moves {
+		var item OrderItem
+		if err := tx.Where("id = ? AND order_id = ?", move.OrderItemID, from.ID).First(&item).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return errors.New("order item not found")
+			}
+			return err
+		}
+
+		quantity := move.Quantity
+		if quantity == 0 {
+			quantity = item.Quantity
+		}
+		if quantity < 0 || quantity > item.Quantity {
+			return fmt.Errorf("%w: only %d of item %d can be moved", ErrInvalidOrderMove, item.Quantity, item.ID)
+		}
+
+		// hooks are skipped, the stock was already taken when the item was ordered
+		if quantity == item.Quantity {
+			if err := tx.Model(&item).UpdateColumn("order_id", to.ID).Error; err != nil {
+				return fmt.Errorf("failed to move order item: %v", err)
+			}
+			if err := tx.Model(&OrderDiscount{}).Where("order_item_id = ? AND source <> ?", item.ID, DiscountRule).
+				UpdateColumn("order_id", to.ID).Error; err != nil {
+				return fmt.Errorf("failed to move item discounts: %v", err)
+			}
+			continue
+		}
+
+		if err := tx.Model(&item).UpdateColumn("quantity", item.Quantity-quantity).Error; err != nil {
+			return fmt.Errorf("failed to split order item: %v", err)
+		}
+		moved := OrderItem{OrderID: to.ID, ProductID: item.ProductID, Quantity: quantity}
+		if err := tx.Session(&gorm.Session{SkipHooks: true}).Create(&moved).Error; err != nil {
+			return fmt.Errorf("failed to split order item: %v", err)
+		}
+	}
+	return nil
+}
+
+// TransferTable seats an open order at another table, freeing the one it leaves
+func TransferTable(tx *gorm.DB, o *Order, tableID uint) error {
+	if err := o.checkMovable(); err != nil {
+		return err
+	}
+	if !o.Type.NeedsTable() {
+		return fmt.Errorf("%w: only dine-in orders have a table", ErrInvalidOrderMove)
+	}
+	if o.TableID != nil && *o.TableID == tableID {
+		return fmt.Errorf("%w: the order is already at this table", ErrInvalidOrderMove)
+	}
+
+	if err := changeTable(tx, o.ID, &tableID); err != nil {
+		return err
+	}
+	o.TableID = &tableID
+	return tx.Model(&Order{}).Where("id = ?", o.ID).UpdateColumn("table_id", tableID).Error
+}
+
+// JoinTables adds available tables to an open dine-in order and marks them occupied
+func JoinTables(tx *gorm.DB, o *Order, tableIDs []uint) error {
+	if len(tableIDs) == 0 {
+		return fmt.Errorf("%w: no tables to join", ErrInvalidOrderMove)
+	}
+	if err := o.checkMovable(); err != nil {
+		return err
+	}
+	if !o.Type.NeedsTable() {
+		return fmt.Errorf("%w: only dine-in orders have tables", ErrInvalidOrderMove)
+	}
+
+	for _, tableID := range tableIDs {
+		if err := validateTable(tx, &tableID, o.ReservationID); err != nil {
+			return err
+		}
+		if err := joinTable(tx, o, tableID); err != nil {
+			return err
+		}
+		if err := updateTableStatus(tx, &tableID, TableOccupied); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// LeaveTable takes a joined table off an open order and frees it
+func LeaveTable(tx *gorm.DB, o *Order, tableID uint) error {
+	if err := o.checkMovable(); err != nil {
+		return err
+	}
+
+	result := tx.Where("order_id = ? AND table_id = ?", o.ID, tableID).Delete(&OrderTable{})
+	if result.Error != nil {
+		return fmt.Errorf("failed to remove joined table: %v", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return fmt.Errorf("%w: table %d is not joined to the order", ErrInvalidOrderMove, tableID)
+	}
+	return releaseTable(tx, &tableID, TableAvailable, o.ID)
+}
+
+// joinTable records a table as joined to the order, unless the order already sits at it
+func joinTable(tx *gorm.DB, o *Order, tableID uint) error {
+	if o.TableID != nil && *o.TableID == tableID {
+		return nil
+	}
+	joined := OrderTable{OrderID: o.ID, TableID: tableID}
+	if err := tx.Where(joined).FirstOrCreate(&joined).Error; err != nil {
+		return fmt.Errorf("failed to join table: %v", err)
+	}
+	return nil
+}
+
+// orderTableIDs lists the table of the order followed by the tables joined to it
+func orderTableIDs(tx *gorm.DB, o *Order) ([]uint, error) {
+	var tables []uint
+	if o.TableID != nil {
+		tables = append(tables, *o.TableID)
+	}
+
+	var joined []uint
+	if err := tx.Model(&OrderTable{}).Where("order_id = ?", o.ID).Order("table_id").Pluck("table_id", &joined).Error; err != nil {
+		return nil, fmt.Errorf("failed to retrieve joined tables: %v", err)
+	}
+	return append(tables, joined...), nil
+}
+
+// releaseTable frees a table the order leaves, unless another open order still sits at it
+func releaseTable(tx *gorm.DB, tableID *uint, status TableStatus, orderID uint) error {
+	if tableID == nil {
+		return nil
+	}
+
+	var others int64
+	err := tx.Model(&Order{}).
+		Where("status_payment = ? AND id <> ?", OrderInProcess, orderID).
+		Where("table_id = ? OR id IN (?)", *tableID, tx.Model(&OrderTable{}).Select("order_id").Where("table_id = ?", *tableID)).
+		Count(&others).Error
+	if err != nil {
+		return fmt.Errorf("failed to check open orders of table: %v", err)
+	}
+	if others > 0 {
+		return nil
+	}
+	return updateTableStatus(tx, tableID, status)
+}
+
+// releaseOrderTables frees the table of the order and every table joined to it
+func releaseOrderTables(tx *gorm.DB, o *Order, status TableStatus) error {
+	tables, err := orderTableIDs(tx, o)
+	if err != nil {
+		return err
+	}
+	for i := range tables {
+		if err := releaseTable(tx, &tables[i], status, o.ID); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,82 @@
+package domain_test
+
+import (
+	"project/domain"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestOrder_Split(t *testing.T) {
+	table := uint(3)
+	promised := time.Date(2024, 5, 10, 19, 30, 0, 0, time.UTC)
+	order := domain.Order{
+		ID:              7,
+		Name:            "Smith",
+		Type:            domain.OrderDelivery,
+		TableID:         &table,
+		PartySize:       4,
+		CustomerPhone:   "+1 555",
+		DeliveryAddress: "1st Street 12",
+		DeliveryFee:     3.5,
+		PromisedAt:      &promised,
+		CodeOrder:       "ORD-0007",
+	}
+
+	split := order.Split()
+	assert.Zero(t, split.ID)
+	assert.Empty(t, split.CodeOrder)
+	assert.Equal(t, "Smith", split.Name)
+	assert.Equal(t, &table, split.TableID)
+	assert.Equal(t, 1, split.PartySize)
+	assert.Equal(t, "1st Street 12", split.DeliveryAddress)
+	assert.Zero(t, split.DeliveryFee, "the delivery fee stays on the original order")
+}
+
+func TestMergeSources(t *testing.T) {
+	ids, err := domain.MergeSources(1, []uint{3, 2, 3, 2})
+	assert.NoError(t, err)
+	assert.Equal(t, []uint{3, 2}, ids)
+
+	_, err = domain.MergeSources(1, []uint{2, 1})
+	assert.ErrorIs(t, err, domain.ErrInvalidOrderMove)
+
+	_, err = domain.MergeSources(1, nil)
+	assert.ErrorIs(t, err, domain.ErrInvalidOrderMove)
+}
+
+func TestOrderMoves_Rejected(t *testing.T) {
+	open := func(id uint) *domain.Order {
+		return &domain.Order{ID: id, Type: domain.OrderDineIn, StatusPayment: domain.OrderInProcess}
+	}
+	paid := &domain.Order{ID: 9, Type: domain.OrderDineIn, StatusPayment: domain.OrderCompleted}
+	takeaway := &domain.Order{ID: 10, Type: domain.OrderTakeaway, StatusPayment: domain.OrderInProcess}
+	items := []domain.OrderItemMove{{OrderItemID: 1}}
+
+	tests := []struct {
+		name string
+		move func() error
+		err  error
+	}{
+		{"Merge nothing", func() error { return domain.MergeOrders(nil, open(1), nil) }, domain.ErrInvalidOrderMove},
+		{"Merge into itself", func() error { return domain.MergeOrders(nil, open(1), []*domain.Order{open(1)}) }, domain.ErrInvalidOrderMove},
+		{"Merge itself among others", func() error { return domain.MergeOrders(nil, open(1), []*domain.Order{open(2), open(1)}) }, domain.ErrInvalidOrderMove},
+		{"Merge into a paid order", func() error { return domain.MergeOrders(nil, paid, []*domain.Order{open(1)}) }, domain.ErrOrderMoveNotAllowed},
+		{"Merge a paid order", func() error { return domain.MergeOrders(nil, open(1), []*domain.Order{paid}) }, domain.ErrOrderMoveNotAllowed},
+		{"Move no items", func() error { return domain.MoveItems(nil, open(1), open(2), nil) }, domain.ErrInvalidOrderMove},
+		{"Move to the same order", func() error { return domain.MoveItems(nil, open(1), open(1), items) }, domain.ErrInvalidOrderMove},
+		{"Move to a paid order", func() error { return domain.MoveItems(nil, open(1), paid, items) }, domain.ErrOrderMoveNotAllowed},
+		{"Transfer a takeaway order", func() error { return domain.TransferTable(nil, takeaway, 2) }, domain.ErrInvalidOrderMove},
+		{"Transfer a paid order", func() error { return domain.TransferTable(nil, paid, 2) }, domain.ErrOrderMoveNotAllowed},
+		{"Join no tables", func() error { return domain.JoinTables(nil, open(1), nil) }, domain.ErrInvalidOrderMove},
+		{"Join tables to a takeaway order", func() error { return domain.JoinTables(nil, takeaway, []uint{2}) }, domain.ErrInvalidOrderMove},
+		{"Leave a table of a paid order", func() error { return domain.LeaveTable(nil, paid, 2) }, domain.ErrOrderMoveNotAllowed},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.ErrorIs(t, tt.move(), tt.err)
+		})
+	}
+}
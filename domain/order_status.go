@@ -0,0 +1,229 @@
+package domain
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+var (
+	ErrInvalidOrderTransition = errors.New("invalid order status transition")
+	ErrOrderTransitionGuard   = errors.New("order transition requirement not met")
+)
+
+type OrderAction string
+
+const (
+	OrderActionFire   OrderAction = "fire"
+	OrderActionReady  OrderAction = "ready"
+	OrderActionServe  OrderAction = "serve"
+	OrderActionPay    OrderAction = "pay"
+	OrderActionCancel OrderAction = "cancel"
+
+	// void and refund are recorded in the history but never change the order statuses
+	OrderActionVoid   OrderAction = "void"
+	OrderActionRefund OrderAction = "refund"
+
+	// moves between orders and tables are recorded in the history, a merge cancels the merged orders
+	OrderActionMerge      OrderAction = "merge"
+	OrderActionSplit      OrderAction = "split"
+	OrderActionMoveItems  OrderAction = "move_items"
+	OrderActionTransfer   OrderAction = "transfer"
+	OrderActionJoinTable  OrderAction = "join_table"
+	OrderActionLeaveTable OrderAction = "leave_table"
+)
+
+// OrderTransition lists the statuses an action may start from and the statuses it leads to.
+// An empty target leaves that status unchanged.
+type OrderTransition struct {
+	FromPayment []StatusPayment
+	FromKitchen []StatusKitchen
+	ToPayment   StatusPayment
+	ToKitchen   StatusKitchen
+}
+
+var activeOrder = []StatusPayment{OrderInProcess, OrderCompleted}
+
+var OrderTransitions = map[OrderAction]OrderTransition{
+	OrderActionFire: {
+		FromPayment: activeOrder,
+		FromKitchen: []StatusKitchen{OrderInTheKitchen},
+		ToKitchen:   OrderCookingNow,
+	},
+	OrderActionReady: {
+		FromPayment: activeOrder,
+		FromKitchen: []StatusKitchen{OrderCookingNow},
+		ToKitchen:   OrderReadyToServe,
+	},
+	OrderActionServe: {
+		FromPayment: activeOrder,
+		FromKitchen: []StatusKitchen{OrderReadyToServe},
+		ToKitchen:   OrderServed,
+	},
+	OrderActionPay: {
+		FromPayment: []StatusPayment{OrderInProcess},
+		ToPayment:   OrderCompleted,
+	},
+	OrderActionCancel: {
+		FromPayment: []StatusPayment{OrderInProcess},
+		ToPayment:   OrderCancelled,
+	},
+}
+
+type OrderTransitionInput struct {
+	UserID          *uint
+	Reason          string
+	PaymentMethodID *uint
+	Tip             float64
+}
+
+type OrderStatusHistory struct {
+	ID          uint          `gorm:"primaryKey" json:"id"`
+	OrderID     uint          `gorm:"not null;index" json:"order_id"`
+	Action      OrderAction   `gorm:"size:20;not null" json:"action" example:"fire"`
+	FromPayment StatusPayment `gorm:"type:status_payment" json:"from_payment" example:"In Process"`
+	ToPayment   StatusPayment `gorm:"type:status_payment" json:"to_payment" example:"In Process"`
+	FromKitchen StatusKitchen `gorm:"type:status_kitchen" json:"from_kitchen" example:"In The Kitchen"`
+	ToKitchen   StatusKitchen `gorm:"type:status_kitchen" json:"to_kitchen" example:"Cooking Now"`
+	UserID      *uint         `json:"user_id"`
+	User        *User         `gorm:"foreignKey:UserID;references:ID" json:"-"`
+	Reason      string        `gorm:"size:255" json:"reason"`
+	CreatedAt   time.Time     `gorm:"autoCreateTime" json:"created_at"`
+}
+
+func (OrderStatusHistory) TableName() string {
+	return "order_status_history"
+}
+
+// Transition validates action against the current statuses and guards, applies its side effects
+// (stock and table) and moves the order to the new statuses. The caller persists the order and history.
+func (o *Order) Transition(tx *gorm.DB, action OrderAction, input OrderTransitionInput) (*OrderStatusHistory, error) {
+	transition, ok := OrderTransitions[action]
+	if !ok {
+		return nil, fmt.Errorf("%w: unknown action %s", ErrInvalidOrderTransition, action)
+	}
+
+	if !containsStatus(transition.FromPayment, o.StatusPayment) ||
+		(len(transition.FromKitchen) > 0 && !containsStatus(transition.FromKitchen, o.StatusKitchen)) {
+		return nil, fmt.Errorf("%w: cannot %s an order that is %s / %s", ErrInvalidOrderTransition, action, o.StatusPayment, o.StatusKitchen)
+	}
+
+	if err := o.checkTransitionGuards(tx, action, input); err != nil {
+		return nil, err
+	}
+
+	if err := o.applyTransitionEffects(tx, action, input); err != nil {
+		return nil, err
+	}
+
+	history := &OrderStatusHistory{
+		OrderID:     o.ID,
+		Action:      action,
+		FromPayment: o.StatusPayment,
+		FromKitchen: o.StatusKitchen,
+		UserID:      input.UserID,
+		Reason:      input.Reason,
+	}
+
+	if transition.ToPayment != "" {
+		o.StatusPayment = transition.ToPayment
+	}
+	if transition.ToKitchen != "" {
+		o.StatusKitchen = transition.ToKitchen
+	}
+	if action == OrderActionPay {
+		o.PaymentMethodID = input.PaymentMethodID
+	}
+
+	history.ToPayment = o.StatusPayment
+	history.ToKitchen = o.StatusKitchen
+	return history, nil
+}
+
+func (o *Order) checkTransitionGuards(tx *gorm.DB, action OrderAction, input OrderTransitionInput) error {
+	switch action {
+	case OrderActionPay:
+		if input.PaymentMethodID == nil {
+			return fmt.Errorf("%w: payment method is required", ErrOrderTransitionGuard)
+		}
+
+		var paymentMethod PaymentMethod
+		if err := tx.Where("status = ?", true).First(&paymentMethod, *input.PaymentMethodID).Error; err != nil {
+			return fmt.Errorf("%w: payment method %d is not available", ErrOrderTransitionGuard, *input.PaymentMethodID)
+		}
+
+		var items int64
+		if err := tx.Model(&OrderItem{}).Where("order_id = ?", o.ID).Count(&items).Error; err != nil {
+			return fmt.Errorf("failed to count order items: %v", err)
+		}
+		if items == 0 {
+			return fmt.Errorf("%w: order has no items", ErrOrderTransitionGuard)
+		}
+	case OrderActionCancel:
+		if input.Reason == "" {
+			return fmt.Errorf("%w: a reason is required to cancel an order", ErrOrderTransitionGuard)
+		}
+	}
+	return nil
+}
+
+func (o *Order) applyTransitionEffects(tx *gorm.DB, action OrderAction, input OrderTransitionInput) error {
+	switch action {
+	case OrderActionCancel:
+		if err := restoreStockForCancelledOrder(tx, o.ID); err != nil {
+			return err
+		}
+		if err := releasePromoCodes(tx, o.ID); err != nil {
+			return err
+		}
+		return releaseOrderTables(tx, o, TableAvailable)
+	case OrderActionPay:
+		amount, err := o.AmountDue(tx)
+		if err != nil {
+			return err
+		}
+		// the deposit of the reservation the party was seated with, loyalty points and gift cards are already paid
+		tendered, err := OrderTendered(tx, o.ID)
+		if err != nil {
+			return fmt.Errorf("failed to calculate order tenders: %v", err)
+		}
+		// a deposit larger than the bill is not kept, what the bill did not use goes back to the guest.
+		// Points and gift cards never exceed the bill, pricing the order keeps it above them.
+		if excess := math.Round((tendered-amount)*100) / 100; excess > 0 {
+			if err := refundExcessDeposit(tx, o.ID, excess, input.UserID); err != nil {
+				return err
+			}
+		}
+		amount = math.Max(math.Round((amount-tendered)*100)/100, 0)
+
+		payment := Payment{
+			OrderID:         &o.ID,
+			PaymentMethodID: input.PaymentMethodID,
+			Type:            PaymentTypePayment,
+			Amount:          amount,
+			Tip:             input.Tip,
+			UserID:          input.UserID,
+		}
+		if err := tx.Create(&payment).Error; err != nil {
+			return fmt.Errorf("failed to record payment: %v", err)
+		}
+		if err := completeReservation(tx, o.ID, input); err != nil {
+			return err
+		}
+		// the guests have left, the table is cleared before the next party is seated
+		return releaseOrderTables(tx, o, TableNeedsCleaning)
+	}
+	return nil
+}
+
+func containsStatus[T comparable](statuses []T, status T) bool {
+	for _, s := range statuses {
+		if s == status {
+			return true
+		}
+	}
+	return false
+}
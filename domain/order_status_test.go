@@ -0,0 +1,81 @@
+package domain_test
+
+import (
+	"project/domain"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestOrder_TransitionKitchenFlow(t *testing.T) {
+	order := domain.Order{ID: 1, StatusPayment: domain.OrderInProcess, StatusKitchen: domain.OrderInTheKitchen}
+
+	for _, step := range []struct {
+		action   domain.OrderAction
+		expected domain.StatusKitchen
+	}{
+		{domain.OrderActionFire, domain.OrderCookingNow},
+		{domain.OrderActionReady, domain.OrderReadyToServe},
+		{domain.OrderActionServe, domain.OrderServed},
+	} {
+		history, err := order.Transition(nil, step.action, domain.OrderTransitionInput{})
+
+		assert.NoError(t, err)
+		assert.Equal(t, step.expected, order.StatusKitchen)
+		assert.Equal(t, step.expected, history.ToKitchen)
+		assert.Equal(t, domain.OrderInProcess, history.ToPayment)
+	}
+}
+
+func TestOrder_TransitionRejected(t *testing.T) {
+	tests := []struct {
+		name   string
+		order  domain.Order
+		action domain.OrderAction
+		input  domain.OrderTransitionInput
+		err    error
+	}{
+		{
+			name:   "Serve before ready",
+			order:  domain.Order{StatusPayment: domain.OrderInProcess, StatusKitchen: domain.OrderCookingNow},
+			action: domain.OrderActionServe,
+			err:    domain.ErrInvalidOrderTransition,
+		},
+		{
+			name:   "Fire cancelled order",
+			order:  domain.Order{StatusPayment: domain.OrderCancelled, StatusKitchen: domain.OrderInTheKitchen},
+			action: domain.OrderActionFire,
+			err:    domain.ErrInvalidOrderTransition,
+		},
+		{
+			name:   "Cancel completed order",
+			order:  domain.Order{StatusPayment: domain.OrderCompleted, StatusKitchen: domain.OrderServed},
+			action: domain.OrderActionCancel,
+			input:  domain.OrderTransitionInput{Reason: "wrong table"},
+			err:    domain.ErrInvalidOrderTransition,
+		},
+		{
+			name:   "Cancel without reason",
+			order:  domain.Order{StatusPayment: domain.OrderInProcess, StatusKitchen: domain.OrderInTheKitchen},
+			action: domain.OrderActionCancel,
+			err:    domain.ErrOrderTransitionGuard,
+		},
+		{
+			name:   "Pay without payment method",
+			order:  domain.Order{StatusPayment: domain.OrderInProcess, StatusKitchen: domain.OrderServed},
+			action: domain.OrderActionPay,
+			err:    domain.ErrOrderTransitionGuard,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			before := tt.order
+			history, err := tt.order.Transition(nil, tt.action, tt.input)
+
+			assert.ErrorIs(t, err, tt.err)
+			assert.Nil(t, history)
+			assert.Equal(t, before, tt.order)
+		})
+	}
+}
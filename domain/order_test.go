@@ -0,0 +1,41 @@
+package domain_test
+
+import (
+	"project/domain"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestOrder_Validate(t *testing.T) {
+	table := uint(3)
+	promised := time.Date(2024, 5, 10, 19, 30, 0, 0, time.UTC)
+
+	tests := []struct {
+		name  string
+		order domain.Order
+		ok    bool
+	}{
+		{"Dine-in at a table", domain.Order{Type: domain.OrderDineIn, TableID: &table}, true},
+		{"Dine-in without a table", domain.Order{Type: domain.OrderDineIn}, false},
+		{"Takeaway without a table", domain.Order{Type: domain.OrderTakeaway, PromisedAt: &promised}, true},
+		{"Pickup with a table", domain.Order{Type: domain.OrderPickup, TableID: &table}, false},
+		{"Delivery with an address and fee", domain.Order{Type: domain.OrderDelivery, DeliveryAddress: "1st Street 12", DeliveryFee: 3.5, PromisedAt: &promised}, true},
+		{"Delivery without an address", domain.Order{Type: domain.OrderDelivery, DeliveryFee: 3.5}, false},
+		{"Delivery fee on a takeaway order", domain.Order{Type: domain.OrderTakeaway, DeliveryFee: 2}, false},
+		{"Promised time on a dine-in order", domain.Order{Type: domain.OrderDineIn, TableID: &table, PromisedAt: &promised}, false},
+		{"Unknown type", domain.Order{Type: "drive_through"}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.order.Validate()
+			if tt.ok {
+				assert.NoError(t, err)
+			} else {
+				assert.ErrorIs(t, err, domain.ErrInvalidOrder)
+			}
+		})
+	}
+}
@@ -0,0 +1,160 @@
+package domain
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// ErrBillBelowTenders is returned when a change would take the bill of an order below what points
+// and gift cards already paid towards it
+var ErrBillBelowTenders = errors.New("the bill is less than what was paid with points and gift cards")
+
+type PaymentType string
+
+const (
+	PaymentTypePayment PaymentType = "payment"
+	PaymentTypeRefund  PaymentType = "refund"
+	// a deposit is taken with a reservation and credited against the bill of the order it is seated with
+	PaymentTypeDeposit PaymentType = "deposit"
+	// a forfeit records the part of a deposit kept when the reservation is cancelled late or not shown up for
+	PaymentTypeForfeit PaymentType = "forfeit"
+	// points pays part of an order with the loyalty points of its customer, it is never refunded in money
+	PaymentTypePoints PaymentType = "points"
+	// gift card pays part of an order from the balance of a gift card, refunds go back to the card
+	PaymentTypeGiftCard PaymentType = "gift_card"
+)
+
+// Payment records money taken for an order or returned to the guest, amounts are always positive.
+// Tips are kept apart from Amount: they belong to the staff, are not revenue and are never refunded.
+// Reservation deposits belong to the reservation and to the order once it is seated.
+type Payment struct {
+	ID              uint          `gorm:"primaryKey" json:"id"`
+	OrderID         *uint         `gorm:"index" json:"order_id"`
+	ReservationID   *uint         `gorm:"index;check:chk_payments_owner,order_id IS NOT NULL OR reservation_id IS NOT NULL" json:"reservation_id"`
+	PaymentMethodID *uint         `json:"payment_method_id"`
+	PaymentMethod   PaymentMethod `gorm:"foreignKey:PaymentMethodID;references:ID" json:"-"`
+	Type            PaymentType   `gorm:"size:20;not null;check:type IN ('payment', 'refund', 'deposit', 'forfeit', 'points', 'gift_card')" json:"type" example:"payment"`
+	Amount          float64       `gorm:"type:decimal(10,2);not null" json:"amount" example:"31.99"`
+	Tip             float64       `gorm:"type:decimal(10,2);not null;default:0" json:"tip" example:"4.00"`
+	UserID          *uint         `json:"user_id"`
+	CreatedAt       time.Time     `gorm:"autoCreateTime" json:"created_at"`
+}
+
+// OrderSubtotal sums the order items at their product prices, before discounts and exclusive taxes
+func OrderSubtotal(tx *gorm.DB, orderID uint) (float64, error) {
+	var subtotal float64
+	err := tx.Model(&OrderItem{}).
+		Select("COALESCE(SUM(order_items.quantity * products.price), 0)").
+		Joins("JOIN products ON products.id = order_items.product_id").
+		Where("order_items.order_id = ?", orderID).
+		Scan(&subtotal).Error
+	return subtotal, err
+}
+
+// AmountDue is the order subtotal less its discounts, plus the taxes not included in the prices, the service charge and the delivery fee
+func (o *Order) AmountDue(tx *gorm.DB) (float64, error) {
+	subtotal, err := OrderSubtotal(tx, o.ID)
+	if err != nil {
+		return 0, fmt.Errorf("failed to calculate order total: %v", err)
+	}
+
+	discount, err := OrderDiscountTotal(tx, o.ID)
+	if err != nil {
+		return 0, fmt.Errorf("failed to calculate order discounts: %v", err)
+	}
+
+	tax, err := OrderExclusiveTax(tx, o.ID)
+	if err != nil {
+		return 0, fmt.Errorf("failed to calculate order taxes: %v", err)
+	}
+
+	var charges struct {
+		ServiceCharge float64
+		DeliveryFee   float64
+	}
+	if err := tx.Model(&Order{}).Select("service_charge, delivery_fee").Where("id = ?", o.ID).Scan(&charges).Error; err != nil {
+		return 0, fmt.Errorf("failed to retrieve order charges: %v", err)
+	}
+
+	return math.Round((math.Max(subtotal-discount, 0)+tax+charges.ServiceCharge+charges.DeliveryFee)*100) / 100, nil
+}
+
+// ChargedAmount converts an amount at list prices into what the guest pays for it,
+// applying the order's discounts and taxes in proportion
+func (o *Order) ChargedAmount(tx *gorm.DB, listAmount float64) (float64, error) {
+	subtotal, err := OrderSubtotal(tx, o.ID)
+	if err != nil {
+		return 0, fmt.Errorf("failed to calculate order total: %v", err)
+	}
+	if subtotal == 0 {
+		return 0, nil
+	}
+
+	amountDue, err := o.AmountDue(tx)
+	if err != nil {
+		return 0, err
+	}
+	return math.Round(listAmount*amountDue/subtotal*100) / 100, nil
+}
+
+// OrderDeposits sums the reservation deposits credited to the order
+func OrderDeposits(tx *gorm.DB, orderID uint) (float64, error) {
+	var deposits float64
+	err := tx.Model(&Payment{}).Select("COALESCE(SUM(amount), 0)").
+		Where("order_id = ? AND type = ?", orderID, PaymentTypeDeposit).
+		Scan(&deposits).Error
+	return deposits, err
+}
+
+// OrderRedeemed sums what the loyalty points and gift cards paid towards the order
+func OrderRedeemed(tx *gorm.DB, orderID uint) (float64, error) {
+	var redeemed float64
+	err := tx.Model(&Payment{}).Select("COALESCE(SUM(amount), 0)").
+		Where("order_id = ? AND type IN ?", orderID, []PaymentType{PaymentTypePoints, PaymentTypeGiftCard}).
+		Scan(&redeemed).Error
+	return redeemed, err
+}
+
+// CheckRedeemed makes sure an amount due still covers what points and gift cards paid. Unlike a
+// deposit they are not paid back in money, so what the bill did not use could not be returned.
+func CheckRedeemed(amountDue, redeemed float64) error {
+	if math.Round((redeemed-amountDue)*100)/100 > 0 {
+		return fmt.Errorf("%w: %.2f is due and %.2f was paid, cancel the order to return them", ErrBillBelowTenders, amountDue, redeemed)
+	}
+	return nil
+}
+
+func (o *Order) checkRedeemed(tx *gorm.DB) error {
+	amountDue, err := o.AmountDue(tx)
+	if err != nil {
+		return err
+	}
+	redeemed, err := OrderRedeemed(tx, o.ID)
+	if err != nil {
+		return fmt.Errorf("failed to calculate redeemed tenders: %v", err)
+	}
+	return CheckRedeemed(amountDue, redeemed)
+}
+
+// OrderPoints sums the loyalty points redeemed on the order, in currency
+func OrderPoints(tx *gorm.DB, orderID uint) (float64, error) {
+	var points float64
+	err := tx.Model(&Payment{}).Select("COALESCE(SUM(amount), 0)").
+		Where("order_id = ? AND type = ?", orderID, PaymentTypePoints).
+		Scan(&points).Error
+	return points, err
+}
+
+// OrderTendered sums what was paid towards the order before it is settled: the reservation deposit,
+// the loyalty points and the gift cards
+func OrderTendered(tx *gorm.DB, orderID uint) (float64, error) {
+	var tendered float64
+	err := tx.Model(&Payment{}).Select("COALESCE(SUM(amount), 0)").
+		Where("order_id = ? AND type IN ?", orderID, []PaymentType{PaymentTypeDeposit, PaymentTypePoints, PaymentTypeGiftCard}).
+		Scan(&tendered).Error
+	return tendered, err
+}
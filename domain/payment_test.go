@@ -0,0 +1,34 @@
+package domain_test
+
+import (
+	"project/domain"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCheckRedeemed(t *testing.T) {
+	tests := []struct {
+		name      string
+		amountDue float64
+		redeemed  float64
+		covered   bool
+	}{
+		{"Nothing redeemed", 40, 0, true},
+		{"Bill above the tenders", 40, 25, true},
+		{"Bill equal to the tenders", 25, 25, true},
+		{"Within rounding", 24.999, 25, true},
+		{"Bill below the tenders", 20, 25, false},
+		{"Everything voided", 0, 10, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := domain.CheckRedeemed(tt.amountDue, tt.redeemed)
+			if tt.covered {
+				assert.NoError(t, err)
+			} else {
+				assert.ErrorIs(t, err, domain.ErrBillBelowTenders)
+			}
+		})
+	}
+}
@@ -0,0 +1,342 @@
+package domain
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+)
+
+type ReceiptFormat string
+
+const (
+	ReceiptESCPOS ReceiptFormat = "escpos"
+	ReceiptPDF    ReceiptFormat = "pdf"
+	ReceiptHTML   ReceiptFormat = "html"
+)
+
+// ReceiptLayout is the configurable part of every receipt
+type ReceiptLayout struct {
+	Header []string
+	Footer []string
+	// Logo is the path of a PNG or JPEG printed above the header
+	Logo string
+	// PaperWidth is 58 or 80 (mm)
+	PaperWidth int
+}
+
+// Columns is the number of characters a line holds on the thermal paper with the default font
+func (l ReceiptLayout) Columns() int {
+	if l.PaperWidth == 58 {
+		return 32
+	}
+	return 48
+}
+
+// Dots is the printable width of the thermal paper in dots at 203 dpi
+func (l ReceiptLayout) Dots() int {
+	if l.PaperWidth == 58 {
+		return 384
+	}
+	return 576
+}
+
+type ReceiptItem struct {
+	Name     string  `json:"product_name"`
+	Price    float64 `json:"product_price"`
+	Quantity int     `json:"quantity"`
+	SubTotal float64 `json:"sub_total"`
+	Discount float64 `json:"discount"`
+}
+
+type ReceiptTaxLine struct {
+	Code      string  `json:"code"`
+	Name      string  `json:"name"`
+	Rate      float64 `json:"rate"`
+	Inclusive bool    `json:"inclusive"`
+	Amount    float64 `json:"amount"`
+}
+
+type ReceiptPayment struct {
+	Method string
+	Type   PaymentType
+	Amount float64
+	Tip    float64
+}
+
+// ReceiptPaymentRow is a payment with the name of its method, as read for a receipt
+type ReceiptPaymentRow struct {
+	Payment
+	MethodName string
+}
+
+// Receipt is everything printed on a customer receipt, built from the order details
+type Receipt struct {
+	Layout        ReceiptLayout
+	CodeOrder     string
+	QueueNumber   int
+	Name          string
+	Type          OrderType
+	TableName     string
+	Date          string
+	Time          string
+	Items         []ReceiptItem
+	Subtotal      float64
+	Discount      float64
+	TaxLines      []ReceiptTaxLine
+	ServiceCharge float64
+	DeliveryFee   float64
+	GrandTotal    float64
+	Refunded      float64
+	NetTotal      float64
+	Tips          float64
+	Payments      []ReceiptPayment
+	// Link is the digital receipt encoded in the QR code, no QR code is printed when empty
+	Link string
+	// FeedbackLink asks the guest to rate a completed order
+	FeedbackLink string
+	// Print counts the receipts printed for the order, anything above one is a reprint
+	Print     int
+	PrintedAt time.Time
+}
+
+// NewReceipt reads the items and tax lines stored as JSON on the order details
+func NewReceipt(detail OrderDetail, payments []ReceiptPaymentRow, layout ReceiptLayout) (*Receipt, error) {
+	receipt := &Receipt{
+		Layout:        layout,
+		CodeOrder:     detail.CodeOrder,
+		QueueNumber:   detail.QueueNumber,
+		Name:          detail.Name,
+		Type:          OrderType(detail.Type),
+		TableName:     detail.TableName,
+		Date:          detail.DateOrder,
+		Time:          detail.TimeOrder,
+		Subtotal:      detail.Total,
+		Discount:      detail.Discount,
+		ServiceCharge: detail.ServiceCharge,
+		DeliveryFee:   detail.DeliveryFee,
+		GrandTotal:    detail.GrandTotal,
+		Refunded:      detail.Refunded,
+		NetTotal:      detail.NetTotal,
+		Tips:          detail.Tips,
+		PrintedAt:     time.Now(),
+	}
+
+	if len(detail.OrderItems) > 0 {
+		if err := json.Unmarshal(detail.OrderItems, &receipt.Items); err != nil {
+			return nil, fmt.Errorf("failed to read order items: %v", err)
+		}
+	}
+	// an order without items still has one null entry from the outer join
+	items := receipt.Items[:0]
+	for _, item := range receipt.Items {
+		if item.Name != "" {
+			items = append(items, item)
+		}
+	}
+	receipt.Items = items
+
+	if len(detail.TaxLines) > 0 {
+		if err := json.Unmarshal(detail.TaxLines, &receipt.TaxLines); err != nil {
+			return nil, fmt.Errorf("failed to read tax lines: %v", err)
+		}
+	}
+
+	for _, payment := range payments {
+		receipt.Payments = append(receipt.Payments, ReceiptPayment{
+			Method: payment.MethodName,
+			Type:   payment.Type,
+			Amount: payment.Amount,
+			Tip:    payment.Tip,
+		})
+	}
+	return receipt, nil
+}
+
+// Reprint tells whether the receipt was printed before
+func (r *Receipt) Reprint() bool {
+	return r.Print > 1
+}
+
+// Lines lays the receipt out as plain text for a printer with the given number of columns
+func (r *Receipt) Lines(columns int) []string {
+	var lines []string
+	separator := strings.Repeat("-", columns)
+
+	for _, line := range r.Layout.Header {
+		lines = append(lines, center(line, columns))
+	}
+	if r.Reprint() {
+		lines = append(lines, center(fmt.Sprintf("*** REPRINT #%d ***", r.Print-1), columns))
+	}
+	lines = append(lines, separator,
+		spread("Order", r.CodeOrder, columns),
+		spread("Queue", fmt.Sprintf("%d", r.QueueNumber), columns),
+		spread("Date", r.Date+" "+r.Time, columns),
+		spread("Type", r.Type.Label(), columns))
+	if r.TableName != "" {
+		lines = append(lines, spread("Table", r.TableName, columns))
+	}
+	if r.Name != "" {
+		lines = append(lines, spread("Customer", r.Name, columns))
+	}
+	lines = append(lines, separator)
+
+	for _, item := range r.Items {
+		lines = append(lines, truncate(item.Name, columns))
+		lines = append(lines, spread(fmt.Sprintf("  %d x %.2f", item.Quantity, item.Price), money(item.SubTotal), columns))
+		if item.Discount > 0 {
+			lines = append(lines, spread("  Discount", money(-item.Discount), columns))
+		}
+	}
+	lines = append(lines, separator, spread("Subtotal", money(r.Subtotal), columns))
+
+	if r.Discount > 0 {
+		lines = append(lines, spread("Discount", money(-r.Discount), columns))
+	}
+	for _, tax := range r.TaxLines {
+		label := fmt.Sprintf("%s %g%%", tax.Name, tax.Rate)
+		if tax.Inclusive {
+			label += " (incl.)"
+		}
+		lines = append(lines, spread(label, money(tax.Amount), columns))
+	}
+	if r.ServiceCharge > 0 {
+		lines = append(lines, spread("Service charge", money(r.ServiceCharge), columns))
+	}
+	if r.DeliveryFee > 0 {
+		lines = append(lines, spread("Delivery fee", money(r.DeliveryFee), columns))
+	}
+	lines = append(lines, spread("TOTAL", money(r.GrandTotal), columns))
+	if r.Refunded > 0 {
+		lines = append(lines, spread("Refunded", money(-r.Refunded), columns), spread("NET TOTAL", money(r.NetTotal), columns))
+	}
+
+	if len(r.Payments) > 0 {
+		lines = append(lines, separator)
+		for _, payment := range r.Payments {
+			amount := payment.Amount
+			label := payment.Method
+			switch payment.Type {
+			case PaymentTypeRefund:
+				amount = -amount
+				label = "Refund " + label
+			case PaymentTypeDeposit:
+				label = strings.TrimSpace("Deposit " + label)
+			case PaymentTypePoints:
+				label = "Loyalty points"
+			case PaymentTypeGiftCard:
+				label = "Gift card"
+			}
+			lines = append(lines, spread(label, money(amount), columns))
+			if payment.Tip > 0 {
+				lines = append(lines, spread("  Tip", money(payment.Tip), columns))
+			}
+		}
+	}
+
+	lines = append(lines, separator)
+	for _, line := range r.Layout.Footer {
+		lines = append(lines, center(line, columns))
+	}
+	return lines
+}
+
+// Label is the order type as printed on receipts and tickets
+func (t OrderType) Label() string {
+	switch t {
+	case OrderTakeaway:
+		return "Takeaway"
+	case OrderDelivery:
+		return "Delivery"
+	case OrderPickup:
+		return "Pickup"
+	default:
+		return "Dine-in"
+	}
+}
+
+// KitchenTicketItem is an order item as read for a kitchen ticket
+type KitchenTicketItem struct {
+	Station  string
+	Name     string
+	Quantity int
+}
+
+// KitchenTicket lists the items of an order prepared at one station
+type KitchenTicket struct {
+	Station     string
+	CodeOrder   string
+	QueueNumber int
+	Type        OrderType
+	TableName   string
+	Items       []KitchenTicketItem
+	PrintedAt   time.Time
+}
+
+// NewKitchenTickets splits the order items into one ticket per station, in order of first appearance
+func NewKitchenTickets(detail OrderDetail, items []KitchenTicketItem) []KitchenTicket {
+	var tickets []KitchenTicket
+	index := make(map[string]int)
+	for _, item := range items {
+		i, ok := index[item.Station]
+		if !ok {
+			i = len(tickets)
+			index[item.Station] = i
+			tickets = append(tickets, KitchenTicket{
+				Station:     item.Station,
+				CodeOrder:   detail.CodeOrder,
+				QueueNumber: detail.QueueNumber,
+				Type:        OrderType(detail.Type),
+				TableName:   detail.TableName,
+				PrintedAt:   time.Now(),
+			})
+		}
+		tickets[i].Items = append(tickets[i].Items, item)
+	}
+	return tickets
+}
+
+// Lines lays the ticket out as plain text for a printer with the given number of columns
+func (t *KitchenTicket) Lines(columns int) []string {
+	lines := []string{
+		center(strings.ToUpper(t.Station), columns),
+		strings.Repeat("=", columns),
+		spread("Order "+t.CodeOrder, fmt.Sprintf("#%d", t.QueueNumber), columns),
+		spread(t.Type.Label(), t.TableName, columns),
+		spread("Time", t.PrintedAt.Format("15:04"), columns),
+		strings.Repeat("-", columns),
+	}
+	for _, item := range t.Items {
+		lines = append(lines, truncate(fmt.Sprintf("%3d x %s", item.Quantity, item.Name), columns))
+	}
+	return append(lines, strings.Repeat("=", columns))
+}
+
+func money(amount float64) string {
+	return fmt.Sprintf("%.2f", amount)
+}
+
+func truncate(text string, columns int) string {
+	runes := []rune(text)
+	if len(runes) > columns {
+		return string(runes[:columns])
+	}
+	return text
+}
+
+func center(text string, columns int) string {
+	text = truncate(text, columns)
+	padding := (columns - len([]rune(text))) / 2
+	return strings.Repeat(" ", padding) + text
+}
+
+// spread puts label on the left and value on the right of one line, cutting the label when needed
+func spread(label, value string, columns int) string {
+	space := columns - len([]rune(value)) - 1
+	if space < 0 {
+		return truncate(value, columns)
+	}
+	label = truncate(label, space)
+	return label + strings.Repeat(" ", columns-len([]rune(label))-len([]rune(value))) + value
+}
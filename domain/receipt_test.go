@@ -0,0 +1,88 @@
+package domain_test
+
+import (
+	"encoding/json"
+	"project/domain"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func receiptDetail() domain.OrderDetail {
+	return domain.OrderDetail{
+		CodeOrder:   "ORD-0001",
+		QueueNumber: 7,
+		Type:        string(domain.OrderDineIn),
+		TableName:   "T1",
+		DateOrder:   "2024-05-10",
+		TimeOrder:   "19:30",
+		OrderItems: json.RawMessage(`[{"product_name":"Fried Rice","product_price":5,"quantity":2,"sub_total":10,"discount":1},
+			{"product_name":"Iced Tea","product_price":1.5,"quantity":1,"sub_total":1.5,"discount":0}]`),
+		TaxLines:   json.RawMessage(`[{"code":"VAT","name":"VAT","rate":10,"inclusive":false,"amount":1.05}]`),
+		Total:      11.5,
+		Discount:   1,
+		GrandTotal: 11.55,
+		NetTotal:   11.55,
+	}
+}
+
+func TestNewReceipt(t *testing.T) {
+	payments := []domain.ReceiptPaymentRow{{Payment: domain.Payment{Type: domain.PaymentTypePayment, Amount: 11.55, Tip: 2}, MethodName: "Cash"}}
+
+	receipt, err := domain.NewReceipt(receiptDetail(), payments, domain.ReceiptLayout{PaperWidth: 58})
+	require.NoError(t, err)
+	assert.Len(t, receipt.Items, 2)
+	assert.Equal(t, "Fried Rice", receipt.Items[0].Name)
+	assert.Len(t, receipt.TaxLines, 1)
+	assert.Equal(t, []domain.ReceiptPayment{{Method: "Cash", Type: domain.PaymentTypePayment, Amount: 11.55, Tip: 2}}, receipt.Payments)
+}
+
+func TestNewReceipt_WithoutItems(t *testing.T) {
+	detail := receiptDetail()
+	detail.OrderItems = json.RawMessage(`[{"product_name":null,"product_price":null,"quantity":null,"sub_total":null,"discount":null}]`)
+
+	receipt, err := domain.NewReceipt(detail, nil, domain.ReceiptLayout{})
+	require.NoError(t, err)
+	assert.Empty(t, receipt.Items)
+}
+
+func TestReceipt_Lines(t *testing.T) {
+	layout := domain.ReceiptLayout{Header: []string{"Warung Makan"}, Footer: []string{"Thank you"}, PaperWidth: 58}
+	receipt, err := domain.NewReceipt(receiptDetail(), nil, layout)
+	require.NoError(t, err)
+
+	receipt.Print = 1
+	lines := receipt.Lines(layout.Columns())
+	for _, line := range lines {
+		assert.LessOrEqual(t, len([]rune(line)), 32, line)
+	}
+	assert.Contains(t, lines, "          Warung Makan")
+	assert.Contains(t, lines, "Order                   ORD-0001")
+	assert.Contains(t, lines, "  Discount                 -1.00")
+	assert.Contains(t, lines, "VAT 10%                     1.05")
+	assert.Contains(t, lines, "TOTAL                      11.55")
+	assert.NotContains(t, lines, "       *** REPRINT #1 ***")
+
+	receipt.Print = 2
+	assert.Contains(t, receipt.Lines(layout.Columns()), "       *** REPRINT #1 ***")
+}
+
+func TestNewKitchenTickets(t *testing.T) {
+	items := []domain.KitchenTicketItem{
+		{Station: "kitchen", Name: "Fried Rice", Quantity: 2},
+		{Station: "bar", Name: "Iced Tea", Quantity: 1},
+		{Station: "kitchen", Name: "Satay", Quantity: 1},
+	}
+
+	tickets := domain.NewKitchenTickets(receiptDetail(), items)
+	require.Len(t, tickets, 2)
+	assert.Equal(t, "kitchen", tickets[0].Station)
+	assert.Len(t, tickets[0].Items, 2)
+	assert.Equal(t, "bar", tickets[1].Station)
+
+	lines := tickets[0].Lines(32)
+	assert.Equal(t, "            KITCHEN", lines[0])
+	assert.Contains(t, lines, "  2 x Fried Rice")
+	assert.Contains(t, lines, "Dine-in                       T1")
+}
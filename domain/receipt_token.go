@@ -0,0 +1,62 @@
+package domain
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var (
+	ErrInvalidReceiptToken = errors.New("invalid receipt link")
+	ErrReceiptTokenExpired = errors.New("receipt link has expired")
+	ErrMissingSecret       = errors.New("no secret is configured to sign links")
+)
+
+// SignReceiptToken creates the token of a public receipt link, the order id and expiry signed with HMAC-SHA256
+func SignReceiptToken(orderID uint, expiresAt time.Time, secret []byte) (string, error) {
+	if len(secret) == 0 {
+		return "", ErrMissingSecret
+	}
+	payload := fmt.Sprintf("%d.%d", orderID, expiresAt.Unix())
+	return payload + "." + receiptSignature(payload, secret), nil
+}
+
+// ParseReceiptToken returns the order id of a receipt token after checking its signature and expiry
+func ParseReceiptToken(token string, secret []byte, now time.Time) (uint, error) {
+	if len(secret) == 0 {
+		return 0, ErrMissingSecret
+	}
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return 0, ErrInvalidReceiptToken
+	}
+
+	payload := parts[0] + "." + parts[1]
+	if !hmac.Equal([]byte(parts[2]), []byte(receiptSignature(payload, secret))) {
+		return 0, ErrInvalidReceiptToken
+	}
+
+	orderID, err := strconv.ParseUint(parts[0], 10, 0)
+	if err != nil {
+		return 0, ErrInvalidReceiptToken
+	}
+	expiresAt, err := strconv.ParseInt(parts[1], 10, 64)
+	if err != nil {
+		return 0, ErrInvalidReceiptToken
+	}
+	if now.Unix() > expiresAt {
+		return 0, ErrReceiptTokenExpired
+	}
+	return uint(orderID), nil
+}
+
+func receiptSignature(payload string, secret []byte) string {
+	mac := hmac.New(sha256.New, secret)
+	mac.Write([]byte(payload))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
@@ -0,0 +1,52 @@
+package domain_test
+
+import (
+	"project/domain"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestReceiptToken(t *testing.T) {
+	secret := []byte("secret")
+	now := time.Date(2024, 5, 10, 19, 30, 0, 0, time.UTC)
+	token, err := domain.SignReceiptToken(42, now.Add(24*time.Hour), secret)
+	assert.NoError(t, err)
+
+	t.Run("Valid token", func(t *testing.T) {
+		orderID, err := domain.ParseReceiptToken(token, secret, now)
+		assert.NoError(t, err)
+		assert.Equal(t, uint(42), orderID)
+	})
+
+	t.Run("Expired token", func(t *testing.T) {
+		_, err := domain.ParseReceiptToken(token, secret, now.Add(25*time.Hour))
+		assert.ErrorIs(t, err, domain.ErrReceiptTokenExpired)
+	})
+
+	t.Run("Other secret", func(t *testing.T) {
+		_, err := domain.ParseReceiptToken(token, []byte("other"), now)
+		assert.ErrorIs(t, err, domain.ErrInvalidReceiptToken)
+	})
+
+	t.Run("Tampered order", func(t *testing.T) {
+		_, err := domain.ParseReceiptToken("43"+token[2:], secret, now)
+		assert.ErrorIs(t, err, domain.ErrInvalidReceiptToken)
+	})
+
+	t.Run("Malformed token", func(t *testing.T) {
+		_, err := domain.ParseReceiptToken("not-a-token", secret, now)
+		assert.ErrorIs(t, err, domain.ErrInvalidReceiptToken)
+	})
+
+	t.Run("Empty secret", func(t *testing.T) {
+		_, err := domain.SignReceiptToken(42, now.Add(24*time.Hour), nil)
+		assert.ErrorIs(t, err, domain.ErrMissingSecret)
+
+		forged := "42.1715455800." + token[strings.LastIndex(token, ".")+1:]
+		_, err = domain.ParseReceiptToken(forged, nil, now)
+		assert.ErrorIs(t, err, domain.ErrMissingSecret)
+	})
+}
@@ -0,0 +1,151 @@
+package domain
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"time"
+)
+
+var (
+	ErrInvalidReasonCode       = errors.New("invalid reason code")
+	ErrManagerApprovalRequired = errors.New("manager approval is required for this amount")
+	ErrManagerApprovalInvalid  = errors.New("manager id or pin is invalid")
+	ErrManagerPinLocked        = errors.New("manager pin is locked after too many wrong attempts, try again later")
+	ErrVoidNotAllowed          = errors.New("items can only be voided before payment")
+	ErrRefundNotAllowed        = errors.New("only completed orders can be refunded")
+	ErrRefundExceedsPaid       = errors.New("refund exceeds the refundable amount")
+	ErrInvalidAdjustment       = errors.New("invalid void or refund quantity")
+)
+
+type ReasonCode string
+
+const (
+	ReasonCustomerRequest ReasonCode = "customer_request"
+	ReasonWrongItem       ReasonCode = "wrong_item"
+	ReasonQualityIssue    ReasonCode = "quality_issue"
+	ReasonKitchenError    ReasonCode = "kitchen_error"
+	ReasonDuplicateEntry  ReasonCode = "duplicate_entry"
+	ReasonOther           ReasonCode = "other"
+)
+
+var ReasonCodes = map[ReasonCode]string{
+	ReasonCustomerRequest: "Customer changed their mind",
+	ReasonWrongItem:       "Wrong item entered or served",
+	ReasonQualityIssue:    "Food or drink quality issue",
+	ReasonKitchenError:    "Kitchen could not prepare the item",
+	ReasonDuplicateEntry:  "Item was entered twice",
+	ReasonOther:           "Other, a note is required",
+}
+
+// Validate checks that code is known and that "other" comes with a note
+func (code ReasonCode) Validate(note string) error {
+	if _, ok := ReasonCodes[code]; !ok {
+		return ErrInvalidReasonCode
+	}
+	if code == ReasonOther && note == "" {
+		return fmt.Errorf("%w: a note is required for reason code 'other'", ErrInvalidReasonCode)
+	}
+	return nil
+}
+
+// ManagerApproval carries the credentials of the manager approving a void or refund above the threshold
+type ManagerApproval struct {
+	ManagerID uint   `json:"manager_id" example:"1"`
+	Pin       string `json:"manager_pin" example:"1234"`
+}
+
+// A manager pin entered wrong MaxPinAttempts times in a row is locked for PinLockout
+const (
+	MaxPinAttempts = 5
+	PinLockout     = 15 * time.Minute
+)
+
+// PinLocked reports whether the manager pin of u is locked at now
+func (u *User) PinLocked(now time.Time) bool {
+	return u.PinLockedUntil != nil && now.Before(*u.PinLockedUntil)
+}
+
+type OrderVoid struct {
+	ID           uint       `gorm:"primaryKey" json:"id"`
+	OrderID      uint       `gorm:"not null;index" json:"order_id"`
+	OrderItemID  uint       `gorm:"not null" json:"order_item_id"`
+	ProductID    uint       `gorm:"not null" json:"product_id"`
+	Quantity     int        `gorm:"not null" json:"quantity"`
+	Amount       float64    `gorm:"type:decimal(10,2);not null" json:"amount"`
+	ReasonCode   ReasonCode `gorm:"size:30;not null" json:"reason_code"`
+	Note         string     `gorm:"size:255" json:"note"`
+	ReturnStock  bool       `gorm:"not null;default:true" json:"return_stock"`
+	UserID       *uint      `json:"user_id"`
+	ApprovedByID *uint      `json:"approved_by_id"`
+	CreatedAt    time.Time  `gorm:"autoCreateTime" json:"created_at"`
+}
+
+// Refund is money and tenders given back for a completed order. Amount is the value refunded, the
+// payment is the part of it paid back in money, the rest goes back to the points and gift cards.
+type Refund struct {
+	ID           uint         `gorm:"primaryKey" json:"id"`
+	OrderID      uint         `gorm:"not null;index" json:"order_id"`
+	PaymentID    uint         `gorm:"not null" json:"payment_id"`
+	Payment      Payment      `gorm:"foreignKey:PaymentID;references:ID" json:"payment"`
+	Amount       float64      `gorm:"type:decimal(10,2);not null" json:"amount"`
+	ReasonCode   ReasonCode   `gorm:"size:30;not null" json:"reason_code"`
+	Note         string       `gorm:"size:255" json:"note"`
+	ReturnStock  bool         `gorm:"not null;default:false" json:"return_stock"`
+	UserID       *uint        `json:"user_id"`
+	ApprovedByID *uint        `json:"approved_by_id"`
+	Items        []RefundItem `gorm:"foreignKey:RefundID;references:ID" json:"items"`
+	CreatedAt    time.Time    `gorm:"autoCreateTime" json:"created_at"`
+}
+
+type RefundItem struct {
+	ID          uint    `gorm:"primaryKey" json:"id"`
+	RefundID    uint    `gorm:"not null;index" json:"refund_id"`
+	OrderItemID uint    `gorm:"not null" json:"order_item_id" binding:"required"`
+	ProductID   uint    `gorm:"not null" json:"product_id"`
+	Quantity    int     `gorm:"not null" json:"quantity" binding:"gt=0"`
+	Amount      float64 `gorm:"type:decimal(10,2);not null" json:"amount"`
+}
+
+type VoidInput struct {
+	OrderID     uint
+	OrderItemID uint
+	Quantity    int
+	ReasonCode  ReasonCode
+	Note        string
+	ReturnStock bool
+	UserID      *uint
+	Approval    ManagerApproval
+}
+
+type RefundInput struct {
+	OrderID         uint
+	Items           []RefundItem
+	Amount          float64
+	PaymentMethodID *uint
+	ReasonCode      ReasonCode
+	Note            string
+	ReturnStock     bool
+	UserID          *uint
+	Approval        ManagerApproval
+}
+
+// RefundSplit is how a refund is spread over the tenders of an order
+type RefundSplit struct {
+	// Money is paid back to the payment method
+	Money float64
+	// Share is the part of the points and gift cards still on the order that goes back to them, and
+	// of the points earned that is taken back
+	Share float64
+}
+
+// SplitRefund spreads a refund of amount over the money and the points and gift cards still left on
+// an order, in proportion to each
+func SplitRefund(amount, money, tenders float64) RefundSplit {
+	remaining := money + tenders
+	if remaining <= 0 || amount <= 0 {
+		return RefundSplit{}
+	}
+	share := math.Min(amount/remaining, 1)
+	return RefundSplit{Money: math.Round(money*share*100) / 100, Share: share}
+}
@@ -0,0 +1,33 @@
+package domain_test
+
+import (
+	"project/domain"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSplitRefund(t *testing.T) {
+	tests := []struct {
+		name    string
+		amount  float64
+		money   float64
+		tenders float64
+		want    domain.RefundSplit
+	}{
+		{"Money only", 25, 100, 0, domain.RefundSplit{Money: 25, Share: 0.25}},
+		{"Mixed tenders", 30, 60, 40, domain.RefundSplit{Money: 18, Share: 0.3}},
+		{"Mixed tenders in full", 100, 60, 40, domain.RefundSplit{Money: 60, Share: 1}},
+		{"Tenders only", 20, 0, 80, domain.RefundSplit{Money: 0, Share: 0.25}},
+		{"More than is left", 150, 60, 40, domain.RefundSplit{Money: 60, Share: 1}},
+		{"Nothing left", 10, 0, 0, domain.RefundSplit{}},
+		{"Rounded to cents", 10, 20, 10, domain.RefundSplit{Money: 6.67, Share: 1.0 / 3}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			split := domain.SplitRefund(tt.amount, tt.money, tt.tenders)
+			assert.Equal(t, tt.want.Money, split.Money)
+			assert.InDelta(t, tt.want.Share, split.Share, 1e-9)
+		})
+	}
+}
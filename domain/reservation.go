@@ -1,50 +1,173 @@
 package domain
 
 import (
+	"errors"
+	"fmt"
+	"strings"
 	"time"
 
 	"gorm.io/datatypes"
 )
 
+// DefaultReservationDuration is how many minutes a table is held when a booking gives no duration
+const DefaultReservationDuration = 90
+
+var (
+	ErrInvalidReservation  = errors.New("invalid reservation")
+	ErrReservationConflict = errors.New("this table is already reserved at the selected time")
+	ErrTableTooSmall       = errors.New("table capacity is below the number of guests")
+)
+
 type Reservation struct {
-	ID              uint           `gorm:"primaryKey" json:"id"`
-	ReservationDate string         `gorm:"type:date;not null" json:"reservation_date" example:"2024-12-14"`
-	ReservationTime datatypes.Time `gorm:"type:time;not null" json:"reservation_time" example:"14:00:00"`
-	TableNumber     uint           `gorm:"not null" json:"table_number"`
-	Status          string         `gorm:"not null" json:"status"`
-	ReservationName string         `gorm:"size:100;not null" json:"reservation_name"`
-	PaxNumber       uint           `gorm:"not null" json:"pax_number"`
-	DepositFee      float64        `gorm:"type:decimal(10,2);not null" json:"deposit_fee,omitempty"` // added DepositFee
-	Title           string         `gorm:"size:10" json:"title,omitempty"`                           // added Title
-	FirstName       string         `gorm:"size:50" json:"first_name,omitempty"`                      // added FirstName
-	Surname         string         `gorm:"size:50" json:"surname,omitempty"`                         // added Surname
-	PhoneNumber     string         `gorm:"size:20" json:"phone_number,omitempty"`                    // added PhoneNumber
-	EmailAddress    string         `gorm:"size:100" json:"email_address,omitempty"`                  // added EmailAddress
-	CreatedAt       time.Time      `gorm:"autoCreateTime" json:"created_at" swaggerignore:"true"`
-	UpdatedAt       time.Time      `gorm:"autoUpdateTime" json:"updated_at" swaggerignore:"true"`
+	ID              uint              `gorm:"primaryKey" json:"id"`
+	ReservationDate string            `gorm:"type:date;not null" json:"reservation_date" example:"2024-12-14"`
+	ReservationTime datatypes.Time    `gorm:"type:time;not null" json:"reservation_time" example:"14:00:00"`
+	Duration        int               `gorm:"not null;default:90" json:"duration" binding:"omitempty,gte=15,lte=720" example:"90"`
+	TableID         uint              `gorm:"not null;index" json:"table_id" example:"3"`
+	Table           *Table            `gorm:"foreignKey:TableID;references:ID" json:"table,omitempty" swaggerignore:"true"`
+	JoinedTableIDs  []uint            `gorm:"-" json:"joined_table_ids,omitempty" binding:"omitempty,dive,gt=0" example:"4"`
+	Status          ReservationStatus `gorm:"size:20;not null;index" json:"status" example:"Confirmed"`
+	ReservationName string            `gorm:"size:100;not null" json:"reservation_name"`
+	PaxNumber       uint              `gorm:"not null" json:"pax_number"`
+	DepositFee      float64           `gorm:"type:decimal(10,2);not null" json:"deposit_fee,omitempty"` // added DepositFee
+	DepositStatus   DepositStatus     `gorm:"size:20;not null;default:'none'" json:"deposit_status" swaggerignore:"true"`
+	Title           string            `gorm:"size:10" json:"title,omitempty"`          // added Title
+	FirstName       string            `gorm:"size:50" json:"first_name,omitempty"`     // added FirstName
+	Surname         string            `gorm:"size:50" json:"surname,omitempty"`        // added Surname
+	PhoneNumber     string            `gorm:"size:20" json:"phone_number,omitempty"`   // added PhoneNumber
+	EmailAddress    string            `gorm:"size:100" json:"email_address,omitempty"` // added EmailAddress
+	CustomerID      *uint             `gorm:"index" json:"customer_id,omitempty" example:"1"`
+	ConfirmedAt     *time.Time        `json:"confirmed_at,omitempty" swaggerignore:"true"`
+	RemindedAt      *time.Time        `json:"reminded_at,omitempty" swaggerignore:"true"`
+	ArrivedAt       *time.Time        `json:"arrived_at,omitempty" swaggerignore:"true"`
+	SeatedAt        *time.Time        `json:"seated_at,omitempty" swaggerignore:"true"`
+	ClosedAt        *time.Time        `json:"closed_at,omitempty" swaggerignore:"true"`
+	OrderID         *uint             `gorm:"index" json:"order_id,omitempty" swaggerignore:"true"`
+	NoShows         int               `gorm:"-" json:"no_shows" swaggerignore:"true"`
+	Payments        []Payment         `gorm:"foreignKey:ReservationID;references:ID" json:"payments,omitempty" swaggerignore:"true"`
+	Version         uint              `gorm:"not null;default:1" json:"version" swaggerignore:"true"`
+	CreatedAt       time.Time         `gorm:"autoCreateTime" json:"created_at" swaggerignore:"true"`
+	UpdatedAt       time.Time         `gorm:"autoUpdateTime" json:"updated_at" swaggerignore:"true"`
 }
 type AllReservation struct {
-	ID              uint           `gorm:"primaryKey" json:"id"`
-	ReservationDate string         `gorm:"type:date;not null" json:"reservation_date" example:"2024-12-14"`
-	ReservationTime datatypes.Time `gorm:"type:time;not null" json:"reservation_time" example:"14:00:00"`
-	TableNumber     uint           `gorm:"not null" json:"table_number"`
-	Status          string         `gorm:"not null" json:"status"`
-	ReservationName string         `gorm:"size:100;not null" json:"reservation_name"`
-	PaxNumber       uint           `gorm:"not null" json:"pax_number"`
-	CreatedAt       time.Time      `gorm:"autoCreateTime" json:"created_at" swaggerignore:"true"`
-	UpdatedAt       time.Time      `gorm:"autoUpdateTime" json:"updated_at" swaggerignore:"true"`
+	ID              uint              `gorm:"primaryKey" json:"id"`
+	ReservationDate string            `gorm:"type:date;not null" json:"reservation_date" example:"2024-12-14"`
+	ReservationTime datatypes.Time    `gorm:"type:time;not null" json:"reservation_time" example:"14:00:00"`
+	Duration        int               `gorm:"not null;default:90" json:"duration"`
+	TableID         uint              `gorm:"not null" json:"table_id"`
+	Status          ReservationStatus `gorm:"size:20;not null" json:"status"`
+	ReservationName string            `gorm:"size:100;not null" json:"reservation_name"`
+	PaxNumber       uint              `gorm:"not null" json:"pax_number"`
+	PhoneNumber     string            `gorm:"size:20" json:"phone_number,omitempty"`
+	DepositStatus   DepositStatus     `gorm:"size:20" json:"deposit_status"`
+	CreatedAt       time.Time         `gorm:"autoCreateTime" json:"created_at" swaggerignore:"true"`
+	UpdatedAt       time.Time         `gorm:"autoUpdateTime" json:"updated_at" swaggerignore:"true"`
+}
+
+// ReservationTable is an extra table booked with a reservation for a party larger than one table
+type ReservationTable struct {
+	ReservationID uint      `gorm:"primaryKey" json:"reservation_id"`
+	TableID       uint      `gorm:"primaryKey" json:"table_id"`
+	Table         Table     `gorm:"foreignKey:TableID;references:ID" json:"table"`
+	CreatedAt     time.Time `gorm:"autoCreateTime" json:"created_at"`
+}
+
+// TableIDs are the table of the reservation followed by the tables joined to it
+func (r *Reservation) TableIDs() []uint {
+	return append([]uint{r.TableID}, r.JoinedTableIDs...)
+}
+
+// Books tells whether the reservation holds the table, as its own or a joined one
+func (r *Reservation) Books(tableID uint) bool {
+	for _, id := range r.TableIDs() {
+		if id == tableID {
+			return true
+		}
+	}
+	return false
+}
+
+// Start is when the guests are expected, in local time
+func (r *Reservation) Start() (time.Time, error) {
+	// dates read back from the database carry a time part
+	date := r.ReservationDate
+	if len(date) > len("2006-01-02") {
+		date = date[:len("2006-01-02")]
+	}
+	day, err := time.ParseInLocation("2006-01-02", date, time.Local)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("%w: reservation_date must be YYYY-MM-DD", ErrInvalidReservation)
+	}
+	return day.Add(time.Duration(r.ReservationTime)), nil
+}
+
+// End is when the table is free again for the next booking
+func (r *Reservation) End() (time.Time, error) {
+	start, err := r.Start()
+	if err != nil {
+		return time.Time{}, err
+	}
+	return start.Add(time.Duration(r.Duration) * time.Minute), nil
+}
+
+// Overlaps tells whether the reservation holds its table at any moment between start and end
+func (r *Reservation) Overlaps(start, end time.Time) bool {
+	from, err := r.Start()
+	if err != nil {
+		return false
+	}
+	to := from.Add(time.Duration(r.Duration) * time.Minute)
+	return from.Before(end) && to.After(start)
+}
+
+// Validate checks a new or moved reservation against the tables it books, which together must
+// seat the party
+func (r *Reservation) Validate(tables ...Table) error {
+	if r.Duration == 0 {
+		r.Duration = DefaultReservationDuration
+	}
+	if r.Duration < 0 {
+		return fmt.Errorf("%w: duration must be positive", ErrInvalidReservation)
+	}
+	if r.PaxNumber == 0 {
+		return fmt.Errorf("%w: pax number must be at least 1", ErrInvalidReservation)
+	}
+	if _, err := r.Start(); err != nil {
+		return err
+	}
+
+	seen := map[uint]bool{}
+	for _, id := range r.TableIDs() {
+		if seen[id] {
+			return fmt.Errorf("%w: table %d is booked twice", ErrInvalidReservation, id)
+		}
+		seen[id] = true
+	}
+
+	capacity := 0
+	var names []string
+	for _, table := range tables {
+		capacity += table.Capacity
+		names = append(names, table.Name)
+	}
+	if int(r.PaxNumber) > capacity {
+		return fmt.Errorf("%w: %s seats %d, not %d", ErrTableTooSmall, strings.Join(names, " + "), capacity, r.PaxNumber)
+	}
+	return nil
 }
 
 // ReservationSeed untuk menambahkan contoh data reservasi
 func ReservationSeed() []Reservation {
 	return []Reservation{
 		{
-			TableNumber:     5,
+			TableID:         5,
 			PaxNumber:       4,
+			Duration:        DefaultReservationDuration,
 			ReservationDate: "2024-12-16",
 			ReservationTime: datatypes.NewTime(14, 0, 0, 0),
 			DepositFee:      10.00,
-			Status:          "Confirmed",
+			DepositStatus:   DepositRequested,
+			Status:          ReservationConfirmed,
 			Title:           "Mr",
 			FirstName:       "John",
 			Surname:         "Doe",
@@ -53,12 +176,14 @@ func ReservationSeed() []Reservation {
 			EmailAddress:    "John@gmail.com",
 		},
 		{
-			TableNumber:     6,
+			TableID:         6,
 			PaxNumber:       4,
+			Duration:        DefaultReservationDuration,
 			ReservationDate: "2024-12-16",
 			ReservationTime: datatypes.NewTime(14, 0, 0, 0),
 			DepositFee:      10.00,
-			Status:          "Canceled",
+			DepositStatus:   DepositRequested,
+			Status:          ReservationCanceled,
 			Title:           "Mr",
 			FirstName:       "Jihn",
 			Surname:         "Doe",
@@ -67,12 +192,14 @@ func ReservationSeed() []Reservation {
 			EmailAddress:    "Jihn@gmail.com",
 		},
 		{
-			TableNumber:     7,
-			PaxNumber:       4,
+			TableID:         7,
+			PaxNumber:       2,
+			Duration:        DefaultReservationDuration,
 			ReservationDate: "2024-12-16",
 			ReservationTime: datatypes.NewTime(15, 0, 0, 0),
 			DepositFee:      10.00,
-			Status:          "Confirmed",
+			DepositStatus:   DepositRequested,
+			Status:          ReservationConfirmed,
 			Title:           "Mrs",
 			FirstName:       "Jahn",
 			Surname:         "Doe",
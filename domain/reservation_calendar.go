@@ -0,0 +1,175 @@
+package domain
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+var ErrCalendarFeedNotFound = errors.New("calendar feed not found")
+
+const (
+	// CalendarFeedPast and CalendarFeedAhead are how far back and ahead a subscribed feed reaches
+	CalendarFeedPast  = 30 * 24 * time.Hour
+	CalendarFeedAhead = 180 * 24 * time.Hour
+)
+
+// CalendarFeed is the secret token of the reservation calendar a user subscribes to. Generating a
+// new token replaces the old one, so a leaked feed URL stops working.
+type CalendarFeed struct {
+	UserID    uint      `gorm:"primaryKey" json:"user_id"`
+	User      User      `gorm:"foreignKey:UserID;references:ID" json:"-"`
+	Token     string    `gorm:"size:64;not null;uniqueIndex" json:"token"`
+	Url       string    `gorm:"-" json:"url,omitempty"`
+	CreatedAt time.Time `gorm:"autoCreateTime" json:"created_at"`
+}
+
+func (CalendarFeed) TableName() string {
+	return "reservation_calendar_feeds"
+}
+
+// NewCalendarFeed creates a feed with a random token for the user
+func NewCalendarFeed(userID uint) (*CalendarFeed, error) {
+	key := make([]byte, 32)
+	if _, err := rand.Read(key); err != nil {
+		return nil, err
+	}
+	return &CalendarFeed{UserID: userID, Token: base64.RawURLEncoding.EncodeToString(key)}, nil
+}
+
+// ReservationCalendar is the reservation book of a period as an iCalendar (RFC 5545) file
+type ReservationCalendar struct {
+	Name string
+	// Host makes the event UIDs globally unique, it must not change or clients duplicate every event
+	Host         string
+	Reservations []Reservation
+	// Tables are the names of the tables booked by the reservations by id
+	Tables map[uint]string
+}
+
+// ICS writes the calendar, one event per reservation. The UID of an event only depends on the
+// reservation id and its SEQUENCE is the reservation version, so clients update an event in place
+// when the reservation is moved, changed or cancelled.
+func (c ReservationCalendar) ICS(now time.Time) []byte {
+	var b strings.Builder
+	line := func(name, value string) {
+		b.WriteString(foldICSLine(name + ":" + value))
+	}
+
+	line("BEGIN", "VCALENDAR")
+	line("VERSION", "2.0")
+	line("PRODID", "-//POS Restaurant//Reservations//EN")
+	line("CALSCALE", "GREGORIAN")
+	line("METHOD", "PUBLISH")
+	if c.Name != "" {
+		line("X-WR-CALNAME", escapeICSText(c.Name))
+	}
+	// subscribed clients poll the feed hourly
+	line("REFRESH-INTERVAL;VALUE=DURATION", "PT1H")
+	line("X-PUBLISHED-TTL", "PT1H")
+
+	for i := range c.Reservations {
+		r := &c.Reservations[i]
+		start, err := r.Start()
+		if err != nil {
+			continue
+		}
+		end := start.Add(time.Duration(r.Duration) * time.Minute)
+		stamp := r.UpdatedAt
+		if stamp.IsZero() {
+			stamp = now
+		}
+
+		line("BEGIN", "VEVENT")
+		line("UID", fmt.Sprintf("reservation-%d@%s", r.ID, c.Host))
+		line("DTSTAMP", icsTime(stamp))
+		line("LAST-MODIFIED", icsTime(stamp))
+		line("SEQUENCE", fmt.Sprint(r.Version))
+		line("DTSTART", icsTime(start))
+		line("DTEND", icsTime(end))
+		line("SUMMARY", escapeICSText(fmt.Sprintf("%s (%d pax) - %s", r.ReservationName, r.PaxNumber, c.tableNames(r))))
+		line("LOCATION", escapeICSText(c.tableNames(r)))
+		line("DESCRIPTION", escapeICSText(c.description(r)))
+		line("STATUS", icsStatus(r.Status))
+		line("END", "VEVENT")
+	}
+
+	line("END", "VCALENDAR")
+	return []byte(b.String())
+}
+
+func (c ReservationCalendar) tableNames(r *Reservation) string {
+	names := make([]string, 0, len(r.TableIDs()))
+	for _, id := range r.TableIDs() {
+		if name, ok := c.Tables[id]; ok {
+			names = append(names, name)
+		} else {
+			names = append(names, fmt.Sprintf("Table %d", id))
+		}
+	}
+	return strings.Join(names, " + ")
+}
+
+func (c ReservationCalendar) description(r *Reservation) string {
+	details := []string{
+		"Guest: " + r.ReservationName,
+		fmt.Sprintf("Pax: %d", r.PaxNumber),
+		"Table: " + c.tableNames(r),
+		"Status: " + string(r.Status),
+	}
+	if r.PhoneNumber != "" {
+		details = append(details, "Phone: "+r.PhoneNumber)
+	}
+	if r.EmailAddress != "" {
+		details = append(details, "Email: "+r.EmailAddress)
+	}
+	if r.DepositFee > 0 {
+		details = append(details, fmt.Sprintf("Deposit: %.2f (%s)", r.DepositFee, r.DepositStatus))
+	}
+	return strings.Join(details, "\n")
+}
+
+func icsStatus(status ReservationStatus) string {
+	switch status {
+	case ReservationPending:
+		return "TENTATIVE"
+	case ReservationCanceled, ReservationNoShow:
+		return "CANCELLED"
+	}
+	return "CONFIRMED"
+}
+
+func icsTime(t time.Time) string {
+	return t.UTC().Format("20060102T150405Z")
+}
+
+var icsTextEscaper = strings.NewReplacer(`\`, `\\`, ";", `\;`, ",", `\,`, "\r\n", `\n`, "\n", `\n`)
+
+func escapeICSText(text string) string {
+	return icsTextEscaper.Replace(text)
+}
+
+// foldICSLine ends a content line with CRLF, folding it into lines of at most 75 octets without
+// splitting a UTF-8 character
+func foldICSLine(line string) string {
+	var b strings.Builder
+	limit := 75
+	for len(line) > limit {
+		cut := limit
+		for cut > 0 && !utf8.RuneStart(line[cut]) {
+			cut--
+		}
+		b.WriteString(line[:cut])
+		b.WriteString("\r\n ")
+		line = line[cut:]
+		// the leading space of a continuation line counts toward its length
+		limit = 74
+	}
+	b.WriteString(line)
+	b.WriteString("\r\n")
+	return b.String()
+}
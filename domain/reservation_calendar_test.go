@@ -0,0 +1,64 @@
+package domain_test
+
+import (
+	"project/domain"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"gorm.io/datatypes"
+)
+
+func TestReservationCalendar_ICS(t *testing.T) {
+	updated := time.Date(2024, 12, 10, 9, 0, 0, 0, time.UTC)
+	calendar := domain.ReservationCalendar{
+		Name: "Reservations",
+		Host: "pos.example.com",
+		Reservations: []domain.Reservation{{
+			ID:              7,
+			ReservationDate: "2024-12-16",
+			ReservationTime: datatypes.NewTime(19, 0, 0, 0),
+			Duration:        90,
+			TableID:         3,
+			JoinedTableIDs:  []uint{4},
+			Status:          domain.ReservationConfirmed,
+			ReservationName: "Doe, John; family",
+			PaxNumber:       6,
+			PhoneNumber:     "0895123456",
+			Version:         3,
+			UpdatedAt:       updated,
+		}, {
+			ID:              8,
+			ReservationDate: "2024-12-16",
+			ReservationTime: datatypes.NewTime(20, 0, 0, 0),
+			Duration:        60,
+			TableID:         5,
+			Status:          domain.ReservationCanceled,
+			ReservationName: "Jane",
+			PaxNumber:       2,
+			Version:         2,
+		}},
+		Tables: map[uint]string{3: "Table A", 4: "Table B"},
+	}
+
+	ics := string(calendar.ICS(updated))
+	start := time.Date(2024, 12, 16, 19, 0, 0, 0, time.Local).UTC().Format("20060102T150405Z")
+
+	assert.True(t, strings.HasPrefix(ics, "BEGIN:VCALENDAR\r\nVERSION:2.0\r\n"))
+	assert.True(t, strings.HasSuffix(ics, "END:VCALENDAR\r\n"))
+	assert.Contains(t, ics, "UID:reservation-7@pos.example.com\r\n")
+	assert.Contains(t, ics, "SEQUENCE:3\r\n")
+	assert.Contains(t, ics, "DTSTART:"+start+"\r\n")
+	assert.Contains(t, ics, "LOCATION:Table A + Table B\r\n")
+	assert.Contains(t, ics, `SUMMARY:Doe\, John\; family (6 pax) - Table A + Table B`)
+	assert.Contains(t, ics, "UID:reservation-8@pos.example.com\r\nDTSTAMP:20241210T090000Z\r\n")
+	assert.Contains(t, ics, "LOCATION:Table 5\r\n")
+	assert.Contains(t, ics, "STATUS:CANCELLED\r\n")
+
+	for _, line := range strings.Split(strings.TrimSuffix(ics, "\r\n"), "\r\n") {
+		assert.LessOrEqual(t, len(line), 75, line)
+	}
+	unfolded := strings.ReplaceAll(ics, "\r\n ", "")
+	assert.Contains(t, unfolded, `DESCRIPTION:Guest: Doe\, John\; family\nPax: 6\nTable: Table A + Table B\nStatus: Confirmed\nPhone: 0895123456`)
+}
@@ -0,0 +1,88 @@
+package domain
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// ReservationSortColumns are the fields the reservation list sorts by and their columns
+var ReservationSortColumns = map[string][]string{
+	"date":       {"reservation_date", "reservation_time"},
+	"name":       {"reservation_name"},
+	"pax":        {"pax_number"},
+	"status":     {"status"},
+	"table":      {"table_id"},
+	"created_at": {"created_at"},
+}
+
+// ReservationFilter narrows the reservation list. Start and End bound the booking date, End is
+// exclusive and a zero time leaves that side open.
+type ReservationFilter struct {
+	Page     int
+	Limit    int
+	Start    time.Time
+	End      time.Time
+	Statuses []ReservationStatus
+	// TableID matches the table of the reservation and the tables joined to it
+	TableID uint
+	// Name and Phone match part of the guest name, ignoring case, and of the phone number
+	Name  string
+	Phone string
+	// SortBy is a key of ReservationSortColumns, the booking date when empty
+	SortBy string
+	Desc   bool
+}
+
+// Validate checks the filter and fills the default sort
+func (f *ReservationFilter) Validate() error {
+	for _, status := range f.Statuses {
+		if !status.Valid() {
+			return fmt.Errorf("%w: unknown status %q", ErrInvalidReservation, status)
+		}
+	}
+	if !f.Start.IsZero() && !f.End.IsZero() && !f.End.After(f.Start) {
+		return fmt.Errorf("%w: end date must be after start date", ErrInvalidReservation)
+	}
+	if f.SortBy == "" {
+		f.SortBy = "date"
+	}
+	if _, ok := ReservationSortColumns[f.SortBy]; !ok {
+		return fmt.Errorf("%w: cannot sort by %q", ErrInvalidReservation, f.SortBy)
+	}
+	return nil
+}
+
+// Order is the ORDER BY of the filter, ties broken by id so pages do not overlap
+func (f ReservationFilter) Order() string {
+	direction := " ASC"
+	if f.Desc {
+		direction = " DESC"
+	}
+	columns := ReservationSortColumns[f.SortBy]
+	order := make([]string, 0, len(columns)+1)
+	for _, column := range columns {
+		order = append(order, column+direction)
+	}
+	return strings.Join(append(order, "id"+direction), ", ")
+}
+
+// ReservationPeriod is the date range of a named period around now: today, this_week (Monday to
+// Sunday), this_month or this_year. The end is exclusive.
+func ReservationPeriod(period string, now time.Time) (start, end time.Time, err error) {
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+	switch period {
+	case "today":
+		return today, today.AddDate(0, 0, 1), nil
+	case "this_week":
+		start = today.AddDate(0, 0, -(int(today.Weekday())+6)%7)
+		return start, start.AddDate(0, 0, 7), nil
+	case "this_month":
+		start = time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, now.Location())
+		return start, start.AddDate(0, 1, 0), nil
+	case "this_year":
+		start = time.Date(now.Year(), 1, 1, 0, 0, 0, 0, now.Location())
+		return start, start.AddDate(1, 0, 0), nil
+	}
+	return start, end, fmt.Errorf("%w: filter must be today, this_week, this_month or this_year", ErrInvalidReservation)
+}
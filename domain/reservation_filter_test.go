@@ -0,0 +1,69 @@
+package domain_test
+
+import (
+	"project/domain"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestReservationFilter_Validate(t *testing.T) {
+	t.Run("Default sort", func(t *testing.T) {
+		filter := domain.ReservationFilter{}
+		assert.NoError(t, filter.Validate())
+		assert.Equal(t, "reservation_date ASC, reservation_time ASC, id ASC", filter.Order())
+	})
+
+	t.Run("Descending by name", func(t *testing.T) {
+		filter := domain.ReservationFilter{SortBy: "name", Desc: true}
+		assert.NoError(t, filter.Validate())
+		assert.Equal(t, "reservation_name DESC, id DESC", filter.Order())
+	})
+
+	t.Run("Unknown sort field", func(t *testing.T) {
+		filter := domain.ReservationFilter{SortBy: "email_address; DROP TABLE reservations"}
+		assert.ErrorIs(t, filter.Validate(), domain.ErrInvalidReservation)
+	})
+
+	t.Run("Unknown status", func(t *testing.T) {
+		filter := domain.ReservationFilter{Statuses: []domain.ReservationStatus{domain.ReservationSeated, "Lost"}}
+		assert.ErrorIs(t, filter.Validate(), domain.ErrInvalidReservation)
+	})
+
+	t.Run("End before start", func(t *testing.T) {
+		start := time.Date(2024, 12, 16, 0, 0, 0, 0, time.Local)
+		filter := domain.ReservationFilter{Start: start, End: start.AddDate(0, 0, -1)}
+		assert.ErrorIs(t, filter.Validate(), domain.ErrInvalidReservation)
+	})
+}
+
+func TestReservationPeriod(t *testing.T) {
+	// a Sunday
+	now := time.Date(2024, 12, 15, 19, 30, 0, 0, time.Local)
+	day := func(month time.Month, d int) time.Time {
+		return time.Date(2024, month, d, 0, 0, 0, 0, time.Local)
+	}
+
+	tests := []struct {
+		period string
+		start  time.Time
+		end    time.Time
+	}{
+		{"today", day(12, 15), day(12, 16)},
+		{"this_week", day(12, 9), day(12, 16)},
+		{"this_month", day(12, 1), time.Date(2025, 1, 1, 0, 0, 0, 0, time.Local)},
+		{"this_year", day(1, 1), time.Date(2025, 1, 1, 0, 0, 0, 0, time.Local)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.period, func(t *testing.T) {
+			start, end, err := domain.ReservationPeriod(tt.period, now)
+			assert.NoError(t, err)
+			assert.Equal(t, tt.start, start)
+			assert.Equal(t, tt.end, end)
+		})
+	}
+
+	_, _, err := domain.ReservationPeriod("next_week", now)
+	assert.ErrorIs(t, err, domain.ErrInvalidReservation)
+}
@@ -0,0 +1,214 @@
+package domain
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+var ErrInvalidReservationTransition = errors.New("invalid reservation status transition")
+
+type ReservationStatus string
+
+const (
+	ReservationPending   ReservationStatus = "Pending"
+	ReservationConfirmed ReservationStatus = "Confirmed"
+	ReservationReminded  ReservationStatus = "Reminded"
+	ReservationArrived   ReservationStatus = "Arrived"
+	ReservationSeated    ReservationStatus = "Seated"
+	ReservationCompleted ReservationStatus = "Completed"
+	ReservationNoShow    ReservationStatus = "No-Show"
+	ReservationCanceled  ReservationStatus = "Canceled"
+)
+
+// HoldingReservationStatuses are the statuses of a reservation that still books its tables
+var HoldingReservationStatuses = []ReservationStatus{
+	ReservationPending, ReservationConfirmed, ReservationReminded, ReservationArrived, ReservationSeated,
+}
+
+// Holds tells whether a reservation with the status still books its tables
+func (s ReservationStatus) Holds() bool {
+	return containsStatus(HoldingReservationStatuses, s)
+}
+
+// Valid tells whether s is one of the reservation statuses
+func (s ReservationStatus) Valid() bool {
+	return containsStatus(HoldingReservationStatuses, s) ||
+		s == ReservationCompleted || s == ReservationNoShow || s == ReservationCanceled
+}
+
+type ReservationAction string
+
+const (
+	ReservationActionConfirm  ReservationAction = "confirm"
+	ReservationActionRemind   ReservationAction = "remind"
+	ReservationActionArrive   ReservationAction = "arrive"
+	ReservationActionSeat     ReservationAction = "seat"
+	ReservationActionComplete ReservationAction = "complete"
+	ReservationActionNoShow   ReservationAction = "no_show"
+	ReservationActionCancel   ReservationAction = "cancel"
+)
+
+// ReservationTransition lists the statuses an action may start from and the status it leads to
+type ReservationTransition struct {
+	From []ReservationStatus
+	To   ReservationStatus
+}
+
+var ReservationTransitions = map[ReservationAction]ReservationTransition{
+	ReservationActionConfirm: {
+		From: []ReservationStatus{ReservationPending},
+		To:   ReservationConfirmed,
+	},
+	ReservationActionRemind: {
+		From: []ReservationStatus{ReservationConfirmed},
+		To:   ReservationReminded,
+	},
+	ReservationActionArrive: {
+		From: []ReservationStatus{ReservationConfirmed, ReservationReminded},
+		To:   ReservationArrived,
+	},
+	ReservationActionSeat: {
+		From: []ReservationStatus{ReservationConfirmed, ReservationReminded, ReservationArrived},
+		To:   ReservationSeated,
+	},
+	ReservationActionComplete: {
+		From: []ReservationStatus{ReservationSeated},
+		To:   ReservationCompleted,
+	},
+	ReservationActionNoShow: {
+		From: []ReservationStatus{ReservationConfirmed, ReservationReminded},
+		To:   ReservationNoShow,
+	},
+	ReservationActionCancel: {
+		From: []ReservationStatus{ReservationPending, ReservationConfirmed, ReservationReminded, ReservationArrived},
+		To:   ReservationCanceled,
+	},
+}
+
+type ReservationTransitionInput struct {
+	UserID *uint
+	Reason string
+}
+
+type ReservationStatusHistory struct {
+	ID            uint              `gorm:"primaryKey" json:"id"`
+	ReservationID uint              `gorm:"not null;index" json:"reservation_id"`
+	Action        ReservationAction `gorm:"size:20;not null" json:"action" example:"arrive"`
+	FromStatus    ReservationStatus `gorm:"size:20;not null" json:"from_status" example:"Confirmed"`
+	ToStatus      ReservationStatus `gorm:"size:20;not null" json:"to_status" example:"Arrived"`
+	UserID        *uint             `json:"user_id"`
+	User          *User             `gorm:"foreignKey:UserID;references:ID" json:"-"`
+	Reason        string            `gorm:"size:255" json:"reason"`
+	CreatedAt     time.Time         `gorm:"autoCreateTime" json:"created_at"`
+}
+
+func (ReservationStatusHistory) TableName() string {
+	return "reservation_status_history"
+}
+
+// Can tells whether the action may be taken on the reservation in its current status
+func (r *Reservation) Can(action ReservationAction) bool {
+	transition, ok := ReservationTransitions[action]
+	return ok && containsStatus(transition.From, r.Status)
+}
+
+// Transition validates action against the current status, moves the reservation to the new status
+// and stamps when it happened. The caller opens the order of a seated reservation, releases the
+// tables of one that ended and persists the reservation and history.
+func (r *Reservation) Transition(action ReservationAction, input ReservationTransitionInput, at time.Time) (*ReservationStatusHistory, error) {
+	transition, ok := ReservationTransitions[action]
+	if !ok {
+		return nil, fmt.Errorf("%w: unknown action %s", ErrInvalidReservationTransition, action)
+	}
+	if !containsStatus(transition.From, r.Status) {
+		return nil, fmt.Errorf("%w: cannot %s a reservation that is %s", ErrInvalidReservationTransition, action, r.Status)
+	}
+
+	history := &ReservationStatusHistory{
+		ReservationID: r.ID,
+		Action:        action,
+		FromStatus:    r.Status,
+		ToStatus:      transition.To,
+		UserID:        input.UserID,
+		Reason:        input.Reason,
+	}
+
+	r.Status = transition.To
+	switch transition.To {
+	case ReservationConfirmed:
+		r.ConfirmedAt = &at
+	case ReservationReminded:
+		r.RemindedAt = &at
+	case ReservationArrived:
+		r.ArrivedAt = &at
+	case ReservationSeated:
+		r.SeatedAt = &at
+	default:
+		r.ClosedAt = &at
+	}
+	return history, nil
+}
+
+// cancelSeated cancels a reservation whose party was seated with an order that is now cancelled.
+// Only an order cancellation does this, so it is not one of the ReservationTransitions.
+func (r *Reservation) cancelSeated(input ReservationTransitionInput, at time.Time) *ReservationStatusHistory {
+	history := &ReservationStatusHistory{
+		ReservationID: r.ID,
+		Action:        ReservationActionCancel,
+		FromStatus:    r.Status,
+		ToStatus:      ReservationCanceled,
+		UserID:        input.UserID,
+		Reason:        input.Reason,
+	}
+	r.Status = ReservationCanceled
+	r.ClosedAt = &at
+	return history
+}
+
+// NewOrder is the dine-in order the party is seated with at the table of the reservation
+func (r *Reservation) NewOrder() *Order {
+	tableID := r.TableID
+	return &Order{
+		Name:          r.ReservationName,
+		Type:          OrderDineIn,
+		StatusPayment: OrderInProcess,
+		TableID:       &tableID,
+		PartySize:     int(r.PaxNumber),
+		CustomerPhone: r.PhoneNumber,
+		CustomerID:    r.CustomerID,
+		ReservationID: r.ID,
+	}
+}
+
+// completeReservation completes the reservations seated with the order once the order is paid, a
+// merged order can carry the reservations of every party it took in
+func completeReservation(tx *gorm.DB, orderID uint, input OrderTransitionInput) error {
+	var reservations []Reservation
+	if err := tx.Where("order_id = ? AND status = ?", orderID, ReservationSeated).Order("id").Find(&reservations).Error; err != nil {
+		return fmt.Errorf("failed to retrieve reservation of order: %v", err)
+	}
+
+	for i := range reservations {
+		reservation := &reservations[i]
+		history, err := reservation.Transition(ReservationActionComplete, ReservationTransitionInput{UserID: input.UserID}, time.Now())
+		if err != nil {
+			return err
+		}
+		err = tx.Model(&Reservation{}).Where("id = ?", reservation.ID).UpdateColumns(map[string]interface{}{
+			"status":     reservation.Status,
+			"closed_at":  reservation.ClosedAt,
+			"version":    gorm.Expr("version + 1"),
+			"updated_at": time.Now(),
+		}).Error
+		if err != nil {
+			return fmt.Errorf("failed to complete reservation: %v", err)
+		}
+		if err := tx.Create(history).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,86 @@
+package domain_test
+
+import (
+	"project/domain"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestReservation_Transition(t *testing.T) {
+	tests := []struct {
+		name   string
+		from   domain.ReservationStatus
+		action domain.ReservationAction
+		to     domain.ReservationStatus
+		err    error
+	}{
+		{"Confirm a pending booking", domain.ReservationPending, domain.ReservationActionConfirm, domain.ReservationConfirmed, nil},
+		{"Remind a confirmed booking", domain.ReservationConfirmed, domain.ReservationActionRemind, domain.ReservationReminded, nil},
+		{"Arrive after a reminder", domain.ReservationReminded, domain.ReservationActionArrive, domain.ReservationArrived, nil},
+		{"Seat arrived guests", domain.ReservationArrived, domain.ReservationActionSeat, domain.ReservationSeated, nil},
+		{"Seat without arriving first", domain.ReservationConfirmed, domain.ReservationActionSeat, domain.ReservationSeated, nil},
+		{"Complete a seated booking", domain.ReservationSeated, domain.ReservationActionComplete, domain.ReservationCompleted, nil},
+		{"No-show after a reminder", domain.ReservationReminded, domain.ReservationActionNoShow, domain.ReservationNoShow, nil},
+		{"Cancel a pending booking", domain.ReservationPending, domain.ReservationActionCancel, domain.ReservationCanceled, nil},
+		{"Seat a pending booking", domain.ReservationPending, domain.ReservationActionSeat, "", domain.ErrInvalidReservationTransition},
+		{"No-show of arrived guests", domain.ReservationArrived, domain.ReservationActionNoShow, "", domain.ErrInvalidReservationTransition},
+		{"Cancel a seated booking", domain.ReservationSeated, domain.ReservationActionCancel, "", domain.ErrInvalidReservationTransition},
+		{"Confirm a canceled booking", domain.ReservationCanceled, domain.ReservationActionConfirm, "", domain.ErrInvalidReservationTransition},
+		{"Unknown action", domain.ReservationConfirmed, "walk_out", "", domain.ErrInvalidReservationTransition},
+	}
+
+	at := time.Date(2024, 12, 16, 19, 5, 0, 0, time.Local)
+	userID := uint(2)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			reservation := domain.Reservation{ID: 1, Status: tt.from}
+			history, err := reservation.Transition(tt.action, domain.ReservationTransitionInput{UserID: &userID}, at)
+			if tt.err != nil {
+				assert.ErrorIs(t, err, tt.err)
+				assert.Equal(t, tt.from, reservation.Status)
+				return
+			}
+
+			assert.NoError(t, err)
+			assert.Equal(t, tt.to, reservation.Status)
+			assert.Equal(t, tt.from, history.FromStatus)
+			assert.Equal(t, tt.to, history.ToStatus)
+			assert.Equal(t, &userID, history.UserID)
+		})
+	}
+}
+
+func TestReservation_TransitionTimestamps(t *testing.T) {
+	at := time.Date(2024, 12, 16, 19, 5, 0, 0, time.Local)
+	reservation := domain.Reservation{Status: domain.ReservationConfirmed}
+
+	_, err := reservation.Transition(domain.ReservationActionArrive, domain.ReservationTransitionInput{}, at)
+	assert.NoError(t, err)
+	assert.Equal(t, &at, reservation.ArrivedAt)
+
+	seated := at.Add(10 * time.Minute)
+	_, err = reservation.Transition(domain.ReservationActionSeat, domain.ReservationTransitionInput{}, seated)
+	assert.NoError(t, err)
+	assert.Equal(t, &seated, reservation.SeatedAt)
+	assert.Nil(t, reservation.ClosedAt)
+
+	closed := seated.Add(90 * time.Minute)
+	_, err = reservation.Transition(domain.ReservationActionComplete, domain.ReservationTransitionInput{}, closed)
+	assert.NoError(t, err)
+	assert.Equal(t, &closed, reservation.ClosedAt)
+	assert.False(t, reservation.Status.Holds())
+}
+
+func TestReservation_NewOrder(t *testing.T) {
+	reservation := domain.Reservation{ID: 12, TableID: 5, ReservationName: "John Doe", PaxNumber: 4, PhoneNumber: "0895123456"}
+
+	order := reservation.NewOrder()
+	assert.Equal(t, domain.OrderDineIn, order.Type)
+	assert.Equal(t, uint(5), *order.TableID)
+	assert.Equal(t, 4, order.PartySize)
+	assert.Equal(t, "John Doe", order.Name)
+	assert.Equal(t, "0895123456", order.CustomerPhone)
+	assert.Equal(t, uint(12), order.ReservationID)
+}
@@ -0,0 +1,93 @@
+package domain_test
+
+import (
+	"project/domain"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"gorm.io/datatypes"
+)
+
+func TestReservation_Window(t *testing.T) {
+	reservation := domain.Reservation{
+		ReservationDate: "2024-12-16T00:00:00Z",
+		ReservationTime: datatypes.NewTime(23, 0, 0, 0),
+		Duration:        120,
+	}
+
+	start, err := reservation.Start()
+	assert.NoError(t, err)
+	assert.Equal(t, time.Date(2024, 12, 16, 23, 0, 0, 0, time.Local), start)
+
+	end, err := reservation.End()
+	assert.NoError(t, err)
+	assert.Equal(t, time.Date(2024, 12, 17, 1, 0, 0, 0, time.Local), end, "a late booking runs past midnight")
+}
+
+func TestReservation_Overlaps(t *testing.T) {
+	reservation := domain.Reservation{
+		ReservationDate: "2024-12-16",
+		ReservationTime: datatypes.NewTime(19, 0, 0, 0),
+		Duration:        90,
+	}
+	at := func(hour, min int) time.Time { return time.Date(2024, 12, 16, hour, min, 0, 0, time.Local) }
+
+	tests := []struct {
+		name       string
+		start, end time.Time
+		overlaps   bool
+	}{
+		{"Same time", at(19, 0), at(20, 30), true},
+		{"Starts during", at(20, 0), at(21, 30), true},
+		{"Ends during", at(18, 0), at(19, 30), true},
+		{"Around", at(18, 0), at(22, 0), true},
+		{"Ends when it starts", at(17, 30), at(19, 0), false},
+		{"Starts when it ends", at(20, 30), at(22, 0), false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.overlaps, reservation.Overlaps(tt.start, tt.end))
+		})
+	}
+}
+
+func TestReservation_Validate(t *testing.T) {
+	table := domain.Table{Name: "Table C", Capacity: 4}
+
+	tests := []struct {
+		name        string
+		reservation domain.Reservation
+		err         error
+	}{
+		{"Fits the table", domain.Reservation{ReservationDate: "2024-12-16", PaxNumber: 4}, nil},
+		{"Too many guests", domain.Reservation{ReservationDate: "2024-12-16", PaxNumber: 5}, domain.ErrTableTooSmall},
+		{"Same table joined", domain.Reservation{ReservationDate: "2024-12-16", PaxNumber: 2, TableID: 3, JoinedTableIDs: []uint{3}}, domain.ErrInvalidReservation},
+		{"No guests", domain.Reservation{ReservationDate: "2024-12-16"}, domain.ErrInvalidReservation},
+		{"Bad date", domain.Reservation{ReservationDate: "16/12/2024", PaxNumber: 2}, domain.ErrInvalidReservation},
+		{"Negative duration", domain.Reservation{ReservationDate: "2024-12-16", PaxNumber: 2, Duration: -30}, domain.ErrInvalidReservation},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.reservation.Validate(table)
+			if tt.err != nil {
+				assert.ErrorIs(t, err, tt.err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, domain.DefaultReservationDuration, tt.reservation.Duration)
+		})
+	}
+}
+
+func TestReservation_ValidateJoinedTables(t *testing.T) {
+	reservation := domain.Reservation{ReservationDate: "2024-12-16", PaxNumber: 10, TableID: 5, JoinedTableIDs: []uint{6}}
+
+	err := reservation.Validate(domain.Table{Name: "Table E", Capacity: 6})
+	assert.ErrorIs(t, err, domain.ErrTableTooSmall)
+	assert.NoError(t, reservation.Validate(domain.Table{Name: "Table E", Capacity: 6}, domain.Table{Name: "Table F", Capacity: 8}))
+	assert.True(t, reservation.Books(6))
+	assert.False(t, reservation.Books(7))
+}
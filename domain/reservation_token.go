@@ -0,0 +1,59 @@
+package domain
+
+import (
+	"crypto/hmac"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var (
+	ErrInvalidReservationToken = errors.New("invalid reservation link")
+	ErrReservationTokenExpired = errors.New("reservation link has expired")
+)
+
+// SignReservationToken creates the token of a guest link that confirms or cancels a reservation
+// without logging in, the reservation id, action and expiry signed with HMAC-SHA256
+func SignReservationToken(reservationID uint, action ReservationAction, expiresAt time.Time, secret []byte) (string, error) {
+	if len(secret) == 0 {
+		return "", ErrMissingSecret
+	}
+	payload := fmt.Sprintf("%d.%s.%d", reservationID, action, expiresAt.Unix())
+	return payload + "." + receiptSignature(payload, secret), nil
+}
+
+// ParseReservationToken returns the reservation id and action of a guest link after checking its
+// signature and expiry
+func ParseReservationToken(token string, secret []byte, now time.Time) (uint, ReservationAction, error) {
+	if len(secret) == 0 {
+		return 0, "", ErrMissingSecret
+	}
+	parts := strings.Split(token, ".")
+	if len(parts) != 4 {
+		return 0, "", ErrInvalidReservationToken
+	}
+
+	payload := strings.Join(parts[:3], ".")
+	if !hmac.Equal([]byte(parts[3]), []byte(receiptSignature(payload, secret))) {
+		return 0, "", ErrInvalidReservationToken
+	}
+
+	reservationID, err := strconv.ParseUint(parts[0], 10, 0)
+	if err != nil {
+		return 0, "", ErrInvalidReservationToken
+	}
+	action := ReservationAction(parts[1])
+	if action != ReservationActionConfirm && action != ReservationActionCancel {
+		return 0, "", ErrInvalidReservationToken
+	}
+	expiresAt, err := strconv.ParseInt(parts[2], 10, 64)
+	if err != nil {
+		return 0, "", ErrInvalidReservationToken
+	}
+	if now.Unix() > expiresAt {
+		return 0, "", ErrReservationTokenExpired
+	}
+	return uint(reservationID), action, nil
+}
@@ -0,0 +1,61 @@
+package domain_test
+
+import (
+	"project/domain"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestReservationToken(t *testing.T) {
+	secret := []byte("secret")
+	now := time.Date(2024, 12, 15, 19, 30, 0, 0, time.UTC)
+	token, err := domain.SignReservationToken(7, domain.ReservationActionCancel, now.Add(24*time.Hour), secret)
+	assert.NoError(t, err)
+
+	t.Run("Valid token", func(t *testing.T) {
+		reservationID, action, err := domain.ParseReservationToken(token, secret, now)
+		assert.NoError(t, err)
+		assert.Equal(t, uint(7), reservationID)
+		assert.Equal(t, domain.ReservationActionCancel, action)
+	})
+
+	t.Run("Expired token", func(t *testing.T) {
+		_, _, err := domain.ParseReservationToken(token, secret, now.Add(25*time.Hour))
+		assert.ErrorIs(t, err, domain.ErrReservationTokenExpired)
+	})
+
+	t.Run("Other secret", func(t *testing.T) {
+		_, _, err := domain.ParseReservationToken(token, []byte("other"), now)
+		assert.ErrorIs(t, err, domain.ErrInvalidReservationToken)
+	})
+
+	t.Run("Tampered action", func(t *testing.T) {
+		_, _, err := domain.ParseReservationToken(strings.Replace(token, "cancel", "confirm", 1), secret, now)
+		assert.ErrorIs(t, err, domain.ErrInvalidReservationToken)
+	})
+
+	t.Run("Action guests cannot take", func(t *testing.T) {
+		seat, err := domain.SignReservationToken(7, domain.ReservationActionSeat, now.Add(time.Hour), secret)
+		assert.NoError(t, err)
+		_, _, err = domain.ParseReservationToken(seat, secret, now)
+		assert.ErrorIs(t, err, domain.ErrInvalidReservationToken)
+	})
+
+	t.Run("Receipt token", func(t *testing.T) {
+		receipt, err := domain.SignReceiptToken(7, now.Add(time.Hour), secret)
+		assert.NoError(t, err)
+		_, _, err = domain.ParseReservationToken(receipt, secret, now)
+		assert.ErrorIs(t, err, domain.ErrInvalidReservationToken)
+	})
+
+	t.Run("Empty secret", func(t *testing.T) {
+		_, err := domain.SignReservationToken(7, domain.ReservationActionCancel, now.Add(time.Hour), nil)
+		assert.ErrorIs(t, err, domain.ErrMissingSecret)
+
+		_, _, err = domain.ParseReservationToken(token, nil, now)
+		assert.ErrorIs(t, err, domain.ErrMissingSecret)
+	})
+}
@@ -7,6 +7,7 @@ func CategorySeed() []domain.Category {
 		{
 			Icon:        "/icon/beverage.png",
 			Name:        "Beverage",
+			Station:     "bar",
 			Description: "All kinds of beverages including soft drinks, coffee, and tea",
 		},
 		{
@@ -47,11 +48,13 @@ func CategorySeed() []domain.Category {
 		{
 			Icon:        "/icon/beverages_hot.png",
 			Name:        "Hot Beverages",
+			Station:     "bar",
 			Description: "Coffee, tea, and other hot drinks",
 		},
 		{
 			Icon:        "/icon/beverages_cold.png",
 			Name:        "Cold Beverages",
+			Station:     "bar",
 			Description: "Chilled drinks including soda, juice, and smoothies",
 		},
 	}
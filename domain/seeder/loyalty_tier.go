@@ -0,0 +1,26 @@
+package seeder
+
+import "project/domain"
+
+func LoyaltyTierSeed() []domain.LoyaltyTier {
+	return []domain.LoyaltyTier{
+		{
+			Name:           "Member",
+			MinPoints:      0,
+			EarnMultiplier: 1,
+			Perks:          "Earn points on every visit",
+		},
+		{
+			Name:           "Silver",
+			MinPoints:      1000,
+			EarnMultiplier: 1.25,
+			Perks:          "25% more points and a free dessert on your birthday",
+		},
+		{
+			Name:           "Gold",
+			MinPoints:      5000,
+			EarnMultiplier: 1.5,
+			Perks:          "50% more points, priority booking and a welcome drink on every visit",
+		},
+	}
+}
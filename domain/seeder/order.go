@@ -1,11 +1,14 @@
 package seeder
 
-import "project/domain"
+import (
+	"project/domain"
+	"project/helper"
+)
 
 func OrderSeed() []domain.Order {
 	return []domain.Order{
 		{
-			TableID: 1,
+			TableID: helper.Ptr(uint(1)),
 			Name:    "John Doe",
 			OrderItems: []domain.OrderItem{
 				{
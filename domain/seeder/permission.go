@@ -11,5 +11,7 @@ func Permission() []domain.Permission {
 		{Name: "Reports"},
 		{Name: "Orders"},
 		{Name: "Reservations"},
+		{Name: "Customers"},
+		{Name: "Gift Cards"},
 	}
 }
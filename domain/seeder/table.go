@@ -5,34 +5,88 @@ import "project/domain"
 func TableSeed() []domain.Table {
 	return []domain.Table{
 		{
-			Name: "Table A",
+			Name:     "Table A",
+			Capacity: 2,
+			Section:  "Main Hall",
+			Shape:    domain.TableRound,
+			X:        40,
+			Y:        40,
 		},
 		{
-			Name: "Table B",
+			Name:     "Table B",
+			Capacity: 2,
+			Section:  "Main Hall",
+			Shape:    domain.TableRound,
+			X:        160,
+			Y:        40,
 		},
 		{
-			Name: "Table C",
+			Name:     "Table C",
+			Capacity: 4,
+			Section:  "Main Hall",
+			Shape:    domain.TableSquare,
+			X:        280,
+			Y:        40,
 		},
 		{
-			Name: "Table D",
+			Name:     "Table D",
+			Capacity: 4,
+			Section:  "Main Hall",
+			Shape:    domain.TableSquare,
+			X:        400,
+			Y:        40,
 		},
 		{
-			Name: "Table E",
+			Name:     "Table E",
+			Capacity: 6,
+			Section:  "Main Hall",
+			Shape:    domain.TableRectangle,
+			X:        520,
+			Y:        40,
 		},
 		{
-			Name: "Table F",
+			Name:     "Table F",
+			Capacity: 8,
+			Section:  "Main Hall",
+			Shape:    domain.TableRectangle,
+			X:        640,
+			Y:        40,
 		},
 		{
-			Name: "Table G",
+			Name:     "Table G",
+			Capacity: 2,
+			Section:  "Terrace",
+			Outdoor:  true,
+			Shape:    domain.TableRound,
+			X:        40,
+			Y:        200,
 		},
 		{
-			Name: "Table H",
+			Name:     "Table H",
+			Capacity: 4,
+			Section:  "Terrace",
+			Outdoor:  true,
+			Shape:    domain.TableSquare,
+			X:        160,
+			Y:        200,
 		},
 		{
-			Name: "Table I",
+			Name:     "Table I",
+			Capacity: 4,
+			Section:  "Terrace",
+			Outdoor:  true,
+			Shape:    domain.TableSquare,
+			X:        280,
+			Y:        200,
 		},
 		{
-			Name: "Table J",
+			Name:     "Table J",
+			Capacity: 6,
+			Section:  "Terrace",
+			Outdoor:  true,
+			Shape:    domain.TableRectangle,
+			X:        400,
+			Y:        200,
 		},
 	}
 }
@@ -0,0 +1,15 @@
+package seeder
+
+import "project/domain"
+
+func TaxRateSeed() []domain.TaxRate {
+	return []domain.TaxRate{
+		{
+			Name:      "VAT",
+			Code:      "VAT",
+			Rate:      10,
+			IsDefault: true,
+			Active:    true,
+		},
+	}
+}
@@ -0,0 +1,76 @@
+package domain
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+var ErrServiceChargeRuleNotFound = errors.New("service charge rule not found")
+
+// ServiceChargeRule adds a service charge to matching orders. An empty order type matches every
+// order and a minimum party size of zero matches every party. When several rules match, the one
+// with the largest minimum party size wins, then the one for the order's type.
+type ServiceChargeRule struct {
+	ID           uint           `gorm:"primaryKey" json:"id" swaggerignore:"true"`
+	Name         string         `gorm:"size:100;not null" json:"name" binding:"required" example:"Large party"`
+	OrderType    OrderType      `gorm:"size:20" json:"order_type" binding:"omitempty,oneof=dine_in takeaway delivery pickup" example:"dine_in"`
+	MinPartySize int            `gorm:"not null;default:0" json:"min_party_size" binding:"gte=0" example:"6"`
+	Type         DiscountType   `gorm:"size:20;not null" json:"type" binding:"required,oneof=percentage fixed" example:"percentage"`
+	Value        float64        `gorm:"type:decimal(10,2);not null" json:"value" binding:"required,gt=0" example:"12.5"`
+	Active       bool           `gorm:"not null;default:true" json:"active" example:"true"`
+	CreatedAt    time.Time      `gorm:"autoCreateTime" json:"created_at" swaggerignore:"true"`
+	UpdatedAt    time.Time      `gorm:"autoUpdateTime" json:"updated_at" swaggerignore:"true"`
+	DeletedAt    gorm.DeletedAt `gorm:"index" json:"-" swaggerignore:"true"`
+}
+
+func (r *ServiceChargeRule) Matches(order *Order) bool {
+	if !r.Active {
+		return false
+	}
+	if r.OrderType != "" && r.OrderType != order.Type {
+		return false
+	}
+	return order.PartySize >= r.MinPartySize
+}
+
+// MatchServiceCharge returns the most specific rule for the order, nil when none applies
+func MatchServiceCharge(rules []ServiceChargeRule, order *Order) *ServiceChargeRule {
+	var best *ServiceChargeRule
+	for i := range rules {
+		rule := &rules[i]
+		if !rule.Matches(order) {
+			continue
+		}
+		if best == nil || rule.MinPartySize > best.MinPartySize ||
+			(rule.MinPartySize == best.MinPartySize && rule.OrderType != "" && best.OrderType == "") {
+			best = rule
+		}
+	}
+	return best
+}
+
+// chargeService stores the service charge of the order, calculated on the subtotal after discounts
+func chargeService(tx *gorm.DB, order *Order, discounted float64) error {
+	var rules []ServiceChargeRule
+	if err := tx.Where("active = ?", true).Order("id").Find(&rules).Error; err != nil {
+		return fmt.Errorf("failed to retrieve service charge rules: %v", err)
+	}
+
+	var ruleID *uint
+	var amount float64
+	if rule := MatchServiceCharge(rules, order); rule != nil {
+		ruleID = &rule.ID
+		amount = rule.Type.Amount(rule.Value, math.Max(discounted, 0))
+		if rule.Type == DiscountFixed {
+			// a fixed charge is not limited by the order amount
+			amount = rule.Value
+		}
+	}
+
+	return tx.Model(&Order{}).Where("id = ?", order.ID).
+		UpdateColumns(map[string]interface{}{"service_charge_rule_id": ruleID, "service_charge": amount}).Error
+}
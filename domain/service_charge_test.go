@@ -0,0 +1,39 @@
+package domain_test
+
+import (
+	"project/domain"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMatchServiceCharge(t *testing.T) {
+	rules := []domain.ServiceChargeRule{
+		{ID: 1, Name: "Any order", Type: domain.DiscountPercentage, Value: 5, Active: true},
+		{ID: 2, Name: "Dine in", OrderType: domain.OrderDineIn, Type: domain.DiscountPercentage, Value: 10, Active: true},
+		{ID: 3, Name: "Large party", OrderType: domain.OrderDineIn, MinPartySize: 6, Type: domain.DiscountPercentage, Value: 12.5, Active: true},
+		{ID: 4, Name: "Disabled", MinPartySize: 10, Type: domain.DiscountFixed, Value: 20, Active: false},
+	}
+
+	tests := []struct {
+		name  string
+		order domain.Order
+		want  uint
+	}{
+		{"Order type beats any type", domain.Order{Type: domain.OrderDineIn, PartySize: 2}, 2},
+		{"Party size beats order type", domain.Order{Type: domain.OrderDineIn, PartySize: 8}, 3},
+		{"Inactive rules are skipped", domain.Order{Type: domain.OrderDineIn, PartySize: 12}, 3},
+		{"Falls back to any type", domain.Order{Type: domain.OrderTakeaway, PartySize: 8}, 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rule := domain.MatchServiceCharge(rules, &tt.order)
+			if assert.NotNil(t, rule) {
+				assert.Equal(t, tt.want, rule.ID)
+			}
+		})
+	}
+
+	assert.Nil(t, domain.MatchServiceCharge(rules[1:3], &domain.Order{Type: domain.OrderDelivery, PartySize: 1}))
+}
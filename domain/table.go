@@ -1,13 +1,103 @@
 package domain
 
 import (
+	"errors"
+	"fmt"
 	"time"
+
+	"gorm.io/gorm"
+)
+
+var (
+	ErrTableNotFound      = errors.New("table not found")
+	ErrInvalidTableStatus = errors.New("invalid table status change")
+	ErrTableInUse         = errors.New("table has an open order")
+	ErrTableUnavailable   = errors.New("table is not available")
+)
+
+type TableStatus string
+
+const (
+	TableAvailable     TableStatus = "available"
+	TableOccupied      TableStatus = "occupied"
+	TableReserved      TableStatus = "reserved"
+	TableNeedsCleaning TableStatus = "needs_cleaning"
+	TableOutOfService  TableStatus = "out_of_service"
+)
+
+type TableShape string
+
+const (
+	TableSquare    TableShape = "square"
+	TableRound     TableShape = "round"
+	TableRectangle TableShape = "rectangle"
 )
 
+// Table is a dining table placed on the floor plan. Its position is in floor-plan units from the
+// top left corner and its rotation in degrees.
 type Table struct {
-	ID        uint      `gorm:"primaryKey" json:"id" swaggerignore:"true"`
-	Name      string    `gorm:"size:10;unique" json:"name"`
-	Status    bool      `gorm:"type:boolean;default:true" json:"-" example:"true"`
-	CreatedAt time.Time `gorm:"autoCreateTime" json:"-" swaggerignore:"true"`
-	UpdatedAt time.Time `gorm:"autoUpdateTime" json:"-" swaggerignore:"true"`
+	ID       uint        `gorm:"primaryKey" json:"id" swaggerignore:"true"`
+	Name     string      `gorm:"size:50;unique" json:"name" binding:"required,max=50" example:"Table A"`
+	Capacity int         `gorm:"not null;default:2" json:"capacity" binding:"required,gte=1" example:"4"`
+	Section  string      `gorm:"size:50" json:"section" binding:"max=50" example:"Terrace"`
+	Outdoor  bool        `gorm:"not null;default:false" json:"outdoor" example:"true"`
+	Shape    TableShape  `gorm:"size:20;not null;default:'square'" json:"shape" binding:"omitempty,oneof=square round rectangle" example:"round"`
+	X        float64     `gorm:"not null;default:0" json:"x" example:"120"`
+	Y        float64     `gorm:"not null;default:0" json:"y" example:"80"`
+	Rotation int         `gorm:"not null;default:0" json:"rotation" binding:"gte=0,lt=360" example:"0"`
+	Status   TableStatus `gorm:"size:20;not null;default:'available';check:status IN ('available', 'occupied', 'reserved', 'needs_cleaning', 'out_of_service')" json:"status" swaggerignore:"true"`
+	// StatusChangedAt is when the table entered its current status
+	StatusChangedAt time.Time `gorm:"not null;default:CURRENT_TIMESTAMP" json:"status_changed_at" swaggerignore:"true"`
+	// SeatedAt is when the current guests were seated, empty unless the table is occupied
+	SeatedAt *time.Time `json:"seated_at" swaggerignore:"true"`
+	// SeatedMinutes is filled when tables are read for the host stand
+	SeatedMinutes int            `gorm:"-" json:"seated_minutes" swaggerignore:"true"`
+	CreatedAt     time.Time      `gorm:"autoCreateTime" json:"created_at" swaggerignore:"true"`
+	UpdatedAt     time.Time      `gorm:"autoUpdateTime" json:"updated_at" swaggerignore:"true"`
+	DeletedAt     gorm.DeletedAt `gorm:"index" json:"-" swaggerignore:"true"`
+}
+
+func (s TableStatus) Valid() bool {
+	switch s {
+	case TableAvailable, TableOccupied, TableReserved, TableNeedsCleaning, TableOutOfService:
+		return true
+	}
+	return false
+}
+
+// ChangeStatus is a status change made by staff. Occupied is left to the orders seated at the
+// table, so it can neither be set nor cleared by hand.
+func (t *Table) ChangeStatus(status TableStatus, at time.Time) error {
+	if !status.Valid() {
+		return fmt.Errorf("%w: unknown status %q", ErrInvalidTableStatus, status)
+	}
+	if status == TableOccupied {
+		return fmt.Errorf("%w: a table is occupied by opening an order on it", ErrInvalidTableStatus)
+	}
+	if t.Status == TableOccupied {
+		return ErrTableInUse
+	}
+	t.setStatus(status, at)
+	return nil
+}
+
+// SeatedFor is how long the current guests have been seated, zero when the table is not occupied
+func (t *Table) SeatedFor(now time.Time) time.Duration {
+	if t.SeatedAt == nil {
+		return 0
+	}
+	return now.Sub(*t.SeatedAt)
+}
+
+func (t *Table) setStatus(status TableStatus, at time.Time) {
+	if t.Status == status {
+		return
+	}
+	t.Status = status
+	t.StatusChangedAt = at
+	if status == TableOccupied {
+		t.SeatedAt = &at
+	} else {
+		t.SeatedAt = nil
+	}
 }
@@ -0,0 +1,50 @@
+package domain_test
+
+import (
+	"project/domain"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTable_ChangeStatus(t *testing.T) {
+	now := time.Date(2024, 5, 10, 19, 30, 0, 0, time.UTC)
+
+	tests := []struct {
+		name    string
+		current domain.TableStatus
+		status  domain.TableStatus
+		err     error
+	}{
+		{"Available to reserved", domain.TableAvailable, domain.TableReserved, nil},
+		{"Needs cleaning to available", domain.TableNeedsCleaning, domain.TableAvailable, nil},
+		{"Available to out of service", domain.TableAvailable, domain.TableOutOfService, nil},
+		{"Occupied by hand", domain.TableAvailable, domain.TableOccupied, domain.ErrInvalidTableStatus},
+		{"Unknown status", domain.TableAvailable, "broken", domain.ErrInvalidTableStatus},
+		{"Release an occupied table", domain.TableOccupied, domain.TableAvailable, domain.ErrTableInUse},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			table := domain.Table{Status: tt.current}
+			err := table.ChangeStatus(tt.status, now)
+			if tt.err != nil {
+				assert.ErrorIs(t, err, tt.err)
+				assert.Equal(t, tt.current, table.Status)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.status, table.Status)
+			assert.Equal(t, now, table.StatusChangedAt)
+		})
+	}
+}
+
+func TestTable_SeatedFor(t *testing.T) {
+	now := time.Date(2024, 5, 10, 19, 30, 0, 0, time.UTC)
+	seated := now.Add(-45 * time.Minute)
+
+	assert.Equal(t, 45*time.Minute, (&domain.Table{Status: domain.TableOccupied, SeatedAt: &seated}).SeatedFor(now))
+	assert.Zero(t, (&domain.Table{Status: domain.TableAvailable}).SeatedFor(now))
+}
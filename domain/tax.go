@@ -0,0 +1,258 @@
+package domain
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+var (
+	ErrTaxRateNotFound = errors.New("tax rate not found")
+	ErrInvalidTaxRate  = errors.New("invalid tax rate")
+)
+
+// TaxRate is a configured tax such as VAT, a service charge or a local restaurant tax.
+// Inclusive rates are already part of the product price, exclusive rates are added on top.
+// Products take their own rates, then their category's, then every default rate.
+// A rate limited to some order types, such as a lower takeaway VAT, is skipped on other orders.
+type TaxRate struct {
+	ID        uint    `gorm:"primaryKey" json:"id" swaggerignore:"true"`
+	Name      string  `gorm:"size:100;not null" json:"name" binding:"required" example:"VAT"`
+	Code      string  `gorm:"size:20;unique;not null" json:"code" binding:"required" example:"VAT"`
+	Rate      float64 `gorm:"type:decimal(6,3);not null" json:"rate" binding:"gte=0,lte=100" example:"10"`
+	Inclusive bool    `gorm:"not null;default:false" json:"inclusive" example:"false"`
+	IsDefault bool    `gorm:"not null;default:false" json:"is_default" example:"true"`
+	// OrderTypes is a comma separated list of order types, empty for every type
+	OrderTypes string         `gorm:"size:100" json:"order_types" example:"dine_in,delivery"`
+	Active     bool           `gorm:"not null;default:true" json:"active" example:"true"`
+	CreatedAt  time.Time      `gorm:"autoCreateTime" json:"created_at" swaggerignore:"true"`
+	UpdatedAt  time.Time      `gorm:"autoUpdateTime" json:"updated_at" swaggerignore:"true"`
+	DeletedAt  gorm.DeletedAt `gorm:"index" json:"-" swaggerignore:"true"`
+}
+
+// AppliesTo tells whether the rate is charged on orders of the given type
+func (r TaxRate) AppliesTo(orderType OrderType) bool {
+	if strings.TrimSpace(r.OrderTypes) == "" {
+		return true
+	}
+	for _, t := range strings.Split(r.OrderTypes, ",") {
+		if OrderType(strings.TrimSpace(t)) == orderType {
+			return true
+		}
+	}
+	return false
+}
+
+// Validate checks the listed order types
+func (r TaxRate) Validate() error {
+	if strings.TrimSpace(r.OrderTypes) == "" {
+		return nil
+	}
+	for _, t := range strings.Split(r.OrderTypes, ",") {
+		switch OrderType(strings.TrimSpace(t)) {
+		case OrderDineIn, OrderTakeaway, OrderDelivery, OrderPickup:
+		default:
+			return fmt.Errorf("%w: unknown order type %q", ErrInvalidTaxRate, strings.TrimSpace(t))
+		}
+	}
+	return nil
+}
+
+type TaxRoundingScope string
+
+const (
+	// TaxRoundPerLine rounds the tax of every item before adding them up
+	TaxRoundPerLine TaxRoundingScope = "line"
+	// TaxRoundPerOrder adds up the exact tax of every item and rounds each rate once
+	TaxRoundPerOrder TaxRoundingScope = "order"
+)
+
+type TaxRoundingMode string
+
+const (
+	TaxRoundHalfUp   TaxRoundingMode = "half_up"
+	TaxRoundHalfEven TaxRoundingMode = "half_even"
+	TaxRoundUp       TaxRoundingMode = "up"
+	TaxRoundDown     TaxRoundingMode = "down"
+)
+
+type TaxRounding struct {
+	Scope TaxRoundingScope
+	Mode  TaxRoundingMode
+}
+
+var DefaultTaxRounding = TaxRounding{Scope: TaxRoundPerLine, Mode: TaxRoundHalfUp}
+
+// Round rounds amount to cents with the configured mode
+func (r TaxRounding) Round(amount float64) float64 {
+	// the small offset keeps values such as 1.005 stored as 1.00499.. from rounding the wrong way
+	cents := amount * 100
+	switch r.Mode {
+	case TaxRoundHalfEven:
+		cents = math.RoundToEven(cents)
+	case TaxRoundUp:
+		cents = math.Ceil(cents - 1e-9)
+	case TaxRoundDown:
+		cents = math.Floor(cents + 1e-9)
+	default:
+		cents = math.Round(cents + 1e-9)
+	}
+	return cents / 100
+}
+
+// OrderTaxLine is the tax of one rate on an order, recalculated by PriceOrder whenever the order changes
+type OrderTaxLine struct {
+	ID            uint      `gorm:"primaryKey" json:"id"`
+	OrderID       uint      `gorm:"not null;index" json:"order_id"`
+	TaxRateID     uint      `gorm:"not null" json:"tax_rate_id"`
+	Name          string    `gorm:"size:100;not null" json:"name" example:"VAT"`
+	Code          string    `gorm:"size:20;not null" json:"code" example:"VAT"`
+	Rate          float64   `gorm:"type:decimal(6,3);not null" json:"rate" example:"10"`
+	Inclusive     bool      `gorm:"not null" json:"inclusive" example:"false"`
+	TaxableAmount float64   `gorm:"type:decimal(10,2);not null" json:"taxable_amount" example:"29.08"`
+	Amount        float64   `gorm:"type:decimal(10,2);not null" json:"amount" example:"2.91"`
+	CreatedAt     time.Time `gorm:"autoCreateTime" json:"created_at"`
+}
+
+// TaxableLine is an order item after its discounts, with the rates that apply to it
+type TaxableLine struct {
+	Amount float64
+	Rates  []TaxRate
+}
+
+// CalculateTaxes returns one tax line per rate. For every line the net amount is the line amount
+// without its inclusive rates, every rate, inclusive or exclusive, is then charged on that net amount.
+func CalculateTaxes(lines []TaxableLine, rounding TaxRounding) []OrderTaxLine {
+	taxLines := make(map[uint]*OrderTaxLine)
+	for _, line := range lines {
+		var inclusive float64
+		for _, rate := range line.Rates {
+			if rate.Inclusive {
+				inclusive += rate.Rate
+			}
+		}
+		net := line.Amount / (1 + inclusive/100)
+
+		for _, rate := range line.Rates {
+			taxLine, ok := taxLines[rate.ID]
+			if !ok {
+				taxLine = &OrderTaxLine{TaxRateID: rate.ID, Name: rate.Name, Code: rate.Code, Rate: rate.Rate, Inclusive: rate.Inclusive}
+				taxLines[rate.ID] = taxLine
+			}
+
+			tax := net * rate.Rate / 100
+			if rounding.Scope != TaxRoundPerOrder {
+				tax = rounding.Round(tax)
+			}
+			taxLine.TaxableAmount += net
+			taxLine.Amount += tax
+		}
+	}
+
+	result := make([]OrderTaxLine, 0, len(taxLines))
+	for _, taxLine := range taxLines {
+		taxLine.TaxableAmount = math.Round(taxLine.TaxableAmount*100) / 100
+		taxLine.Amount = rounding.Round(taxLine.Amount)
+		result = append(result, *taxLine)
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].TaxRateID < result[j].TaxRateID })
+	return result
+}
+
+// productTaxRates picks the product's own rates, then its category's, then the default rates,
+// keeping the active ones that apply to the order type
+func productTaxRates(product Product, defaults []TaxRate, orderType OrderType) []TaxRate {
+	rates := product.TaxRates
+	if len(rates) == 0 {
+		rates = product.Category.TaxRates
+	}
+	if len(rates) == 0 {
+		rates = defaults
+	}
+
+	active := make([]TaxRate, 0, len(rates))
+	for _, rate := range rates {
+		if rate.Active && rate.AppliesTo(orderType) {
+			active = append(active, rate)
+		}
+	}
+	return active
+}
+
+// taxOrder replaces the tax lines of an order. Item discounts reduce their item, order discounts
+// are spread over the items by what is left of each so the taxable base matches what is charged.
+func taxOrder(tx *gorm.DB, order *Order, items []OrderItem, discounts []OrderDiscount, rounding TaxRounding) error {
+	var defaults []TaxRate
+	if err := tx.Where("is_default = ? AND active = ?", true, true).Order("id").Find(&defaults).Error; err != nil {
+		return fmt.Errorf("failed to retrieve default tax rates: %v", err)
+	}
+
+	amounts := make(map[uint]float64, len(items))
+	var total float64
+	for _, item := range items {
+		amounts[item.ID] = float64(item.Quantity) * item.Product.Price
+	}
+	var orderDiscount float64
+	for _, discount := range discounts {
+		if discount.OrderItemID != nil {
+			amounts[*discount.OrderItemID] -= discount.Amount
+		} else {
+			orderDiscount += discount.Amount
+		}
+	}
+	for _, amount := range amounts {
+		total += amount
+	}
+
+	lines := make([]TaxableLine, 0, len(items))
+	for _, item := range items {
+		amount := amounts[item.ID]
+		if total > 0 {
+			amount -= orderDiscount * amount / total
+		}
+		lines = append(lines, TaxableLine{Amount: math.Max(amount, 0), Rates: productTaxRates(item.Product, defaults, order.Type)})
+	}
+
+	if err := tx.Where("order_id = ?", order.ID).Delete(&OrderTaxLine{}).Error; err != nil {
+		return fmt.Errorf("failed to clear order taxes: %v", err)
+	}
+
+	taxLines := CalculateTaxes(lines, rounding)
+	for i := range taxLines {
+		taxLines[i].OrderID = order.ID
+	}
+	if len(taxLines) > 0 {
+		if err := tx.Create(&taxLines).Error; err != nil {
+			return fmt.Errorf("failed to save order taxes: %v", err)
+		}
+	}
+	return nil
+}
+
+// OrderExclusiveTax sums the taxes charged on top of the order's prices
+func OrderExclusiveTax(tx *gorm.DB, orderID uint) (float64, error) {
+	var total float64
+	err := tx.Model(&OrderTaxLine{}).
+		Select("COALESCE(SUM(amount), 0)").
+		Where("order_id = ? AND inclusive = ?", orderID, false).
+		Scan(&total).Error
+	return total, err
+}
+
+// TaxSummary is one row of the tax filing report
+type TaxSummary struct {
+	Code              string  `json:"code" example:"VAT"`
+	Name              string  `json:"name" example:"VAT"`
+	Rate              float64 `json:"rate" example:"10"`
+	Inclusive         bool    `json:"inclusive" example:"false"`
+	Orders            int64   `json:"orders" example:"120"`
+	TaxableAmount     float64 `json:"taxable_amount" example:"3250.40"`
+	TaxAmount         float64 `json:"tax_amount" example:"325.04"`
+	RefundedTaxAmount float64 `json:"refunded_tax_amount" example:"4.10"`
+	NetTaxAmount      float64 `json:"net_tax_amount" example:"320.94"`
+}
@@ -0,0 +1,75 @@
+package domain_test
+
+import (
+	"project/domain"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTaxRounding_Round(t *testing.T) {
+	assert.Equal(t, 1.01, domain.TaxRounding{Mode: domain.TaxRoundHalfUp}.Round(1.005))
+	assert.Equal(t, 1.00, domain.TaxRounding{Mode: domain.TaxRoundHalfEven}.Round(1.005))
+	assert.Equal(t, 1.01, domain.TaxRounding{Mode: domain.TaxRoundUp}.Round(1.001))
+	assert.Equal(t, 1.00, domain.TaxRounding{Mode: domain.TaxRoundDown}.Round(1.009))
+}
+
+func TestCalculateTaxes(t *testing.T) {
+	vat := domain.TaxRate{ID: 1, Name: "VAT", Code: "VAT", Rate: 10}
+	service := domain.TaxRate{ID: 2, Name: "Service", Code: "SVC", Rate: 5}
+	inclusiveVat := domain.TaxRate{ID: 3, Name: "VAT incl.", Code: "VATI", Rate: 10, Inclusive: true}
+
+	t.Run("Exclusive rates", func(t *testing.T) {
+		lines := []domain.TaxableLine{
+			{Amount: 20, Rates: []domain.TaxRate{vat, service}},
+			{Amount: 5, Rates: []domain.TaxRate{vat}},
+		}
+
+		taxLines := domain.CalculateTaxes(lines, domain.DefaultTaxRounding)
+
+		assert.Len(t, taxLines, 2)
+		assert.Equal(t, "VAT", taxLines[0].Code)
+		assert.Equal(t, 25.0, taxLines[0].TaxableAmount)
+		assert.Equal(t, 2.5, taxLines[0].Amount)
+		assert.Equal(t, "SVC", taxLines[1].Code)
+		assert.Equal(t, 1.0, taxLines[1].Amount)
+	})
+
+	t.Run("Inclusive rate is taken out of the price", func(t *testing.T) {
+		lines := []domain.TaxableLine{{Amount: 11, Rates: []domain.TaxRate{inclusiveVat}}}
+
+		taxLines := domain.CalculateTaxes(lines, domain.DefaultTaxRounding)
+
+		assert.Len(t, taxLines, 1)
+		assert.True(t, taxLines[0].Inclusive)
+		assert.Equal(t, 10.0, taxLines[0].TaxableAmount)
+		assert.Equal(t, 1.0, taxLines[0].Amount)
+	})
+
+	t.Run("Rounding scope", func(t *testing.T) {
+		// three lines with 0.045 tax each
+		lines := []domain.TaxableLine{
+			{Amount: 0.45, Rates: []domain.TaxRate{vat}},
+			{Amount: 0.45, Rates: []domain.TaxRate{vat}},
+			{Amount: 0.45, Rates: []domain.TaxRate{vat}},
+		}
+
+		perLine := domain.CalculateTaxes(lines, domain.TaxRounding{Scope: domain.TaxRoundPerLine, Mode: domain.TaxRoundHalfUp})
+		perOrder := domain.CalculateTaxes(lines, domain.TaxRounding{Scope: domain.TaxRoundPerOrder, Mode: domain.TaxRoundHalfUp})
+
+		assert.Equal(t, 0.15, perLine[0].Amount)
+		assert.Equal(t, 0.14, perOrder[0].Amount)
+	})
+}
+
+func TestTaxRate_AppliesTo(t *testing.T) {
+	everyType := domain.TaxRate{Code: "VAT"}
+	takeaway := domain.TaxRate{Code: "VAT-TA", OrderTypes: "takeaway, pickup"}
+
+	assert.True(t, everyType.AppliesTo(domain.OrderDelivery))
+	assert.True(t, takeaway.AppliesTo(domain.OrderPickup))
+	assert.False(t, takeaway.AppliesTo(domain.OrderDineIn))
+
+	assert.NoError(t, takeaway.Validate())
+	assert.ErrorIs(t, domain.TaxRate{OrderTypes: "dine_in,drive_through"}.Validate(), domain.ErrInvalidTaxRate)
+}
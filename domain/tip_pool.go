@@ -0,0 +1,188 @@
+package domain
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var (
+	ErrAlreadyClockedIn = errors.New("already clocked in")
+	ErrNotClockedIn     = errors.New("not clocked in")
+)
+
+// TimeClock is one worked shift of a staff member, ClockOut stays empty while the shift is open
+type TimeClock struct {
+	ID        uint       `gorm:"primaryKey" json:"id"`
+	UserID    uint       `gorm:"not null;index" json:"user_id"`
+	User      User       `gorm:"foreignKey:UserID;references:ID" json:"-"`
+	ClockIn   time.Time  `gorm:"not null;index" json:"clock_in"`
+	ClockOut  *time.Time `json:"clock_out"`
+	Note      string     `gorm:"size:255" json:"note" example:"covered the late shift"`
+	CreatedAt time.Time  `gorm:"autoCreateTime" json:"created_at"`
+	UpdatedAt time.Time  `gorm:"autoUpdateTime" json:"updated_at"`
+}
+
+type TipPoolMethod string
+
+const (
+	// TipPoolByHours shares tips in proportion to the hours worked
+	TipPoolByHours TipPoolMethod = "hours"
+	// TipPoolByPoints weights the hours worked by the points of each role
+	TipPoolByPoints TipPoolMethod = "points"
+)
+
+type TipPoolSource string
+
+const (
+	// TipPoolFromClock takes hours from time clock records
+	TipPoolFromClock TipPoolSource = "clock"
+	// TipPoolFromSchedule takes hours from the users' ShiftStart and ShiftEnd
+	TipPoolFromSchedule TipPoolSource = "schedule"
+)
+
+// TipShare is the part of the tip pool going to one staff member
+type TipShare struct {
+	UserID   uint     `json:"user_id" example:"3"`
+	FullName string   `json:"full_name" example:"John Smith"`
+	Role     UserRole `json:"role" example:"staff"`
+	Hours    float64  `json:"hours" example:"38.5"`
+	Points   float64  `json:"points" example:"1"`
+	Amount   float64  `json:"amount" example:"120.40"`
+}
+
+// TipPool is the tip pool report for a period
+type TipPool struct {
+	Start     time.Time     `json:"start"`
+	End       time.Time     `json:"end"`
+	Method    TipPoolMethod `json:"method" example:"hours"`
+	Source    TipPoolSource `json:"source" example:"clock"`
+	TotalTips float64       `json:"total_tips" example:"482.10"`
+	Shares    []TipShare    `json:"shares"`
+}
+
+// ParseTipPoints reads a points scheme such as "staff:1,admin:1.5", roles that are not listed get one point
+func ParseTipPoints(spec string) (map[UserRole]float64, error) {
+	points := make(map[UserRole]float64)
+	for _, entry := range strings.Split(spec, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		role, value, ok := strings.Cut(entry, ":")
+		if !ok {
+			return nil, fmt.Errorf("invalid tip points entry %q, use role:points", entry)
+		}
+		weight, err := strconv.ParseFloat(strings.TrimSpace(value), 64)
+		if err != nil || weight < 0 {
+			return nil, fmt.Errorf("invalid tip points for %q", role)
+		}
+		points[UserRole(strings.ToLower(strings.TrimSpace(role)))] = weight
+	}
+	return points, nil
+}
+
+// ParseShiftTime converts a shift time such as "9am", "3:30pm" or "15:00" into the time since midnight
+func ParseShiftTime(value string) (time.Duration, error) {
+	value = strings.ToLower(strings.ReplaceAll(value, " ", ""))
+	for _, layout := range []string{"3pm", "3:04pm", "15:04", "15"} {
+		if at, err := time.Parse(layout, value); err == nil {
+			return time.Duration(at.Hour())*time.Hour + time.Duration(at.Minute())*time.Minute, nil
+		}
+	}
+	return 0, fmt.Errorf("invalid shift time %q", value)
+}
+
+// ScheduledHours counts the hours of a daily shift that fall within [from, to).
+// A shift ending before it starts runs overnight into the next day.
+func ScheduledHours(shiftStart, shiftEnd string, from, to time.Time) (float64, error) {
+	start, err := ParseShiftTime(shiftStart)
+	if err != nil {
+		return 0, err
+	}
+	end, err := ParseShiftTime(shiftEnd)
+	if err != nil {
+		return 0, err
+	}
+	if end <= start {
+		end += 24 * time.Hour
+	}
+
+	var hours float64
+	// start the day before so an overnight shift reaching into the period is counted
+	day := time.Date(from.Year(), from.Month(), from.Day()-1, 0, 0, 0, 0, from.Location())
+	for ; day.Before(to); day = day.AddDate(0, 0, 1) {
+		hours += overlapHours(day.Add(start), day.Add(end), from, to)
+	}
+	return hours, nil
+}
+
+// ClockedHours counts the hours of a time clock record within [from, to), an open record counts until now
+func ClockedHours(record TimeClock, from, to, now time.Time) float64 {
+	out := now
+	if record.ClockOut != nil {
+		out = *record.ClockOut
+	}
+	return overlapHours(record.ClockIn, out, from, to)
+}
+
+func overlapHours(start, end, from, to time.Time) float64 {
+	if start.Before(from) {
+		start = from
+	}
+	if end.After(to) {
+		end = to
+	}
+	if !end.After(start) {
+		return 0
+	}
+	return end.Sub(start).Hours()
+}
+
+// DistributeTips splits the tips over the shares by their hours, weighted by points with TipPoolByPoints.
+// Amounts are rounded to cents and the cents left over go to the largest shares so the total is kept.
+func DistributeTips(total float64, shares []TipShare, method TipPoolMethod) []TipShare {
+	weights := make([]float64, len(shares))
+	var sum float64
+	for i, share := range shares {
+		weights[i] = share.Hours
+		if method == TipPoolByPoints {
+			weights[i] *= share.Points
+		}
+		sum += weights[i]
+	}
+
+	totalCents := int64(math.Round(total * 100))
+	if sum == 0 || totalCents <= 0 {
+		for i := range shares {
+			shares[i].Amount = 0
+		}
+		return shares
+	}
+
+	var allocated int64
+	cents := make([]int64, len(shares))
+	for i := range shares {
+		cents[i] = int64(math.Floor(float64(totalCents) * weights[i] / sum))
+		allocated += cents[i]
+	}
+
+	order := make([]int, len(shares))
+	for i := range order {
+		order[i] = i
+	}
+	sort.SliceStable(order, func(a, b int) bool { return weights[order[a]] > weights[order[b]] })
+	for i := 0; allocated < totalCents; i = (i + 1) % len(order) {
+		cents[order[i]]++
+		allocated++
+	}
+
+	for i := range shares {
+		shares[i].Amount = float64(cents[i]) / 100
+	}
+	return shares
+}
@@ -0,0 +1,92 @@
+package domain_test
+
+import (
+	"project/domain"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseShiftTime(t *testing.T) {
+	tests := map[string]time.Duration{
+		"9am":    9 * time.Hour,
+		"6pm":    18 * time.Hour,
+		"12am":   0,
+		"3:30pm": 15*time.Hour + 30*time.Minute,
+		"15:00":  15 * time.Hour,
+		"11 PM":  23 * time.Hour,
+	}
+	for value, want := range tests {
+		got, err := domain.ParseShiftTime(value)
+		assert.NoError(t, err, value)
+		assert.Equal(t, want, got, value)
+	}
+
+	_, err := domain.ParseShiftTime("noon")
+	assert.Error(t, err)
+}
+
+func TestScheduledHours(t *testing.T) {
+	from := time.Date(2024, 5, 6, 0, 0, 0, 0, time.UTC)
+	to := from.AddDate(0, 0, 2)
+
+	hours, err := domain.ScheduledHours("9am", "6pm", from, to)
+	assert.NoError(t, err)
+	assert.Equal(t, 18.0, hours)
+
+	// 2pm-11pm on both days plus nothing carried in from the day before
+	hours, err = domain.ScheduledHours("2pm", "11pm", from, to)
+	assert.NoError(t, err)
+	assert.Equal(t, 18.0, hours)
+
+	// the overnight shift of the day before the period ends at 2am inside it, the last one is cut at midnight
+	hours, err = domain.ScheduledHours("10pm", "2am", from, to)
+	assert.NoError(t, err)
+	assert.Equal(t, 2.0+4.0+2.0, hours)
+}
+
+func TestClockedHours(t *testing.T) {
+	from := time.Date(2024, 5, 6, 0, 0, 0, 0, time.UTC)
+	to := from.AddDate(0, 0, 1)
+	out := from.Add(3 * time.Hour)
+
+	assert.Equal(t, 3.0, domain.ClockedHours(domain.TimeClock{ClockIn: from.Add(-2 * time.Hour), ClockOut: &out}, from, to, to))
+	assert.Equal(t, 1.5, domain.ClockedHours(domain.TimeClock{ClockIn: to.Add(-90 * time.Minute)}, from, to, to.Add(time.Hour)), "open shift counts until the end of the period")
+}
+
+func TestParseTipPoints(t *testing.T) {
+	points, err := domain.ParseTipPoints("staff:1, admin:1.5,Super Admin:2")
+	assert.NoError(t, err)
+	assert.Equal(t, map[domain.UserRole]float64{domain.Staff: 1, domain.Admin: 1.5, domain.SuperAdmin: 2}, points)
+
+	_, err = domain.ParseTipPoints("staff")
+	assert.Error(t, err)
+}
+
+func TestDistributeTips(t *testing.T) {
+	shares := []domain.TipShare{
+		{UserID: 1, Hours: 8, Points: 1},
+		{UserID: 2, Hours: 8, Points: 1},
+		{UserID: 3, Hours: 4, Points: 2},
+	}
+
+	byHours := domain.DistributeTips(100, append([]domain.TipShare(nil), shares...), domain.TipPoolByHours)
+	assert.Equal(t, 40.0, byHours[0].Amount)
+	assert.Equal(t, 40.0, byHours[1].Amount)
+	assert.Equal(t, 20.0, byHours[2].Amount)
+
+	byPoints := domain.DistributeTips(100, append([]domain.TipShare(nil), shares...), domain.TipPoolByPoints)
+	var total float64
+	for _, share := range byPoints {
+		total += share.Amount
+	}
+	// three equal weights: 33.33 each and the cent left over goes to the first largest share
+	assert.Equal(t, 33.34, byPoints[0].Amount)
+	assert.Equal(t, 33.33, byPoints[1].Amount)
+	assert.Equal(t, 33.33, byPoints[2].Amount)
+	assert.InDelta(t, 100.0, total, 0.001)
+
+	none := domain.DistributeTips(50, []domain.TipShare{{UserID: 1}}, domain.TipPoolByHours)
+	assert.Equal(t, 0.0, none[0].Amount)
+}
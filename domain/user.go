@@ -19,6 +19,9 @@ type User struct {
 	FullName          string         `gorm:"size:100;not null" json:"full_name" example:"John Smith" form:"full_name" binding:"required"`
 	Email             string         `gorm:"index:,unique,composite:emaildeletedat" json:"email" binding:"required" form:"email"`
 	Password          string         `gorm:"not null;default:''" json:"-" example:"password"`
+	Pin               string         `gorm:"size:255" json:"-"`
+	PinFailures       int            `gorm:"not null;default:0" json:"-"`
+	PinLockedUntil    *time.Time     `json:"-"`
 	Role              UserRole       `gorm:"type:varchar(50);not null" json:"role" example:"admin" form:"role"`
 	ProfilePhoto      string         `gorm:"size:255" json:"profile_photo" example:"/profile_photo/john_smith.jpg"`
 	PhoneNumber       string         `gorm:"size:20" json:"phone_number" example:"+1 (23) 123 4567" form:"phone_number"`
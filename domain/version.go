@@ -0,0 +1,6 @@
+package domain
+
+import "errors"
+
+// ErrVersionConflict is returned when an update is based on a stale version of a record
+var ErrVersionConflict = errors.New("record has been modified by another request")
@@ -0,0 +1,165 @@
+package domain
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+)
+
+var (
+	ErrWaitlistEntryNotFound = errors.New("waitlist entry not found")
+	ErrInvalidWaitlistStatus = errors.New("invalid waitlist status change")
+)
+
+type WaitlistStatus string
+
+const (
+	WaitlistWaiting   WaitlistStatus = "waiting"
+	WaitlistNotified  WaitlistStatus = "notified"
+	WaitlistSeated    WaitlistStatus = "seated"
+	WaitlistNoShow    WaitlistStatus = "no_show"
+	WaitlistCancelled WaitlistStatus = "cancelled"
+)
+
+// WaitlistEntry is a walk-in party waiting for a table. Position, EstimatedWait and NoShows are
+// filled when the waitlist is read.
+type WaitlistEntry struct {
+	ID        uint   `gorm:"primaryKey" json:"id" swaggerignore:"true"`
+	PartyName string `gorm:"size:100;not null" json:"party_name" binding:"required,max=100" example:"Smith"`
+	PartySize int    `gorm:"not null" json:"party_size" binding:"required,gte=1" example:"4"`
+	Phone     string `gorm:"size:20;not null;index" json:"phone" binding:"required,max=20" example:"+1 (23) 123 4567"`
+	// Section is the seating the party prefers, any table is fine when empty
+	Section string `gorm:"size:50" json:"section" binding:"max=50" example:"Terrace"`
+	Notes   string `gorm:"size:255" json:"notes" binding:"max=255" example:"high chair"`
+	// QuotedWait is the wait in minutes the host told the party, the estimate when not given
+	QuotedWait int            `gorm:"not null;default:0" json:"quoted_wait" binding:"gte=0" example:"20"`
+	Status     WaitlistStatus `gorm:"size:20;not null;default:'waiting';index;check:status IN ('waiting', 'notified', 'seated', 'no_show', 'cancelled')" json:"status" swaggerignore:"true"`
+	NotifiedAt *time.Time     `json:"notified_at" swaggerignore:"true"`
+	// ClosedAt is when the party was seated, did not show up or left the list
+	ClosedAt      *time.Time `json:"closed_at" swaggerignore:"true"`
+	TableID       *uint      `json:"table_id" swaggerignore:"true"`
+	Table         *Table     `gorm:"foreignKey:TableID;references:ID" json:"table,omitempty" swaggerignore:"true"`
+	OrderID       *uint      `json:"order_id" swaggerignore:"true"`
+	Order         *Order     `gorm:"foreignKey:OrderID;references:ID" json:"-" swaggerignore:"true"`
+	Position      int        `gorm:"-" json:"position" swaggerignore:"true"`
+	EstimatedWait int        `gorm:"-" json:"estimated_wait" swaggerignore:"true"`
+	// NoShows counts the earlier entries with the same phone that did not show up
+	NoShows   int       `gorm:"-" json:"no_shows" swaggerignore:"true"`
+	CreatedAt time.Time `gorm:"autoCreateTime" json:"created_at" swaggerignore:"true"`
+	UpdatedAt time.Time `gorm:"autoUpdateTime" json:"updated_at" swaggerignore:"true"`
+}
+
+func (s WaitlistStatus) Valid() bool {
+	switch s {
+	case WaitlistWaiting, WaitlistNotified, WaitlistSeated, WaitlistNoShow, WaitlistCancelled:
+		return true
+	}
+	return false
+}
+
+// Active tells whether the party is still waiting for a table
+func (s WaitlistStatus) Active() bool {
+	return s == WaitlistWaiting || s == WaitlistNotified
+}
+
+func (e *WaitlistEntry) checkActive() error {
+	if !e.Status.Active() {
+		return fmt.Errorf("%w: the party is %s", ErrInvalidWaitlistStatus, e.Status)
+	}
+	return nil
+}
+
+// Notify records that the party was told its table is ready, a party can be notified again
+func (e *WaitlistEntry) Notify(at time.Time) error {
+	if err := e.checkActive(); err != nil {
+		return err
+	}
+	e.Status = WaitlistNotified
+	e.NotifiedAt = &at
+	return nil
+}
+
+// Seat takes the party off the list, seated at the table with the order opened for it
+func (e *WaitlistEntry) Seat(tableID, orderID uint, at time.Time) error {
+	if err := e.checkActive(); err != nil {
+		return err
+	}
+	e.Status = WaitlistSeated
+	e.TableID = &tableID
+	e.OrderID = &orderID
+	e.ClosedAt = &at
+	return nil
+}
+
+// Close takes a party that did not show up, or left, off the list
+func (e *WaitlistEntry) Close(status WaitlistStatus, at time.Time) error {
+	if status != WaitlistNoShow && status != WaitlistCancelled {
+		return fmt.Errorf("%w: a party is closed as no_show or cancelled", ErrInvalidWaitlistStatus)
+	}
+	if err := e.checkActive(); err != nil {
+		return err
+	}
+	e.Status = status
+	e.ClosedAt = &at
+	return nil
+}
+
+// NewOrder is the dine-in order the party is seated with
+func (e *WaitlistEntry) NewOrder(tableID uint) *Order {
+	return &Order{
+		Name:          e.PartyName,
+		Type:          OrderDineIn,
+		TableID:       &tableID,
+		PartySize:     e.PartySize,
+		CustomerPhone: e.Phone,
+	}
+}
+
+// EstimateWait is how long a party of size waits behind the parties ahead of it. Every table that
+// seats the party is free now when available, after the turn time when it needs cleaning and after
+// the typical stay and the turn time when occupied. Each party ahead that fits these tables takes
+// the one that frees up first, which is free again after another stay and turn. When no single
+// table seats the party every table is counted, as it is seated at tables pushed together. It is
+// zero when none of the tables can be given to walk-ins.
+func EstimateWait(size int, ahead []int, tables []Table, stay, turn time.Duration, now time.Time) time.Duration {
+	var fitting []Table
+	for _, table := range tables {
+		if table.Capacity >= size {
+			fitting = append(fitting, table)
+		}
+	}
+	if len(fitting) == 0 {
+		fitting = tables
+	}
+
+	// reserved and out of service tables are not given to walk-ins
+	var free []time.Duration
+	largest := size
+	for _, table := range fitting {
+		switch table.Status {
+		case TableAvailable:
+			free = append(free, 0)
+		case TableNeedsCleaning:
+			free = append(free, turn)
+		case TableOccupied:
+			free = append(free, max(stay-table.SeatedFor(now), 0)+turn)
+		default:
+			continue
+		}
+		largest = max(largest, table.Capacity)
+	}
+	if len(free) == 0 {
+		return 0
+	}
+
+	sort.Slice(free, func(i, j int) bool { return free[i] < free[j] })
+	for _, party := range ahead {
+		if party > largest {
+			continue
+		}
+		free[0] += stay + turn
+		sort.Slice(free, func(i, j int) bool { return free[i] < free[j] })
+	}
+	return free[0]
+}
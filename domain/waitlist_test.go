@@ -0,0 +1,77 @@
+package domain_test
+
+import (
+	"project/domain"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWaitlistEntry_Status(t *testing.T) {
+	now := time.Date(2024, 5, 10, 19, 30, 0, 0, time.UTC)
+
+	entry := domain.WaitlistEntry{PartyName: "Smith", PartySize: 4, Phone: "+1 555", Status: domain.WaitlistWaiting}
+	assert.NoError(t, entry.Notify(now))
+	assert.Equal(t, domain.WaitlistNotified, entry.Status)
+	assert.Equal(t, &now, entry.NotifiedAt)
+
+	assert.NoError(t, entry.Seat(3, 12, now))
+	assert.Equal(t, domain.WaitlistSeated, entry.Status)
+	assert.Equal(t, uint(3), *entry.TableID)
+	assert.Equal(t, uint(12), *entry.OrderID)
+
+	assert.ErrorIs(t, entry.Notify(now), domain.ErrInvalidWaitlistStatus)
+	assert.ErrorIs(t, entry.Close(domain.WaitlistNoShow, now), domain.ErrInvalidWaitlistStatus)
+
+	waiting := domain.WaitlistEntry{Status: domain.WaitlistWaiting}
+	assert.ErrorIs(t, waiting.Close(domain.WaitlistSeated, now), domain.ErrInvalidWaitlistStatus)
+	assert.NoError(t, waiting.Close(domain.WaitlistNoShow, now))
+	assert.Equal(t, &now, waiting.ClosedAt)
+}
+
+func TestWaitlistEntry_NewOrder(t *testing.T) {
+	entry := domain.WaitlistEntry{PartyName: "Smith", PartySize: 4, Phone: "+1 555"}
+
+	order := entry.NewOrder(3)
+	assert.Equal(t, domain.OrderDineIn, order.Type)
+	assert.Equal(t, uint(3), *order.TableID)
+	assert.Equal(t, 4, order.PartySize)
+	assert.NoError(t, order.Validate())
+}
+
+func TestEstimateWait(t *testing.T) {
+	now := time.Date(2024, 5, 10, 19, 30, 0, 0, time.UTC)
+	seated := func(minutes int) *time.Time {
+		at := now.Add(-time.Duration(minutes) * time.Minute)
+		return &at
+	}
+	stay, turn := 60*time.Minute, 10*time.Minute
+
+	tables := []domain.Table{
+		{ID: 1, Capacity: 2, Status: domain.TableOccupied, SeatedAt: seated(50)},
+		{ID: 2, Capacity: 4, Status: domain.TableOccupied, SeatedAt: seated(20)},
+		{ID: 3, Capacity: 4, Status: domain.TableNeedsCleaning},
+		{ID: 4, Capacity: 6, Status: domain.TableOutOfService},
+		{ID: 5, Capacity: 6, Status: domain.TableReserved},
+	}
+
+	tests := []struct {
+		name  string
+		size  int
+		ahead []int
+		wait  time.Duration
+	}{
+		{"Table being cleaned", 4, nil, 10 * time.Minute},
+		{"Behind a party", 4, []int{3}, 50 * time.Minute},
+		{"Larger party ahead does not take the table", 2, []int{6}, 10 * time.Minute},
+		{"Small table almost free", 2, []int{2}, 20 * time.Minute},
+		{"Pushed together", 10, nil, 10 * time.Minute},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.wait, domain.EstimateWait(tt.size, tt.ahead, tables, stay, turn, now))
+		})
+	}
+}
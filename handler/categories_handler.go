@@ -54,6 +54,7 @@ func (ctrl *CategoryController) All(c *gin.Context) {
 type CategoryRequest struct {
 	Name        string `json:"name" binding:"required,min=3" form:"name"`
 	Description string `json:"description" binding:"required,min=20" form:"description"`
+	Station     string `json:"station" binding:"max=50" form:"station"`
 }
 
 // @Summary Create Category
@@ -63,6 +64,7 @@ type CategoryRequest struct {
 // @Produce json
 // @Param name formData string true "Category name"
 // @Param description formData string false "Category description"
+// @Param station formData string false "Kitchen station preparing the category, default is kitchen"
 // @Param icon formData file true "Category icon"
 // @Success 201 {object} Response "create success"
 // @Failure 400 {object} Response "Invalid input"
@@ -104,6 +106,7 @@ func (ctrl *CategoryController) Create(c *gin.Context) {
 	category := &domain.Category{
 		Name:        input.Name,
 		Description: input.Description,
+		Station:     input.Station,
 	}
 
 	if file != nil {
@@ -132,6 +135,7 @@ func (ctrl *CategoryController) Create(c *gin.Context) {
 // @Param id path string true "Category ID"
 // @Param name formData string false "Category name"
 // @Param description formData string false "Category description"
+// @Param station formData string false "Kitchen station preparing the category"
 // @Param icon formData file false "New category icon"
 // @Success 200 {object} Response{data=domain.Category} "update success"
 // @Failure 400 {object} Response "invalid input"
@@ -192,6 +196,9 @@ func (ctrl *CategoryController) Update(c *gin.Context) {
 
 	category.Name = input.Name
 	category.Description = input.Description
+	if input.Station != "" {
+		category.Station = input.Station
+	}
 
 	if err := ctrl.service.Update(&category); err != nil {
 		ctrl.logger.Error("Failed to update category", zap.Error(err))
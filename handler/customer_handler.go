@@ -0,0 +1,221 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"project/domain"
+	"project/helper"
+	"project/service"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+type CustomerController struct {
+	service service.CustomerService
+	logger  *zap.Logger
+}
+
+func NewCustomerController(service service.CustomerService, logger *zap.Logger) *CustomerController {
+	return &CustomerController{service: service, logger: logger}
+}
+
+// @Summary Get Customers
+// @Description List the customers by name, or search them by part of the name, phone number or email address
+// @Tags Customers
+// @Produce json
+// @Param page query int false "Page number, default is 1"
+// @Param limit query int false "Number of items per page, default is 10"
+// @Param search query string false "Part of the name, phone number or email address"
+// @Success 200 {object} domain.DataPage{data=[]domain.Customer} "fetch success"
+// @Failure 500 {object} Response "internal server error"
+// @Security Bearer
+// @Router /customers [get]
+func (ctrl *CustomerController) All(c *gin.Context) {
+	page, limit := helper.Page(c)
+
+	customers, totalItems, err := ctrl.service.All(int(page), int(limit), c.Query("search"))
+	if err != nil {
+		customerError(c, err)
+		return
+	}
+
+	totalPages := (totalItems + int64(limit) - 1) / int64(limit)
+	GoodResponseWithPage(c, "fetch success", http.StatusOK, int(totalItems), int(totalPages), int(page), int(limit), customers)
+}
+
+// @Summary Get Customer
+// @Description A customer profile with lifetime spend, visit count, last visit, reservations, no-shows, favourite items and the latest visits
+// @Tags Customers
+// @Produce json
+// @Param id path int true "Customer ID"
+// @Success 200 {object} Response{data=domain.Customer} "fetch success"
+// @Failure 404 {object} Response "Customer not found"
+// @Security Bearer
+// @Router /customers/{id} [get]
+func (ctrl *CustomerController) Get(c *gin.Context) {
+	id, err := helper.Uint(c.Param("id"))
+	if err != nil {
+		BadResponse(c, "invalid customer ID", http.StatusBadRequest)
+		return
+	}
+
+	customer, err := ctrl.service.FindByID(id)
+	if err != nil {
+		customerError(c, err)
+		return
+	}
+
+	c.Header("ETag", helper.ETag(customer.Version))
+	GoodResponseWithData(c, "fetch success", http.StatusOK, customer)
+}
+
+// @Summary Get Customer Visits
+// @Description The completed orders of a customer newest first, with the spend and the reservation of each visit
+// @Tags Customers
+// @Produce json
+// @Param id path int true "Customer ID"
+// @Param page query int false "Page number, default is 1"
+// @Param limit query int false "Number of items per page, default is 10"
+// @Success 200 {object} domain.DataPage{data=[]domain.VisitEntry} "fetch success"
+// @Failure 404 {object} Response "Customer not found"
+// @Security Bearer
+// @Router /customers/{id}/visits [get]
+func (ctrl *CustomerController) Visits(c *gin.Context) {
+	id, err := helper.Uint(c.Param("id"))
+	if err != nil {
+		BadResponse(c, "invalid customer ID", http.StatusBadRequest)
+		return
+	}
+	page, limit := helper.Page(c)
+
+	visits, totalItems, err := ctrl.service.Visits(id, int(page), int(limit))
+	if err != nil {
+		customerError(c, err)
+		return
+	}
+
+	totalPages := (totalItems + int64(limit) - 1) / int64(limit)
+	GoodResponseWithPage(c, "fetch success", http.StatusOK, int(totalItems), int(totalPages), int(page), int(limit), visits)
+}
+
+// @Summary Create Customer
+// @Description Add a customer with a phone number or email address that no other customer has. Reservations and orders are linked to customers by these automatically.
+// @Tags Customers
+// @Accept json
+// @Produce json
+// @Param input body domain.Customer true "Customer"
+// @Success 201 {object} Response{data=domain.Customer} "create success"
+// @Failure 400 {object} Response "Invalid input"
+// @Failure 409 {object} Response "Phone number or email address belongs to another customer"
+// @Security Bearer
+// @Router /customers [post]
+func (ctrl *CustomerController) Create(c *gin.Context) {
+	var customer domain.Customer
+	if err := c.ShouldBindJSON(&customer); err != nil {
+		BadResponse(c, helper.FormatValidationError(err), http.StatusBadRequest)
+		return
+	}
+	customer.CustomerStats = domain.CustomerStats{}
+
+	if err := ctrl.service.Create(&customer); err != nil {
+		customerError(c, err)
+		return
+	}
+
+	GoodResponseWithData(c, "create success", http.StatusCreated, customer)
+}
+
+// @Summary Update Customer
+// @Description Change the name, contact details, notes or allergies of a customer. Send the version read, in an If-Match header or the version field, to avoid overwriting another change.
+// @Tags Customers
+// @Accept json
+// @Produce json
+// @Param id path int true "Customer ID"
+// @Param If-Match header string false "ETag of the customer version read"
+// @Param input body domain.Customer true "Customer"
+// @Success 200 {object} Response{data=domain.Customer} "update success"
+// @Failure 400 {object} Response "Invalid input"
+// @Failure 404 {object} Response "Customer not found"
+// @Failure 409 {object} Response "Stale version, or phone number or email address belongs to another customer"
+// @Security Bearer
+// @Router /customers/{id} [put]
+func (ctrl *CustomerController) Update(c *gin.Context) {
+	id, err := helper.Uint(c.Param("id"))
+	if err != nil {
+		BadResponse(c, "invalid customer ID", http.StatusBadRequest)
+		return
+	}
+
+	var details domain.Customer
+	if err := c.ShouldBindJSON(&details); err != nil {
+		BadResponse(c, helper.FormatValidationError(err), http.StatusBadRequest)
+		return
+	}
+
+	version, ok, err := ifMatchVersion(c)
+	if err != nil {
+		BadResponse(c, "invalid If-Match header", http.StatusBadRequest)
+		return
+	}
+	if !ok {
+		version = details.Version
+	}
+
+	customer, err := ctrl.service.Update(id, version, details)
+	if err != nil {
+		customerError(c, err)
+		return
+	}
+
+	c.Header("ETag", helper.ETag(customer.Version))
+	GoodResponseWithData(c, "update success", http.StatusOK, customer)
+}
+
+// @Summary Merge Customers
+// @Description Fold duplicate customers into this one. Their reservations, orders and loyalty points move over, missing contact details and all notes and allergies are kept, and the duplicates are removed.
+// @Tags Customers
+// @Accept json
+// @Produce json
+// @Param id path int true "Customer ID to keep"
+// @Param input body domain.MergeCustomersRequest true "Duplicates to merge"
+// @Success 200 {object} Response{data=domain.Customer} "merge success"
+// @Failure 400 {object} Response "Invalid input"
+// @Failure 404 {object} Response "Customer not found"
+// @Security Bearer
+// @Router /customers/{id}/merge [post]
+func (ctrl *CustomerController) Merge(c *gin.Context) {
+	id, err := helper.Uint(c.Param("id"))
+	if err != nil {
+		BadResponse(c, "invalid customer ID", http.StatusBadRequest)
+		return
+	}
+
+	var request domain.MergeCustomersRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		BadResponse(c, helper.FormatValidationError(err), http.StatusBadRequest)
+		return
+	}
+
+	customer, err := ctrl.service.Merge(id, request.DuplicateIDs)
+	if err != nil {
+		customerError(c, err)
+		return
+	}
+
+	c.Header("ETag", helper.ETag(customer.Version))
+	GoodResponseWithData(c, "merge success", http.StatusOK, customer)
+}
+
+func customerError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, domain.ErrCustomerNotFound):
+		BadResponse(c, err.Error(), http.StatusNotFound)
+	case errors.Is(err, domain.ErrInvalidCustomer):
+		BadResponse(c, err.Error(), http.StatusBadRequest)
+	case errors.Is(err, domain.ErrDuplicateCustomer), errors.Is(err, domain.ErrVersionConflict):
+		BadResponse(c, err.Error(), http.StatusConflict)
+	default:
+		BadResponse(c, err.Error(), http.StatusInternalServerError)
+	}
+}
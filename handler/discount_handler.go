@@ -0,0 +1,516 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"project/domain"
+	"project/helper"
+	"project/service"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+type DiscountController struct {
+	service service.DiscountService
+	logger  *zap.Logger
+}
+
+func NewDiscountController(service service.DiscountService, logger *zap.Logger) *DiscountController {
+	return &DiscountController{service: service, logger: logger}
+}
+
+// @Summary Get All Promo Codes
+// @Description Retrieve promo codes with pagination
+// @Tags Discounts
+// @Produce json
+// @Param page query int false "Page number, default is 1"
+// @Param limit query int false "Number of items per page, default is 10"
+// @Success 200 {object} domain.DataPage{data=[]domain.PromoCode} "fetch success"
+// @Failure 500 {object} Response "Internal server error"
+// @Security Bearer
+// @Router /promo-codes [get]
+func (ctrl *DiscountController) AllPromoCodes(c *gin.Context) {
+	page, limit := helper.Page(c)
+
+	promoCodes, totalItems, err := ctrl.service.AllPromoCodes(int(page), int(limit))
+	if err != nil {
+		BadResponse(c, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	totalPages := (totalItems + int64(limit) - 1) / int64(limit)
+
+	GoodResponseWithPage(c, "fetch success", http.StatusOK, int(totalItems), int(totalPages), int(page), int(limit), promoCodes)
+}
+
+// @Summary Get Promo Code
+// @Tags Discounts
+// @Produce json
+// @Param id path int true "Promo code ID"
+// @Success 200 {object} Response{data=domain.PromoCode} "fetch success"
+// @Failure 404 {object} Response "Promo code not found"
+// @Security Bearer
+// @Router /promo-codes/{id} [get]
+func (ctrl *DiscountController) GetPromoCode(c *gin.Context) {
+	id, err := helper.Uint(c.Param("id"))
+	if err != nil {
+		BadResponse(c, "invalid promo code ID", http.StatusBadRequest)
+		return
+	}
+
+	promoCode, err := ctrl.service.FindPromoCode(id)
+	if err != nil {
+		discountError(c, err)
+		return
+	}
+
+	GoodResponseWithData(c, "fetch success", http.StatusOK, promoCode)
+}
+
+// @Summary Create Promo Code
+// @Description Create a promo code with an optional validity window, usage limit (0 is unlimited) and minimum spend
+// @Tags Discounts
+// @Accept json
+// @Produce json
+// @Param input body domain.PromoCode true "Promo code"
+// @Success 201 {object} Response{data=domain.PromoCode} "create success"
+// @Failure 400 {object} Response "Invalid input"
+// @Failure 500 {object} Response "Internal server error"
+// @Security Bearer
+// @Router /promo-codes [post]
+func (ctrl *DiscountController) CreatePromoCode(c *gin.Context) {
+	var promoCode domain.PromoCode
+	if err := c.ShouldBindJSON(&promoCode); err != nil {
+		BadResponse(c, helper.FormatValidationError(err), http.StatusBadRequest)
+		return
+	}
+
+	if err := ctrl.service.CreatePromoCode(&promoCode); err != nil {
+		discountError(c, err)
+		return
+	}
+
+	GoodResponseWithData(c, "create success", http.StatusCreated, promoCode)
+}
+
+// @Summary Update Promo Code
+// @Tags Discounts
+// @Accept json
+// @Produce json
+// @Param id path int true "Promo code ID"
+// @Param input body domain.PromoCode true "Promo code"
+// @Success 200 {object} Response{data=domain.PromoCode} "update success"
+// @Failure 400 {object} Response "Invalid input"
+// @Failure 404 {object} Response "Promo code not found"
+// @Security Bearer
+// @Router /promo-codes/{id} [put]
+func (ctrl *DiscountController) UpdatePromoCode(c *gin.Context) {
+	id, err := helper.Uint(c.Param("id"))
+	if err != nil {
+		BadResponse(c, "invalid promo code ID", http.StatusBadRequest)
+		return
+	}
+
+	var promoCode domain.PromoCode
+	if err := c.ShouldBindJSON(&promoCode); err != nil {
+		BadResponse(c, helper.FormatValidationError(err), http.StatusBadRequest)
+		return
+	}
+	promoCode.ID = id
+
+	if err := ctrl.service.UpdatePromoCode(&promoCode); err != nil {
+		discountError(c, err)
+		return
+	}
+
+	GoodResponseWithData(c, "update success", http.StatusOK, promoCode)
+}
+
+// @Summary Delete Promo Code
+// @Tags Discounts
+// @Produce json
+// @Param id path int true "Promo code ID"
+// @Success 200 {object} Response "delete success"
+// @Failure 404 {object} Response "Promo code not found"
+// @Security Bearer
+// @Router /promo-codes/{id} [delete]
+func (ctrl *DiscountController) DeletePromoCode(c *gin.Context) {
+	id, err := helper.Uint(c.Param("id"))
+	if err != nil {
+		BadResponse(c, "invalid promo code ID", http.StatusBadRequest)
+		return
+	}
+
+	if err := ctrl.service.DeletePromoCode(id); err != nil {
+		discountError(c, err)
+		return
+	}
+
+	GoodResponseWithData(c, "delete success", http.StatusOK, nil)
+}
+
+// @Summary Get All Pricing Rules
+// @Description List the automatic pricing rules such as happy hour and buy X get Y
+// @Tags Discounts
+// @Produce json
+// @Success 200 {object} Response{data=[]domain.PricingRule} "fetch success"
+// @Security Bearer
+// @Router /pricing-rules [get]
+func (ctrl *DiscountController) AllPricingRules(c *gin.Context) {
+	rules, err := ctrl.service.AllPricingRules()
+	if err != nil {
+		BadResponse(c, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	GoodResponseWithData(c, "fetch success", http.StatusOK, rules)
+}
+
+// @Summary Get Pricing Rule
+// @Tags Discounts
+// @Produce json
+// @Param id path int true "Pricing rule ID"
+// @Success 200 {object} Response{data=domain.PricingRule} "fetch success"
+// @Failure 404 {object} Response "Pricing rule not found"
+// @Security Bearer
+// @Router /pricing-rules/{id} [get]
+func (ctrl *DiscountController) GetPricingRule(c *gin.Context) {
+	id, err := helper.Uint(c.Param("id"))
+	if err != nil {
+		BadResponse(c, "invalid pricing rule ID", http.StatusBadRequest)
+		return
+	}
+
+	rule, err := ctrl.service.FindPricingRule(id)
+	if err != nil {
+		discountError(c, err)
+		return
+	}
+
+	GoodResponseWithData(c, "fetch success", http.StatusOK, rule)
+}
+
+// @Summary Create Pricing Rule
+// @Description Create a rule evaluated on every new or updated order. Times use HH:MM, weekdays are 0 (Sunday) to 6.
+// @Tags Discounts
+// @Accept json
+// @Produce json
+// @Param input body domain.PricingRule true "Pricing rule"
+// @Success 201 {object} Response{data=domain.PricingRule} "create success"
+// @Failure 400 {object} Response "Invalid input"
+// @Security Bearer
+// @Router /pricing-rules [post]
+func (ctrl *DiscountController) CreatePricingRule(c *gin.Context) {
+	var rule domain.PricingRule
+	if err := c.ShouldBindJSON(&rule); err != nil {
+		BadResponse(c, helper.FormatValidationError(err), http.StatusBadRequest)
+		return
+	}
+
+	if err := ctrl.service.CreatePricingRule(&rule); err != nil {
+		discountError(c, err)
+		return
+	}
+
+	GoodResponseWithData(c, "create success", http.StatusCreated, rule)
+}
+
+// @Summary Update Pricing Rule
+// @Tags Discounts
+// @Accept json
+// @Produce json
+// @Param id path int true "Pricing rule ID"
+// @Param input body domain.PricingRule true "Pricing rule"
+// @Success 200 {object} Response{data=domain.PricingRule} "update success"
+// @Failure 400 {object} Response "Invalid input"
+// @Failure 404 {object} Response "Pricing rule not found"
+// @Security Bearer
+// @Router /pricing-rules/{id} [put]
+func (ctrl *DiscountController) UpdatePricingRule(c *gin.Context) {
+	id, err := helper.Uint(c.Param("id"))
+	if err != nil {
+		BadResponse(c, "invalid pricing rule ID", http.StatusBadRequest)
+		return
+	}
+
+	var rule domain.PricingRule
+	if err := c.ShouldBindJSON(&rule); err != nil {
+		BadResponse(c, helper.FormatValidationError(err), http.StatusBadRequest)
+		return
+	}
+	rule.ID = id
+
+	if err := ctrl.service.UpdatePricingRule(&rule); err != nil {
+		discountError(c, err)
+		return
+	}
+
+	GoodResponseWithData(c, "update success", http.StatusOK, rule)
+}
+
+// @Summary Delete Pricing Rule
+// @Tags Discounts
+// @Produce json
+// @Param id path int true "Pricing rule ID"
+// @Success 200 {object} Response "delete success"
+// @Failure 404 {object} Response "Pricing rule not found"
+// @Security Bearer
+// @Router /pricing-rules/{id} [delete]
+func (ctrl *DiscountController) DeletePricingRule(c *gin.Context) {
+	id, err := helper.Uint(c.Param("id"))
+	if err != nil {
+		BadResponse(c, "invalid pricing rule ID", http.StatusBadRequest)
+		return
+	}
+
+	if err := ctrl.service.DeletePricingRule(id); err != nil {
+		discountError(c, err)
+		return
+	}
+
+	GoodResponseWithData(c, "delete success", http.StatusOK, nil)
+}
+
+// @Summary Get All Service Charge Rules
+// @Description List the service charge rules applied by order type and party size
+// @Tags Discounts
+// @Produce json
+// @Success 200 {object} Response{data=[]domain.ServiceChargeRule} "fetch success"
+// @Security Bearer
+// @Router /service-charges [get]
+func (ctrl *DiscountController) AllServiceChargeRules(c *gin.Context) {
+	rules, err := ctrl.service.AllServiceChargeRules()
+	if err != nil {
+		BadResponse(c, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	GoodResponseWithData(c, "fetch success", http.StatusOK, rules)
+}
+
+// @Summary Get Service Charge Rule
+// @Tags Discounts
+// @Produce json
+// @Param id path int true "Service charge rule ID"
+// @Success 200 {object} Response{data=domain.ServiceChargeRule} "fetch success"
+// @Failure 404 {object} Response "Service charge rule not found"
+// @Security Bearer
+// @Router /service-charges/{id} [get]
+func (ctrl *DiscountController) GetServiceChargeRule(c *gin.Context) {
+	id, err := helper.Uint(c.Param("id"))
+	if err != nil {
+		BadResponse(c, "invalid service charge rule ID", http.StatusBadRequest)
+		return
+	}
+
+	rule, err := ctrl.service.FindServiceChargeRule(id)
+	if err != nil {
+		discountError(c, err)
+		return
+	}
+
+	GoodResponseWithData(c, "fetch success", http.StatusOK, rule)
+}
+
+// @Summary Create Service Charge Rule
+// @Description Create a service charge added to orders of a type (empty for every type) from a party size. The most specific matching rule applies, on the subtotal after discounts.
+// @Tags Discounts
+// @Accept json
+// @Produce json
+// @Param input body domain.ServiceChargeRule true "Service charge rule"
+// @Success 201 {object} Response{data=domain.ServiceChargeRule} "create success"
+// @Failure 400 {object} Response "Invalid input"
+// @Security Bearer
+// @Router /service-charges [post]
+func (ctrl *DiscountController) CreateServiceChargeRule(c *gin.Context) {
+	var rule domain.ServiceChargeRule
+	if err := c.ShouldBindJSON(&rule); err != nil {
+		BadResponse(c, helper.FormatValidationError(err), http.StatusBadRequest)
+		return
+	}
+
+	if err := ctrl.service.CreateServiceChargeRule(&rule); err != nil {
+		discountError(c, err)
+		return
+	}
+
+	GoodResponseWithData(c, "create success", http.StatusCreated, rule)
+}
+
+// @Summary Update Service Charge Rule
+// @Tags Discounts
+// @Accept json
+// @Produce json
+// @Param id path int true "Service charge rule ID"
+// @Param input body domain.ServiceChargeRule true "Service charge rule"
+// @Success 200 {object} Response{data=domain.ServiceChargeRule} "update success"
+// @Failure 400 {object} Response "Invalid input"
+// @Failure 404 {object} Response "Service charge rule not found"
+// @Security Bearer
+// @Router /service-charges/{id} [put]
+func (ctrl *DiscountController) UpdateServiceChargeRule(c *gin.Context) {
+	id, err := helper.Uint(c.Param("id"))
+	if err != nil {
+		BadResponse(c, "invalid service charge rule ID", http.StatusBadRequest)
+		return
+	}
+
+	var rule domain.ServiceChargeRule
+	if err := c.ShouldBindJSON(&rule); err != nil {
+		BadResponse(c, helper.FormatValidationError(err), http.StatusBadRequest)
+		return
+	}
+	rule.ID = id
+
+	if err := ctrl.service.UpdateServiceChargeRule(&rule); err != nil {
+		discountError(c, err)
+		return
+	}
+
+	GoodResponseWithData(c, "update success", http.StatusOK, rule)
+}
+
+// @Summary Delete Service Charge Rule
+// @Tags Discounts
+// @Produce json
+// @Param id path int true "Service charge rule ID"
+// @Success 200 {object} Response "delete success"
+// @Failure 404 {object} Response "Service charge rule not found"
+// @Security Bearer
+// @Router /service-charges/{id} [delete]
+func (ctrl *DiscountController) DeleteServiceChargeRule(c *gin.Context) {
+	id, err := helper.Uint(c.Param("id"))
+	if err != nil {
+		BadResponse(c, "invalid service charge rule ID", http.StatusBadRequest)
+		return
+	}
+
+	if err := ctrl.service.DeleteServiceChargeRule(id); err != nil {
+		discountError(c, err)
+		return
+	}
+
+	GoodResponseWithData(c, "delete success", http.StatusOK, nil)
+}
+
+type orderDiscountRequest struct {
+	PromoCode   string              `json:"promo_code" example:"WELCOME10"`
+	OrderItemID *uint               `json:"order_item_id" example:"1"`
+	Type        domain.DiscountType `json:"type" example:"percentage"`
+	Value       float64             `json:"value" example:"10"`
+	Name        string              `json:"name" example:"Birthday"`
+}
+
+// @Summary Order Discounts
+// @Description List the discounts applied to an order
+// @Tags Orders
+// @Produce json
+// @Param id path int true "Order ID"
+// @Success 200 {object} Response{data=[]domain.OrderDiscount} "fetch success"
+// @Failure 400 {object} Response "Invalid order ID"
+// @Security Bearer
+// @Router /orders/{id}/discounts [get]
+func (ctrl *DiscountController) OrderDiscounts(c *gin.Context) {
+	id, err := helper.Uint(c.Param("id"))
+	if err != nil {
+		BadResponse(c, "invalid order ID", http.StatusBadRequest)
+		return
+	}
+
+	discounts, err := ctrl.service.OrderDiscounts(id)
+	if err != nil {
+		BadResponse(c, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	GoodResponseWithData(c, "fetch success", http.StatusOK, discounts)
+}
+
+// @Summary Apply Order Discount
+// @Description Apply a promo code, or a manual percentage or fixed discount to the order or one of its items
+// @Tags Orders
+// @Accept json
+// @Produce json
+// @Param id path int true "Order ID"
+// @Param input body orderDiscountRequest true "promo_code, or type and value"
+// @Success 201 {object} Response{data=domain.OrderDiscount} "discount applied"
+// @Failure 400 {object} Response "Invalid discount or promo code cannot be used"
+// @Failure 404 {object} Response "Order, item or promo code not found"
+// @Failure 409 {object} Response "Order is no longer in process or the bill would go below what points and gift cards paid"
+// @Security Bearer
+// @Router /orders/{id}/discounts [post]
+func (ctrl *DiscountController) ApplyDiscount(c *gin.Context) {
+	id, err := helper.Uint(c.Param("id"))
+	if err != nil {
+		BadResponse(c, "invalid order ID", http.StatusBadRequest)
+		return
+	}
+
+	var request orderDiscountRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		BadResponse(c, helper.FormatValidationError(err), http.StatusBadRequest)
+		return
+	}
+
+	discount := domain.OrderDiscount{
+		OrderItemID: request.OrderItemID,
+		Name:        request.Name,
+		Type:        request.Type,
+		Value:       request.Value,
+		UserID:      currentUserID(c),
+	}
+
+	applied, err := ctrl.service.ApplyDiscount(id, discount, request.PromoCode)
+	if err != nil {
+		discountError(c, err)
+		return
+	}
+
+	GoodResponseWithData(c, "discount applied", http.StatusCreated, applied)
+}
+
+// @Summary Remove Order Discount
+// @Description Remove a manual or promo discount from an open order, a promo code gets its use back
+// @Tags Orders
+// @Produce json
+// @Param id path int true "Order ID"
+// @Param discount_id path int true "Order discount ID"
+// @Success 200 {object} Response "discount removed"
+// @Failure 404 {object} Response "Order or discount not found"
+// @Failure 409 {object} Response "Order is no longer in process"
+// @Security Bearer
+// @Router /orders/{id}/discounts/{discount_id} [delete]
+func (ctrl *DiscountController) RemoveDiscount(c *gin.Context) {
+	id, err := helper.Uint(c.Param("id"))
+	if err != nil {
+		BadResponse(c, "invalid order ID", http.StatusBadRequest)
+		return
+	}
+	discountID, err := helper.Uint(c.Param("discount_id"))
+	if err != nil {
+		BadResponse(c, "invalid discount ID", http.StatusBadRequest)
+		return
+	}
+
+	if err := ctrl.service.RemoveDiscount(id, discountID); err != nil {
+		discountError(c, err)
+		return
+	}
+
+	GoodResponseWithData(c, "discount removed", http.StatusOK, nil)
+}
+
+func discountError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, domain.ErrOrderDiscountNotFound), errors.Is(err, domain.ErrPromoCodeNotFound), errors.Is(err, domain.ErrServiceChargeRuleNotFound),
+		err.Error() == "order not found", err.Error() == "order item not found", err.Error() == "pricing rule not found":
+		BadResponse(c, err.Error(), http.StatusNotFound)
+	case errors.Is(err, domain.ErrInvalidDiscount), errors.Is(err, domain.ErrPromoCodeInvalid):
+		BadResponse(c, err.Error(), http.StatusBadRequest)
+	case errors.Is(err, domain.ErrDiscountNotAllowed), errors.Is(err, domain.ErrBillBelowTenders), err.Error() == "promo code already exists":
+		BadResponse(c, err.Error(), http.StatusConflict)
+	default:
+		BadResponse(c, err.Error(), http.StatusInternalServerError)
+	}
+}
@@ -0,0 +1,193 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"project/domain"
+	"project/helper"
+	"project/service"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+type FeedbackController struct {
+	service service.FeedbackService
+	logger  *zap.Logger
+}
+
+func NewFeedbackController(service service.FeedbackService, logger *zap.Logger) *FeedbackController {
+	return &FeedbackController{service: service, logger: logger}
+}
+
+// @Summary Feedback Form
+// @Description Open the feedback link of a receipt, no login needed. Shows the products of the completed order that can be rated, and the feedback already given.
+// @Tags Feedback
+// @Produce json
+// @Param token path string true "Signed receipt token"
+// @Success 200 {object} Response{data=domain.FeedbackForm} "fetch success"
+// @Failure 404 {object} Response "Invalid link or order not found"
+// @Failure 409 {object} Response "Order not completed"
+// @Failure 410 {object} Response "Link expired"
+// @Router /receipts/{token}/feedback [get]
+func (ctrl *FeedbackController) Form(c *gin.Context) {
+	form, err := ctrl.service.Form(c.Param("token"))
+	if err != nil {
+		feedbackError(c, err)
+		return
+	}
+
+	GoodResponseWithData(c, "fetch success", http.StatusOK, form)
+}
+
+// @Summary Give Feedback
+// @Description Rate a completed order from 1 to 5 overall, for food and for service, and optionally rate its products, through the link on the receipt. Each order can be rated once, low ratings notify the admins.
+// @Tags Feedback
+// @Accept json
+// @Produce json
+// @Param token path string true "Signed receipt token"
+// @Param input body domain.FeedbackRequest true "Ratings"
+// @Success 201 {object} Response{data=domain.Feedback} "thank you for your feedback"
+// @Failure 400 {object} Response "Invalid input or product not on the order"
+// @Failure 404 {object} Response "Invalid link or order not found"
+// @Failure 409 {object} Response "Order not completed or already rated"
+// @Failure 410 {object} Response "Link expired"
+// @Router /receipts/{token}/feedback [post]
+func (ctrl *FeedbackController) Submit(c *gin.Context) {
+	var request domain.FeedbackRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		BadResponse(c, helper.FormatValidationError(err), http.StatusBadRequest)
+		return
+	}
+
+	feedback, err := ctrl.service.Submit(c.Param("token"), request)
+	if err != nil {
+		feedbackError(c, err)
+		return
+	}
+
+	GoodResponseWithData(c, "thank you for your feedback", http.StatusCreated, feedback)
+}
+
+// @Summary Get Feedback
+// @Description List the feedback given between two dates newest first, optionally only the feedback with a low rating
+// @Tags Feedback
+// @Produce json
+// @Param start query string false "Start date (YYYY-MM-DD), default is the first day of the current month"
+// @Param end query string false "End date inclusive (YYYY-MM-DD), default is today"
+// @Param low query bool false "Only feedback with a rating at or below the low rating"
+// @Param page query int false "Page number, default is 1"
+// @Param limit query int false "Number of items per page, default is 10"
+// @Success 200 {object} domain.DataPage{data=[]domain.Feedback} "fetch success"
+// @Failure 400 {object} Response "Invalid date"
+// @Security Bearer
+// @Router /feedback [get]
+func (ctrl *FeedbackController) All(c *gin.Context) {
+	start, end, ok := reportPeriod(c)
+	if !ok {
+		return
+	}
+	page, limit := helper.Page(c)
+
+	feedback, totalItems, err := ctrl.service.All(int(page), int(limit), start, end, c.Query("low") == "true")
+	if err != nil {
+		feedbackError(c, err)
+		return
+	}
+
+	totalPages := (totalItems + int64(limit) - 1) / int64(limit)
+	GoodResponseWithPage(c, "fetch success", http.StatusOK, int(totalItems), int(totalPages), int(page), int(limit), feedback)
+}
+
+// @Summary Ratings By Product
+// @Description The number of ratings, the average rating and the low ratings of each product rated between two dates, lowest average first
+// @Tags Feedback
+// @Produce json
+// @Param start query string false "Start date (YYYY-MM-DD), default is the first day of the current month"
+// @Param end query string false "End date inclusive (YYYY-MM-DD), default is today"
+// @Success 200 {object} Response{data=[]domain.FeedbackProductRating} "fetch success"
+// @Failure 400 {object} Response "Invalid date"
+// @Security Bearer
+// @Router /feedback/products [get]
+func (ctrl *FeedbackController) ByProduct(c *gin.Context) {
+	start, end, ok := reportPeriod(c)
+	if !ok {
+		return
+	}
+
+	data, err := ctrl.service.ByProduct(start, end)
+	if err != nil {
+		feedbackError(c, err)
+		return
+	}
+	GoodResponseWithData(c, "fetch success", http.StatusOK, data)
+}
+
+// @Summary Ratings By Staff
+// @Description The average overall, food and service ratings between two dates per staff member who served the rated orders, lowest overall first
+// @Tags Feedback
+// @Produce json
+// @Param start query string false "Start date (YYYY-MM-DD), default is the first day of the current month"
+// @Param end query string false "End date inclusive (YYYY-MM-DD), default is today"
+// @Success 200 {object} Response{data=[]domain.FeedbackStaffRating} "fetch success"
+// @Failure 400 {object} Response "Invalid date"
+// @Security Bearer
+// @Router /feedback/staff [get]
+func (ctrl *FeedbackController) ByStaff(c *gin.Context) {
+	start, end, ok := reportPeriod(c)
+	if !ok {
+		return
+	}
+
+	data, err := ctrl.service.ByStaff(start, end)
+	if err != nil {
+		feedbackError(c, err)
+		return
+	}
+	GoodResponseWithData(c, "fetch success", http.StatusOK, data)
+}
+
+// @Summary Rating Trend
+// @Description The average overall, food and service ratings between two dates per day, week or month
+// @Tags Feedback
+// @Produce json
+// @Param start query string false "Start date (YYYY-MM-DD), default is the first day of the current month"
+// @Param end query string false "End date inclusive (YYYY-MM-DD), default is today"
+// @Param interval query string false "day, week or month, default is day"
+// @Success 200 {object} Response{data=[]domain.FeedbackTrend} "fetch success"
+// @Failure 400 {object} Response "Invalid date or interval"
+// @Security Bearer
+// @Router /feedback/trend [get]
+func (ctrl *FeedbackController) Trend(c *gin.Context) {
+	start, end, ok := reportPeriod(c)
+	if !ok {
+		return
+	}
+	interval := domain.FeedbackInterval(c.DefaultQuery("interval", string(domain.FeedbackDaily)))
+	if !interval.Valid() {
+		BadResponse(c, "invalid interval, use day, week or month", http.StatusBadRequest)
+		return
+	}
+
+	data, err := ctrl.service.Trend(start, end, interval)
+	if err != nil {
+		feedbackError(c, err)
+		return
+	}
+	GoodResponseWithData(c, "fetch success", http.StatusOK, data)
+}
+
+func feedbackError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, domain.ErrInvalidReceiptToken), err.Error() == "order not found":
+		BadResponse(c, err.Error(), http.StatusNotFound)
+	case errors.Is(err, domain.ErrReceiptTokenExpired):
+		BadResponse(c, err.Error(), http.StatusGone)
+	case errors.Is(err, domain.ErrInvalidFeedback), err.Error() == "end date must be after start date":
+		BadResponse(c, err.Error(), http.StatusBadRequest)
+	case errors.Is(err, domain.ErrFeedbackNotOpen), errors.Is(err, domain.ErrFeedbackGiven):
+		BadResponse(c, err.Error(), http.StatusConflict)
+	default:
+		BadResponse(c, err.Error(), http.StatusInternalServerError)
+	}
+}
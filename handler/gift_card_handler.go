@@ -0,0 +1,207 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"project/domain"
+	"project/helper"
+	"project/service"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+type GiftCardController struct {
+	service service.GiftCardService
+	logger  *zap.Logger
+}
+
+func NewGiftCardController(service service.GiftCardService, logger *zap.Logger) *GiftCardController {
+	return &GiftCardController{service: service, logger: logger}
+}
+
+type voidGiftCardRequest struct {
+	Note string `json:"note" binding:"required,max=255" example:"Reported stolen"`
+}
+
+// @Summary Get Gift Cards
+// @Description List the gift cards newest first, or search them by part of the code or recipient
+// @Tags Gift Cards
+// @Produce json
+// @Param page query int false "Page number, default is 1"
+// @Param limit query int false "Number of items per page, default is 10"
+// @Param search query string false "Part of the code, recipient name or recipient email"
+// @Param status query string false "active or voided"
+// @Success 200 {object} domain.DataPage{data=[]domain.GiftCard} "fetch success"
+// @Failure 400 {object} Response "Invalid status"
+// @Security Bearer
+// @Router /gift-cards [get]
+func (ctrl *GiftCardController) All(c *gin.Context) {
+	page, limit := helper.Page(c)
+	status := domain.GiftCardStatus(c.Query("status"))
+	if status != "" && status != domain.GiftCardActive && status != domain.GiftCardVoided {
+		BadResponse(c, "invalid status, use active or voided", http.StatusBadRequest)
+		return
+	}
+
+	cards, totalItems, err := ctrl.service.All(int(page), int(limit), c.Query("search"), status)
+	if err != nil {
+		giftCardError(c, err)
+		return
+	}
+
+	totalPages := (totalItems + int64(limit) - 1) / int64(limit)
+	GoodResponseWithPage(c, "fetch success", http.StatusOK, int(totalItems), int(totalPages), int(page), int(limit), cards)
+}
+
+// @Summary Get Gift Card
+// @Description A gift card with its balance and transaction history
+// @Tags Gift Cards
+// @Produce json
+// @Param id path int true "Gift card ID"
+// @Success 200 {object} Response{data=domain.GiftCard} "fetch success"
+// @Failure 404 {object} Response "Gift card not found"
+// @Security Bearer
+// @Router /gift-cards/{id} [get]
+func (ctrl *GiftCardController) Get(c *gin.Context) {
+	id, err := helper.Uint(c.Param("id"))
+	if err != nil {
+		BadResponse(c, "invalid gift card ID", http.StatusBadRequest)
+		return
+	}
+
+	card, err := ctrl.service.FindByID(id)
+	if err != nil {
+		giftCardError(c, err)
+		return
+	}
+
+	GoodResponseWithData(c, "fetch success", http.StatusOK, card)
+}
+
+// @Summary Check Gift Card Balance
+// @Description Look a gift card up by its code, with or without dashes, to check its balance before paying with it
+// @Tags Gift Cards
+// @Produce json
+// @Param code path string true "Gift card code"
+// @Success 200 {object} Response{data=domain.GiftCard} "fetch success"
+// @Failure 404 {object} Response "Gift card not found"
+// @Security Bearer
+// @Router /gift-cards/code/{code} [get]
+func (ctrl *GiftCardController) GetByCode(c *gin.Context) {
+	card, err := ctrl.service.FindByCode(c.Param("code"))
+	if err != nil {
+		giftCardError(c, err)
+		return
+	}
+
+	GoodResponseWithData(c, "fetch success", http.StatusOK, card)
+}
+
+// @Summary Issue Gift Card
+// @Description Sell a gift card with a new unique code. It expires after the configured days unless an expiry date is given, and its code is emailed to the recipient when an address is given.
+// @Tags Gift Cards
+// @Accept json
+// @Produce json
+// @Param input body domain.IssueGiftCardRequest true "Gift card"
+// @Success 201 {object} Response{data=domain.GiftCard} "gift card issued"
+// @Failure 400 {object} Response "Invalid input"
+// @Security Bearer
+// @Router /gift-cards [post]
+func (ctrl *GiftCardController) Issue(c *gin.Context) {
+	var request domain.IssueGiftCardRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		BadResponse(c, helper.FormatValidationError(err), http.StatusBadRequest)
+		return
+	}
+
+	card, err := ctrl.service.Issue(request, currentUserID(c))
+	if err != nil {
+		giftCardError(c, err)
+		return
+	}
+
+	GoodResponseWithData(c, "gift card issued", http.StatusCreated, card)
+}
+
+// @Summary Void Gift Card
+// @Description Take the balance off a gift card sold by mistake or reported stolen, it cannot be used again
+// @Tags Gift Cards
+// @Accept json
+// @Produce json
+// @Param id path int true "Gift card ID"
+// @Param input body voidGiftCardRequest true "Reason"
+// @Success 200 {object} Response{data=domain.GiftCard} "gift card voided"
+// @Failure 400 {object} Response "Invalid input or already voided"
+// @Failure 404 {object} Response "Gift card not found"
+// @Security Bearer
+// @Router /gift-cards/{id}/void [post]
+func (ctrl *GiftCardController) Void(c *gin.Context) {
+	id, err := helper.Uint(c.Param("id"))
+	if err != nil {
+		BadResponse(c, "invalid gift card ID", http.StatusBadRequest)
+		return
+	}
+
+	var request voidGiftCardRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		BadResponse(c, helper.FormatValidationError(err), http.StatusBadRequest)
+		return
+	}
+
+	card, err := ctrl.service.Void(id, request.Note, currentUserID(c))
+	if err != nil {
+		giftCardError(c, err)
+		return
+	}
+
+	GoodResponseWithData(c, "gift card voided", http.StatusOK, card)
+}
+
+// @Summary Pay With Gift Card
+// @Description Pay part of an open order from a gift card, as much as the card and the bill allow when no amount is given. The rest stays on the card, and it is credited back when the order is cancelled or refunded.
+// @Tags Gift Cards
+// @Accept json
+// @Produce json
+// @Param id path int true "Order ID"
+// @Param input body domain.RedeemGiftCardRequest true "Gift card and amount"
+// @Success 201 {object} Response{data=domain.Payment} "gift card redeemed"
+// @Failure 400 {object} Response "Order not open, nothing left to pay, or amount more than is left to pay"
+// @Failure 404 {object} Response "Order or gift card not found"
+// @Failure 409 {object} Response "Gift card voided, expired or without enough balance"
+// @Security Bearer
+// @Router /orders/{id}/gift-card [post]
+func (ctrl *GiftCardController) Redeem(c *gin.Context) {
+	id, err := helper.Uint(c.Param("id"))
+	if err != nil {
+		BadResponse(c, "invalid order ID", http.StatusBadRequest)
+		return
+	}
+
+	var request domain.RedeemGiftCardRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		BadResponse(c, helper.FormatValidationError(err), http.StatusBadRequest)
+		return
+	}
+
+	payment, err := ctrl.service.Redeem(id, request, currentUserID(c))
+	if err != nil {
+		giftCardError(c, err)
+		return
+	}
+
+	GoodResponseWithData(c, "gift card redeemed", http.StatusCreated, payment)
+}
+
+func giftCardError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, domain.ErrGiftCardNotFound), err.Error() == "order not found":
+		BadResponse(c, err.Error(), http.StatusNotFound)
+	case errors.Is(err, domain.ErrInvalidGiftCard):
+		BadResponse(c, err.Error(), http.StatusBadRequest)
+	case errors.Is(err, domain.ErrGiftCardUnusable), errors.Is(err, domain.ErrGiftCardBalance):
+		BadResponse(c, err.Error(), http.StatusConflict)
+	default:
+		BadResponse(c, err.Error(), http.StatusInternalServerError)
+	}
+}
@@ -1,8 +1,10 @@
 package handler
 
 import (
+	"net/http"
 	"project/database"
 	"project/domain"
+	"project/helper"
 	"project/infra/jwt"
 	"project/service"
 
@@ -23,6 +25,18 @@ type Handler struct {
 	DashboardHandler      DashboardController
 	UserPermissionHandler UserPermissionController
 	RevenueHandler        RevenueController
+	RefundHandler         RefundController
+	DiscountHandler       DiscountController
+	TaxHandler            TaxController
+	TimeClockHandler      TimeClockController
+	ReceiptHandler        ReceiptController
+	TableHandler          TableController
+	WaitlistHandler       WaitlistController
+	CustomerHandler       CustomerController
+	LoyaltyHandler        LoyaltyController
+	GiftCardHandler       GiftCardController
+	FeedbackHandler       FeedbackController
+	MenuHandler           MenuController
 }
 
 func NewHandler(service service.Service, logger *zap.Logger, rdb database.Cacher, jwt jwt.JWT) *Handler {
@@ -35,10 +49,22 @@ func NewHandler(service service.Service, logger *zap.Logger, rdb database.Cacher
 		NotificationHandler:   *NewNotificationController(service, logger),
 		CategoryHandler:       *NewCategoryController(service.Category, logger),
 		ProductHandler:        *NewProductController(service.Product, logger),
-		OrderHandler:          *NewOrderController(service.Order, logger),
+		OrderHandler:          *NewOrderController(service.Order, service.Receipt, logger),
 		DashboardHandler:      *NewDashboardController(service.Dashboard, logger),
 		UserPermissionHandler: *NewUserPermissionController(service.UserPermission, logger),
 		RevenueHandler:        *NewRevenueController(service.Revenue, logger),
+		RefundHandler:         *NewRefundController(service.Refund, logger),
+		DiscountHandler:       *NewDiscountController(service.Discount, logger),
+		TaxHandler:            *NewTaxController(service.Tax, logger),
+		TimeClockHandler:      *NewTimeClockController(service.TimeClock, logger),
+		ReceiptHandler:        *NewReceiptController(service.Receipt, logger),
+		TableHandler:          *NewTableController(service.Table, logger),
+		WaitlistHandler:       *NewWaitlistController(service.Waitlist, logger),
+		CustomerHandler:       *NewCustomerController(service.Customer, logger),
+		LoyaltyHandler:        *NewLoyaltyController(service.Loyalty, logger),
+		GiftCardHandler:       *NewGiftCardController(service.GiftCard, logger),
+		FeedbackHandler:       *NewFeedbackController(service.Feedback, logger),
+		MenuHandler:           *NewMenuController(service.Menu, logger),
 	}
 }
 
@@ -63,6 +89,39 @@ func GoodResponseWithData(c *gin.Context, message string, statusCode int, data i
 	})
 }
 
+// ConflictResponse answers a stale write with the current representation and its ETag
+func ConflictResponse(c *gin.Context, version uint, current interface{}) {
+	c.Header("ETag", helper.ETag(version))
+	c.JSON(http.StatusConflict, Response{
+		Status:  false,
+		Message: domain.ErrVersionConflict.Error(),
+		Data:    current,
+	})
+}
+
+// ifMatchVersion returns the version sent in the If-Match header, ok is false when the header is absent or "*"
+func ifMatchVersion(c *gin.Context) (version uint, ok bool, err error) {
+	header := c.GetHeader("If-Match")
+	if header == "" || header == "*" {
+		return 0, false, nil
+	}
+
+	version, err = helper.ParseETag(header)
+	if err != nil {
+		return 0, false, err
+	}
+	return version, true, nil
+}
+
+// currentUserID returns the id of the authenticated user, or nil when the request carries none
+func currentUserID(c *gin.Context) *uint {
+	userID, err := helper.Uint(c.GetString("user-id"))
+	if err != nil {
+		return nil
+	}
+	return &userID
+}
+
 func GoodResponseWithPage(c *gin.Context, message string, statusCode, total, totalPages, page, Limit int, data interface{}) {
 	c.JSON(statusCode, domain.DataPage{
 		Status:      true,
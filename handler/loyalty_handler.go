@@ -0,0 +1,286 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"project/domain"
+	"project/helper"
+	"project/service"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+type LoyaltyController struct {
+	service service.LoyaltyService
+	logger  *zap.Logger
+}
+
+func NewLoyaltyController(service service.LoyaltyService, logger *zap.Logger) *LoyaltyController {
+	return &LoyaltyController{service: service, logger: logger}
+}
+
+type redeemPointsRequest struct {
+	Points int `json:"points" binding:"required,gt=0" example:"500"`
+}
+
+type earnRateRequest struct {
+	// EarnRate is the points per currency unit, null for the default rate
+	EarnRate *float64 `json:"earn_rate" binding:"omitempty,gte=0" example:"2"`
+}
+
+// @Summary Get Loyalty Account
+// @Description The points balance of a customer with its value, the tier reached with the points earned over the tier period, the points to the next tier and the points expiring soon
+// @Tags Loyalty
+// @Produce json
+// @Param id path int true "Customer ID"
+// @Success 200 {object} Response{data=domain.LoyaltyAccount} "fetch success"
+// @Failure 404 {object} Response "Customer not found"
+// @Security Bearer
+// @Router /customers/{id}/loyalty [get]
+func (ctrl *LoyaltyController) Account(c *gin.Context) {
+	id, err := helper.Uint(c.Param("id"))
+	if err != nil {
+		BadResponse(c, "invalid customer ID", http.StatusBadRequest)
+		return
+	}
+
+	account, err := ctrl.service.Account(id)
+	if err != nil {
+		loyaltyError(c, err)
+		return
+	}
+
+	GoodResponseWithData(c, "fetch success", http.StatusOK, account)
+}
+
+// @Summary Get Loyalty Ledger
+// @Description The points earned, redeemed, reversed, restored, expired and adjusted for a customer, newest first
+// @Tags Loyalty
+// @Produce json
+// @Param id path int true "Customer ID"
+// @Param page query int false "Page number, default is 1"
+// @Param limit query int false "Number of items per page, default is 10"
+// @Success 200 {object} domain.DataPage{data=[]domain.LoyaltyEntry} "fetch success"
+// @Failure 404 {object} Response "Customer not found"
+// @Security Bearer
+// @Router /customers/{id}/loyalty/ledger [get]
+func (ctrl *LoyaltyController) Ledger(c *gin.Context) {
+	id, err := helper.Uint(c.Param("id"))
+	if err != nil {
+		BadResponse(c, "invalid customer ID", http.StatusBadRequest)
+		return
+	}
+	page, limit := helper.Page(c)
+
+	entries, totalItems, err := ctrl.service.Ledger(id, int(page), int(limit))
+	if err != nil {
+		loyaltyError(c, err)
+		return
+	}
+
+	totalPages := (totalItems + int64(limit) - 1) / int64(limit)
+	GoodResponseWithPage(c, "fetch success", http.StatusOK, int(totalItems), int(totalPages), int(page), int(limit), entries)
+}
+
+// @Summary Adjust Loyalty Points
+// @Description Add points to a customer, or take points away up to the balance, with a note of why
+// @Tags Loyalty
+// @Accept json
+// @Produce json
+// @Param id path int true "Customer ID"
+// @Param input body domain.LoyaltyAdjustment true "Adjustment"
+// @Success 201 {object} Response{data=domain.LoyaltyEntry} "points adjusted"
+// @Failure 400 {object} Response "Invalid input"
+// @Failure 404 {object} Response "Customer not found"
+// @Failure 409 {object} Response "Not enough points"
+// @Security Bearer
+// @Router /customers/{id}/loyalty/adjust [post]
+func (ctrl *LoyaltyController) Adjust(c *gin.Context) {
+	id, err := helper.Uint(c.Param("id"))
+	if err != nil {
+		BadResponse(c, "invalid customer ID", http.StatusBadRequest)
+		return
+	}
+
+	var adjustment domain.LoyaltyAdjustment
+	if err := c.ShouldBindJSON(&adjustment); err != nil {
+		BadResponse(c, helper.FormatValidationError(err), http.StatusBadRequest)
+		return
+	}
+
+	entry, err := ctrl.service.Adjust(id, adjustment, currentUserID(c))
+	if err != nil {
+		loyaltyError(c, err)
+		return
+	}
+
+	GoodResponseWithData(c, "points adjusted", http.StatusCreated, entry)
+}
+
+// @Summary Redeem Loyalty Points
+// @Description Pay part of an open order with the points of its customer. The points payment is taken off what is left to pay, is not refunded in money and earns no points. Cancelling the order gives the points back.
+// @Tags Loyalty
+// @Accept json
+// @Produce json
+// @Param id path int true "Order ID"
+// @Param input body redeemPointsRequest true "Points to redeem"
+// @Success 201 {object} Response{data=domain.Payment} "points redeemed"
+// @Failure 400 {object} Response "Order not open, without a customer, or points worth more than is left to pay"
+// @Failure 404 {object} Response "Order not found"
+// @Failure 409 {object} Response "Not enough points"
+// @Security Bearer
+// @Router /orders/{id}/redeem-points [post]
+func (ctrl *LoyaltyController) Redeem(c *gin.Context) {
+	id, err := helper.Uint(c.Param("id"))
+	if err != nil {
+		BadResponse(c, "invalid order ID", http.StatusBadRequest)
+		return
+	}
+
+	var request redeemPointsRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		BadResponse(c, helper.FormatValidationError(err), http.StatusBadRequest)
+		return
+	}
+
+	payment, err := ctrl.service.Redeem(id, request.Points, currentUserID(c))
+	if err != nil {
+		loyaltyError(c, err)
+		return
+	}
+
+	GoodResponseWithData(c, "points redeemed", http.StatusCreated, payment)
+}
+
+// @Summary Get Loyalty Tiers
+// @Description The membership tiers by the points needed, with their earn multiplier and perks
+// @Tags Loyalty
+// @Produce json
+// @Success 200 {object} Response{data=[]domain.LoyaltyTier} "fetch success"
+// @Security Bearer
+// @Router /loyalty/tiers [get]
+func (ctrl *LoyaltyController) Tiers(c *gin.Context) {
+	tiers, err := ctrl.service.Tiers()
+	if err != nil {
+		loyaltyError(c, err)
+		return
+	}
+
+	GoodResponseWithData(c, "fetch success", http.StatusOK, tiers)
+}
+
+// @Summary Create Loyalty Tier
+// @Description Add a membership tier reached with the given points earned over the tier period
+// @Tags Loyalty
+// @Accept json
+// @Produce json
+// @Param input body domain.LoyaltyTier true "Tier"
+// @Success 201 {object} Response{data=domain.LoyaltyTier} "create success"
+// @Failure 400 {object} Response "Invalid input, or another tier has the name or minimum points"
+// @Security Bearer
+// @Router /loyalty/tiers [post]
+func (ctrl *LoyaltyController) CreateTier(c *gin.Context) {
+	var tier domain.LoyaltyTier
+	if err := c.ShouldBindJSON(&tier); err != nil {
+		BadResponse(c, helper.FormatValidationError(err), http.StatusBadRequest)
+		return
+	}
+	tier.ID = 0
+
+	if err := ctrl.service.CreateTier(&tier); err != nil {
+		loyaltyError(c, err)
+		return
+	}
+
+	GoodResponseWithData(c, "create success", http.StatusCreated, tier)
+}
+
+// @Summary Update Loyalty Tier
+// @Description Change the name, minimum points, earn multiplier or perks of a tier
+// @Tags Loyalty
+// @Accept json
+// @Produce json
+// @Param id path int true "Tier ID"
+// @Param input body domain.LoyaltyTier true "Tier"
+// @Success 200 {object} Response{data=domain.LoyaltyTier} "update success"
+// @Failure 400 {object} Response "Invalid input, or another tier has the name or minimum points"
+// @Failure 404 {object} Response "Tier not found"
+// @Security Bearer
+// @Router /loyalty/tiers/{id} [put]
+func (ctrl *LoyaltyController) UpdateTier(c *gin.Context) {
+	id, err := helper.Uint(c.Param("id"))
+	if err != nil {
+		BadResponse(c, "invalid tier ID", http.StatusBadRequest)
+		return
+	}
+
+	var details domain.LoyaltyTier
+	if err := c.ShouldBindJSON(&details); err != nil {
+		BadResponse(c, helper.FormatValidationError(err), http.StatusBadRequest)
+		return
+	}
+
+	tier, err := ctrl.service.UpdateTier(id, details)
+	if err != nil {
+		loyaltyError(c, err)
+		return
+	}
+
+	GoodResponseWithData(c, "update success", http.StatusOK, tier)
+}
+
+// @Summary Set Category Earn Rate
+// @Description Set the points per currency unit earned on the items of a category, null goes back to the default rate
+// @Tags Loyalty
+// @Accept json
+// @Produce json
+// @Param id path int true "Category ID"
+// @Param input body earnRateRequest true "Earn rate"
+// @Success 200 {object} Response{data=domain.Category} "update success"
+// @Failure 400 {object} Response "Invalid input"
+// @Failure 404 {object} Response "Category not found"
+// @Security Bearer
+// @Router /categories/{id}/loyalty-earn-rate [put]
+func (ctrl *LoyaltyController) SetCategoryEarnRate(c *gin.Context) {
+	id, err := helper.Uint(c.Param("id"))
+	if err != nil {
+		BadResponse(c, "invalid category ID", http.StatusBadRequest)
+		return
+	}
+
+	var request earnRateRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		BadResponse(c, helper.FormatValidationError(err), http.StatusBadRequest)
+		return
+	}
+
+	category, err := ctrl.service.SetCategoryEarnRate(id, request.EarnRate)
+	if err != nil {
+		loyaltyError(c, err)
+		return
+	}
+
+	GoodResponseWithData(c, "update success", http.StatusOK, category)
+}
+
+// ExpirePoints dijalankan cron untuk menghapus poin loyalitas yang sudah kedaluwarsa
+func (ctrl *LoyaltyController) ExpirePoints() {
+	if _, err := ctrl.service.Expire(); err != nil {
+		ctrl.logger.Error("failed to expire loyalty points", zap.Error(err))
+	}
+}
+
+func loyaltyError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, domain.ErrCustomerNotFound), errors.Is(err, domain.ErrLoyaltyTierNotFound),
+		err.Error() == "order not found", err.Error() == "category not found":
+		BadResponse(c, err.Error(), http.StatusNotFound)
+	case errors.Is(err, domain.ErrInvalidLoyalty):
+		BadResponse(c, err.Error(), http.StatusBadRequest)
+	case errors.Is(err, domain.ErrInsufficientPoints):
+		BadResponse(c, err.Error(), http.StatusConflict)
+	default:
+		BadResponse(c, err.Error(), http.StatusInternalServerError)
+	}
+}
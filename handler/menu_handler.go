@@ -0,0 +1,46 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+	"project/helper"
+	"project/service"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+type MenuController struct {
+	service service.MenuService
+	logger  *zap.Logger
+}
+
+func NewMenuController(service service.MenuService, logger *zap.Logger) *MenuController {
+	return &MenuController{service: service, logger: logger}
+}
+
+// @Summary Public Menu
+// @Description The menu for online ordering channels, no login needed: the active products in stock per category with their description, image, allergens, modifiers and current price. Send the ETag back in If-None-Match to get 304 Not Modified while the menu is unchanged.
+// @Tags Menu
+// @Produce json
+// @Param If-None-Match header string false "ETag of the menu already held"
+// @Success 200 {object} Response{data=domain.Menu} "fetch success"
+// @Success 304 "Menu not modified"
+// @Router /menu [get]
+func (ctrl *MenuController) Menu(c *gin.Context) {
+	body, etag, err := ctrl.service.Menu()
+	if err != nil {
+		BadResponse(c, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	// caches may keep the menu but have to check it is still current before using it
+	c.Header("ETag", etag)
+	c.Header("Cache-Control", "public, no-cache")
+	if helper.ETagMatches(c.GetHeader("If-None-Match"), etag) {
+		c.Status(http.StatusNotModified)
+		return
+	}
+
+	GoodResponseWithData(c, "fetch success", http.StatusOK, json.RawMessage(body))
+}
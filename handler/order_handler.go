@@ -1,11 +1,12 @@
 package handler
 
 import (
-	"math"
+	"errors"
 	"net/http"
 	"project/domain"
 	"project/helper"
 	"project/service"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"go.uber.org/zap"
@@ -13,42 +14,12 @@ import (
 
 type OrderController struct {
 	service service.OrderService
+	receipt service.ReceiptService
 	logger  *zap.Logger
 }
 
-func NewOrderController(service service.OrderService, logger *zap.Logger) *OrderController {
-	return &OrderController{service: service, logger: logger}
-}
-
-// @Summary Get All Tables
-// @Description Retrieve a list of tables with pagination
-// @Tags Tables
-// @Accept  json
-// @Produce json
-// @Param page query int false "Page number, default is 1"
-// @Param limit query int false "Number of items per page, default is 10"
-// @Success 200 {object} domain.DataPage{data=[]domain.Table} "fetch success"
-// @Failure 404 {object} Response "tables not found"
-// @Failure 500 {object} Response "internal server error"
-// @Security Bearer
-// @Router /tables/ [get]
-func (ctrl *OrderController) AllTables(c *gin.Context) {
-	page, _ := helper.Uint(c.DefaultQuery("page", "1"))
-	limit, _ := helper.Uint(c.DefaultQuery("limit", "10"))
-
-	tables, totalItems, err := ctrl.service.AllTables(int(page), int(limit))
-	if err != nil {
-		if err.Error() == "tables not found" {
-			BadResponse(c, err.Error(), http.StatusNotFound)
-			return
-		}
-		BadResponse(c, err.Error(), http.StatusInternalServerError)
-		return
-	}
-
-	totalPages := (totalItems + int64(limit) - 1) / int64(limit)
-
-	GoodResponseWithPage(c, "fetch success", http.StatusOK, int(totalItems), int(totalPages), int(page), int(limit), tables)
+func NewOrderController(service service.OrderService, receipt service.ReceiptService, logger *zap.Logger) *OrderController {
+	return &OrderController{service: service, receipt: receipt, logger: logger}
 }
 
 // @Summary Get All Payments
@@ -77,18 +48,32 @@ func (ctrl *OrderController) AllPayments(c *gin.Context) {
 }
 
 type orderRequest struct {
-	Name       string             `json:"name" binding:"required,min=3"`
-	TableID    uint               `json:"table_id" binding:"required"`
-	OrderItems []domain.OrderItem `json:"order_items" binding:"required,dive"`
+	Name            string             `json:"name" binding:"required,min=3"`
+	Type            domain.OrderType   `json:"type" binding:"omitempty,oneof=dine_in takeaway delivery pickup" example:"dine_in"`
+	TableID         *uint              `json:"table_id" example:"1"`
+	PartySize       int                `json:"party_size" binding:"gte=0" example:"4"`
+	CustomerPhone   string             `json:"customer_phone" binding:"max=20" example:"+1 (23) 123 4567"`
+	CustomerID      *uint              `json:"customer_id" example:"1"`
+	DeliveryAddress string             `json:"delivery_address" binding:"max=255" example:"1st Street 12"`
+	DeliveryFee     float64            `json:"delivery_fee" binding:"gte=0" example:"3.50"`
+	PromisedAt      *time.Time         `json:"promised_at" example:"2024-05-10T19:30:00Z"`
+	OrderItems      []domain.OrderItem `json:"order_items" binding:"required,dive"`
+	PromoCode       string             `json:"promo_code" example:"WELCOME10"`
+}
+
+type createOrderResponse struct {
+	OrderID     uint   `json:"order_id" example:"1"`
+	CodeOrder   string `json:"code_order" example:"ORD0001"`
+	QueueNumber int    `json:"queue_number" example:"12"`
 }
 
 // @Summary Create Order
-// @Description Create a new order with specified table ID and order items
+// @Description Create a new order with its items. Dine-in orders need a table, takeaway, pickup and delivery orders have none and delivery orders carry an address and a fee. Pricing rules are applied automatically and an optional promo code is checked and applied.
 // @Tags Orders
 // @Accept  json
 // @Produce json
 // @Param input body orderRequest true "Order Input"
-// @Success 201 {object} Response "Order created successfully"
+// @Success 201 {object} Response{data=createOrderResponse} "Order created successfully"
 // @Failure 400 {object} Response "Invalid input"
 // @Failure 500 {object} Response "Internal server error"
 // @Security Bearer
@@ -102,34 +87,86 @@ func (ctrl *OrderController) Create(c *gin.Context) {
 		return
 	}
 
-	err := ctrl.service.CreateOrder(input.Name, input.TableID, input.OrderItems)
-	if err != nil {
+	order := domain.Order{
+		Name:            input.Name,
+		Type:            input.Type,
+		TableID:         input.TableID,
+		PartySize:       input.PartySize,
+		CustomerPhone:   input.CustomerPhone,
+		CustomerID:      input.CustomerID,
+		DeliveryAddress: input.DeliveryAddress,
+		DeliveryFee:     input.DeliveryFee,
+		PromisedAt:      input.PromisedAt,
+		OrderItems:      input.OrderItems,
+	}
+	if err := ctrl.service.CreateOrder(&order, input.PromoCode); err != nil {
+		if errors.Is(err, domain.ErrPromoCodeNotFound) || errors.Is(err, domain.ErrPromoCodeInvalid) || errors.Is(err, domain.ErrInvalidOrder) ||
+			errors.Is(err, domain.ErrInvalidCustomer) {
+			BadResponse(c, err.Error(), http.StatusBadRequest)
+			return
+		}
 		BadResponse(c, err.Error(), http.StatusInternalServerError)
 		return
 	}
 
-	GoodResponseWithData(c, "Order created successfully", http.StatusCreated, nil)
+	GoodResponseWithData(c, "Order created successfully", http.StatusCreated, createOrderResponse{
+		OrderID:     order.ID,
+		CodeOrder:   order.CodeOrder,
+		QueueNumber: order.QueueNumber,
+	})
 }
 
 type updateOrderRequest struct {
 	Name            string             `json:"name" binding:"required"`
-	TableID         uint               `json:"table_id" binding:"required"`
-	PaymentMethodID *uint              `json:"payment_method_id"`
-	StatusPayment   string             `json:"status_payment"`
-	StatusKitchen   string             `json:"status_kitchen"`
+	Type            domain.OrderType   `json:"type" binding:"omitempty,oneof=dine_in takeaway delivery pickup" example:"dine_in"`
+	TableID         *uint              `json:"table_id" example:"1"`
+	PartySize       int                `json:"party_size" binding:"gte=0" example:"4"`
+	CustomerPhone   string             `json:"customer_phone" binding:"max=20" example:"+1 (23) 123 4567"`
+	DeliveryAddress string             `json:"delivery_address" binding:"max=255" example:"1st Street 12"`
+	DeliveryFee     float64            `json:"delivery_fee" binding:"gte=0" example:"3.50"`
+	PromisedAt      *time.Time         `json:"promised_at" example:"2024-05-10T19:30:00Z"`
 	OrderItems      []domain.OrderItem `json:"order_items" binding:"required,dive"`
 }
 
+// @Summary Get Order By ID
+// @Description Get the details of an order, with its version in the ETag header for If-Match on later updates
+// @Tags Orders
+// @Produce json
+// @Param id path int true "Order ID"
+// @Success 200 {object} Response{data=domain.OrderDetail} "fetch success"
+// @Failure 400 {object} Response "Invalid order ID"
+// @Failure 404 {object} Response "Order not found"
+// @Security Bearer
+// @Router /orders/{id} [get]
+func (ctrl *OrderController) GetByID(c *gin.Context) {
+	id := c.Param("id")
+	if _, err := helper.Uint(id); err != nil {
+		BadResponse(c, "invalid order ID", http.StatusBadRequest)
+		return
+	}
+
+	var order domain.OrderDetail
+	if err := ctrl.service.FindByIDOrderDetail(&order, id); err != nil {
+		BadResponse(c, "Order not found", http.StatusNotFound)
+		return
+	}
+
+	c.Header("ETag", helper.ETag(order.Version))
+	GoodResponseWithData(c, "fetch success", http.StatusOK, order)
+}
+
 // @Summary Update Order
-// @Description Update an existing order. Allows updating the name, table ID, payment method, order items, payment status, and kitchen status.
+// @Description Update an existing order. Allows updating the name, order type, table ID, party size, delivery details and order items. Status changes use the transition endpoints.
 // @Tags Orders
 // @Accept json
 // @Produce json
 // @Param id path string true "Order ID"
+// @Param If-Match header string false "ETag of the order version being updated"
 // @Param input body updateOrderRequest true "Order Update Input"
 // @Success 200 {object} Response{data=orderResponse} "Order updated successfully"
 // @Failure 400 {object} Response "Invalid input"
 // @Failure 404 {object} Response "Order not found"
+// @Failure 409 {object} Response{data=orderResponse} "Order has been modified by another request, or the bill would go below what points and gift cards paid"
 // @Failure 500 {object} Response "Internal server error"
 // @Security Bearer
 // @Router /orders/{id} [put]
@@ -151,19 +188,48 @@ func (ctrl *OrderController) Update(c *gin.Context) {
 		return
 	}
 
+	version, ok, err := ifMatchVersion(c)
+	if err != nil {
+		BadResponse(c, "invalid If-Match header", http.StatusBadRequest)
+		return
+	}
+	if ok && version != input.Version {
+		ctrl.orderConflict(c, id)
+		return
+	}
+
 	if input.StatusPayment != domain.OrderInProcess {
 		BadResponse(c, "cannot update if status payment cancelled or completed", http.StatusBadRequest)
 		return
 	}
 
 	input.Name = request.Name
+	if request.Type != "" {
+		input.Type = request.Type
+	}
 	input.TableID = request.TableID
-	input.PaymentMethodID = request.PaymentMethodID
+	if request.PartySize > 0 {
+		input.PartySize = request.PartySize
+	}
+	input.CustomerPhone = request.CustomerPhone
+	input.DeliveryAddress = request.DeliveryAddress
+	input.DeliveryFee = request.DeliveryFee
+	input.PromisedAt = request.PromisedAt
 	input.OrderItems = request.OrderItems
-	input.StatusPayment = domain.StatusPayment(request.StatusPayment)
-	input.StatusKitchen = domain.StatusKitchen(request.StatusKitchen)
 
-	err := ctrl.service.Update(&input)
+	err = ctrl.service.Update(&input)
+	if errors.Is(err, domain.ErrVersionConflict) {
+		ctrl.orderConflict(c, id)
+		return
+	}
+	if errors.Is(err, domain.ErrInvalidOrder) {
+		BadResponse(c, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if errors.Is(err, domain.ErrBillBelowTenders) {
+		BadResponse(c, err.Error(), http.StatusConflict)
+		return
+	}
 	if err != nil {
 		BadResponse(c, err.Error(), http.StatusInternalServerError)
 		return
@@ -176,11 +242,190 @@ func (ctrl *OrderController) Update(c *gin.Context) {
 		return
 	}
 
-	response.Total = math.Round((response.Total+(response.Total*input.Tax/100))*100) / 100
-
+	c.Header("ETag", helper.ETag(response.Version))
 	GoodResponseWithData(c, "Order updated successfully", http.StatusOK, response)
 }
 
+func (ctrl *OrderController) orderConflict(c *gin.Context, id string) {
+	var current domain.OrderDetail
+	if err := ctrl.service.FindByIDOrderDetail(&current, id); err != nil {
+		BadResponse(c, domain.ErrVersionConflict.Error(), http.StatusConflict)
+		return
+	}
+	ConflictResponse(c, current.Version, current)
+}
+
+type orderTransitionRequest struct {
+	Reason          string  `json:"reason" example:"customer left"`
+	PaymentMethodID *uint   `json:"payment_method_id" example:"1"`
+	Tip             float64 `json:"tip" binding:"gte=0" example:"4.00"`
+	// ReceiptEmail receives the receipt once the order is paid
+	ReceiptEmail string `json:"receipt_email" binding:"omitempty,email" example:"guest@example.com"`
+}
+
+// @Summary Fire Order
+// @Description Send an order to the kitchen, moving it from 'In The Kitchen' to 'Cooking Now'
+// @Tags Orders
+// @Accept json
+// @Produce json
+// @Param id path int true "Order ID"
+// @Param input body orderTransitionRequest false "Optional reason"
+// @Success 200 {object} Response{data=orderResponse} "Order fired"
+// @Failure 404 {object} Response "Order not found"
+// @Failure 409 {object} Response "Invalid order status transition"
+// @Security Bearer
+// @Router /orders/{id}/fire [post]
+func (ctrl *OrderController) Fire(c *gin.Context) {
+	ctrl.transition(c, domain.OrderActionFire, "Order fired")
+}
+
+// @Summary Mark Order Ready
+// @Description Mark a cooking order as 'Ready To Serve'
+// @Tags Orders
+// @Accept json
+// @Produce json
+// @Param id path int true "Order ID"
+// @Param input body orderTransitionRequest false "Optional reason"
+// @Success 200 {object} Response{data=orderResponse} "Order ready to serve"
+// @Failure 404 {object} Response "Order not found"
+// @Failure 409 {object} Response "Invalid order status transition"
+// @Security Bearer
+// @Router /orders/{id}/ready [post]
+func (ctrl *OrderController) Ready(c *gin.Context) {
+	ctrl.transition(c, domain.OrderActionReady, "Order ready to serve")
+}
+
+// @Summary Serve Order
+// @Description Mark a ready order as 'Served'
+// @Tags Orders
+// @Accept json
+// @Produce json
+// @Param id path int true "Order ID"
+// @Param input body orderTransitionRequest false "Optional reason"
+// @Success 200 {object} Response{data=orderResponse} "Order served"
+// @Failure 404 {object} Response "Order not found"
+// @Failure 409 {object} Response "Invalid order status transition"
+// @Security Bearer
+// @Router /orders/{id}/serve [post]
+func (ctrl *OrderController) Serve(c *gin.Context) {
+	ctrl.transition(c, domain.OrderActionServe, "Order served")
+}
+
+// @Summary Pay Order
+// @Description Complete the payment of an order that is 'In Process' and release its table. A tip is recorded on the payment apart from the amount due. The receipt is emailed when receipt_email is given.
+// @Tags Orders
+// @Accept json
+// @Produce json
+// @Param id path int true "Order ID"
+// @Param input body orderTransitionRequest true "Payment method and optional tip"
+// @Success 200 {object} Response{data=orderResponse} "Order paid"
+// @Failure 400 {object} Response "Payment method missing or unavailable"
+// @Failure 404 {object} Response "Order not found"
+// @Failure 409 {object} Response "Invalid order status transition"
+// @Security Bearer
+// @Router /orders/{id}/pay [post]
+func (ctrl *OrderController) Pay(c *gin.Context) {
+	ctrl.transition(c, domain.OrderActionPay, "Order paid")
+}
+
+// @Summary Cancel Order
+// @Description Cancel an order that is 'In Process', restoring stock and releasing its table
+// @Tags Orders
+// @Accept json
+// @Produce json
+// @Param id path int true "Order ID"
+// @Param input body orderTransitionRequest true "Cancellation reason"
+// @Success 200 {object} Response{data=orderResponse} "Order cancelled"
+// @Failure 400 {object} Response "Reason missing"
+// @Failure 404 {object} Response "Order not found"
+// @Failure 409 {object} Response "Invalid order status transition"
+// @Security Bearer
+// @Router /orders/{id}/cancel [post]
+func (ctrl *OrderController) Cancel(c *gin.Context) {
+	ctrl.transition(c, domain.OrderActionCancel, "Order cancelled")
+}
+
+func (ctrl *OrderController) transition(c *gin.Context, action domain.OrderAction, message string) {
+	id, err := helper.Uint(c.Param("id"))
+	if err != nil {
+		BadResponse(c, "invalid order ID", http.StatusBadRequest)
+		return
+	}
+
+	var request orderTransitionRequest
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&request); err != nil {
+			BadResponse(c, "invalid request body", http.StatusBadRequest)
+			return
+		}
+	}
+
+	input := domain.OrderTransitionInput{
+		Reason:          request.Reason,
+		PaymentMethodID: request.PaymentMethodID,
+		Tip:             request.Tip,
+		UserID:          currentUserID(c),
+	}
+
+	order, err := ctrl.service.Transition(id, action, input)
+	if err != nil {
+		switch {
+		case err.Error() == "order not found":
+			BadResponse(c, err.Error(), http.StatusNotFound)
+		case errors.Is(err, domain.ErrOrderTransitionGuard):
+			BadResponse(c, err.Error(), http.StatusBadRequest)
+		case errors.Is(err, domain.ErrInvalidOrderTransition), errors.Is(err, domain.ErrVersionConflict):
+			BadResponse(c, err.Error(), http.StatusConflict)
+		default:
+			BadResponse(c, err.Error(), http.StatusInternalServerError)
+		}
+		return
+	}
+
+	if action == domain.OrderActionPay && request.ReceiptEmail != "" {
+		// the order stays paid when the email fails, the receipt can be sent again later
+		if err := ctrl.receipt.Email(id, request.ReceiptEmail); err != nil {
+			ctrl.logger.Error("Failed to email receipt", zap.Uint("order_id", id), zap.Error(err))
+			message += ", but the receipt email could not be sent"
+		}
+	}
+
+	var response domain.OrderDetail
+	if err := ctrl.service.FindByIDOrderDetail(&response, c.Param("id")); err != nil {
+		BadResponse(c, "Order not found", http.StatusNotFound)
+		return
+	}
+
+	c.Header("ETag", helper.ETag(order.Version))
+	GoodResponseWithData(c, message, http.StatusOK, response)
+}
+
+// @Summary Order Status History
+// @Description List every status transition of an order with the user and reason
+// @Tags Orders
+// @Produce json
+// @Param id path int true "Order ID"
+// @Success 200 {object} Response{data=[]domain.OrderStatusHistory} "fetch success"
+// @Failure 400 {object} Response "Invalid order ID"
+// @Failure 500 {object} Response "Internal server error"
+// @Security Bearer
+// @Router /orders/{id}/history [get]
+func (ctrl *OrderController) History(c *gin.Context) {
+	id, err := helper.Uint(c.Param("id"))
+	if err != nil {
+		BadResponse(c, "invalid order ID", http.StatusBadRequest)
+		return
+	}
+
+	histories, err := ctrl.service.History(id)
+	if err != nil {
+		BadResponse(c, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	GoodResponseWithData(c, "fetch success", http.StatusOK, histories)
+}
+
 type orderResponse struct {
 	OrderID           int                 `json:"order_id" example:"1"`
 	Name              string              `json:"name" example:"John Doe"`
@@ -210,6 +455,7 @@ type orderItemResponse struct {
 // @Param name query string false "Filter by customer name"
 // @Param code_order query string false "Filter by order code"
 // @Param status query string false "Filter by order status"
+// @Param type query string false "Filter by order type: dine_in, takeaway, delivery or pickup"
 // @Success 200 {object} domain.DataPage{data=[]orderResponse} "fetch success"
 // @Failure 404 {object} Response "Orders not found"
 // @Failure 500 {object} Response "Internal server error"
@@ -221,8 +467,9 @@ func (ctrl *OrderController) AllOrders(c *gin.Context) {
 	name := c.Query("name")
 	codeOrder := c.Query("code_order")
 	status := domain.StatusPayment(c.Query("status_payment"))
+	orderType := domain.OrderType(c.Query("type"))
 
-	orders, totalItems, err := ctrl.service.AllOrders(int(page), int(limit), name, codeOrder, status)
+	orders, totalItems, err := ctrl.service.AllOrders(int(page), int(limit), name, codeOrder, status, orderType)
 	if err != nil {
 		if err.Error() == "orders not found" {
 			BadResponse(c, err.Error(), http.StatusNotFound)
@@ -0,0 +1,232 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"project/domain"
+	"project/helper"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+type mergeOrdersRequest struct {
+	OrderIDs []uint `json:"order_ids" binding:"required,min=1" example:"12,13"`
+}
+
+type moveItemsRequest struct {
+	// TargetOrderID is the open order receiving the items, a new order is split off when empty
+	TargetOrderID *uint                  `json:"target_order_id" example:"12"`
+	Items         []domain.OrderItemMove `json:"items" binding:"required,min=1,dive"`
+}
+
+type transferTableRequest struct {
+	TableID uint `json:"table_id" binding:"required" example:"4"`
+}
+
+type joinTablesRequest struct {
+	TableIDs []uint `json:"table_ids" binding:"required,min=1" example:"5,6"`
+}
+
+// @Summary Merge Orders
+// @Description Merge open orders into this one. Their items, discounts and tables move over and the merged orders are cancelled.
+// @Tags Orders
+// @Accept json
+// @Produce json
+// @Param id path int true "Order ID receiving the other orders"
+// @Param input body mergeOrdersRequest true "Orders to merge"
+// @Success 200 {object} Response{data=domain.OrderDetail} "orders merged"
+// @Failure 400 {object} Response "Invalid request"
+// @Failure 404 {object} Response "Order not found"
+// @Failure 409 {object} Response "Order is not in process"
+// @Security Bearer
+// @Router /orders/{id}/merge [post]
+func (ctrl *OrderController) Merge(c *gin.Context) {
+	id, err := helper.Uint(c.Param("id"))
+	if err != nil {
+		BadResponse(c, "invalid order ID", http.StatusBadRequest)
+		return
+	}
+
+	var request mergeOrdersRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		BadResponse(c, helper.FormatValidationError(err), http.StatusBadRequest)
+		return
+	}
+
+	order, err := ctrl.service.Merge(id, request.OrderIDs, currentUserID(c))
+	if err != nil {
+		orderMoveError(c, err)
+		return
+	}
+
+	ctrl.movedOrder(c, order, "orders merged", http.StatusOK)
+}
+
+// @Summary Move Order Items
+// @Description Move items, or part of their quantity, to another open order. Without a target order the items are split off to a new order at the same table.
+// @Tags Orders
+// @Accept json
+// @Produce json
+// @Param id path int true "Order ID the items come from"
+// @Param input body moveItemsRequest true "Items to move"
+// @Success 200 {object} Response{data=domain.OrderDetail} "items moved, with the order receiving them"
+// @Success 201 {object} Response{data=domain.OrderDetail} "order split"
+// @Failure 400 {object} Response "Invalid request"
+// @Failure 404 {object} Response "Order or item not found"
+// @Failure 409 {object} Response "Order is not in process or the bill would go below what points and gift cards paid"
+// @Security Bearer
+// @Router /orders/{id}/items/move [post]
+func (ctrl *OrderController) MoveItems(c *gin.Context) {
+	id, err := helper.Uint(c.Param("id"))
+	if err != nil {
+		BadResponse(c, "invalid order ID", http.StatusBadRequest)
+		return
+	}
+
+	var request moveItemsRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		BadResponse(c, helper.FormatValidationError(err), http.StatusBadRequest)
+		return
+	}
+
+	order, err := ctrl.service.MoveItems(id, request.TargetOrderID, request.Items, currentUserID(c))
+	if err != nil {
+		orderMoveError(c, err)
+		return
+	}
+
+	if request.TargetOrderID == nil {
+		ctrl.movedOrder(c, order, "order split", http.StatusCreated)
+		return
+	}
+	ctrl.movedOrder(c, order, "items moved", http.StatusOK)
+}
+
+// @Summary Transfer Order
+// @Description Move an open dine-in order to another available table, freeing the table it leaves
+// @Tags Orders
+// @Accept json
+// @Produce json
+// @Param id path int true "Order ID"
+// @Param input body transferTableRequest true "New table"
+// @Success 200 {object} Response{data=domain.OrderDetail} "order transferred"
+// @Failure 400 {object} Response "Invalid request"
+// @Failure 404 {object} Response "Order or table not found"
+// @Failure 409 {object} Response "Order is not in process or table not available"
+// @Security Bearer
+// @Router /orders/{id}/transfer [post]
+func (ctrl *OrderController) Transfer(c *gin.Context) {
+	id, err := helper.Uint(c.Param("id"))
+	if err != nil {
+		BadResponse(c, "invalid order ID", http.StatusBadRequest)
+		return
+	}
+
+	var request transferTableRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		BadResponse(c, helper.FormatValidationError(err), http.StatusBadRequest)
+		return
+	}
+
+	order, err := ctrl.service.Transfer(id, request.TableID, currentUserID(c))
+	if err != nil {
+		orderMoveError(c, err)
+		return
+	}
+
+	ctrl.movedOrder(c, order, "order transferred", http.StatusOK)
+}
+
+// @Summary Join Tables
+// @Description Seat a large party at more tables under the same bill. The tables must be available and are released with the order.
+// @Tags Orders
+// @Accept json
+// @Produce json
+// @Param id path int true "Order ID"
+// @Param input body joinTablesRequest true "Tables to join"
+// @Success 200 {object} Response{data=domain.OrderDetail} "tables joined"
+// @Failure 400 {object} Response "Invalid request"
+// @Failure 404 {object} Response "Order or table not found"
+// @Failure 409 {object} Response "Order is not in process or table not available"
+// @Security Bearer
+// @Router /orders/{id}/tables [post]
+func (ctrl *OrderController) JoinTables(c *gin.Context) {
+	id, err := helper.Uint(c.Param("id"))
+	if err != nil {
+		BadResponse(c, "invalid order ID", http.StatusBadRequest)
+		return
+	}
+
+	var request joinTablesRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		BadResponse(c, helper.FormatValidationError(err), http.StatusBadRequest)
+		return
+	}
+
+	order, err := ctrl.service.JoinTables(id, request.TableIDs, currentUserID(c))
+	if err != nil {
+		orderMoveError(c, err)
+		return
+	}
+
+	ctrl.movedOrder(c, order, "tables joined", http.StatusOK)
+}
+
+// @Summary Leave Table
+// @Description Take a joined table off an open order and make it available again
+// @Tags Orders
+// @Produce json
+// @Param id path int true "Order ID"
+// @Param table_id path int true "Joined table ID"
+// @Success 200 {object} Response{data=domain.OrderDetail} "table left"
+// @Failure 400 {object} Response "Table is not joined to the order"
+// @Failure 404 {object} Response "Order not found"
+// @Failure 409 {object} Response "Order is not in process"
+// @Security Bearer
+// @Router /orders/{id}/tables/{table_id} [delete]
+func (ctrl *OrderController) LeaveTable(c *gin.Context) {
+	id, err := helper.Uint(c.Param("id"))
+	if err != nil {
+		BadResponse(c, "invalid order ID", http.StatusBadRequest)
+		return
+	}
+	tableID, err := helper.Uint(c.Param("table_id"))
+	if err != nil {
+		BadResponse(c, "invalid table ID", http.StatusBadRequest)
+		return
+	}
+
+	order, err := ctrl.service.LeaveTable(id, tableID, currentUserID(c))
+	if err != nil {
+		orderMoveError(c, err)
+		return
+	}
+
+	ctrl.movedOrder(c, order, "table left", http.StatusOK)
+}
+
+// movedOrder answers with the details of the order after a move and its new ETag
+func (ctrl *OrderController) movedOrder(c *gin.Context, order *domain.Order, message string, status int) {
+	var response domain.OrderDetail
+	if err := ctrl.service.FindByIDOrderDetail(&response, strconv.FormatUint(uint64(order.ID), 10)); err != nil {
+		BadResponse(c, "Order not found", http.StatusNotFound)
+		return
+	}
+
+	c.Header("ETag", helper.ETag(order.Version))
+	GoodResponseWithData(c, message, status, response)
+}
+
+func orderMoveError(c *gin.Context, err error) {
+	switch {
+	case err.Error() == "order not found", err.Error() == "order item not found", errors.Is(err, domain.ErrTableNotFound):
+		BadResponse(c, err.Error(), http.StatusNotFound)
+	case errors.Is(err, domain.ErrInvalidOrderMove), errors.Is(err, domain.ErrInvalidOrder):
+		BadResponse(c, err.Error(), http.StatusBadRequest)
+	case errors.Is(err, domain.ErrOrderMoveNotAllowed), errors.Is(err, domain.ErrTableUnavailable), errors.Is(err, domain.ErrBillBelowTenders):
+		BadResponse(c, err.Error(), http.StatusConflict)
+	default:
+		BadResponse(c, err.Error(), http.StatusInternalServerError)
+	}
+}
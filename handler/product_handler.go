@@ -1,6 +1,7 @@
 package handler
 
 import (
+	"errors"
 	"fmt"
 	"net/http"
 	"project/domain"
@@ -97,6 +98,8 @@ func (ctrl *ProductController) Add(c *gin.Context) {
 		Price        float64 `json:"price" binding:"required"`
 		Status       string  `json:"status" binding:"required"`
 		Image        string  `json:"image"`
+		Description  string  `json:"description"`
+		Allergens    string  `json:"allergens"`
 	}
 
 	// Bind input JSON
@@ -114,6 +117,8 @@ func (ctrl *ProductController) Add(c *gin.Context) {
 		Price:       input.Price,
 		Status:      input.Status,
 		Image:       input.Image,
+		Description: input.Description,
+		Allergens:   input.Allergens,
 	}
 
 	// Panggil service untuk menambahkan inventory
@@ -131,17 +136,45 @@ func (ctrl *ProductController) Add(c *gin.Context) {
 	GoodResponseWithData(c, "inventory created successfully", http.StatusCreated, nil)
 }
 
+// @Summary Get inventory by ID
+// @Description Get a product by ID, with its version in the ETag header for If-Match on later updates
+// @Tags Inventory
+// @Produce json
+// @Param id path int true "Inventory ID"
+// @Success 200 {object} Response{data=domain.Product} "fetch success"
+// @Failure 400 {object} Response "Invalid product ID"
+// @Failure 404 {object} Response "Inventory not found"
+// @Router /inventory/{id} [get]
+func (ctrl *ProductController) GetByID(c *gin.Context) {
+	productID, err := helper.Uint(c.Param("id"))
+	if err != nil {
+		BadResponse(c, "Invalid product ID", http.StatusBadRequest)
+		return
+	}
+
+	product, err := ctrl.service.GetByID(productID)
+	if err != nil {
+		BadResponse(c, err.Error(), http.StatusNotFound)
+		return
+	}
+
+	c.Header("ETag", helper.ETag(product.Version))
+	GoodResponseWithData(c, "fetch success", http.StatusOK, product)
+}
+
 // @Summary Update Inventory
 // @Description Update inventory details by ID
 // @Tags Inventory
 // @Accept  json
 // @Produce json
 // @Param id path int true "Inventory ID"
+// @Param If-Match header string false "ETag of the product version being updated"
 // @Param inventory body domain.Product true "Inventory data"
 // @Param category_name query string false "Category name to update category ID"
 // @Success 200 {object} domain.Product "Inventory updated successfully"
 // @Failure 404 {object} Response "Inventory not found"
 // @Failure 400 {object} Response "Bad request"
+// @Failure 409 {object} Response{data=domain.Product} "Product has been modified by another request"
 // @Failure 500 {object} Response "Internal server error"
 // @Router /inventory/{id} [put]
 func (ctrl *ProductController) Update(c *gin.Context) {
@@ -163,13 +196,32 @@ func (ctrl *ProductController) Update(c *gin.Context) {
 		return
 	}
 
+	version, ok, err := ifMatchVersion(c)
+	if err != nil {
+		BadResponse(c, "invalid If-Match header", http.StatusBadRequest)
+		return
+	}
+	if ok {
+		product.Version = version
+	}
+
 	// Panggil service untuk update product
 	_, err = ctrl.service.Update(productID, &product, categoryName)
+	if errors.Is(err, domain.ErrVersionConflict) {
+		current, err := ctrl.service.GetByID(productID)
+		if err != nil {
+			BadResponse(c, domain.ErrVersionConflict.Error(), http.StatusConflict)
+			return
+		}
+		ConflictResponse(c, current.Version, current)
+		return
+	}
 	if err != nil {
 		BadResponse(c, err.Error(), http.StatusInternalServerError)
 		return
 	}
 
+	c.Header("ETag", helper.ETag(product.Version))
 	GoodResponseWithData(c, "product updated successfully", http.StatusOK, nil)
 }
 
@@ -203,3 +255,47 @@ func (ctrl *ProductController) Delete(c *gin.Context) {
 
 	GoodResponseWithData(c, "product soft deleted successfully", http.StatusOK, nil)
 }
+
+// @Summary Set Product Modifiers
+// @Description Replace the options offered with a product on the public menu, like sizes or extra toppings with their extra price, in the order they are shown. An empty list removes them.
+// @Tags Inventory
+// @Accept json
+// @Produce json
+// @Param id path int true "Product ID"
+// @Param input body domain.ModifiersRequest true "Modifiers"
+// @Success 200 {object} Response{data=[]domain.ProductModifier} "modifiers updated"
+// @Failure 400 {object} Response "Invalid input or a name listed twice in a group"
+// @Failure 404 {object} Response "Product not found"
+// @Security Bearer
+// @Router /inventory/{id}/modifiers [put]
+func (ctrl *ProductController) SetModifiers(c *gin.Context) {
+	id, err := helper.Uint(c.Param("id"))
+	if err != nil {
+		BadResponse(c, "invalid product ID", http.StatusBadRequest)
+		return
+	}
+
+	var request domain.ModifiersRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		BadResponse(c, helper.FormatValidationError(err), http.StatusBadRequest)
+		return
+	}
+
+	if request.Modifiers == nil {
+		request.Modifiers = []domain.ProductModifier{}
+	}
+	modifiers, err := ctrl.service.SetModifiers(id, request.Modifiers)
+	if err != nil {
+		switch {
+		case errors.Is(err, domain.ErrInvalidModifier):
+			BadResponse(c, err.Error(), http.StatusBadRequest)
+		case err.Error() == "product not found":
+			BadResponse(c, err.Error(), http.StatusNotFound)
+		default:
+			BadResponse(c, err.Error(), http.StatusInternalServerError)
+		}
+		return
+	}
+
+	GoodResponseWithData(c, "modifiers updated", http.StatusOK, modifiers)
+}
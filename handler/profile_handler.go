@@ -89,3 +89,42 @@ func (ctrl *ProfileController) Update(c *gin.Context) {
 
 	GoodResponseWithData(c, "user updated", http.StatusOK, nil)
 }
+
+type pinRequest struct {
+	Pin string `json:"pin" binding:"required,numeric,min=4,max=8" example:"1234"`
+}
+
+// UpdatePin endpoint
+// @Summary Update Approval PIN
+// @Description set the PIN an admin uses to approve voids and refunds
+// @Tags Profile
+// @Accept json
+// @Produce  json
+// @Param input body pinRequest true "new PIN, 4 to 8 digits"
+// @Success 200 {object} Response "pin updated"
+// @Failure 401 {object} Response "invalid authorization header"
+// @Failure 422 {object} Response "Invalid input"
+// @Router  /profile/pin [put]
+// @Security Bearer
+func (ctrl *ProfileController) UpdatePin(c *gin.Context) {
+	userID, err := helper.Uint(c.GetString("user-id"))
+	if err != nil {
+		ctrl.logger.Error("Unable to retrieve user ID", zap.Error(err))
+		BadResponse(c, err.Error(), http.StatusUnauthorized)
+		return
+	}
+
+	var request pinRequest
+	if err = c.ShouldBindJSON(&request); err != nil {
+		BadResponse(c, helper.FormatValidationError(err), http.StatusUnprocessableEntity)
+		return
+	}
+
+	if err = ctrl.service.User.UpdatePin(userID, request.Pin); err != nil {
+		ctrl.logger.Error("Unable to update pin", zap.Error(err))
+		BadResponse(c, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	GoodResponseWithData(c, "pin updated", http.StatusOK, nil)
+}
@@ -0,0 +1,171 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"project/domain"
+	"project/helper"
+	"project/service"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+type ReceiptController struct {
+	service service.ReceiptService
+	logger  *zap.Logger
+}
+
+func NewReceiptController(service service.ReceiptService, logger *zap.Logger) *ReceiptController {
+	return &ReceiptController{service: service, logger: logger}
+}
+
+// @Summary Order Receipt
+// @Description Preview the customer receipt of an order. Previews are not counted as prints, use the print endpoint for the printer.
+// @Tags Orders
+// @Produce application/octet-stream,application/pdf,text/html
+// @Param id path int true "Order ID"
+// @Param format query string false "escpos, pdf or html (default html)"
+// @Success 200 {file} file "receipt"
+// @Header 200 {integer} X-Receipt-Print "number of times the receipt was printed"
+// @Failure 400 {object} Response "Invalid order ID or format"
+// @Failure 404 {object} Response "Order not found"
+// @Security Bearer
+// @Router /orders/{id}/receipt [get]
+func (ctrl *ReceiptController) Receipt(c *gin.Context) {
+	ctrl.receipt(c, string(domain.ReceiptHTML), ctrl.service.Receipt)
+}
+
+// @Summary Print Order Receipt
+// @Description Render the customer receipt of an order for the printer and count it as a print, later prints are marked as reprints
+// @Tags Orders
+// @Produce application/octet-stream,application/pdf,text/html
+// @Param id path int true "Order ID"
+// @Param format query string false "escpos, pdf or html (default escpos)"
+// @Success 200 {file} file "receipt"
+// @Header 200 {integer} X-Receipt-Print "number of times the receipt was printed, this print included"
+// @Failure 400 {object} Response "Invalid order ID or format"
+// @Failure 404 {object} Response "Order not found"
+// @Security Bearer
+// @Router /orders/{id}/receipt/print [post]
+func (ctrl *ReceiptController) Print(c *gin.Context) {
+	ctrl.receipt(c, string(domain.ReceiptESCPOS), ctrl.service.Print)
+}
+
+func (ctrl *ReceiptController) receipt(c *gin.Context, defaultFormat string, render func(uint, domain.ReceiptFormat) ([]byte, string, int, error)) {
+	id, err := helper.Uint(c.Param("id"))
+	if err != nil {
+		BadResponse(c, "invalid order ID", http.StatusBadRequest)
+		return
+	}
+
+	format := domain.ReceiptFormat(c.DefaultQuery("format", defaultFormat))
+	content, contentType, print, err := render(id, format)
+	if err != nil {
+		receiptError(c, err)
+		return
+	}
+
+	c.Header("X-Receipt-Print", strconv.Itoa(print))
+	c.Data(http.StatusOK, contentType, content)
+}
+
+// @Summary Kitchen Tickets
+// @Description Render one ticket per preparation station for the items of an order
+// @Tags Orders
+// @Produce application/octet-stream,application/pdf,text/html
+// @Param id path int true "Order ID"
+// @Param station query string false "Only the ticket of this station, e.g. kitchen or bar"
+// @Param format query string false "escpos, pdf or html (default html)"
+// @Success 200 {file} file "kitchen tickets"
+// @Failure 400 {object} Response "Invalid order ID or format"
+// @Failure 404 {object} Response "Order not found"
+// @Security Bearer
+// @Router /orders/{id}/kitchen-tickets [get]
+func (ctrl *ReceiptController) KitchenTickets(c *gin.Context) {
+	id, err := helper.Uint(c.Param("id"))
+	if err != nil {
+		BadResponse(c, "invalid order ID", http.StatusBadRequest)
+		return
+	}
+
+	format := domain.ReceiptFormat(c.DefaultQuery("format", string(domain.ReceiptHTML)))
+	content, contentType, err := ctrl.service.KitchenTickets(id, c.Query("station"), format)
+	if err != nil {
+		receiptError(c, err)
+		return
+	}
+
+	c.Data(http.StatusOK, contentType, content)
+}
+
+type receiptEmailRequest struct {
+	Email string `json:"email" binding:"required,email" example:"guest@example.com"`
+}
+
+// @Summary Email Order Receipt
+// @Description Send the receipt of an order to a customer with a public link that needs no login
+// @Tags Orders
+// @Accept json
+// @Produce json
+// @Param id path int true "Order ID"
+// @Param input body receiptEmailRequest true "Customer email"
+// @Success 200 {object} Response "receipt sent"
+// @Failure 400 {object} Response "Invalid order ID or email"
+// @Failure 404 {object} Response "Order not found"
+// @Failure 500 {object} Response "Failed to send email"
+// @Security Bearer
+// @Router /orders/{id}/receipt/email [post]
+func (ctrl *ReceiptController) Email(c *gin.Context) {
+	id, err := helper.Uint(c.Param("id"))
+	if err != nil {
+		BadResponse(c, "invalid order ID", http.StatusBadRequest)
+		return
+	}
+
+	var request receiptEmailRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		BadResponse(c, helper.FormatValidationError(err), http.StatusBadRequest)
+		return
+	}
+
+	if err := ctrl.service.Email(id, request.Email); err != nil {
+		receiptError(c, err)
+		return
+	}
+
+	GoodResponseWithData(c, "receipt sent", http.StatusOK, nil)
+}
+
+// @Summary Public Receipt
+// @Description View a receipt through the signed link sent to the customer, no login needed
+// @Tags Receipts
+// @Produce text/html
+// @Param token path string true "Signed receipt token"
+// @Success 200 {file} file "receipt"
+// @Failure 404 {object} Response "Invalid link or order not found"
+// @Failure 410 {object} Response "Link expired"
+// @Router /receipts/{token} [get]
+func (ctrl *ReceiptController) Public(c *gin.Context) {
+	content, err := ctrl.service.Public(c.Param("token"))
+	if err != nil {
+		receiptError(c, err)
+		return
+	}
+
+	c.Data(http.StatusOK, "text/html; charset=utf-8", content)
+}
+
+func receiptError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, service.ErrInvalidReceiptFormat):
+		BadResponse(c, err.Error(), http.StatusBadRequest)
+	case errors.Is(err, domain.ErrInvalidReceiptToken), err.Error() == "order not found":
+		BadResponse(c, err.Error(), http.StatusNotFound)
+	case errors.Is(err, domain.ErrReceiptTokenExpired):
+		BadResponse(c, err.Error(), http.StatusGone)
+	default:
+		BadResponse(c, err.Error(), http.StatusInternalServerError)
+	}
+}
@@ -0,0 +1,232 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"project/domain"
+	"project/helper"
+	"project/service"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+type RefundController struct {
+	service service.RefundService
+	logger  *zap.Logger
+}
+
+func NewRefundController(service service.RefundService, logger *zap.Logger) *RefundController {
+	return &RefundController{service: service, logger: logger}
+}
+
+type voidRequest struct {
+	Quantity    int               `json:"quantity" binding:"required,gt=0" example:"1"`
+	ReasonCode  domain.ReasonCode `json:"reason_code" binding:"required" example:"wrong_item"`
+	Note        string            `json:"note" example:"entered the wrong size"`
+	ReturnStock *bool             `json:"return_stock" example:"true"`
+	domain.ManagerApproval
+}
+
+type refundRequest struct {
+	Items           []domain.RefundItem `json:"items" binding:"dive"`
+	Amount          float64             `json:"amount" binding:"gte=0" example:"0"`
+	PaymentMethodID *uint               `json:"payment_method_id" example:"1"`
+	ReasonCode      domain.ReasonCode   `json:"reason_code" binding:"required" example:"quality_issue"`
+	Note            string              `json:"note" example:"steak was overcooked"`
+	ReturnStock     bool                `json:"return_stock" example:"false"`
+	domain.ManagerApproval
+}
+
+type adjustmentsResponse struct {
+	Voids   []domain.OrderVoid `json:"voids"`
+	Refunds []domain.Refund    `json:"refunds"`
+}
+
+// @Summary Void and Refund Reason Codes
+// @Description List the reason codes accepted by void and refund requests
+// @Tags Orders
+// @Produce json
+// @Success 200 {object} Response{data=map[string]string} "fetch success"
+// @Security Bearer
+// @Router /reason-codes [get]
+func (ctrl *RefundController) ReasonCodes(c *gin.Context) {
+	GoodResponseWithData(c, "fetch success", http.StatusOK, ctrl.service.ReasonCodes())
+}
+
+// @Summary Void Order Item
+// @Description Remove an item, fully or partially, from an order that is not paid yet. Amounts above the approval limit need a manager id and PIN.
+// @Tags Orders
+// @Accept json
+// @Produce json
+// @Param id path int true "Order ID"
+// @Param item_id path int true "Order item ID"
+// @Param input body voidRequest true "void details"
+// @Success 200 {object} Response{data=domain.OrderVoid} "item voided"
+// @Failure 400 {object} Response "Invalid request or reason code"
+// @Failure 403 {object} Response "Manager approval required or invalid"
+// @Failure 429 {object} Response "Manager pin locked after too many wrong attempts"
+// @Failure 404 {object} Response "Order or item not found"
+// @Failure 409 {object} Response "Order can no longer be voided or the bill would go below what points and gift cards paid"
+// @Security Bearer
+// @Router /orders/{id}/items/{item_id}/void [post]
+func (ctrl *RefundController) Void(c *gin.Context) {
+	id, err := helper.Uint(c.Param("id"))
+	if err != nil {
+		BadResponse(c, "invalid order ID", http.StatusBadRequest)
+		return
+	}
+	itemID, err := helper.Uint(c.Param("item_id"))
+	if err != nil {
+		BadResponse(c, "invalid order item ID", http.StatusBadRequest)
+		return
+	}
+
+	var request voidRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		BadResponse(c, helper.FormatValidationError(err), http.StatusBadRequest)
+		return
+	}
+
+	input := domain.VoidInput{
+		OrderID:     id,
+		OrderItemID: itemID,
+		Quantity:    request.Quantity,
+		ReasonCode:  request.ReasonCode,
+		Note:        request.Note,
+		ReturnStock: request.ReturnStock == nil || *request.ReturnStock,
+		UserID:      currentUserID(c),
+		Approval:    request.ManagerApproval,
+	}
+
+	void, err := ctrl.service.Void(input)
+	if err != nil {
+		adjustmentError(c, err)
+		return
+	}
+
+	GoodResponseWithData(c, "item voided", http.StatusOK, void)
+}
+
+// @Summary Refund Order
+// @Description Refund a completed order, fully (no items and no amount), per item or by amount. Amounts above the approval limit need a manager id and PIN.
+// @Tags Orders
+// @Accept json
+// @Produce json
+// @Param id path int true "Order ID"
+// @Param input body refundRequest true "refund details"
+// @Success 201 {object} Response{data=domain.Refund} "order refunded"
+// @Failure 400 {object} Response "Invalid request or reason code"
+// @Failure 403 {object} Response "Manager approval required or invalid"
+// @Failure 429 {object} Response "Manager pin locked after too many wrong attempts"
+// @Failure 404 {object} Response "Order or item not found"
+// @Failure 409 {object} Response "Order cannot be refunded or amount exceeds what was paid"
+// @Security Bearer
+// @Router /orders/{id}/refunds [post]
+func (ctrl *RefundController) Refund(c *gin.Context) {
+	id, err := helper.Uint(c.Param("id"))
+	if err != nil {
+		BadResponse(c, "invalid order ID", http.StatusBadRequest)
+		return
+	}
+
+	var request refundRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		BadResponse(c, helper.FormatValidationError(err), http.StatusBadRequest)
+		return
+	}
+
+	input := domain.RefundInput{
+		OrderID:         id,
+		Items:           request.Items,
+		Amount:          request.Amount,
+		PaymentMethodID: request.PaymentMethodID,
+		ReasonCode:      request.ReasonCode,
+		Note:            request.Note,
+		ReturnStock:     request.ReturnStock,
+		UserID:          currentUserID(c),
+		Approval:        request.ManagerApproval,
+	}
+
+	refund, err := ctrl.service.Refund(input)
+	if err != nil {
+		adjustmentError(c, err)
+		return
+	}
+
+	GoodResponseWithData(c, "order refunded", http.StatusCreated, refund)
+}
+
+// @Summary Order Voids
+// @Description List the item voids of an order
+// @Tags Orders
+// @Produce json
+// @Param id path int true "Order ID"
+// @Success 200 {object} Response{data=[]domain.OrderVoid} "fetch success"
+// @Failure 400 {object} Response "Invalid order ID"
+// @Security Bearer
+// @Router /orders/{id}/voids [get]
+func (ctrl *RefundController) Voids(c *gin.Context) {
+	id, err := helper.Uint(c.Param("id"))
+	if err != nil {
+		BadResponse(c, "invalid order ID", http.StatusBadRequest)
+		return
+	}
+
+	voids, err := ctrl.service.Voids(id)
+	if err != nil {
+		BadResponse(c, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	GoodResponseWithData(c, "fetch success", http.StatusOK, voids)
+}
+
+// @Summary Order Refunds
+// @Description List the voids and refunds of an order
+// @Tags Orders
+// @Produce json
+// @Param id path int true "Order ID"
+// @Success 200 {object} Response{data=adjustmentsResponse} "fetch success"
+// @Failure 400 {object} Response "Invalid order ID"
+// @Security Bearer
+// @Router /orders/{id}/refunds [get]
+func (ctrl *RefundController) Refunds(c *gin.Context) {
+	id, err := helper.Uint(c.Param("id"))
+	if err != nil {
+		BadResponse(c, "invalid order ID", http.StatusBadRequest)
+		return
+	}
+
+	voids, err := ctrl.service.Voids(id)
+	if err != nil {
+		BadResponse(c, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	refunds, err := ctrl.service.Refunds(id)
+	if err != nil {
+		BadResponse(c, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	GoodResponseWithData(c, "fetch success", http.StatusOK, adjustmentsResponse{Voids: voids, Refunds: refunds})
+}
+
+func adjustmentError(c *gin.Context, err error) {
+	switch {
+	case err.Error() == "order not found", err.Error() == "order item not found":
+		BadResponse(c, err.Error(), http.StatusNotFound)
+	case errors.Is(err, domain.ErrManagerApprovalRequired), errors.Is(err, domain.ErrManagerApprovalInvalid):
+		BadResponse(c, err.Error(), http.StatusForbidden)
+	case errors.Is(err, domain.ErrManagerPinLocked):
+		BadResponse(c, err.Error(), http.StatusTooManyRequests)
+	case errors.Is(err, domain.ErrVoidNotAllowed), errors.Is(err, domain.ErrRefundNotAllowed),
+		errors.Is(err, domain.ErrRefundExceedsPaid), errors.Is(err, domain.ErrVersionConflict), errors.Is(err, domain.ErrBillBelowTenders):
+		BadResponse(c, err.Error(), http.StatusConflict)
+	case errors.Is(err, domain.ErrInvalidReasonCode), errors.Is(err, domain.ErrInvalidAdjustment):
+		BadResponse(c, err.Error(), http.StatusBadRequest)
+	default:
+		BadResponse(c, err.Error(), http.StatusInternalServerError)
+	}
+}
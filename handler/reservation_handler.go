@@ -1,10 +1,14 @@
 package handler
 
 import (
+	"errors"
 	"net/http"
 	"project/domain"
+	"project/helper"
 	"project/service"
 	"strconv"
+	"strings"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"go.uber.org/zap"
@@ -19,46 +23,92 @@ func NewReservationController(service service.ReservationService, logger *zap.Lo
 	return &ReservationController{service: service, logger: logger}
 }
 
-// GetAllReservations endpoint
 // @Summary Get All Reservations
-// @Description Get a list of reservations based on the time filter (today, this week, this month, this year)
+// @Description List reservations page by page, filtered by booking date, status, table, guest name or phone and sorted by a field. start and end take precedence over filter, with neither the current month is listed.
 // @Tags Reservation
-// @Accept  json
-// @Produce  json
-// @Param filter query string false "Time Filter (today, this_week, this_month, this_year)" default(this_month)
-// @Success 200 {object} handler.Response "Successfully fetched reservations"
+// @Produce json
+// @Param page query int false "Page number, default is 1"
+// @Param limit query int false "Number of items per page, default is 10"
+// @Param start query string false "First booking date, YYYY-MM-DD"
+// @Param end query string false "Last booking date, YYYY-MM-DD"
+// @Param filter query string false "Period when start and end are empty: today, this_week, this_month, this_year or all" default(this_month)
+// @Param status query string false "Comma separated statuses, e.g. Confirmed,Seated"
+// @Param table_id query int false "Reservations booking this table, joined tables included"
+// @Param name query string false "Part of the guest name"
+// @Param phone query string false "Part of the phone number"
+// @Param sort_by query string false "date, name, pax, status, table or created_at" default(date)
+// @Param sort query string false "asc or desc" default(asc)
+// @Success 200 {object} domain.DataPage{data=[]domain.AllReservation} "fetch success"
+// @Failure 400 {object} handler.Response "Invalid filter"
 // @Failure 500 {object} handler.Response "Internal Server Error"
-// @Failure 404 {object} handler.Response "No reservations found"
+// @Security Bearer
 // @Router  /reservations [get]
 func (ctrl *ReservationController) All(c *gin.Context) {
-	// Ambil parameter filter dari query string, dengan default "this_month"
-	timeFilter := c.DefaultQuery("filter", "this_month")
+	page, limit := helper.Page(c)
 
-	// Panggil service untuk mengambil daftar reservasi berdasarkan filter
-	reservations, err := ctrl.service.All(timeFilter)
-	if err != nil {
-		// Jika tidak ada reservasi yang ditemukan, kembalikan error dengan status 404
-		if err.Error() == "no reservations found" {
-			BadResponse(c, err.Error(), http.StatusNotFound)
+	filter := domain.ReservationFilter{
+		Page:   int(page),
+		Limit:  int(limit),
+		Name:   c.Query("name"),
+		Phone:  c.Query("phone"),
+		SortBy: c.Query("sort_by"),
+		Desc:   strings.EqualFold(c.Query("sort"), "desc"),
+	}
+
+	var err error
+	now := time.Now()
+	if value := c.Query("start"); value != "" {
+		if filter.Start, err = time.ParseInLocation("2006-01-02", value, now.Location()); err != nil {
+			BadResponse(c, "invalid start date, use YYYY-MM-DD", http.StatusBadRequest)
 			return
 		}
-		// Jika terjadi error lain, kembalikan error 500
-		BadResponse(c, err.Error(), http.StatusInternalServerError)
+	}
+	if value := c.Query("end"); value != "" {
+		if filter.End, err = time.ParseInLocation("2006-01-02", value, now.Location()); err != nil {
+			BadResponse(c, "invalid end date, use YYYY-MM-DD", http.StatusBadRequest)
+			return
+		}
+		filter.End = filter.End.AddDate(0, 0, 1)
+	}
+	if period := c.DefaultQuery("filter", "this_month"); filter.Start.IsZero() && filter.End.IsZero() && period != "all" {
+		if filter.Start, filter.End, err = domain.ReservationPeriod(period, now); err != nil {
+			reservationError(c, err)
+			return
+		}
+	}
+
+	if value := c.Query("status"); value != "" {
+		for _, status := range strings.Split(value, ",") {
+			filter.Statuses = append(filter.Statuses, domain.ReservationStatus(strings.TrimSpace(status)))
+		}
+	}
+	if value := c.Query("table_id"); value != "" {
+		if filter.TableID, err = helper.Uint(value); err != nil {
+			BadResponse(c, "invalid table ID", http.StatusBadRequest)
+			return
+		}
+	}
+
+	reservations, totalItems, err := ctrl.service.All(filter)
+	if err != nil {
+		reservationError(c, err)
 		return
 	}
 
-	// Kirimkan response dengan data reservasi yang ditemukan
-	GoodResponseWithData(c, "fetch success", http.StatusOK, reservations)
+	totalPages := (totalItems + int64(limit) - 1) / int64(limit)
+	GoodResponseWithPage(c, "fetch success", http.StatusOK, int(totalItems), int(totalPages), int(page), int(limit), reservations)
 }
 
 // @Summary Create Reservation
-// @Description Add a new reservation to the system
+// @Description Book a table for a time window, with joined_table_ids for a party larger than one table. The tables must seat the party together and have no other confirmed reservation within the window and turn time.
 // @Tags Reservations
 // @Accept  json
 // @Produce json
 // @Param reservation body domain.Reservation true "Reservation details"
 // @Success 201 {object} handler.Response "Reservation successfully created"
-// @Failure 400 {object} handler.Response "Invalid input"
+// @Failure 400 {object} handler.Response "Invalid input or table too small"
+// @Failure 404 {object} handler.Response "Table not found"
+// @Failure 409 {object} handler.Response "Table already reserved at the selected time"
 // @Failure 500 {object} handler.Response "Internal server error"
 // @Router /reservations/ [post]
 func (ctrl *ReservationController) Add(c *gin.Context) {
@@ -71,7 +121,7 @@ func (ctrl *ReservationController) Add(c *gin.Context) {
 	// Call the service layer to add the reservation
 	err := ctrl.service.Add(&reservationRequest)
 	if err != nil {
-		BadResponse(c, err.Error(), http.StatusInternalServerError)
+		reservationError(c, err)
 		return
 	}
 
@@ -114,20 +164,23 @@ func (ctrl *ReservationController) GetByID(c *gin.Context) {
 	}
 
 	// Kirimkan response dengan data reservasi
+	c.Header("ETag", helper.ETag(reservation.Version))
 	GoodResponseWithData(c, "fetch success", http.StatusOK, reservation)
 }
 
 // UpdateReservation endpoint
 // @Summary Update Reservation
-// @Description Update reservation table number or change status to 'Canceled'
+// @Description Move a booked reservation to another table or change status to 'Canceled'. Other status changes go through the lifecycle endpoints.
 // @Tags Reservation
 // @Accept  json
 // @Produce  json
 // @Param id path int true "Reservation ID"
-// @Param body body domain.Reservation true "Fields to update (table_number or status)"
+// @Param If-Match header string false "ETag of the reservation version being updated"
+// @Param body body domain.Reservation true "Fields to update (table_id or status)"
 // @Success 200 {object} handler.Response "Reservation updated successfully"
 // @Failure 400 {object} handler.Response "Invalid input or validation error"
-// @Failure 404 {object} handler.Response "Reservation not found"
+// @Failure 404 {object} handler.Response "Reservation or table not found"
+// @Failure 409 {object} handler.Response{data=domain.Reservation} "Reservation has been modified by another request or table already reserved"
 // @Failure 500 {object} handler.Response "Internal Server Error"
 // @Router /reservations/{id} [put]
 func (ctrl *ReservationController) Update(c *gin.Context) {
@@ -148,8 +201,8 @@ func (ctrl *ReservationController) Update(c *gin.Context) {
 
 	// Siapkan map untuk fields yang akan diupdate
 	updates := map[string]interface{}{}
-	if request.TableNumber > 0 { // Hanya tambahkan jika table_number tidak 0
-		updates["table_number"] = request.TableNumber
+	if request.TableID > 0 { // Hanya tambahkan jika table_id tidak 0
+		updates["table_id"] = request.TableID
 	}
 	if request.Status != "" { // Hanya tambahkan jika status tidak kosong
 		updates["status"] = request.Status
@@ -157,26 +210,455 @@ func (ctrl *ReservationController) Update(c *gin.Context) {
 
 	// Validasi: Harus ada field yang diupdate
 	if len(updates) == 0 {
-		BadResponse(c, "only table number and status can edit", http.StatusBadRequest)
+		BadResponse(c, "only table and status can edit", http.StatusBadRequest)
 		return
 	}
 
+	// Versi yang diharapkan diambil dari header If-Match atau field version pada body
+	version, ok, err := ifMatchVersion(c)
+	if err != nil {
+		BadResponse(c, "invalid If-Match header", http.StatusBadRequest)
+		return
+	}
+	if !ok {
+		version = request.Version
+	}
+
 	// Panggil service untuk update data
-	err = ctrl.service.Update(uint(reservationID), updates)
+	err = ctrl.service.Update(uint(reservationID), version, currentUserID(c), updates)
+	if errors.Is(err, domain.ErrVersionConflict) {
+		current, err := ctrl.service.GetReservationByID(uint(reservationID))
+		if err != nil {
+			BadResponse(c, domain.ErrVersionConflict.Error(), http.StatusConflict)
+			return
+		}
+		ConflictResponse(c, current.Version, current)
+		return
+	}
 	if err != nil {
-		if err.Error() == "reservation not found" {
-			BadResponse(c, err.Error(), http.StatusNotFound)
+		reservationError(c, err)
+		return
+	}
+
+	// Berhasil update
+	GoodResponseWithData(c, "reservation updated successfully", http.StatusOK, nil)
+}
+
+// @Summary Reservation Availability
+// @Description Search the bookable time slots of a day for a party, with the free tables for each slot. Slots follow the opening hours and keep the turn time between bookings. Tables are combined within a section when no single table seats the party.
+// @Tags Reservation
+// @Produce json
+// @Param date query string true "Day to search, YYYY-MM-DD" example(2024-12-16)
+// @Param pax query int true "Number of guests"
+// @Param duration query int false "Minutes the table is needed, 90 by default"
+// @Param section query string false "Only tables in this section"
+// @Param outdoor query bool false "Only outdoor (true) or indoor (false) tables"
+// @Success 200 {object} handler.Response{data=[]domain.AvailableSlot} "fetch success"
+// @Failure 400 {object} handler.Response "Invalid query"
+// @Failure 500 {object} handler.Response "Internal Server Error"
+// @Security Bearer
+// @Router /reservations/availability [get]
+func (ctrl *ReservationController) Availability(c *gin.Context) {
+	date, err := time.ParseInLocation("2006-01-02", c.Query("date"), time.Local)
+	if err != nil {
+		BadResponse(c, "date must be YYYY-MM-DD", http.StatusBadRequest)
+		return
+	}
+	pax, err := helper.Uint(c.Query("pax"))
+	if err != nil || pax == 0 {
+		BadResponse(c, "invalid pax", http.StatusBadRequest)
+		return
+	}
+	duration, err := strconv.Atoi(c.DefaultQuery("duration", "0"))
+	if err != nil {
+		BadResponse(c, "invalid duration", http.StatusBadRequest)
+		return
+	}
+
+	query := domain.AvailabilityQuery{Date: date, Pax: pax, Duration: duration, Section: c.Query("section")}
+	if outdoor := c.Query("outdoor"); outdoor != "" {
+		value, err := strconv.ParseBool(outdoor)
+		if err != nil {
+			BadResponse(c, "outdoor must be true or false", http.StatusBadRequest)
 			return
 		}
-		if err.Error() == "status can only be updated to 'Canceled'" ||
-			err.Error() == "table number cannot exceed 7" {
-			BadResponse(c, err.Error(), http.StatusBadRequest)
+		query.Outdoor = &value
+	}
+
+	slots, err := ctrl.service.Availability(query)
+	if err != nil {
+		reservationError(c, err)
+		return
+	}
+
+	GoodResponseWithData(c, "fetch success", http.StatusOK, slots)
+}
+
+type reservationTransitionRequest struct {
+	Reason string `json:"reason" example:"guest called to cancel"`
+}
+
+// @Summary Confirm Reservation
+// @Description Confirm a 'Pending' reservation
+// @Tags Reservation
+// @Accept json
+// @Produce json
+// @Param id path int true "Reservation ID"
+// @Param input body reservationTransitionRequest false "Optional reason"
+// @Success 200 {object} handler.Response{data=domain.Reservation} "Reservation confirmed"
+// @Failure 404 {object} handler.Response "Reservation not found"
+// @Failure 409 {object} handler.Response "Invalid reservation status transition"
+// @Security Bearer
+// @Router /reservations/{id}/confirm [post]
+func (ctrl *ReservationController) Confirm(c *gin.Context) {
+	ctrl.transition(c, domain.ReservationActionConfirm, "Reservation confirmed")
+}
+
+// @Summary Remind Reservation
+// @Description Record that the guest of a 'Confirmed' reservation was reminded of the booking
+// @Tags Reservation
+// @Accept json
+// @Produce json
+// @Param id path int true "Reservation ID"
+// @Param input body reservationTransitionRequest false "Optional reason"
+// @Success 200 {object} handler.Response{data=domain.Reservation} "Reservation reminded"
+// @Failure 404 {object} handler.Response "Reservation not found"
+// @Failure 409 {object} handler.Response "Invalid reservation status transition"
+// @Security Bearer
+// @Router /reservations/{id}/remind [post]
+func (ctrl *ReservationController) Remind(c *gin.Context) {
+	ctrl.transition(c, domain.ReservationActionRemind, "Reservation reminded")
+}
+
+// @Summary Guests Arrived
+// @Description Record that the guests of a 'Confirmed' or 'Reminded' reservation arrived and wait to be seated
+// @Tags Reservation
+// @Accept json
+// @Produce json
+// @Param id path int true "Reservation ID"
+// @Param input body reservationTransitionRequest false "Optional reason"
+// @Success 200 {object} handler.Response{data=domain.Reservation} "Guests arrived"
+// @Failure 404 {object} handler.Response "Reservation not found"
+// @Failure 409 {object} handler.Response "Invalid reservation status transition"
+// @Security Bearer
+// @Router /reservations/{id}/arrive [post]
+func (ctrl *ReservationController) Arrive(c *gin.Context) {
+	ctrl.transition(c, domain.ReservationActionArrive, "Guests arrived")
+}
+
+// @Summary Seat Reservation
+// @Description Seat the guests of a reservation: a dine-in order is opened at the booked tables and the deposit is credited to its bill
+// @Tags Reservation
+// @Accept json
+// @Produce json
+// @Param id path int true "Reservation ID"
+// @Param input body reservationTransitionRequest false "Optional reason"
+// @Success 200 {object} handler.Response{data=domain.Reservation} "Reservation seated"
+// @Failure 404 {object} handler.Response "Reservation or table not found"
+// @Failure 409 {object} handler.Response "Invalid reservation status transition or table unavailable"
+// @Security Bearer
+// @Router /reservations/{id}/seat [post]
+func (ctrl *ReservationController) Seat(c *gin.Context) {
+	ctrl.transition(c, domain.ReservationActionSeat, "Reservation seated")
+}
+
+// @Summary Complete Reservation
+// @Description Complete a 'Seated' reservation. Paying the order of the reservation completes it as well.
+// @Tags Reservation
+// @Accept json
+// @Produce json
+// @Param id path int true "Reservation ID"
+// @Param input body reservationTransitionRequest false "Optional reason"
+// @Success 200 {object} handler.Response{data=domain.Reservation} "Reservation completed"
+// @Failure 404 {object} handler.Response "Reservation not found"
+// @Failure 409 {object} handler.Response "Invalid reservation status transition"
+// @Security Bearer
+// @Router /reservations/{id}/complete [post]
+func (ctrl *ReservationController) Complete(c *gin.Context) {
+	ctrl.transition(c, domain.ReservationActionComplete, "Reservation completed")
+}
+
+// @Summary Reservation No-Show
+// @Description Mark a 'Confirmed' or 'Reminded' reservation as a no-show and release its tables. No-shows are counted per guest.
+// @Tags Reservation
+// @Accept json
+// @Produce json
+// @Param id path int true "Reservation ID"
+// @Param input body reservationTransitionRequest false "Optional reason"
+// @Success 200 {object} handler.Response{data=domain.Reservation} "Reservation marked as no-show"
+// @Failure 404 {object} handler.Response "Reservation not found"
+// @Failure 409 {object} handler.Response "Invalid reservation status transition"
+// @Security Bearer
+// @Router /reservations/{id}/no-show [post]
+func (ctrl *ReservationController) NoShow(c *gin.Context) {
+	ctrl.transition(c, domain.ReservationActionNoShow, "Reservation marked as no-show")
+}
+
+// @Summary Cancel Reservation
+// @Description Cancel a reservation before the guests are seated and release its tables
+// @Tags Reservation
+// @Accept json
+// @Produce json
+// @Param id path int true "Reservation ID"
+// @Param input body reservationTransitionRequest false "Optional reason"
+// @Success 200 {object} handler.Response{data=domain.Reservation} "Reservation canceled"
+// @Failure 404 {object} handler.Response "Reservation not found"
+// @Failure 409 {object} handler.Response "Invalid reservation status transition"
+// @Security Bearer
+// @Router /reservations/{id}/cancel [post]
+func (ctrl *ReservationController) Cancel(c *gin.Context) {
+	ctrl.transition(c, domain.ReservationActionCancel, "Reservation canceled")
+}
+
+func (ctrl *ReservationController) transition(c *gin.Context, action domain.ReservationAction, message string) {
+	id, err := helper.Uint(c.Param("id"))
+	if err != nil {
+		BadResponse(c, "invalid reservation ID", http.StatusBadRequest)
+		return
+	}
+
+	var request reservationTransitionRequest
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&request); err != nil {
+			BadResponse(c, "invalid request body", http.StatusBadRequest)
 			return
 		}
+	}
+
+	input := domain.ReservationTransitionInput{Reason: request.Reason, UserID: currentUserID(c)}
+	reservation, err := ctrl.service.Transition(id, action, input)
+	if err != nil {
+		reservationError(c, err)
+		return
+	}
+
+	c.Header("ETag", helper.ETag(reservation.Version))
+	GoodResponseWithData(c, message, http.StatusOK, reservation)
+}
+
+type depositRequest struct {
+	PaymentMethodID uint `json:"payment_method_id" binding:"required" example:"1"`
+	// Amount is the requested deposit fee of the reservation when left out
+	Amount float64 `json:"amount" binding:"gte=0" example:"10.00"`
+}
+
+// @Summary Collect Reservation Deposit
+// @Description Record the deposit of a booked reservation as a payment with a payment method. The deposit is credited to the order when the guests are seated, and refunded or forfeited by the deposit policy when the reservation is cancelled or a no-show.
+// @Tags Reservation
+// @Accept json
+// @Produce json
+// @Param id path int true "Reservation ID"
+// @Param input body depositRequest true "Payment method and optional amount"
+// @Success 200 {object} handler.Response{data=domain.Reservation} "Deposit collected"
+// @Failure 400 {object} handler.Response "Invalid deposit or payment method"
+// @Failure 404 {object} handler.Response "Reservation not found"
+// @Security Bearer
+// @Router /reservations/{id}/deposit [post]
+func (ctrl *ReservationController) CollectDeposit(c *gin.Context) {
+	id, err := helper.Uint(c.Param("id"))
+	if err != nil {
+		BadResponse(c, "invalid reservation ID", http.StatusBadRequest)
+		return
+	}
+
+	var request depositRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		BadResponse(c, helper.FormatValidationError(err), http.StatusBadRequest)
+		return
+	}
+
+	input := domain.DepositInput{PaymentMethodID: request.PaymentMethodID, Amount: request.Amount, UserID: currentUserID(c)}
+	reservation, err := ctrl.service.CollectDeposit(id, input)
+	if err != nil {
+		reservationError(c, err)
+		return
+	}
+
+	c.Header("ETag", helper.ETag(reservation.Version))
+	GoodResponseWithData(c, "Deposit collected", http.StatusOK, reservation)
+}
+
+// @Summary Reservation Status History
+// @Description List every status change of a reservation with the staff member who made it and when
+// @Tags Reservation
+// @Produce json
+// @Param id path int true "Reservation ID"
+// @Success 200 {object} handler.Response{data=[]domain.ReservationStatusHistory} "fetch success"
+// @Failure 400 {object} handler.Response "Invalid reservation ID"
+// @Failure 500 {object} handler.Response "Internal server error"
+// @Security Bearer
+// @Router /reservations/{id}/history [get]
+func (ctrl *ReservationController) History(c *gin.Context) {
+	id, err := helper.Uint(c.Param("id"))
+	if err != nil {
+		BadResponse(c, "invalid reservation ID", http.StatusBadRequest)
+		return
+	}
+
+	histories, err := ctrl.service.History(id)
+	if err != nil {
 		BadResponse(c, err.Error(), http.StatusInternalServerError)
 		return
 	}
 
-	// Berhasil update
-	GoodResponseWithData(c, "reservation updated successfully", http.StatusOK, nil)
+	GoodResponseWithData(c, "fetch success", http.StatusOK, histories)
+}
+
+// @Summary Reservation Guest Link
+// @Description Open the signed link of a reservation email, no login needed. GET shows the booking with a button, POST confirms or cancels the reservation as the link says.
+// @Tags Reservation
+// @Produce text/html
+// @Param token path string true "Signed reservation token"
+// @Success 200 {file} file "reservation page"
+// @Failure 404 {object} handler.Response "Invalid link or reservation not found"
+// @Failure 410 {object} handler.Response "Link expired"
+// @Router /reservation-links/{token} [get]
+// @Router /reservation-links/{token} [post]
+func (ctrl *ReservationController) GuestLink(c *gin.Context) {
+	content, err := ctrl.service.GuestLink(c.Param("token"), c.Request.Method == http.MethodPost)
+	if err != nil {
+		reservationError(c, err)
+		return
+	}
+
+	c.Data(http.StatusOK, "text/html; charset=utf-8", content)
+}
+
+// @Summary Export Reservations Calendar
+// @Description Download the reservations of a date range as an iCalendar (RFC 5545) file with the table, pax and guest details of each booking. Events keep their UID across changes, importing the file again updates them.
+// @Tags Reservation
+// @Produce text/calendar
+// @Param start query string false "First day, YYYY-MM-DD, default today"
+// @Param end query string false "Last day, YYYY-MM-DD, default 30 days after start"
+// @Success 200 {file} file "reservations.ics"
+// @Failure 400 {object} handler.Response "Invalid date range"
+// @Security Bearer
+// @Router /reservations/export.ics [get]
+func (ctrl *ReservationController) Export(c *gin.Context) {
+	now := time.Now()
+	start := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+	var end time.Time
+
+	var err error
+	if value := c.Query("start"); value != "" {
+		if start, err = time.ParseInLocation("2006-01-02", value, now.Location()); err != nil {
+			BadResponse(c, "invalid start date, use YYYY-MM-DD", http.StatusBadRequest)
+			return
+		}
+	}
+	if value := c.Query("end"); value != "" {
+		if end, err = time.ParseInLocation("2006-01-02", value, now.Location()); err != nil {
+			BadResponse(c, "invalid end date, use YYYY-MM-DD", http.StatusBadRequest)
+			return
+		}
+		end = end.AddDate(0, 0, 1)
+	} else {
+		end = start.AddDate(0, 0, 30)
+	}
+
+	content, err := ctrl.service.Calendar(start, end)
+	if err != nil {
+		reservationError(c, err)
+		return
+	}
+
+	c.Header("Content-Disposition", `attachment; filename="reservations.ics"`)
+	c.Data(http.StatusOK, "text/calendar; charset=utf-8", content)
+}
+
+// @Summary Create Reservations Calendar Feed
+// @Description Create the secret URL of a reservations calendar the signed in user can subscribe to from a calendar app. A new URL replaces the previous one, which stops working.
+// @Tags Reservation
+// @Produce json
+// @Success 201 {object} handler.Response{data=domain.CalendarFeed} "Feed created"
+// @Failure 401 {object} handler.Response "Not signed in"
+// @Security Bearer
+// @Router /reservations/calendar-feed [post]
+func (ctrl *ReservationController) CreateCalendarFeed(c *gin.Context) {
+	userID := currentUserID(c)
+	if userID == nil {
+		BadResponse(c, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	feed, err := ctrl.service.CalendarFeed(*userID)
+	if err != nil {
+		reservationError(c, err)
+		return
+	}
+
+	GoodResponseWithData(c, "Calendar feed created", http.StatusCreated, feed)
+}
+
+// @Summary Delete Reservations Calendar Feed
+// @Description Stop the calendar feed of the signed in user
+// @Tags Reservation
+// @Produce json
+// @Success 200 {object} handler.Response "Feed deleted"
+// @Failure 404 {object} handler.Response "No calendar feed"
+// @Security Bearer
+// @Router /reservations/calendar-feed [delete]
+func (ctrl *ReservationController) DeleteCalendarFeed(c *gin.Context) {
+	userID := currentUserID(c)
+	if userID == nil {
+		BadResponse(c, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	if err := ctrl.service.DeleteCalendarFeed(*userID); err != nil {
+		reservationError(c, err)
+		return
+	}
+
+	GoodResponseWithData(c, "Calendar feed deleted", http.StatusOK, nil)
+}
+
+// @Summary Subscribed Reservations Calendar
+// @Description The calendar feed a calendar app subscribes to, no login needed. It holds the reservations from 30 days back to 180 days ahead.
+// @Tags Reservation
+// @Produce text/calendar
+// @Param token path string true "Secret feed token, optionally followed by .ics"
+// @Success 200 {file} file "reservations.ics"
+// @Failure 404 {object} handler.Response "Unknown or revoked feed"
+// @Router /reservation-calendar/{token} [get]
+func (ctrl *ReservationController) SubscribedCalendar(c *gin.Context) {
+	content, err := ctrl.service.SubscribedCalendar(c.Param("token"))
+	if err != nil {
+		reservationError(c, err)
+		return
+	}
+
+	c.Data(http.StatusOK, "text/calendar; charset=utf-8", content)
+}
+
+// SendReminders dijalankan cron untuk mengirim email pengingat reservasi
+func (ctrl *ReservationController) SendReminders() {
+	if _, err := ctrl.service.SendReminders(); err != nil {
+		ctrl.logger.Error("failed to send reservation reminders", zap.Error(err))
+	}
+}
+
+// HoldTables dijalankan cron untuk menandai meja sebagai reserved saat waktu reservasinya tiba
+func (ctrl *ReservationController) HoldTables() {
+	if _, err := ctrl.service.HoldTables(); err != nil {
+		ctrl.logger.Error("failed to hold reserved tables", zap.Error(err))
+	}
+}
+
+func reservationError(c *gin.Context, err error) {
+	switch {
+	case err.Error() == "reservation not found", errors.Is(err, domain.ErrTableNotFound), errors.Is(err, domain.ErrInvalidReservationToken),
+		errors.Is(err, domain.ErrCalendarFeedNotFound):
+		BadResponse(c, err.Error(), http.StatusNotFound)
+	case errors.Is(err, domain.ErrReservationTokenExpired):
+		BadResponse(c, err.Error(), http.StatusGone)
+	case errors.Is(err, domain.ErrInvalidReservation), errors.Is(err, domain.ErrTableTooSmall), errors.Is(err, domain.ErrInvalidOrder),
+		errors.Is(err, domain.ErrInvalidDeposit), errors.Is(err, domain.ErrInvalidCustomer):
+		BadResponse(c, err.Error(), http.StatusBadRequest)
+	case errors.Is(err, domain.ErrReservationConflict), errors.Is(err, domain.ErrInvalidReservationTransition),
+		errors.Is(err, domain.ErrTableUnavailable), errors.Is(err, domain.ErrOrderMoveNotAllowed), errors.Is(err, domain.ErrVersionConflict):
+		BadResponse(c, err.Error(), http.StatusConflict)
+	default:
+		BadResponse(c, err.Error(), http.StatusInternalServerError)
+	}
 }
@@ -77,6 +77,90 @@ type MonthlyRevenueResponse struct {
 	Year    uint               `json:"year"`
 }
 
+// @Summary Revenue By Order Type
+// @Description Orders, revenue, discounts, taxes, service charges and delivery fees of completed orders per order type between two dates
+// @Tags Revenue
+// @Produce json
+// @Param start query string false "Start date (YYYY-MM-DD), default is the first day of the current month"
+// @Param end query string false "End date inclusive (YYYY-MM-DD), default is today"
+// @Success 200 {object} Response{data=[]domain.OrderTypeRevenue} "fetch success"
+// @Failure 400 {object} Response "Invalid date"
+// @Security Bearer
+// @Router /revenue-reports/order-types [get]
+func (ctrl *RevenueController) GetRevenueByOrderType(c *gin.Context) {
+	start, end, ok := reportPeriod(c)
+	if !ok {
+		return
+	}
+
+	data, err := ctrl.service.GetRevenueByOrderType(start, end)
+	if err != nil {
+		if err.Error() == "end date must be after start date" {
+			BadResponse(c, err.Error(), http.StatusBadRequest)
+			return
+		}
+		BadResponse(c, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	GoodResponseWithData(c, "fetch success", http.StatusOK, data)
+}
+
+// @Summary Deposit Revenue
+// @Description Reservation deposits collected, applied to orders, refunded and forfeited between two dates, and the deposits still held for upcoming bookings
+// @Tags Revenue
+// @Produce json
+// @Param start query string false "Start date (YYYY-MM-DD), default is the first day of the current month"
+// @Param end query string false "End date inclusive (YYYY-MM-DD), default is today"
+// @Success 200 {object} Response{data=domain.DepositRevenue} "fetch success"
+// @Failure 400 {object} Response "Invalid date"
+// @Security Bearer
+// @Router /revenue-reports/deposits [get]
+func (ctrl *RevenueController) GetDepositRevenue(c *gin.Context) {
+	start, end, ok := reportPeriod(c)
+	if !ok {
+		return
+	}
+
+	data, err := ctrl.service.GetDepositRevenue(start, end)
+	if err != nil {
+		if err.Error() == "end date must be after start date" {
+			BadResponse(c, err.Error(), http.StatusBadRequest)
+			return
+		}
+		BadResponse(c, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	GoodResponseWithData(c, "fetch success", http.StatusOK, data)
+}
+
+// @Summary Gift Card Liability
+// @Description The balances still owed on usable gift cards and left on expired ones, and the gift cards issued, redeemed, refunded to and voided between two dates
+// @Tags Revenue
+// @Produce json
+// @Param start query string false "Start date (YYYY-MM-DD), default is the first day of the current month"
+// @Param end query string false "End date inclusive (YYYY-MM-DD), default is today"
+// @Success 200 {object} Response{data=domain.GiftCardLiability} "fetch success"
+// @Failure 400 {object} Response "Invalid date"
+// @Security Bearer
+// @Router /revenue-reports/gift-cards [get]
+func (ctrl *RevenueController) GetGiftCardLiability(c *gin.Context) {
+	start, end, ok := reportPeriod(c)
+	if !ok {
+		return
+	}
+
+	data, err := ctrl.service.GetGiftCardLiability(start, end)
+	if err != nil {
+		if err.Error() == "end date must be after start date" {
+			BadResponse(c, err.Error(), http.StatusBadRequest)
+			return
+		}
+		BadResponse(c, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	GoodResponseWithData(c, "fetch success", http.StatusOK, data)
+}
+
 func (ctrl *RevenueController) GetProductRevenueDetails(c *gin.Context) {
 	bestsellers, _ := ctrl.service.GetProductRevenueDetails()
 	GoodResponseWithData(c, "daily best seller retrieved", http.StatusOK, bestsellers)
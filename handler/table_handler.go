@@ -0,0 +1,215 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"project/domain"
+	"project/helper"
+	"project/service"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+type TableController struct {
+	service service.TableService
+	logger  *zap.Logger
+}
+
+func NewTableController(service service.TableService, logger *zap.Logger) *TableController {
+	return &TableController{service: service, logger: logger}
+}
+
+type tableStatusRequest struct {
+	Status domain.TableStatus `json:"status" binding:"required,oneof=available reserved needs_cleaning out_of_service" example:"needs_cleaning"`
+}
+
+// @Summary Get All Tables
+// @Description Retrieve the tables of the floor plan with their status and how long the guests have been seated
+// @Tags Tables
+// @Produce json
+// @Param page query int false "Page number, default is 1"
+// @Param limit query int false "Number of items per page, default is 10"
+// @Param status query string false "available, occupied, reserved, needs_cleaning or out_of_service"
+// @Param section query string false "Section or zone"
+// @Success 200 {object} domain.DataPage{data=[]domain.Table} "fetch success"
+// @Failure 400 {object} Response "Invalid status"
+// @Failure 500 {object} Response "internal server error"
+// @Security Bearer
+// @Router /tables [get]
+func (ctrl *TableController) All(c *gin.Context) {
+	page, limit := helper.Page(c)
+
+	status := domain.TableStatus(c.Query("status"))
+	if status != "" && !status.Valid() {
+		BadResponse(c, "invalid table status", http.StatusBadRequest)
+		return
+	}
+
+	tables, totalItems, err := ctrl.service.All(int(page), int(limit), status, c.Query("section"))
+	if err != nil {
+		tableError(c, err)
+		return
+	}
+
+	totalPages := (totalItems + int64(limit) - 1) / int64(limit)
+	GoodResponseWithPage(c, "fetch success", http.StatusOK, int(totalItems), int(totalPages), int(page), int(limit), tables)
+}
+
+// @Summary Get Table
+// @Tags Tables
+// @Produce json
+// @Param id path int true "Table ID"
+// @Success 200 {object} Response{data=domain.Table} "fetch success"
+// @Failure 404 {object} Response "Table not found"
+// @Security Bearer
+// @Router /tables/{id} [get]
+func (ctrl *TableController) Get(c *gin.Context) {
+	id, err := helper.Uint(c.Param("id"))
+	if err != nil {
+		BadResponse(c, "invalid table ID", http.StatusBadRequest)
+		return
+	}
+
+	table, err := ctrl.service.FindByID(id)
+	if err != nil {
+		tableError(c, err)
+		return
+	}
+
+	GoodResponseWithData(c, "fetch success", http.StatusOK, table)
+}
+
+// @Summary Create Table
+// @Description Add a table to the floor plan, it starts available
+// @Tags Tables
+// @Accept json
+// @Produce json
+// @Param input body domain.Table true "Table"
+// @Success 201 {object} Response{data=domain.Table} "create success"
+// @Failure 400 {object} Response "Invalid input"
+// @Failure 409 {object} Response "Table name already exists"
+// @Security Bearer
+// @Router /tables [post]
+func (ctrl *TableController) Create(c *gin.Context) {
+	var table domain.Table
+	if err := c.ShouldBindJSON(&table); err != nil {
+		BadResponse(c, helper.FormatValidationError(err), http.StatusBadRequest)
+		return
+	}
+	table.Status = domain.TableAvailable
+
+	if err := ctrl.service.Create(&table); err != nil {
+		tableError(c, err)
+		return
+	}
+
+	GoodResponseWithData(c, "create success", http.StatusCreated, table)
+}
+
+// @Summary Update Table
+// @Description Change the name, capacity, section, shape or position of a table
+// @Tags Tables
+// @Accept json
+// @Produce json
+// @Param id path int true "Table ID"
+// @Param input body domain.Table true "Table"
+// @Success 200 {object} Response{data=domain.Table} "update success"
+// @Failure 400 {object} Response "Invalid input"
+// @Failure 404 {object} Response "Table not found"
+// @Failure 409 {object} Response "Table name already exists"
+// @Security Bearer
+// @Router /tables/{id} [put]
+func (ctrl *TableController) Update(c *gin.Context) {
+	id, err := helper.Uint(c.Param("id"))
+	if err != nil {
+		BadResponse(c, "invalid table ID", http.StatusBadRequest)
+		return
+	}
+
+	var table domain.Table
+	if err := c.ShouldBindJSON(&table); err != nil {
+		BadResponse(c, helper.FormatValidationError(err), http.StatusBadRequest)
+		return
+	}
+	table.ID = id
+
+	if err := ctrl.service.Update(&table); err != nil {
+		tableError(c, err)
+		return
+	}
+
+	GoodResponseWithData(c, "update success", http.StatusOK, table)
+}
+
+// @Summary Change Table Status
+// @Description Mark a table available, reserved, needing cleaning or out of service. Tables become occupied and free again through their orders.
+// @Tags Tables
+// @Accept json
+// @Produce json
+// @Param id path int true "Table ID"
+// @Param input body tableStatusRequest true "New status"
+// @Success 200 {object} Response{data=domain.Table} "status updated"
+// @Failure 400 {object} Response "Invalid status"
+// @Failure 404 {object} Response "Table not found"
+// @Failure 409 {object} Response "Table has an open order"
+// @Security Bearer
+// @Router /tables/{id}/status [patch]
+func (ctrl *TableController) UpdateStatus(c *gin.Context) {
+	id, err := helper.Uint(c.Param("id"))
+	if err != nil {
+		BadResponse(c, "invalid table ID", http.StatusBadRequest)
+		return
+	}
+
+	var request tableStatusRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		BadResponse(c, helper.FormatValidationError(err), http.StatusBadRequest)
+		return
+	}
+
+	table, err := ctrl.service.UpdateStatus(id, request.Status)
+	if err != nil {
+		tableError(c, err)
+		return
+	}
+
+	GoodResponseWithData(c, "status updated", http.StatusOK, table)
+}
+
+// @Summary Delete Table
+// @Tags Tables
+// @Produce json
+// @Param id path int true "Table ID"
+// @Success 200 {object} Response "delete success"
+// @Failure 404 {object} Response "Table not found"
+// @Failure 409 {object} Response "Table has an open order"
+// @Security Bearer
+// @Router /tables/{id} [delete]
+func (ctrl *TableController) Delete(c *gin.Context) {
+	id, err := helper.Uint(c.Param("id"))
+	if err != nil {
+		BadResponse(c, "invalid table ID", http.StatusBadRequest)
+		return
+	}
+
+	if err := ctrl.service.Delete(id); err != nil {
+		tableError(c, err)
+		return
+	}
+
+	GoodResponseWithData(c, "delete success", http.StatusOK, nil)
+}
+
+func tableError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, domain.ErrTableNotFound):
+		BadResponse(c, err.Error(), http.StatusNotFound)
+	case errors.Is(err, domain.ErrInvalidTableStatus):
+		BadResponse(c, err.Error(), http.StatusBadRequest)
+	case errors.Is(err, domain.ErrTableInUse), err.Error() == "table with this name already exists":
+		BadResponse(c, err.Error(), http.StatusConflict)
+	default:
+		BadResponse(c, err.Error(), http.StatusInternalServerError)
+	}
+}
@@ -0,0 +1,240 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"project/domain"
+	"project/helper"
+	"project/service"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+type TaxController struct {
+	service service.TaxService
+	logger  *zap.Logger
+}
+
+func NewTaxController(service service.TaxService, logger *zap.Logger) *TaxController {
+	return &TaxController{service: service, logger: logger}
+}
+
+// @Summary Get All Tax Rates
+// @Description List the configured tax rates such as VAT, service charge and local restaurant tax
+// @Tags Taxes
+// @Produce json
+// @Success 200 {object} Response{data=[]domain.TaxRate} "fetch success"
+// @Failure 500 {object} Response "Internal server error"
+// @Security Bearer
+// @Router /tax-rates [get]
+func (ctrl *TaxController) All(c *gin.Context) {
+	rates, err := ctrl.service.All()
+	if err != nil {
+		BadResponse(c, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	GoodResponseWithData(c, "fetch success", http.StatusOK, rates)
+}
+
+// @Summary Get Tax Rate
+// @Tags Taxes
+// @Produce json
+// @Param id path int true "Tax rate ID"
+// @Success 200 {object} Response{data=domain.TaxRate} "fetch success"
+// @Failure 404 {object} Response "Tax rate not found"
+// @Security Bearer
+// @Router /tax-rates/{id} [get]
+func (ctrl *TaxController) GetByID(c *gin.Context) {
+	id, err := helper.Uint(c.Param("id"))
+	if err != nil {
+		BadResponse(c, "invalid tax rate ID", http.StatusBadRequest)
+		return
+	}
+
+	rate, err := ctrl.service.FindByID(id)
+	if err != nil {
+		taxError(c, err)
+		return
+	}
+
+	GoodResponseWithData(c, "fetch success", http.StatusOK, rate)
+}
+
+// @Summary Create Tax Rate
+// @Description Create a tax rate. Inclusive rates are part of the product price, exclusive rates are added on top. Default rates apply to products without their own or category rates.
+// @Tags Taxes
+// @Accept json
+// @Produce json
+// @Param input body domain.TaxRate true "Tax rate"
+// @Success 201 {object} Response{data=domain.TaxRate} "create success"
+// @Failure 400 {object} Response "Invalid input"
+// @Failure 409 {object} Response "Code already exists"
+// @Security Bearer
+// @Router /tax-rates [post]
+func (ctrl *TaxController) Create(c *gin.Context) {
+	var rate domain.TaxRate
+	if err := c.ShouldBindJSON(&rate); err != nil {
+		BadResponse(c, helper.FormatValidationError(err), http.StatusBadRequest)
+		return
+	}
+
+	if err := ctrl.service.Create(&rate); err != nil {
+		taxError(c, err)
+		return
+	}
+
+	GoodResponseWithData(c, "create success", http.StatusCreated, rate)
+}
+
+// @Summary Update Tax Rate
+// @Description Update a tax rate, orders are repriced with it from their next change on
+// @Tags Taxes
+// @Accept json
+// @Produce json
+// @Param id path int true "Tax rate ID"
+// @Param input body domain.TaxRate true "Tax rate"
+// @Success 200 {object} Response{data=domain.TaxRate} "update success"
+// @Failure 400 {object} Response "Invalid input"
+// @Failure 404 {object} Response "Tax rate not found"
+// @Security Bearer
+// @Router /tax-rates/{id} [put]
+func (ctrl *TaxController) Update(c *gin.Context) {
+	id, err := helper.Uint(c.Param("id"))
+	if err != nil {
+		BadResponse(c, "invalid tax rate ID", http.StatusBadRequest)
+		return
+	}
+
+	var rate domain.TaxRate
+	if err := c.ShouldBindJSON(&rate); err != nil {
+		BadResponse(c, helper.FormatValidationError(err), http.StatusBadRequest)
+		return
+	}
+	rate.ID = id
+
+	if err := ctrl.service.Update(&rate); err != nil {
+		taxError(c, err)
+		return
+	}
+
+	GoodResponseWithData(c, "update success", http.StatusOK, rate)
+}
+
+// @Summary Delete Tax Rate
+// @Tags Taxes
+// @Produce json
+// @Param id path int true "Tax rate ID"
+// @Success 200 {object} Response "delete success"
+// @Failure 404 {object} Response "Tax rate not found"
+// @Security Bearer
+// @Router /tax-rates/{id} [delete]
+func (ctrl *TaxController) Delete(c *gin.Context) {
+	id, err := helper.Uint(c.Param("id"))
+	if err != nil {
+		BadResponse(c, "invalid tax rate ID", http.StatusBadRequest)
+		return
+	}
+
+	if err := ctrl.service.Delete(id); err != nil {
+		taxError(c, err)
+		return
+	}
+
+	GoodResponseWithData(c, "delete success", http.StatusOK, nil)
+}
+
+type taxAssignmentRequest struct {
+	TaxRateIDs []uint `json:"tax_rate_ids" example:"1,2"`
+}
+
+// @Summary Assign Category Tax Rates
+// @Description Replace the tax rates of a category, an empty list falls back to the default rates
+// @Tags Taxes
+// @Accept json
+// @Produce json
+// @Param id path int true "Category ID"
+// @Param input body taxAssignmentRequest true "Tax rate IDs"
+// @Success 200 {object} Response "tax rates assigned"
+// @Failure 404 {object} Response "Category or tax rate not found"
+// @Security Bearer
+// @Router /categories/{id}/tax-rates [put]
+func (ctrl *TaxController) AssignToCategory(c *gin.Context) {
+	ctrl.assign(c, ctrl.service.AssignToCategory)
+}
+
+// @Summary Assign Product Tax Rates
+// @Description Replace the tax rates of a product, an empty list falls back to its category's rates
+// @Tags Taxes
+// @Accept json
+// @Produce json
+// @Param id path int true "Product ID"
+// @Param input body taxAssignmentRequest true "Tax rate IDs"
+// @Success 200 {object} Response "tax rates assigned"
+// @Failure 404 {object} Response "Product or tax rate not found"
+// @Security Bearer
+// @Router /inventory/{id}/tax-rates [put]
+func (ctrl *TaxController) AssignToProduct(c *gin.Context) {
+	ctrl.assign(c, ctrl.service.AssignToProduct)
+}
+
+func (ctrl *TaxController) assign(c *gin.Context, assign func(id uint, rateIDs []uint) error) {
+	id, err := helper.Uint(c.Param("id"))
+	if err != nil {
+		BadResponse(c, "invalid ID", http.StatusBadRequest)
+		return
+	}
+
+	var request taxAssignmentRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		BadResponse(c, helper.FormatValidationError(err), http.StatusBadRequest)
+		return
+	}
+
+	if err := assign(id, request.TaxRateIDs); err != nil {
+		taxError(c, err)
+		return
+	}
+
+	GoodResponseWithData(c, "tax rates assigned", http.StatusOK, nil)
+}
+
+// @Summary Tax Summary Report
+// @Description Tax collected per rate on completed orders between two dates, with the refunded share, for filing
+// @Tags Revenue
+// @Produce json
+// @Param start query string false "Start date (YYYY-MM-DD), default is the first day of the current month"
+// @Param end query string false "End date inclusive (YYYY-MM-DD), default is today"
+// @Param type query string false "Only orders of this type: dine_in, takeaway, delivery or pickup"
+// @Success 200 {object} Response{data=[]domain.TaxSummary} "fetch success"
+// @Failure 400 {object} Response "Invalid date"
+// @Security Bearer
+// @Router /revenue-reports/tax-summary [get]
+func (ctrl *TaxController) Summary(c *gin.Context) {
+	start, end, ok := reportPeriod(c)
+	if !ok {
+		return
+	}
+
+	summary, err := ctrl.service.Summary(start, end, domain.OrderType(c.Query("type")))
+	if err != nil {
+		taxError(c, err)
+		return
+	}
+
+	GoodResponseWithData(c, "fetch success", http.StatusOK, summary)
+}
+
+func taxError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, domain.ErrTaxRateNotFound), err.Error() == "category not found", err.Error() == "product not found":
+		BadResponse(c, err.Error(), http.StatusNotFound)
+	case err.Error() == "tax rate with this code already exists":
+		BadResponse(c, err.Error(), http.StatusConflict)
+	case errors.Is(err, domain.ErrInvalidTaxRate), err.Error() == "end date must be after start date":
+		BadResponse(c, err.Error(), http.StatusBadRequest)
+	default:
+		BadResponse(c, err.Error(), http.StatusInternalServerError)
+	}
+}
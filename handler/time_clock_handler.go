@@ -0,0 +1,198 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"project/domain"
+	"project/helper"
+	"project/service"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+type TimeClockController struct {
+	service service.TimeClockService
+	logger  *zap.Logger
+}
+
+func NewTimeClockController(service service.TimeClockService, logger *zap.Logger) *TimeClockController {
+	return &TimeClockController{service: service, logger: logger}
+}
+
+type timeClockRequest struct {
+	Note string `json:"note" binding:"max=255" example:"covered the late shift"`
+}
+
+// @Summary Clock In
+// @Description Start a shift for the logged in user
+// @Tags Time Clock
+// @Accept json
+// @Produce json
+// @Param input body timeClockRequest false "Optional note"
+// @Success 201 {object} Response{data=domain.TimeClock} "clocked in"
+// @Failure 409 {object} Response "Already clocked in"
+// @Security Bearer
+// @Router /time-clock/clock-in [post]
+func (ctrl *TimeClockController) ClockIn(c *gin.Context) {
+	userID := currentUserID(c)
+	if userID == nil {
+		BadResponse(c, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	var request timeClockRequest
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&request); err != nil {
+			BadResponse(c, helper.FormatValidationError(err), http.StatusBadRequest)
+			return
+		}
+	}
+
+	record, err := ctrl.service.ClockIn(*userID, request.Note)
+	if err != nil {
+		timeClockError(c, err)
+		return
+	}
+
+	GoodResponseWithData(c, "clocked in", http.StatusCreated, record)
+}
+
+// @Summary Clock Out
+// @Description End the open shift of the logged in user
+// @Tags Time Clock
+// @Accept json
+// @Produce json
+// @Param input body timeClockRequest false "Optional note"
+// @Success 200 {object} Response{data=domain.TimeClock} "clocked out"
+// @Failure 409 {object} Response "Not clocked in"
+// @Security Bearer
+// @Router /time-clock/clock-out [post]
+func (ctrl *TimeClockController) ClockOut(c *gin.Context) {
+	userID := currentUserID(c)
+	if userID == nil {
+		BadResponse(c, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	var request timeClockRequest
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&request); err != nil {
+			BadResponse(c, helper.FormatValidationError(err), http.StatusBadRequest)
+			return
+		}
+	}
+
+	record, err := ctrl.service.ClockOut(*userID, request.Note)
+	if err != nil {
+		timeClockError(c, err)
+		return
+	}
+
+	GoodResponseWithData(c, "clocked out", http.StatusOK, record)
+}
+
+// @Summary Get Time Clock Records
+// @Description List the shifts worked between two dates, optionally for one staff member
+// @Tags Time Clock
+// @Produce json
+// @Param page query int false "Page number, default is 1"
+// @Param limit query int false "Number of items per page, default is 10"
+// @Param user_id query int false "Staff member"
+// @Param start query string false "Start date (YYYY-MM-DD), default is the first day of the current month"
+// @Param end query string false "End date inclusive (YYYY-MM-DD), default is today"
+// @Success 200 {object} domain.DataPage{data=[]domain.TimeClock} "fetch success"
+// @Failure 400 {object} Response "Invalid date"
+// @Security Bearer
+// @Router /time-clock [get]
+func (ctrl *TimeClockController) All(c *gin.Context) {
+	page, limit := helper.Page(c)
+	userID, _ := helper.Uint(c.Query("user_id"))
+
+	start, end, ok := reportPeriod(c)
+	if !ok {
+		return
+	}
+
+	records, totalItems, err := ctrl.service.All(int(page), int(limit), userID, start, end)
+	if err != nil {
+		timeClockError(c, err)
+		return
+	}
+
+	totalPages := (totalItems + int64(limit) - 1) / int64(limit)
+	GoodResponseWithPage(c, "fetch success", http.StatusOK, int(totalItems), int(totalPages), int(page), int(limit), records)
+}
+
+// @Summary Tip Pool Report
+// @Description Share the tips taken between two dates across the staff who worked, by hours worked or by hours weighted with role points. Hours come from time clock records or from the staff shift schedule.
+// @Tags Revenue
+// @Produce json
+// @Param start query string false "Start date (YYYY-MM-DD), default is the first day of the current month"
+// @Param end query string false "End date inclusive (YYYY-MM-DD), default is today"
+// @Param method query string false "hours or points, default is hours"
+// @Param source query string false "clock or schedule, default is clock"
+// @Success 200 {object} Response{data=domain.TipPool} "fetch success"
+// @Failure 400 {object} Response "Invalid parameters"
+// @Security Bearer
+// @Router /revenue-reports/tip-pool [get]
+func (ctrl *TimeClockController) TipPool(c *gin.Context) {
+	method := domain.TipPoolMethod(c.DefaultQuery("method", string(domain.TipPoolByHours)))
+	if method != domain.TipPoolByHours && method != domain.TipPoolByPoints {
+		BadResponse(c, "invalid method, use hours or points", http.StatusBadRequest)
+		return
+	}
+	source := domain.TipPoolSource(c.DefaultQuery("source", string(domain.TipPoolFromClock)))
+	if source != domain.TipPoolFromClock && source != domain.TipPoolFromSchedule {
+		BadResponse(c, "invalid source, use clock or schedule", http.StatusBadRequest)
+		return
+	}
+
+	start, end, ok := reportPeriod(c)
+	if !ok {
+		return
+	}
+
+	pool, err := ctrl.service.TipPool(start, end, method, source)
+	if err != nil {
+		timeClockError(c, err)
+		return
+	}
+
+	GoodResponseWithData(c, "fetch success", http.StatusOK, pool)
+}
+
+// reportPeriod reads the start and inclusive end dates of a report, defaulting to the current month
+// to date, and returns them as [start, end)
+func reportPeriod(c *gin.Context) (time.Time, time.Time, bool) {
+	now := time.Now()
+	start := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, now.Location())
+	end := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+
+	var err error
+	if value := c.Query("start"); value != "" {
+		if start, err = time.ParseInLocation("2006-01-02", value, now.Location()); err != nil {
+			BadResponse(c, "invalid start date, use YYYY-MM-DD", http.StatusBadRequest)
+			return start, end, false
+		}
+	}
+	if value := c.Query("end"); value != "" {
+		if end, err = time.ParseInLocation("2006-01-02", value, now.Location()); err != nil {
+			BadResponse(c, "invalid end date, use YYYY-MM-DD", http.StatusBadRequest)
+			return start, end, false
+		}
+	}
+	return start, end.AddDate(0, 0, 1), true
+}
+
+func timeClockError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, domain.ErrAlreadyClockedIn), errors.Is(err, domain.ErrNotClockedIn):
+		BadResponse(c, err.Error(), http.StatusConflict)
+	case err.Error() == "end date must be after start date":
+		BadResponse(c, err.Error(), http.StatusBadRequest)
+	default:
+		BadResponse(c, err.Error(), http.StatusInternalServerError)
+	}
+}
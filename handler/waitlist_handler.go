@@ -0,0 +1,224 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"project/domain"
+	"project/helper"
+	"project/service"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+type WaitlistController struct {
+	service service.WaitlistService
+	logger  *zap.Logger
+}
+
+func NewWaitlistController(service service.WaitlistService, logger *zap.Logger) *WaitlistController {
+	return &WaitlistController{service: service, logger: logger}
+}
+
+type seatPartyRequest struct {
+	TableID uint `json:"table_id" binding:"required" example:"3"`
+}
+
+// @Summary Get Waitlist
+// @Description List the parties waiting in queue order with their position and estimated wait in minutes, or past entries with a status
+// @Tags Waitlist
+// @Produce json
+// @Param page query int false "Page number, default is 1"
+// @Param limit query int false "Number of items per page, default is 10"
+// @Param status query string false "waiting, notified, seated, no_show or cancelled, the parties still waiting when empty"
+// @Param phone query string false "Only entries of this phone number"
+// @Success 200 {object} domain.DataPage{data=[]domain.WaitlistEntry} "fetch success"
+// @Failure 400 {object} Response "Invalid status"
+// @Failure 500 {object} Response "internal server error"
+// @Security Bearer
+// @Router /waitlist [get]
+func (ctrl *WaitlistController) All(c *gin.Context) {
+	page, limit := helper.Page(c)
+
+	status := domain.WaitlistStatus(c.Query("status"))
+	if status != "" && !status.Valid() {
+		BadResponse(c, "invalid waitlist status", http.StatusBadRequest)
+		return
+	}
+
+	entries, totalItems, err := ctrl.service.All(int(page), int(limit), status, c.Query("phone"))
+	if err != nil {
+		waitlistError(c, err)
+		return
+	}
+
+	totalPages := (totalItems + int64(limit) - 1) / int64(limit)
+	GoodResponseWithPage(c, "fetch success", http.StatusOK, int(totalItems), int(totalPages), int(page), int(limit), entries)
+}
+
+// @Summary Get Waitlist Entry
+// @Tags Waitlist
+// @Produce json
+// @Param id path int true "Waitlist entry ID"
+// @Success 200 {object} Response{data=domain.WaitlistEntry} "fetch success"
+// @Failure 404 {object} Response "Waitlist entry not found"
+// @Security Bearer
+// @Router /waitlist/{id} [get]
+func (ctrl *WaitlistController) Get(c *gin.Context) {
+	id, err := helper.Uint(c.Param("id"))
+	if err != nil {
+		BadResponse(c, "invalid waitlist entry ID", http.StatusBadRequest)
+		return
+	}
+
+	entry, err := ctrl.service.FindByID(id)
+	if err != nil {
+		waitlistError(c, err)
+		return
+	}
+
+	GoodResponseWithData(c, "fetch success", http.StatusOK, entry)
+}
+
+// @Summary Add Party To Waitlist
+// @Description Put a walk-in party at the end of the queue. The wait is estimated from the tables that seat the party, how long they have been occupied, the typical stay and the parties ahead. Without quoted_wait the estimate is quoted.
+// @Tags Waitlist
+// @Accept json
+// @Produce json
+// @Param input body domain.WaitlistEntry true "Party"
+// @Success 201 {object} Response{data=domain.WaitlistEntry} "party added"
+// @Failure 400 {object} Response "Invalid input"
+// @Security Bearer
+// @Router /waitlist [post]
+func (ctrl *WaitlistController) Create(c *gin.Context) {
+	var entry domain.WaitlistEntry
+	if err := c.ShouldBindJSON(&entry); err != nil {
+		BadResponse(c, helper.FormatValidationError(err), http.StatusBadRequest)
+		return
+	}
+
+	if err := ctrl.service.Create(&entry); err != nil {
+		waitlistError(c, err)
+		return
+	}
+
+	GoodResponseWithData(c, "party added", http.StatusCreated, entry)
+}
+
+// @Summary Notify Party
+// @Description Record that the party was told its table is ready
+// @Tags Waitlist
+// @Produce json
+// @Param id path int true "Waitlist entry ID"
+// @Success 200 {object} Response{data=domain.WaitlistEntry} "party notified"
+// @Failure 404 {object} Response "Waitlist entry not found"
+// @Failure 409 {object} Response "Party is no longer waiting"
+// @Security Bearer
+// @Router /waitlist/{id}/notify [post]
+func (ctrl *WaitlistController) Notify(c *gin.Context) {
+	id, err := helper.Uint(c.Param("id"))
+	if err != nil {
+		BadResponse(c, "invalid waitlist entry ID", http.StatusBadRequest)
+		return
+	}
+
+	entry, err := ctrl.service.Notify(id)
+	if err != nil {
+		waitlistError(c, err)
+		return
+	}
+
+	GoodResponseWithData(c, "party notified", http.StatusOK, entry)
+}
+
+// @Summary Seat Party
+// @Description Seat the party at a table, which opens a dine-in order for it and takes it off the waitlist
+// @Tags Waitlist
+// @Accept json
+// @Produce json
+// @Param id path int true "Waitlist entry ID"
+// @Param input body seatPartyRequest true "Table"
+// @Success 200 {object} Response{data=domain.WaitlistEntry} "party seated, with the order opened for it"
+// @Failure 400 {object} Response "Invalid input"
+// @Failure 404 {object} Response "Waitlist entry or table not found"
+// @Failure 409 {object} Response "Party is no longer waiting or table not available"
+// @Security Bearer
+// @Router /waitlist/{id}/seat [post]
+func (ctrl *WaitlistController) Seat(c *gin.Context) {
+	id, err := helper.Uint(c.Param("id"))
+	if err != nil {
+		BadResponse(c, "invalid waitlist entry ID", http.StatusBadRequest)
+		return
+	}
+
+	var request seatPartyRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		BadResponse(c, helper.FormatValidationError(err), http.StatusBadRequest)
+		return
+	}
+
+	entry, err := ctrl.service.Seat(id, request.TableID)
+	if err != nil {
+		waitlistError(c, err)
+		return
+	}
+
+	GoodResponseWithData(c, "party seated", http.StatusOK, entry)
+}
+
+// @Summary Mark Party No-Show
+// @Description Take a party that did not come back off the waitlist, it is counted against its phone number
+// @Tags Waitlist
+// @Produce json
+// @Param id path int true "Waitlist entry ID"
+// @Success 200 {object} Response{data=domain.WaitlistEntry} "party marked as no-show"
+// @Failure 404 {object} Response "Waitlist entry not found"
+// @Failure 409 {object} Response "Party is no longer waiting"
+// @Security Bearer
+// @Router /waitlist/{id}/no-show [post]
+func (ctrl *WaitlistController) NoShow(c *gin.Context) {
+	ctrl.close(c, domain.WaitlistNoShow, "party marked as no-show")
+}
+
+// @Summary Cancel Waitlist Entry
+// @Description Take a party that left off the waitlist
+// @Tags Waitlist
+// @Produce json
+// @Param id path int true "Waitlist entry ID"
+// @Success 200 {object} Response{data=domain.WaitlistEntry} "waitlist entry cancelled"
+// @Failure 404 {object} Response "Waitlist entry not found"
+// @Failure 409 {object} Response "Party is no longer waiting"
+// @Security Bearer
+// @Router /waitlist/{id}/cancel [post]
+func (ctrl *WaitlistController) Cancel(c *gin.Context) {
+	ctrl.close(c, domain.WaitlistCancelled, "waitlist entry cancelled")
+}
+
+func (ctrl *WaitlistController) close(c *gin.Context, status domain.WaitlistStatus, message string) {
+	id, err := helper.Uint(c.Param("id"))
+	if err != nil {
+		BadResponse(c, "invalid waitlist entry ID", http.StatusBadRequest)
+		return
+	}
+
+	entry, err := ctrl.service.Close(id, status)
+	if err != nil {
+		waitlistError(c, err)
+		return
+	}
+
+	GoodResponseWithData(c, message, http.StatusOK, entry)
+}
+
+func waitlistError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, domain.ErrWaitlistEntryNotFound), errors.Is(err, domain.ErrTableNotFound):
+		BadResponse(c, err.Error(), http.StatusNotFound)
+	case errors.Is(err, domain.ErrInvalidOrder):
+		BadResponse(c, err.Error(), http.StatusBadRequest)
+	case errors.Is(err, domain.ErrInvalidWaitlistStatus), errors.Is(err, domain.ErrTableUnavailable):
+		BadResponse(c, err.Error(), http.StatusConflict)
+	default:
+		BadResponse(c, err.Error(), http.StatusInternalServerError)
+	}
+}
@@ -0,0 +1,124 @@
+package helper
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+)
+
+// ESCPOS builds a byte stream of ESC/POS commands for thermal receipt printers
+type ESCPOS struct {
+	buf bytes.Buffer
+}
+
+type ESCPOSAlign byte
+
+const (
+	ESCPOSLeft   ESCPOSAlign = 0
+	ESCPOSCenter ESCPOSAlign = 1
+	ESCPOSRight  ESCPOSAlign = 2
+)
+
+// NewESCPOS starts a stream with the printer reset to its defaults
+func NewESCPOS() *ESCPOS {
+	p := &ESCPOS{}
+	p.buf.Write([]byte{0x1b, '@'})
+	return p
+}
+
+func (p *ESCPOS) Align(align ESCPOSAlign) *ESCPOS {
+	p.buf.Write([]byte{0x1b, 'a', byte(align)})
+	return p
+}
+
+func (p *ESCPOS) Bold(on bool) *ESCPOS {
+	p.buf.Write([]byte{0x1b, 'E', boolByte(on)})
+	return p
+}
+
+// DoubleSize doubles the height and width of the following text
+func (p *ESCPOS) DoubleSize(on bool) *ESCPOS {
+	size := byte(0x00)
+	if on {
+		size = 0x11
+	}
+	p.buf.Write([]byte{0x1d, '!', size})
+	return p
+}
+
+// Line prints text followed by a line feed
+func (p *ESCPOS) Line(text string) *ESCPOS {
+	p.buf.WriteString(text)
+	p.buf.WriteByte('\n')
+	return p
+}
+
+func (p *ESCPOS) Feed(lines int) *ESCPOS {
+	p.buf.Write([]byte{0x1b, 'd', byte(lines)})
+	return p
+}
+
+// Image prints img as a black and white raster no wider than maxDots
+func (p *ESCPOS) Image(img image.Image, maxDots int) *ESCPOS {
+	bounds := img.Bounds()
+	width, height := bounds.Dx(), bounds.Dy()
+	if width == 0 || height == 0 {
+		return p
+	}
+	scale := 1.0
+	if width > maxDots {
+		scale = float64(maxDots) / float64(width)
+	}
+	dotsWide := int(float64(width) * scale)
+	dotsHigh := int(float64(height) * scale)
+	bytesWide := (dotsWide + 7) / 8
+
+	// GS v 0: raster bit image, one bit per dot, most significant bit first
+	p.buf.Write([]byte{0x1d, 'v', '0', 0, byte(bytesWide), byte(bytesWide >> 8), byte(dotsHigh), byte(dotsHigh >> 8)})
+	for y := 0; y < dotsHigh; y++ {
+		row := make([]byte, bytesWide)
+		for x := 0; x < dotsWide; x++ {
+			at := img.At(bounds.Min.X+int(float64(x)/scale), bounds.Min.Y+int(float64(y)/scale))
+			gray := color.GrayModel.Convert(at).(color.Gray)
+			_, _, _, alpha := at.RGBA()
+			if alpha > 0x7fff && gray.Y < 128 {
+				row[x/8] |= 0x80 >> (x % 8)
+			}
+		}
+		p.buf.Write(row)
+	}
+	return p
+}
+
+// QRCode prints data as a QR code rendered by the printer, size is the module size from 1 to 16
+func (p *ESCPOS) QRCode(data string, size byte) *ESCPOS {
+	// model 2
+	p.buf.Write([]byte{0x1d, '(', 'k', 4, 0, 49, 65, 50, 0})
+	// module size
+	p.buf.Write([]byte{0x1d, '(', 'k', 3, 0, 49, 67, size})
+	// error correction level M
+	p.buf.Write([]byte{0x1d, '(', 'k', 3, 0, 49, 69, 49})
+	// store the data, then print it
+	length := len(data) + 3
+	p.buf.Write([]byte{0x1d, '(', 'k', byte(length), byte(length >> 8), 49, 80, 48})
+	p.buf.WriteString(data)
+	p.buf.Write([]byte{0x1d, '(', 'k', 3, 0, 49, 81, 48})
+	return p
+}
+
+// Cut feeds the paper past the cutter and makes a partial cut
+func (p *ESCPOS) Cut() *ESCPOS {
+	p.buf.Write([]byte{0x1d, 'V', 66, 3})
+	return p
+}
+
+func (p *ESCPOS) Bytes() []byte {
+	return p.buf.Bytes()
+}
+
+func boolByte(on bool) byte {
+	if on {
+		return 1
+	}
+	return 0
+}
@@ -0,0 +1,37 @@
+package helper
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"strings"
+)
+
+func ETag(version uint) string {
+	return fmt.Sprintf(`"%d"`, version)
+}
+
+// ContentETag is a strong ETag of a response body, it changes whenever the body does
+func ContentETag(body []byte) string {
+	sum := sha256.Sum256(body)
+	return fmt.Sprintf(`"%x"`, sum[:16])
+}
+
+// ParseETag reads the version back from an ETag or If-Match value, accepting weak tags
+func ParseETag(tag string) (uint, error) {
+	tag = strings.TrimSpace(tag)
+	tag = strings.TrimPrefix(tag, "W/")
+	return Uint(strings.Trim(tag, `"`))
+}
+
+// ETagMatches tells whether an If-None-Match value lists the ETag, comparing weakly as RFC 9110 asks
+func ETagMatches(ifNoneMatch, etag string) bool {
+	if strings.TrimSpace(ifNoneMatch) == "*" {
+		return true
+	}
+	for _, tag := range strings.Split(ifNoneMatch, ",") {
+		if strings.TrimPrefix(strings.TrimSpace(tag), "W/") == strings.TrimPrefix(etag, "W/") {
+			return true
+		}
+	}
+	return false
+}
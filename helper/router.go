@@ -2,6 +2,8 @@ package helper
 
 import (
 	"strconv"
+
+	"github.com/gin-gonic/gin"
 )
 
 func Uint(param string) (uint, error) {
@@ -12,6 +14,20 @@ func Uint(param string) (uint, error) {
 	return uint(i), nil
 }
 
+// Page reads the page and limit query parameters of a listing. A page below 1 is the first page and
+// a limit below 1 is the default of 10, so the number of pages can always be worked out.
+func Page(c *gin.Context) (page, limit uint) {
+	page, _ = Uint(c.DefaultQuery("page", "1"))
+	limit, _ = Uint(c.DefaultQuery("limit", "10"))
+	if page < 1 {
+		page = 1
+	}
+	if limit < 1 {
+		limit = 10
+	}
+	return page, limit
+}
+
 func IntToString(num int) string {
 	convStr := strconv.Itoa(num)
 	return convStr
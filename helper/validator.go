@@ -40,7 +40,7 @@ func FormatValidationError(err error) string {
 		"OrderItems_required":  "Order item cannot nil",
 		"OrderItems_notempty":  "Order item cannot empty",
 		"StatusPayment_oneof":  "Status payment must be 'In Process', 'Completed' or 'Cancelled'",
-		"StatusKitchen_oneof":  "Status kitchen must be 'In The Kitchen', 'Cooking Now', or 'Ready To Serve'",
+		"StatusKitchen_oneof":  "Status kitchen must be 'In The Kitchen', 'Cooking Now', 'Ready To Serve' or 'Served'",
 	}
 
 	var errMessages []string
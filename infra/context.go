@@ -49,9 +49,15 @@ func NewServiceContext() (*ServiceContext, error) {
 	jwtLib := jwt.NewJWT(appConfig.PrivateKey, appConfig.PublicKey, logger)
 
 	rdb := database.NewCacher(appConfig, 60*60)
+	if err := database.RegisterMenuInvalidation(db, rdb); err != nil {
+		return handlerError(err)
+	}
 
 	// instance repository
-	repo := repository.NewRepository(db, rdb, appConfig, logger)
+	repo, err := repository.NewRepository(db, rdb, appConfig, logger)
+	if err != nil {
+		return handlerError(err)
+	}
 
 	// instance service
 	services := service.NewService(repo, appConfig, logger)
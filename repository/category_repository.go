@@ -10,12 +10,14 @@ import (
 )
 
 type CategoryRepository struct {
-	db  *gorm.DB
-	log *zap.Logger
+	db   *gorm.DB
+	menu MenuRepository
+	log  *zap.Logger
 }
 
-func NewCategoryRepository(db *gorm.DB, log *zap.Logger) *CategoryRepository {
-	return &CategoryRepository{db: db, log: log}
+// NewCategoryRepository drops the cached menu once a category is written
+func NewCategoryRepository(db *gorm.DB, menu MenuRepository, log *zap.Logger) *CategoryRepository {
+	return &CategoryRepository{db: db, menu: menu, log: log}
 }
 
 func (repo CategoryRepository) Create(category *domain.Category) error {
@@ -29,6 +31,7 @@ func (repo CategoryRepository) Create(category *domain.Category) error {
 		repo.log.Error("Failed to save category", zap.Error(err))
 		return err
 	}
+	repo.menu.Invalidate()
 
 	repo.log.Info("Category successfully created")
 	return nil
@@ -77,6 +80,7 @@ func (repo *CategoryRepository) Update(category *domain.Category) error {
 		repo.log.Error("Failed to update category", zap.Error(err))
 		return err
 	}
+	repo.menu.Invalidate()
 	return nil
 }
 
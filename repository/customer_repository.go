@@ -0,0 +1,332 @@
+package repository
+
+import (
+	"errors"
+	"fmt"
+	"project/domain"
+	"project/helper"
+	"time"
+
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// paidPaymentTypes are the payments of an order that count as spend, less its refunds
+var paidPaymentTypes = []domain.PaymentType{domain.PaymentTypePayment, domain.PaymentTypeDeposit}
+
+type CustomerRepository struct {
+	db  *gorm.DB
+	log *zap.Logger
+}
+
+func NewCustomerRepository(db *gorm.DB, log *zap.Logger) *CustomerRepository {
+	return &CustomerRepository{db: db, log: log}
+}
+
+// All lists the customers by name, optionally those whose name, phone number or email address
+// contains search
+func (repo CustomerRepository) All(page, limit int, search string) ([]domain.Customer, int64, error) {
+	customers := []domain.Customer{}
+	var totalItems int64
+
+	query := repo.db.Model(&domain.Customer{})
+	if search != "" {
+		like := "%" + search + "%"
+		condition := repo.db.Where("name ILIKE ?", like).Or("email ILIKE ?", like)
+		if phone := domain.NormalizePhone(search); phone != "" {
+			condition = condition.Or("phone LIKE ?", "%"+phone+"%")
+		}
+		query = query.Where(condition)
+	}
+
+	if err := query.Count(&totalItems).Error; err != nil {
+		repo.log.Error("Failed to count customers", zap.Error(err))
+		return nil, 0, err
+	}
+	if err := query.Order("name, id").Scopes(helper.Paginate(uint(page), uint(limit))).Find(&customers).Error; err != nil {
+		repo.log.Error("Failed to fetch customers", zap.Error(err))
+		return nil, 0, err
+	}
+	return customers, totalItems, nil
+}
+
+// FindByID reads a customer with the lifetime figures, favourite items and latest visits
+func (repo CustomerRepository) FindByID(id uint) (*domain.Customer, error) {
+	var customer domain.Customer
+	if err := repo.db.First(&customer, id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, domain.ErrCustomerNotFound
+		}
+		repo.log.Error("Failed to fetch customer", zap.Uint("id", id), zap.Error(err))
+		return nil, err
+	}
+
+	if err := repo.stats(&customer); err != nil {
+		repo.log.Error("Failed to read customer stats", zap.Uint("id", id), zap.Error(err))
+		return nil, err
+	}
+	return &customer, nil
+}
+
+func (repo CustomerRepository) stats(customer *domain.Customer) error {
+	stats := &customer.CustomerStats
+
+	// Belanja: pembayaran dan deposit pesanan dikurangi refund, ditambah deposit yang hangus
+	var spend struct {
+		Orders   float64
+		Forfeits float64
+	}
+	err := repo.db.Model(&domain.Payment{}).
+		Select(`COALESCE(SUM(CASE WHEN payments.type IN ? THEN payments.amount WHEN payments.type = ? THEN -payments.amount END), 0) AS orders`,
+			paidPaymentTypes, domain.PaymentTypeRefund).
+		Joins("JOIN orders ON orders.id = payments.order_id AND orders.deleted_at IS NULL").
+		Where("orders.customer_id = ?", customer.ID).
+		Scan(&spend.Orders).Error
+	if err != nil {
+		return err
+	}
+	err = repo.db.Model(&domain.Payment{}).Select("COALESCE(SUM(payments.amount), 0)").
+		Joins("JOIN reservations ON reservations.id = payments.reservation_id").
+		Where("reservations.customer_id = ? AND payments.type = ?", customer.ID, domain.PaymentTypeForfeit).
+		Scan(&spend.Forfeits).Error
+	if err != nil {
+		return err
+	}
+	stats.LifetimeSpend = spend.Orders + spend.Forfeits
+
+	var visits struct {
+		Count int
+		Last  *time.Time
+	}
+	err = repo.db.Model(&domain.Order{}).Select("COUNT(*) AS count, MAX(created_at) AS last").
+		Where("customer_id = ? AND status_payment = ?", customer.ID, domain.OrderCompleted).
+		Scan(&visits).Error
+	if err != nil {
+		return err
+	}
+	stats.Visits = visits.Count
+	stats.LastVisit = visits.Last
+
+	var reservations struct {
+		Count   int
+		NoShows int
+	}
+	err = repo.db.Model(&domain.Reservation{}).
+		Select("COUNT(*) AS count, COUNT(*) FILTER (WHERE status = ?) AS no_shows", domain.ReservationNoShow).
+		Where("customer_id = ?", customer.ID).
+		Scan(&reservations).Error
+	if err != nil {
+		return err
+	}
+	stats.Reservations = reservations.Count
+	stats.NoShows = reservations.NoShows
+
+	err = repo.db.Model(&domain.OrderItem{}).
+		Select("order_items.product_id, products.name, SUM(order_items.quantity - order_items.refunded_quantity) AS quantity").
+		Joins("JOIN orders ON orders.id = order_items.order_id AND orders.deleted_at IS NULL").
+		Joins("JOIN products ON products.id = order_items.product_id").
+		Where("orders.customer_id = ? AND orders.status_payment = ?", customer.ID, domain.OrderCompleted).
+		Group("order_items.product_id, products.name").
+		Having("SUM(order_items.quantity - order_items.refunded_quantity) > 0").
+		Order("quantity DESC, order_items.product_id").
+		Limit(5).
+		Scan(&stats.Favourites).Error
+	if err != nil {
+		return err
+	}
+
+	stats.RecentVisits, _, err = repo.Visits(customer.ID, 1, 5)
+	return err
+}
+
+// Visits lists the completed orders of a customer newest first, with what was spent on each
+func (repo CustomerRepository) Visits(customerID uint, page, limit int) ([]domain.VisitEntry, int64, error) {
+	visits := []domain.VisitEntry{}
+	var totalItems int64
+
+	query := repo.db.Model(&domain.Order{}).Where("orders.customer_id = ? AND orders.status_payment = ?", customerID, domain.OrderCompleted)
+	if err := query.Count(&totalItems).Error; err != nil {
+		repo.log.Error("Failed to count customer visits", zap.Error(err))
+		return nil, 0, err
+	}
+
+	err := query.Select(`orders.id AS order_id, orders.code_order, orders.type, orders.party_size, orders.created_at AS visited_at,
+			(SELECT reservations.id FROM reservations WHERE reservations.order_id = orders.id LIMIT 1) AS reservation_id,
+			(SELECT COALESCE(SUM(CASE WHEN payments.type = ? THEN -payments.amount ELSE payments.amount END), 0)
+				FROM payments WHERE payments.order_id = orders.id AND payments.type IN ?) AS spend`,
+		domain.PaymentTypeRefund, append(paidPaymentTypes, domain.PaymentTypeRefund)).
+		Order("orders.created_at DESC, orders.id DESC").
+		Scopes(helper.Paginate(uint(page), uint(limit))).
+		Scan(&visits).Error
+	if err != nil {
+		repo.log.Error("Failed to fetch customer visits", zap.Uint("customer_id", customerID), zap.Error(err))
+		return nil, 0, err
+	}
+	return visits, totalItems, nil
+}
+
+func (repo CustomerRepository) Create(customer *domain.Customer) error {
+	err := repo.db.Transaction(func(tx *gorm.DB) error {
+		if err := checkCustomerContacts(tx, customer); err != nil {
+			return err
+		}
+		return tx.Create(customer).Error
+	})
+	if err != nil && !errors.Is(err, domain.ErrDuplicateCustomer) {
+		repo.log.Error("Failed to create customer", zap.Error(err))
+	}
+	return err
+}
+
+// Update saves the details of a customer when its version is still the expected one, 0 takes the
+// version just read
+func (repo CustomerRepository) Update(id, expectedVersion uint, details domain.Customer) (*domain.Customer, error) {
+	var customer domain.Customer
+	err := repo.db.Transaction(func(tx *gorm.DB) error {
+		if err := lockCustomer(tx, &customer, id); err != nil {
+			return err
+		}
+		if expectedVersion == 0 {
+			expectedVersion = customer.Version
+		}
+		if customer.Version != expectedVersion {
+			return domain.ErrVersionConflict
+		}
+
+		details.ID = customer.ID
+		if err := checkCustomerContacts(tx, &details); err != nil {
+			return err
+		}
+		return tx.Model(&customer).UpdateColumns(map[string]interface{}{
+			"name":       details.Name,
+			"phone":      details.Phone,
+			"email":      details.Email,
+			"notes":      details.Notes,
+			"allergies":  details.Allergies,
+			"version":    customer.Version + 1,
+			"updated_at": time.Now(),
+		}).Error
+	})
+	if err != nil {
+		if !errors.Is(err, domain.ErrCustomerNotFound) && !errors.Is(err, domain.ErrDuplicateCustomer) && !errors.Is(err, domain.ErrVersionConflict) {
+			repo.log.Error("Failed to update customer", zap.Uint("id", id), zap.Error(err))
+		}
+		return nil, err
+	}
+	return repo.FindByID(id)
+}
+
+// Merge folds duplicate customers into one: their reservations and orders move over, contact
+// details the customer lacks and all notes and allergies are kept, and the duplicates are removed
+// with a pointer to the customer they were merged into
+func (repo CustomerRepository) Merge(id uint, duplicateIDs []uint) (*domain.Customer, error) {
+	err := repo.db.Transaction(func(tx *gorm.DB) error {
+		var customer domain.Customer
+		if err := lockCustomer(tx, &customer, id); err != nil {
+			return err
+		}
+
+		ids := map[uint]bool{}
+		for _, duplicateID := range duplicateIDs {
+			if duplicateID == id {
+				return fmt.Errorf("%w: a customer cannot be merged into itself", domain.ErrInvalidCustomer)
+			}
+			ids[duplicateID] = true
+		}
+		var duplicates []domain.Customer
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where("id IN ?", duplicateIDs).Order("id").Find(&duplicates).Error
+		if err != nil {
+			return err
+		}
+		if len(duplicates) != len(ids) {
+			return fmt.Errorf("%w: a duplicate customer was not found", domain.ErrCustomerNotFound)
+		}
+
+		for _, duplicate := range duplicates {
+			customer.Absorb(duplicate)
+		}
+
+		// Reservasi, pesanan dan ulasan, juga yang sudah dihapus, pindah ke pelanggan ini
+		if err := tx.Model(&domain.Reservation{}).Where("customer_id IN ?", duplicateIDs).UpdateColumn("customer_id", id).Error; err != nil {
+			return err
+		}
+		if err := tx.Unscoped().Model(&domain.Order{}).Where("customer_id IN ?", duplicateIDs).UpdateColumn("customer_id", id).Error; err != nil {
+			return err
+		}
+		if err := tx.Model(&domain.Feedback{}).Where("customer_id IN ?", duplicateIDs).UpdateColumn("customer_id", id).Error; err != nil {
+			return err
+		}
+		if err := tx.Unscoped().Model(&domain.Customer{}).Where("merged_into_id IN ?", duplicateIDs).UpdateColumn("merged_into_id", id).Error; err != nil {
+			return err
+		}
+
+		// Poin loyalitas duplikat digabung ke saldo pelanggan ini
+		for _, customerID := range append([]uint{id}, duplicateIDs...) {
+			if err := domain.LockLoyaltyAccount(tx, customerID); err != nil {
+				return err
+			}
+		}
+		if err := tx.Model(&domain.LoyaltyEntry{}).Where("customer_id IN ?", duplicateIDs).UpdateColumn("customer_id", id).Error; err != nil {
+			return err
+		}
+
+		// Duplikat dihapus lebih dulu agar nomor telepon dan emailnya bisa dipakai pelanggan ini
+		now := time.Now()
+		err = tx.Model(&domain.Customer{}).Where("id IN ?", duplicateIDs).
+			UpdateColumns(map[string]interface{}{"merged_into_id": id, "deleted_at": now, "updated_at": now}).Error
+		if err != nil {
+			return err
+		}
+		return tx.Model(&customer).UpdateColumns(map[string]interface{}{
+			"phone":      customer.Phone,
+			"email":      customer.Email,
+			"notes":      customer.Notes,
+			"allergies":  customer.Allergies,
+			"version":    customer.Version + 1,
+			"updated_at": now,
+		}).Error
+	})
+	if err != nil {
+		if !errors.Is(err, domain.ErrCustomerNotFound) && !errors.Is(err, domain.ErrInvalidCustomer) {
+			repo.log.Error("Failed to merge customers", zap.Uint("id", id), zap.Error(err))
+		}
+		return nil, err
+	}
+
+	repo.log.Info("Customers merged", zap.Uint("id", id), zap.Uints("duplicates", duplicateIDs))
+	return repo.FindByID(id)
+}
+
+func lockCustomer(tx *gorm.DB, customer *domain.Customer, id uint) error {
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(customer, id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return domain.ErrCustomerNotFound
+		}
+		return err
+	}
+	return nil
+}
+
+// checkCustomerContacts normalizes the customer and makes sure no other customer has its phone
+// number or email address, which stay locked until the transaction ends
+func checkCustomerContacts(tx *gorm.DB, customer *domain.Customer) error {
+	if err := customer.Normalize(); err != nil {
+		return err
+	}
+	if err := domain.LockCustomerContacts(tx, customer.Phone, customer.Email); err != nil {
+		return err
+	}
+
+	var taken int64
+	err := tx.Model(&domain.Customer{}).Where("id <> ?", customer.ID).
+		Where(tx.Where("phone <> '' AND phone = ?", customer.Phone).Or("email <> '' AND email = ?", customer.Email)).
+		Count(&taken).Error
+	if err != nil {
+		return err
+	}
+	if taken > 0 {
+		return domain.ErrDuplicateCustomer
+	}
+	return nil
+}
@@ -0,0 +1,92 @@
+package repository_test
+
+import (
+	"project/domain"
+	"project/repository"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+func TestMergeCustomers(t *testing.T) {
+
+	db, mock, err := setupMockDB()
+	assert.NoError(t, err)
+
+	repo := repository.NewCustomerRepository(db, zap.NewNop())
+
+	customerColumns := []string{"id", "name", "phone", "email", "notes", "allergies", "version"}
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(`SELECT \* FROM "customers" WHERE "customers"."id" = \$1 .* FOR UPDATE`).
+		WillReturnRows(sqlmock.NewRows(customerColumns).AddRow(1, "John Doe", "", "john@example.com", "Window table", "", 2))
+	mock.ExpectQuery(`SELECT \* FROM "customers" WHERE id IN \(\$1\) .* FOR UPDATE`).
+		WithArgs(2).
+		WillReturnRows(sqlmock.NewRows(customerColumns).AddRow(2, "John", "+62895123456", "", "", "Peanuts", 1))
+
+	// the rows are moved without running the hooks of orders and reservations
+	mock.ExpectExec(`UPDATE "reservations" SET "customer_id"=\$1 WHERE customer_id IN \(\$2\)`).
+		WithArgs(1, 2).WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(`UPDATE "orders" SET "customer_id"=\$1 WHERE customer_id IN \(\$2\)`).
+		WithArgs(1, 2).WillReturnResult(sqlmock.NewResult(0, 3))
+	mock.ExpectExec(`UPDATE "feedback" SET "customer_id"=\$1 WHERE customer_id IN \(\$2\)`).
+		WithArgs(1, 2).WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec(`UPDATE "customers" SET "merged_into_id"=\$1 WHERE merged_into_id IN \(\$2\)`).
+		WithArgs(1, 2).WillReturnResult(sqlmock.NewResult(0, 0))
+
+	mock.ExpectExec(`SELECT pg_advisory_xact_lock`).WithArgs("loyalty:1").WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec(`SELECT pg_advisory_xact_lock`).WithArgs("loyalty:2").WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec(`UPDATE "loyalty_entries" SET "customer_id"=\$1 WHERE customer_id IN \(\$2\)`).
+		WithArgs(1, 2).WillReturnResult(sqlmock.NewResult(0, 2))
+
+	mock.ExpectExec(`UPDATE "customers" SET "deleted_at"=\$1,"merged_into_id"=\$2,"updated_at"=\$3 WHERE id IN \(\$4\)`).
+		WithArgs(sqlmock.AnyArg(), 1, sqlmock.AnyArg(), 2).WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(`UPDATE "customers" SET "allergies"=\$1,"email"=\$2,"notes"=\$3,"phone"=\$4,"updated_at"=\$5,"version"=\$6`).
+		WithArgs("Peanuts", "john@example.com", "Window table", "+62895123456", sqlmock.AnyArg(), 3, 1).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	// the merged customer is read again with its stats
+	mock.ExpectQuery(`SELECT \* FROM "customers" WHERE "customers"."id" = \$1`).
+		WillReturnRows(sqlmock.NewRows(customerColumns).AddRow(1, "John Doe", "+62895123456", "john@example.com", "Window table", "Peanuts", 3))
+	mock.ExpectQuery(`FROM "payments" JOIN orders`).WillReturnRows(sqlmock.NewRows([]string{"orders"}).AddRow(120.0))
+	mock.ExpectQuery(`FROM "payments" JOIN reservations`).WillReturnRows(sqlmock.NewRows([]string{"coalesce"}).AddRow(10.0))
+	mock.ExpectQuery(`FROM "orders"`).WillReturnRows(sqlmock.NewRows([]string{"count", "last"}).AddRow(3, nil))
+	mock.ExpectQuery(`FROM "reservations"`).WillReturnRows(sqlmock.NewRows([]string{"count", "no_shows"}).AddRow(1, 0))
+	mock.ExpectQuery(`FROM "order_items"`).WillReturnRows(sqlmock.NewRows([]string{"product_id", "name", "quantity"}))
+	mock.ExpectQuery(`SELECT count\(\*\) FROM "orders"`).WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(0))
+	mock.ExpectQuery(`FROM "orders"`).WillReturnRows(sqlmock.NewRows([]string{"order_id"}))
+
+	customer, err := repo.Merge(1, []uint{2})
+
+	require.NoError(t, err)
+	assert.Equal(t, "+62895123456", customer.Phone)
+	assert.Equal(t, "Peanuts", customer.Allergies)
+	assert.Equal(t, 130.0, customer.LifetimeSpend)
+	assert.Equal(t, 3, customer.Visits)
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestMergeCustomers_IntoItself(t *testing.T) {
+
+	db, mock, err := setupMockDB()
+	assert.NoError(t, err)
+
+	repo := repository.NewCustomerRepository(db, zap.NewNop())
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(`SELECT \* FROM "customers"`).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "name"}).AddRow(1, "John Doe"))
+	mock.ExpectRollback()
+
+	customer, err := repo.Merge(1, []uint{1})
+
+	assert.ErrorIs(t, err, domain.ErrInvalidCustomer)
+	assert.Nil(t, customer)
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
@@ -31,9 +31,8 @@ func (repo *DashboardRepository) FetchSummary() (float64, float64, float64, erro
 	// Daily Sales Query
 	err := repo.db.Model(&domain.Order{}).
 		Where("DATE(orders.created_at) = ? AND orders.status_payment = ?", today, domain.OrderCompleted).
-		Select("COALESCE(SUM(order_items.quantity * products.price), 0) AS daily_sales").
-		Joins("JOIN order_items ON orders.id = order_items.order_id").
-		Joins("JOIN products ON order_items.product_id = products.id").
+		Select("COALESCE(SUM(order_details.net_total), 0) AS daily_sales").
+		Joins("JOIN order_details ON orders.id = order_details.order_id").
 		Scan(&dailySales).Error
 	if err != nil {
 		return 0, 0, 0, fmt.Errorf("failed to fetch daily sales: %v", err)
@@ -42,9 +41,8 @@ func (repo *DashboardRepository) FetchSummary() (float64, float64, float64, erro
 	// Monthly Sales Query
 	err = repo.db.Model(&domain.Order{}).
 		Where("TO_CHAR(orders.created_at, 'YYYY-MM') = ? AND orders.status_payment = ?", month, domain.OrderCompleted).
-		Select("COALESCE(SUM(order_items.quantity * products.price), 0) AS monthly_sales").
-		Joins("JOIN order_items ON orders.id = order_items.order_id").
-		Joins("JOIN products ON order_items.product_id = products.id").
+		Select("COALESCE(SUM(order_details.net_total), 0) AS monthly_sales").
+		Joins("JOIN order_details ON orders.id = order_details.order_id").
 		Scan(&monthlySales).Error
 	if err != nil {
 		return 0, 0, 0, fmt.Errorf("failed to fetch monthly sales: %v", err)
@@ -0,0 +1,283 @@
+package repository
+
+import (
+	"errors"
+	"project/domain"
+	"project/helper"
+	"time"
+
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+type DiscountRepository struct {
+	db          *gorm.DB
+	taxRounding domain.TaxRounding
+	log         *zap.Logger
+}
+
+func NewDiscountRepository(db *gorm.DB, taxRounding domain.TaxRounding, log *zap.Logger) *DiscountRepository {
+	return &DiscountRepository{db: db, taxRounding: taxRounding, log: log}
+}
+
+func (repo DiscountRepository) AllPromoCodes(page, limit int) ([]domain.PromoCode, int64, error) {
+	var promoCodes []domain.PromoCode
+	var totalItems int64
+
+	if err := repo.db.Model(&domain.PromoCode{}).Count(&totalItems).Error; err != nil {
+		repo.log.Error("Failed to count promo codes", zap.Error(err))
+		return nil, 0, err
+	}
+
+	err := repo.db.Order("id").Scopes(helper.Paginate(uint(page), uint(limit))).Find(&promoCodes).Error
+	if err != nil {
+		repo.log.Error("Failed to fetch promo codes", zap.Error(err))
+		return nil, 0, err
+	}
+
+	return promoCodes, totalItems, nil
+}
+
+func (repo DiscountRepository) FindPromoCode(promoCode *domain.PromoCode, id uint) error {
+	if err := repo.db.First(promoCode, id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return domain.ErrPromoCodeNotFound
+		}
+		repo.log.Error("Failed to fetch promo code", zap.Error(err))
+		return err
+	}
+	return nil
+}
+
+func (repo DiscountRepository) CreatePromoCode(promoCode *domain.PromoCode) error {
+	if err := repo.db.Create(promoCode).Error; err != nil {
+		if errors.Is(err, gorm.ErrDuplicatedKey) {
+			return errors.New("promo code already exists")
+		}
+		repo.log.Error("Failed to save promo code", zap.Error(err))
+		return err
+	}
+	return nil
+}
+
+func (repo DiscountRepository) UpdatePromoCode(promoCode *domain.PromoCode) error {
+	err := repo.db.Model(promoCode).Select("*").Omit("id", "used_count", "created_at", "deleted_at").Updates(promoCode).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrDuplicatedKey) {
+			return errors.New("promo code already exists")
+		}
+		repo.log.Error("Failed to update promo code", zap.Error(err))
+		return err
+	}
+	return nil
+}
+
+func (repo DiscountRepository) DeletePromoCode(id uint) error {
+	result := repo.db.Delete(&domain.PromoCode{}, id)
+	if result.Error != nil {
+		repo.log.Error("Failed to delete promo code", zap.Error(result.Error))
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return domain.ErrPromoCodeNotFound
+	}
+	return nil
+}
+
+func (repo DiscountRepository) AllPricingRules() ([]domain.PricingRule, error) {
+	var rules []domain.PricingRule
+	if err := repo.db.Order("id").Find(&rules).Error; err != nil {
+		repo.log.Error("Failed to fetch pricing rules", zap.Error(err))
+		return nil, err
+	}
+	return rules, nil
+}
+
+func (repo DiscountRepository) FindPricingRule(rule *domain.PricingRule, id uint) error {
+	if err := repo.db.First(rule, id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return errors.New("pricing rule not found")
+		}
+		repo.log.Error("Failed to fetch pricing rule", zap.Error(err))
+		return err
+	}
+	return nil
+}
+
+func (repo DiscountRepository) CreatePricingRule(rule *domain.PricingRule) error {
+	if err := repo.db.Create(rule).Error; err != nil {
+		repo.log.Error("Failed to save pricing rule", zap.Error(err))
+		return err
+	}
+	return nil
+}
+
+func (repo DiscountRepository) UpdatePricingRule(rule *domain.PricingRule) error {
+	if err := repo.db.Model(rule).Select("*").Omit("id", "created_at", "deleted_at").Updates(rule).Error; err != nil {
+		repo.log.Error("Failed to update pricing rule", zap.Error(err))
+		return err
+	}
+	return nil
+}
+
+func (repo DiscountRepository) DeletePricingRule(id uint) error {
+	result := repo.db.Delete(&domain.PricingRule{}, id)
+	if result.Error != nil {
+		repo.log.Error("Failed to delete pricing rule", zap.Error(result.Error))
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return errors.New("pricing rule not found")
+	}
+	return nil
+}
+
+func (repo DiscountRepository) AllServiceChargeRules() ([]domain.ServiceChargeRule, error) {
+	var rules []domain.ServiceChargeRule
+	if err := repo.db.Order("id").Find(&rules).Error; err != nil {
+		repo.log.Error("Failed to fetch service charge rules", zap.Error(err))
+		return nil, err
+	}
+	return rules, nil
+}
+
+func (repo DiscountRepository) FindServiceChargeRule(rule *domain.ServiceChargeRule, id uint) error {
+	if err := repo.db.First(rule, id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return domain.ErrServiceChargeRuleNotFound
+		}
+		repo.log.Error("Failed to fetch service charge rule", zap.Error(err))
+		return err
+	}
+	return nil
+}
+
+func (repo DiscountRepository) CreateServiceChargeRule(rule *domain.ServiceChargeRule) error {
+	if err := repo.db.Create(rule).Error; err != nil {
+		repo.log.Error("Failed to save service charge rule", zap.Error(err))
+		return err
+	}
+	return nil
+}
+
+func (repo DiscountRepository) UpdateServiceChargeRule(rule *domain.ServiceChargeRule) error {
+	if err := repo.db.Model(rule).Select("*").Omit("id", "created_at", "deleted_at").Updates(rule).Error; err != nil {
+		repo.log.Error("Failed to update service charge rule", zap.Error(err))
+		return err
+	}
+	return nil
+}
+
+func (repo DiscountRepository) DeleteServiceChargeRule(id uint) error {
+	result := repo.db.Delete(&domain.ServiceChargeRule{}, id)
+	if result.Error != nil {
+		repo.log.Error("Failed to delete service charge rule", zap.Error(result.Error))
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return domain.ErrServiceChargeRuleNotFound
+	}
+	return nil
+}
+
+func (repo DiscountRepository) OrderDiscounts(orderID uint) ([]domain.OrderDiscount, error) {
+	var discounts []domain.OrderDiscount
+	if err := repo.db.Where("order_id = ?", orderID).Order("id").Find(&discounts).Error; err != nil {
+		repo.log.Error("Failed to fetch order discounts", zap.Error(err))
+		return nil, err
+	}
+	return discounts, nil
+}
+
+// ApplyDiscount adds a manual discount, or the promo code when one is given, to an open order
+func (repo DiscountRepository) ApplyDiscount(orderID uint, discount domain.OrderDiscount, promoCode string) (*domain.OrderDiscount, error) {
+	var applied *domain.OrderDiscount
+	err := repo.db.Transaction(func(tx *gorm.DB) error {
+		order, err := lockOpenOrder(tx, orderID)
+		if err != nil {
+			return err
+		}
+
+		if promoCode != "" {
+			if applied, err = domain.ApplyPromoCode(tx, order.ID, promoCode, discount.UserID, time.Now()); err != nil {
+				return err
+			}
+		} else {
+			if discount.OrderItemID != nil {
+				if _, err := findOrderItem(tx, order.ID, *discount.OrderItemID); err != nil {
+					return err
+				}
+			}
+
+			discount.OrderID = order.ID
+			discount.Source = domain.DiscountManual
+			if err := tx.Create(&discount).Error; err != nil {
+				return err
+			}
+			applied = &discount
+		}
+
+		if err := domain.PriceOrder(tx, order.ID, time.Now(), repo.taxRounding); err != nil {
+			return err
+		}
+		if err := tx.First(applied, applied.ID).Error; err != nil {
+			return err
+		}
+		return bumpOrderVersion(tx, order.ID)
+	})
+	if err != nil {
+		repo.log.Error("Failed to apply discount", zap.Uint("order_id", orderID), zap.Error(err))
+		return nil, err
+	}
+
+	repo.log.Info("Discount applied", zap.Uint("order_id", orderID), zap.Float64("amount", applied.Amount))
+	return applied, nil
+}
+
+// RemoveDiscount takes a manual or promo discount off an open order, pricing rule discounts cannot be removed
+func (repo DiscountRepository) RemoveDiscount(orderID, discountID uint) error {
+	err := repo.db.Transaction(func(tx *gorm.DB) error {
+		order, err := lockOpenOrder(tx, orderID)
+		if err != nil {
+			return err
+		}
+
+		var discount domain.OrderDiscount
+		if err := tx.Where("id = ? AND order_id = ? AND source <> ?", discountID, order.ID, domain.DiscountRule).First(&discount).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return domain.ErrOrderDiscountNotFound
+			}
+			return err
+		}
+
+		if err := domain.RemoveOrderDiscount(tx, &discount); err != nil {
+			return err
+		}
+		if err := domain.PriceOrder(tx, order.ID, time.Now(), repo.taxRounding); err != nil {
+			return err
+		}
+		return bumpOrderVersion(tx, order.ID)
+	})
+	if err != nil {
+		repo.log.Error("Failed to remove discount", zap.Uint("order_id", orderID), zap.Error(err))
+		return err
+	}
+	return nil
+}
+
+func lockOpenOrder(tx *gorm.DB, orderID uint) (*domain.Order, error) {
+	var order domain.Order
+	if err := lockOrder(tx, &order, orderID); err != nil {
+		return nil, err
+	}
+	if order.StatusPayment != domain.OrderInProcess {
+		return nil, domain.ErrDiscountNotAllowed
+	}
+	return &order, nil
+}
+
+// bumpOrderVersion marks the order as changed so clients holding an older ETag have to reload it
+func bumpOrderVersion(tx *gorm.DB, orderID uint) error {
+	return tx.Model(&domain.Order{}).Where("id = ?", orderID).
+		UpdateColumns(map[string]interface{}{"version": gorm.Expr("version + 1"), "updated_at": time.Now()}).Error
+}
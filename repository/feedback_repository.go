@@ -0,0 +1,166 @@
+package repository
+
+import (
+	"errors"
+	"fmt"
+	"project/domain"
+	"project/helper"
+	"time"
+
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+type FeedbackRepository struct {
+	db *gorm.DB
+	// lowRating is the rating at or below which the admins are notified and a rating counts as low
+	lowRating int
+	log       *zap.Logger
+}
+
+func NewFeedbackRepository(db *gorm.DB, lowRating int, log *zap.Logger) *FeedbackRepository {
+	return &FeedbackRepository{db: db, lowRating: lowRating, log: log}
+}
+
+// Form reads the products a guest can rate on an order, and the feedback already given
+func (repo FeedbackRepository) Form(orderID uint) (*domain.FeedbackForm, error) {
+	var order domain.Order
+	if err := repo.db.First(&order, orderID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errors.New("order not found")
+		}
+		repo.log.Error("Failed to fetch order", zap.Uint("order_id", orderID), zap.Error(err))
+		return nil, err
+	}
+	if order.StatusPayment != domain.OrderCompleted {
+		return nil, domain.ErrFeedbackNotOpen
+	}
+
+	form := domain.FeedbackForm{CodeOrder: order.CodeOrder, Date: order.CreatedAt, Products: []domain.FeedbackProduct{}}
+	err := repo.db.Table("order_items oi").
+		Select("p.id AS product_id, p.name, p.image").
+		Joins("JOIN products p ON p.id = oi.product_id").
+		Where("oi.order_id = ?", orderID).
+		Group("p.id, p.name, p.image").
+		Order("MIN(oi.id)").
+		Scan(&form.Products).Error
+	if err != nil {
+		repo.log.Error("Failed to fetch feedback products", zap.Uint("order_id", orderID), zap.Error(err))
+		return nil, err
+	}
+
+	var feedback domain.Feedback
+	if err := repo.db.Preload("Items").Where("order_id = ?", orderID).Limit(1).Find(&feedback).Error; err != nil {
+		repo.log.Error("Failed to fetch feedback", zap.Uint("order_id", orderID), zap.Error(err))
+		return nil, err
+	}
+	if feedback.ID != 0 {
+		form.Feedback = &feedback
+	}
+	return &form, nil
+}
+
+// Submit saves the feedback of a completed order, only once per order
+func (repo FeedbackRepository) Submit(orderID uint, request domain.FeedbackRequest) (*domain.Feedback, error) {
+	var feedback *domain.Feedback
+	err := repo.db.Transaction(func(tx *gorm.DB) error {
+		var order domain.Order
+		if err := lockOrder(tx, &order, orderID); err != nil {
+			return err
+		}
+
+		var err error
+		feedback, err = domain.SubmitFeedback(tx, &order, request, repo.lowRating)
+		return err
+	})
+	if err != nil {
+		repo.log.Error("Failed to submit feedback", zap.Uint("order_id", orderID), zap.Error(err))
+		return nil, err
+	}
+
+	repo.log.Info("Feedback submitted", zap.Uint("order_id", orderID), zap.Int("overall", feedback.Overall))
+	return feedback, nil
+}
+
+// All lists the feedback given in [start, end) newest first, only low rated feedback when low is true
+func (repo FeedbackRepository) All(page, limit int, start, end time.Time, low bool) ([]domain.Feedback, int64, error) {
+	feedback := []domain.Feedback{}
+	var totalItems int64
+
+	query := repo.db.Model(&domain.Feedback{}).Where("created_at >= ? AND created_at < ?", start, end)
+	if low {
+		query = query.Where(`(overall <= ? OR food <= ? OR service <= ?
+			OR EXISTS (SELECT 1 FROM feedback_items fi WHERE fi.feedback_id = feedback.id AND fi.rating <= ?))`,
+			repo.lowRating, repo.lowRating, repo.lowRating, repo.lowRating)
+	}
+
+	if err := query.Count(&totalItems).Error; err != nil {
+		repo.log.Error("Failed to count feedback", zap.Error(err))
+		return nil, 0, err
+	}
+	err := query.Preload("Items").Order("created_at DESC, id DESC").
+		Scopes(helper.Paginate(uint(page), uint(limit))).Find(&feedback).Error
+	if err != nil {
+		repo.log.Error("Failed to fetch feedback", zap.Error(err))
+		return nil, 0, err
+	}
+	return feedback, totalItems, nil
+}
+
+// ByProduct averages the item ratings given in [start, end) per product, lowest first
+func (repo FeedbackRepository) ByProduct(start, end time.Time) ([]domain.FeedbackProductRating, error) {
+	results := []domain.FeedbackProductRating{}
+	err := repo.db.Table("feedback_items fi").
+		Select(`fi.product_id, p.name, COUNT(*) AS ratings, ROUND(AVG(fi.rating), 2) AS average,
+			COUNT(*) FILTER (WHERE fi.rating <= ?) AS low`, repo.lowRating).
+		Joins("JOIN feedback f ON f.id = fi.feedback_id").
+		Joins("JOIN products p ON p.id = fi.product_id").
+		Where("f.created_at >= ? AND f.created_at < ?", start, end).
+		Group("fi.product_id, p.name").
+		Order("average, ratings DESC").
+		Scan(&results).Error
+	if err != nil {
+		repo.log.Error("Failed to fetch ratings by product", zap.Error(err))
+		return nil, fmt.Errorf("failed to fetch ratings by product: %w", err)
+	}
+	return results, nil
+}
+
+// ByStaff averages the ratings given in [start, end) per staff member who served the order
+func (repo FeedbackRepository) ByStaff(start, end time.Time) ([]domain.FeedbackStaffRating, error) {
+	results := []domain.FeedbackStaffRating{}
+	err := repo.db.Table("feedback f").
+		Select(`f.served_by_id AS user_id, COALESCE(u.full_name, '') AS full_name, COUNT(*) AS ratings,
+			ROUND(AVG(f.overall), 2) AS overall, ROUND(AVG(f.food), 2) AS food, ROUND(AVG(f.service), 2) AS service,
+			COUNT(*) FILTER (WHERE f.overall <= ? OR f.food <= ? OR f.service <= ?) AS low`,
+			repo.lowRating, repo.lowRating, repo.lowRating).
+		Joins("LEFT JOIN users u ON u.id = f.served_by_id").
+		Where("f.created_at >= ? AND f.created_at < ?", start, end).
+		Group("f.served_by_id, u.full_name").
+		Order("overall, ratings DESC").
+		Scan(&results).Error
+	if err != nil {
+		repo.log.Error("Failed to fetch ratings by staff", zap.Error(err))
+		return nil, fmt.Errorf("failed to fetch ratings by staff: %w", err)
+	}
+	return results, nil
+}
+
+// Trend averages the ratings given in [start, end) per day, week or month
+func (repo FeedbackRepository) Trend(start, end time.Time, interval domain.FeedbackInterval) ([]domain.FeedbackTrend, error) {
+	results := []domain.FeedbackTrend{}
+	err := repo.db.Table("feedback f").
+		Select(`DATE_TRUNC(?, f.created_at) AS period, COUNT(*) AS ratings,
+			ROUND(AVG(f.overall), 2) AS overall, ROUND(AVG(f.food), 2) AS food, ROUND(AVG(f.service), 2) AS service,
+			COUNT(*) FILTER (WHERE f.overall <= ? OR f.food <= ? OR f.service <= ?) AS low`,
+			string(interval), repo.lowRating, repo.lowRating, repo.lowRating).
+		Where("f.created_at >= ? AND f.created_at < ?", start, end).
+		Group("period").
+		Order("period").
+		Scan(&results).Error
+	if err != nil {
+		repo.log.Error("Failed to fetch rating trend", zap.Error(err))
+		return nil, fmt.Errorf("failed to fetch rating trend: %w", err)
+	}
+	return results, nil
+}
@@ -0,0 +1,156 @@
+package repository
+
+import (
+	"errors"
+	"fmt"
+	"project/domain"
+	"project/helper"
+	"time"
+
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type GiftCardRepository struct {
+	db         *gorm.DB
+	expiryDays int
+	log        *zap.Logger
+}
+
+func NewGiftCardRepository(db *gorm.DB, expiryDays int, log *zap.Logger) *GiftCardRepository {
+	return &GiftCardRepository{db: db, expiryDays: expiryDays, log: log}
+}
+
+// All lists the gift cards newest first, optionally those whose code or recipient contains search
+// and those of a status. Expired and empty cards keep the active status.
+func (repo GiftCardRepository) All(page, limit int, search string, status domain.GiftCardStatus) ([]domain.GiftCard, int64, error) {
+	cards := []domain.GiftCard{}
+	var totalItems int64
+
+	query := repo.db.Model(&domain.GiftCard{})
+	if search != "" {
+		like := "%" + search + "%"
+		query = query.Where(repo.db.Where("code LIKE ?", "%"+domain.NormalizeGiftCardCode(search)+"%").
+			Or("recipient_name ILIKE ?", like).Or("recipient_email ILIKE ?", like))
+	}
+	if status != "" {
+		query = query.Where("status = ?", status)
+	}
+
+	if err := query.Count(&totalItems).Error; err != nil {
+		repo.log.Error("Failed to count gift cards", zap.Error(err))
+		return nil, 0, err
+	}
+	if err := query.Order("created_at DESC, id DESC").Scopes(helper.Paginate(uint(page), uint(limit))).Find(&cards).Error; err != nil {
+		repo.log.Error("Failed to fetch gift cards", zap.Error(err))
+		return nil, 0, err
+	}
+	return cards, totalItems, nil
+}
+
+// FindByID reads a gift card with its transactions, newest first
+func (repo GiftCardRepository) FindByID(id uint) (*domain.GiftCard, error) {
+	return repo.find(repo.db.Where("id = ?", id))
+}
+
+// FindByCode reads a gift card by its code as typed, with its transactions
+func (repo GiftCardRepository) FindByCode(code string) (*domain.GiftCard, error) {
+	return repo.find(repo.db.Where("code = ?", domain.NormalizeGiftCardCode(code)))
+}
+
+func (repo GiftCardRepository) find(query *gorm.DB) (*domain.GiftCard, error) {
+	var card domain.GiftCard
+	err := query.Preload("Transactions", func(db *gorm.DB) *gorm.DB {
+		return db.Order("id DESC")
+	}).First(&card).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, domain.ErrGiftCardNotFound
+		}
+		repo.log.Error("Failed to fetch gift card", zap.Error(err))
+		return nil, err
+	}
+	return &card, nil
+}
+
+// Issue sells a new gift card with a unique code
+func (repo GiftCardRepository) Issue(request domain.IssueGiftCardRequest, userID *uint) (*domain.GiftCard, error) {
+	now := time.Now()
+	expiresAt, err := domain.GiftCardExpiry(request.ExpiresOn, repo.expiryDays, now)
+	if err != nil {
+		return nil, err
+	}
+
+	card := domain.GiftCard{
+		InitialBalance:  request.Amount,
+		ExpiresAt:       expiresAt,
+		RecipientName:   request.RecipientName,
+		RecipientEmail:  domain.NormalizeEmail(request.RecipientEmail),
+		Message:         request.Message,
+		PaymentMethodID: &request.PaymentMethodID,
+		IssuedByID:      userID,
+	}
+	err = repo.db.Transaction(func(tx *gorm.DB) error {
+		var count int64
+		if err := tx.Model(&domain.PaymentMethod{}).Where("id = ?", request.PaymentMethodID).Count(&count).Error; err != nil {
+			return err
+		}
+		if count == 0 {
+			return fmt.Errorf("%w: payment method not found", domain.ErrInvalidGiftCard)
+		}
+		return domain.IssueGiftCard(tx, &card)
+	})
+	if err != nil {
+		repo.log.Error("Failed to issue gift card", zap.Error(err))
+		return nil, err
+	}
+
+	repo.log.Info("Gift card issued", zap.Uint("id", card.ID), zap.Float64("amount", card.InitialBalance))
+	return &card, nil
+}
+
+// Void takes the balance off a gift card
+func (repo GiftCardRepository) Void(id uint, note string, userID *uint) (*domain.GiftCard, error) {
+	var card domain.GiftCard
+	err := repo.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&card, id).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return domain.ErrGiftCardNotFound
+			}
+			return err
+		}
+		return domain.VoidGiftCard(tx, &card, note, userID)
+	})
+	if err != nil {
+		repo.log.Error("Failed to void gift card", zap.Uint("id", id), zap.Error(err))
+		return nil, err
+	}
+
+	repo.log.Info("Gift card voided", zap.Uint("id", id))
+	return &card, nil
+}
+
+// Redeem pays part of an open order from a gift card
+func (repo GiftCardRepository) Redeem(orderID uint, request domain.RedeemGiftCardRequest, userID *uint) (*domain.Payment, error) {
+	var payment *domain.Payment
+	err := repo.db.Transaction(func(tx *gorm.DB) error {
+		var order domain.Order
+		if err := lockOrder(tx, &order, orderID); err != nil {
+			return err
+		}
+
+		var err error
+		if payment, err = domain.RedeemGiftCard(tx, &order, request.Code, request.Amount, userID, time.Now()); err != nil {
+			return err
+		}
+		return bumpOrderVersion(tx, order.ID)
+	})
+	if err != nil {
+		repo.log.Error("Failed to redeem gift card", zap.Uint("order_id", orderID), zap.Error(err))
+		return nil, err
+	}
+
+	repo.log.Info("Gift card redeemed", zap.Uint("order_id", orderID), zap.Float64("amount", payment.Amount))
+	return payment, nil
+}
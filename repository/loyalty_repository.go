@@ -0,0 +1,202 @@
+package repository
+
+import (
+	"errors"
+	"fmt"
+	"project/domain"
+	"project/helper"
+	"time"
+
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+type LoyaltyRepository struct {
+	db     *gorm.DB
+	policy domain.LoyaltyPolicy
+	log    *zap.Logger
+}
+
+func NewLoyaltyRepository(db *gorm.DB, policy domain.LoyaltyPolicy, log *zap.Logger) *LoyaltyRepository {
+	return &LoyaltyRepository{db: db, policy: policy, log: log}
+}
+
+// Account reads the points balance and the tier of a customer
+func (repo LoyaltyRepository) Account(customerID uint) (*domain.LoyaltyAccount, error) {
+	if err := customerExists(repo.db, customerID); err != nil {
+		return nil, err
+	}
+
+	account, err := domain.CustomerLoyalty(repo.db, customerID, repo.policy, time.Now())
+	if err != nil {
+		repo.log.Error("Failed to read loyalty account", zap.Uint("customer_id", customerID), zap.Error(err))
+		return nil, err
+	}
+	return account, nil
+}
+
+// Ledger lists the points entries of a customer newest first
+func (repo LoyaltyRepository) Ledger(customerID uint, page, limit int) ([]domain.LoyaltyEntry, int64, error) {
+	if err := customerExists(repo.db, customerID); err != nil {
+		return nil, 0, err
+	}
+
+	entries := []domain.LoyaltyEntry{}
+	var totalItems int64
+	query := repo.db.Model(&domain.LoyaltyEntry{}).Where("customer_id = ?", customerID)
+	if err := query.Count(&totalItems).Error; err != nil {
+		repo.log.Error("Failed to count loyalty entries", zap.Error(err))
+		return nil, 0, err
+	}
+	if err := query.Order("created_at DESC, id DESC").Scopes(helper.Paginate(uint(page), uint(limit))).Find(&entries).Error; err != nil {
+		repo.log.Error("Failed to fetch loyalty entries", zap.Uint("customer_id", customerID), zap.Error(err))
+		return nil, 0, err
+	}
+	return entries, totalItems, nil
+}
+
+func (repo LoyaltyRepository) Adjust(customerID uint, adjustment domain.LoyaltyAdjustment, userID *uint) (*domain.LoyaltyEntry, error) {
+	var entry *domain.LoyaltyEntry
+	err := repo.db.Transaction(func(tx *gorm.DB) error {
+		if err := customerExists(tx, customerID); err != nil {
+			return err
+		}
+
+		var err error
+		entry, err = domain.AdjustLoyaltyPoints(tx, customerID, adjustment, repo.policy, userID, time.Now())
+		return err
+	})
+	if err != nil {
+		repo.log.Error("Failed to adjust loyalty points", zap.Uint("customer_id", customerID), zap.Error(err))
+		return nil, err
+	}
+
+	repo.log.Info("Loyalty points adjusted", zap.Uint("customer_id", customerID), zap.Int("points", adjustment.Points))
+	return entry, nil
+}
+
+// Redeem pays part of an open order with the points of its customer
+func (repo LoyaltyRepository) Redeem(orderID uint, points int, userID *uint) (*domain.Payment, error) {
+	var payment *domain.Payment
+	err := repo.db.Transaction(func(tx *gorm.DB) error {
+		var order domain.Order
+		if err := lockOrder(tx, &order, orderID); err != nil {
+			return err
+		}
+
+		var err error
+		if payment, err = domain.RedeemLoyaltyPoints(tx, &order, points, repo.policy, userID, time.Now()); err != nil {
+			return err
+		}
+		return bumpOrderVersion(tx, order.ID)
+	})
+	if err != nil {
+		repo.log.Error("Failed to redeem loyalty points", zap.Uint("order_id", orderID), zap.Error(err))
+		return nil, err
+	}
+
+	repo.log.Info("Loyalty points redeemed", zap.Uint("order_id", orderID), zap.Int("points", points))
+	return payment, nil
+}
+
+// Expire writes off the points expired by now and returns how many
+func (repo LoyaltyRepository) Expire(now time.Time) (int, error) {
+	var expired int
+	err := repo.db.Transaction(func(tx *gorm.DB) error {
+		var err error
+		expired, err = domain.ExpireLoyaltyPoints(tx, now)
+		return err
+	})
+	if err != nil {
+		repo.log.Error("Failed to expire loyalty points", zap.Error(err))
+		return 0, err
+	}
+	return expired, nil
+}
+
+func (repo LoyaltyRepository) Tiers() ([]domain.LoyaltyTier, error) {
+	tiers := []domain.LoyaltyTier{}
+	if err := repo.db.Order("min_points").Find(&tiers).Error; err != nil {
+		repo.log.Error("Failed to fetch loyalty tiers", zap.Error(err))
+		return nil, err
+	}
+	return tiers, nil
+}
+
+func (repo LoyaltyRepository) CreateTier(tier *domain.LoyaltyTier) error {
+	if err := checkLoyaltyTier(repo.db, tier); err != nil {
+		return err
+	}
+	if err := repo.db.Create(tier).Error; err != nil {
+		repo.log.Error("Failed to create loyalty tier", zap.Error(err))
+		return err
+	}
+	return nil
+}
+
+func (repo LoyaltyRepository) UpdateTier(id uint, details domain.LoyaltyTier) (*domain.LoyaltyTier, error) {
+	var tier domain.LoyaltyTier
+	if err := repo.db.First(&tier, id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, domain.ErrLoyaltyTierNotFound
+		}
+		return nil, err
+	}
+
+	tier.Name = details.Name
+	tier.MinPoints = details.MinPoints
+	tier.EarnMultiplier = details.EarnMultiplier
+	tier.Perks = details.Perks
+	if err := checkLoyaltyTier(repo.db, &tier); err != nil {
+		return nil, err
+	}
+	if err := repo.db.Save(&tier).Error; err != nil {
+		repo.log.Error("Failed to update loyalty tier", zap.Uint("id", id), zap.Error(err))
+		return nil, err
+	}
+	return &tier, nil
+}
+
+// SetCategoryEarnRate sets the points per currency unit of a category, nil goes back to the default rate
+func (repo LoyaltyRepository) SetCategoryEarnRate(categoryID uint, rate *float64) (*domain.Category, error) {
+	var category domain.Category
+	if err := repo.db.First(&category, categoryID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errors.New("category not found")
+		}
+		return nil, err
+	}
+
+	if err := repo.db.Model(&category).UpdateColumns(map[string]interface{}{"loyalty_earn_rate": rate, "updated_at": time.Now()}).Error; err != nil {
+		repo.log.Error("Failed to update category earn rate", zap.Uint("id", categoryID), zap.Error(err))
+		return nil, err
+	}
+	category.LoyaltyEarnRate = rate
+	return &category, nil
+}
+
+// checkLoyaltyTier keeps tier names and thresholds apart from the other tiers
+func checkLoyaltyTier(tx *gorm.DB, tier *domain.LoyaltyTier) error {
+	var count int64
+	err := tx.Model(&domain.LoyaltyTier{}).Where("id <> ?", tier.ID).
+		Where("LOWER(name) = LOWER(?) OR min_points = ?", tier.Name, tier.MinPoints).
+		Count(&count).Error
+	if err != nil {
+		return err
+	}
+	if count > 0 {
+		return fmt.Errorf("%w: another tier has this name or minimum points", domain.ErrInvalidLoyalty)
+	}
+	return nil
+}
+
+func customerExists(tx *gorm.DB, id uint) error {
+	var count int64
+	if err := tx.Model(&domain.Customer{}).Where("id = ?", id).Count(&count).Error; err != nil {
+		return err
+	}
+	if count == 0 {
+		return domain.ErrCustomerNotFound
+	}
+	return nil
+}
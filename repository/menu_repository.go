@@ -0,0 +1,84 @@
+package repository
+
+import (
+	"encoding/json"
+	"project/database"
+	"project/domain"
+	"time"
+
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+type MenuRepository struct {
+	db     *gorm.DB
+	cacher database.Cacher
+	ttl    time.Duration
+	log    *zap.Logger
+}
+
+// NewMenuRepository serves the menu from the cache for ttl seconds
+func NewMenuRepository(db *gorm.DB, cacher database.Cacher, ttl int, log *zap.Logger) *MenuRepository {
+	repo := &MenuRepository{db: db, cacher: cacher, ttl: time.Duration(ttl) * time.Second, log: log}
+	if repo.ttl <= 0 {
+		repo.ttl = 5 * time.Minute
+	}
+	return repo
+}
+
+// Menu reads the public menu as JSON, from the cache when it is there. The menu is still served
+// from the database when the cache cannot be reached. Stock changes with every sale, so the cached
+// menu holds the products whether in stock or not and the sold out ones are taken off when served.
+func (repo MenuRepository) Menu() ([]byte, error) {
+	menu, err := repo.cachedMenu()
+	if err != nil {
+		return nil, err
+	}
+
+	var soldOut []uint
+	if err := repo.db.Model(&domain.Product{}).Where("stock <= 0").Pluck("id", &soldOut).Error; err != nil {
+		repo.log.Error("Failed to fetch sold out products", zap.Error(err))
+		return nil, err
+	}
+	return json.Marshal(menu.Without(soldOut))
+}
+
+func (repo MenuRepository) cachedMenu() (domain.Menu, error) {
+	var menu domain.Menu
+	if cached, err := repo.cacher.Get(database.MenuCacheKey); err == nil && cached != "" {
+		if err := json.Unmarshal([]byte(cached), &menu); err == nil {
+			return menu, nil
+		}
+	}
+
+	var categories []domain.Category
+	if err := repo.db.Order("id").Find(&categories).Error; err != nil {
+		repo.log.Error("Failed to fetch menu categories", zap.Error(err))
+		return menu, err
+	}
+	var products []domain.Product
+	err := repo.db.Preload("Modifiers", func(db *gorm.DB) *gorm.DB {
+		return db.Order("position, id")
+	}).Where("status = ? AND deleted_at IS NULL", "Active").Order("name, id").Find(&products).Error
+	if err != nil {
+		repo.log.Error("Failed to fetch menu products", zap.Error(err))
+		return menu, err
+	}
+
+	menu = domain.NewMenu(categories, products)
+	body, err := json.Marshal(menu)
+	if err != nil {
+		return menu, err
+	}
+	if err := repo.cacher.SetFor(database.MenuCacheKey, string(body), repo.ttl); err != nil {
+		repo.log.Warn("Failed to cache menu", zap.Error(err))
+	}
+	return menu, nil
+}
+
+// Invalidate drops the cached menu so the next request reads it again
+func (repo MenuRepository) Invalidate() {
+	if err := repo.cacher.Delete(database.MenuCacheKey); err != nil {
+		repo.log.Warn("Failed to drop cached menu", zap.Error(err))
+	}
+}
@@ -0,0 +1,223 @@
+package repository
+
+import (
+	"fmt"
+	"project/domain"
+	"sort"
+	"strings"
+	"time"
+
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+// Merge moves the sources into the target order, prices it again and cancels the sources
+func (repo OrderRepository) Merge(targetID uint, sourceIDs []uint, userID *uint) (*domain.Order, error) {
+	sourceIDs, err := domain.MergeSources(targetID, sourceIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	var target *domain.Order
+	err = repo.db.Transaction(func(tx *gorm.DB) error {
+		orders, err := lockOrders(tx, append([]uint{targetID}, sourceIDs...))
+		if err != nil {
+			return err
+		}
+		target = orders[targetID]
+
+		var sources []*domain.Order
+		var codes []string
+		for _, id := range sourceIDs {
+			sources = append(sources, orders[id])
+			codes = append(codes, orders[id].CodeOrder)
+		}
+
+		if err := domain.MergeOrders(tx, target, sources); err != nil {
+			return err
+		}
+
+		now := time.Now()
+		for _, source := range sources {
+			if err := domain.PriceOrder(tx, source.ID, now, repo.taxRounding); err != nil {
+				return err
+			}
+			if err := recordMove(tx, source, domain.OrderActionMerge, domain.OrderInProcess, userID, "merged into "+target.CodeOrder); err != nil {
+				return err
+			}
+		}
+		if err := domain.PriceOrder(tx, target.ID, now, repo.taxRounding); err != nil {
+			return err
+		}
+		return recordMove(tx, target, domain.OrderActionMerge, target.StatusPayment, userID, "merged "+strings.Join(codes, ", "))
+	})
+	if err != nil {
+		repo.log.Error("Failed to merge orders", zap.Uint("order_id", targetID), zap.Uints("source_ids", sourceIDs), zap.Error(err))
+		return nil, err
+	}
+
+	repo.log.Info("Orders merged", zap.Uint("order_id", targetID), zap.Uints("source_ids", sourceIDs))
+	return target, nil
+}
+
+// MoveItems moves items to another open order, or to a new order at the same table when toID is nil
+func (repo OrderRepository) MoveItems(fromID uint, toID *uint, moves []domain.OrderItemMove, userID *uint) (*domain.Order, error) {
+	var to *domain.Order
+	err := repo.db.Transaction(func(tx *gorm.DB) error {
+		ids := []uint{fromID}
+		if toID != nil {
+			ids = append(ids, *toID)
+		}
+		orders, err := lockOrders(tx, ids)
+		if err != nil {
+			return err
+		}
+		from := orders[fromID]
+
+		action := domain.OrderActionMoveItems
+		if toID != nil {
+			to = orders[*toID]
+		} else {
+			action = domain.OrderActionSplit
+			if to, err = repo.split(tx, from); err != nil {
+				return err
+			}
+		}
+
+		if err := domain.MoveItems(tx, from, to, moves); err != nil {
+			return err
+		}
+
+		now := time.Now()
+		for _, order := range []*domain.Order{from, to} {
+			if err := domain.PriceOrder(tx, order.ID, now, repo.taxRounding); err != nil {
+				return err
+			}
+		}
+		if err := recordMove(tx, from, action, from.StatusPayment, userID, "items moved to "+to.CodeOrder); err != nil {
+			return err
+		}
+		return recordMove(tx, to, action, to.StatusPayment, userID, "items moved from "+from.CodeOrder)
+	})
+	if err != nil {
+		repo.log.Error("Failed to move order items", zap.Uint("order_id", fromID), zap.Error(err))
+		return nil, err
+	}
+
+	repo.log.Info("Order items moved", zap.Uint("order_id", fromID), zap.Uint("target_order_id", to.ID))
+	return to, nil
+}
+
+// split creates the order a split bill moves its items to. Table checks are skipped because the
+// new order shares the table of the one it is split from.
+func (repo OrderRepository) split(tx *gorm.DB, from *domain.Order) (*domain.Order, error) {
+	order := from.Split()
+	if err := order.Validate(); err != nil {
+		return nil, err
+	}
+
+	codeOrder, queueNumber, err := repo.codeFormat.Next(tx, time.Now())
+	if err != nil {
+		return nil, err
+	}
+	order.CodeOrder = codeOrder
+	order.QueueNumber = queueNumber
+	order.StatusPayment = domain.OrderInProcess
+	order.StatusKitchen = from.StatusKitchen
+
+	if err := tx.Session(&gorm.Session{SkipHooks: true}).Create(order).Error; err != nil {
+		return nil, fmt.Errorf("failed to create split order: %v", err)
+	}
+	return order, nil
+}
+
+// Transfer moves an open order to another table
+func (repo OrderRepository) Transfer(id, tableID uint, userID *uint) (*domain.Order, error) {
+	return repo.moveTables(id, userID, func(tx *gorm.DB, order *domain.Order) (domain.OrderAction, string, error) {
+		from := "no table"
+		if order.TableID != nil {
+			from = fmt.Sprintf("table %d", *order.TableID)
+		}
+		if err := domain.TransferTable(tx, order, tableID); err != nil {
+			return "", "", err
+		}
+		return domain.OrderActionTransfer, fmt.Sprintf("moved from %s to table %d", from, tableID), nil
+	})
+}
+
+// JoinTables adds tables to an open order for a large party
+func (repo OrderRepository) JoinTables(id uint, tableIDs []uint, userID *uint) (*domain.Order, error) {
+	return repo.moveTables(id, userID, func(tx *gorm.DB, order *domain.Order) (domain.OrderAction, string, error) {
+		if err := domain.JoinTables(tx, order, tableIDs); err != nil {
+			return "", "", err
+		}
+		return domain.OrderActionJoinTable, fmt.Sprintf("joined tables %v", tableIDs), nil
+	})
+}
+
+// LeaveTable takes a joined table off an open order
+func (repo OrderRepository) LeaveTable(id, tableID uint, userID *uint) (*domain.Order, error) {
+	return repo.moveTables(id, userID, func(tx *gorm.DB, order *domain.Order) (domain.OrderAction, string, error) {
+		if err := domain.LeaveTable(tx, order, tableID); err != nil {
+			return "", "", err
+		}
+		return domain.OrderActionLeaveTable, fmt.Sprintf("left table %d", tableID), nil
+	})
+}
+
+func (repo OrderRepository) moveTables(id uint, userID *uint, move func(tx *gorm.DB, order *domain.Order) (domain.OrderAction, string, error)) (*domain.Order, error) {
+	var order domain.Order
+	err := repo.db.Transaction(func(tx *gorm.DB) error {
+		if err := lockOrder(tx, &order, id); err != nil {
+			return err
+		}
+		action, reason, err := move(tx, &order)
+		if err != nil {
+			return err
+		}
+		return recordMove(tx, &order, action, order.StatusPayment, userID, reason)
+	})
+	if err != nil {
+		repo.log.Error("Failed to move order tables", zap.Uint("order_id", id), zap.Error(err))
+		return nil, err
+	}
+	return &order, nil
+}
+
+// lockOrders locks the orders in id order, so two moves between the same orders cannot deadlock
+func lockOrders(tx *gorm.DB, ids []uint) (map[uint]*domain.Order, error) {
+	sorted := append([]uint(nil), ids...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+
+	orders := make(map[uint]*domain.Order, len(sorted))
+	for _, id := range sorted {
+		if _, ok := orders[id]; ok {
+			continue
+		}
+		var order domain.Order
+		if err := lockOrder(tx, &order, id); err != nil {
+			return nil, err
+		}
+		orders[id] = &order
+	}
+	return orders, nil
+}
+
+// recordMove bumps the order version and writes the history entry of a merge, split or move
+func recordMove(tx *gorm.DB, order *domain.Order, action domain.OrderAction, from domain.StatusPayment, userID *uint, reason string) error {
+	if err := bumpOrderVersion(tx, order.ID); err != nil {
+		return err
+	}
+	order.Version++
+
+	return tx.Create(&domain.OrderStatusHistory{
+		OrderID:     order.ID,
+		Action:      action,
+		FromPayment: from,
+		ToPayment:   order.StatusPayment,
+		FromKitchen: order.StatusKitchen,
+		ToKitchen:   order.StatusKitchen,
+		UserID:      userID,
+		Reason:      reason,
+	}).Error
+}
@@ -4,63 +4,81 @@ import (
 	"errors"
 	"project/domain"
 	"project/helper"
+	"time"
 
 	"go.uber.org/zap"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 type OrderRepository struct {
-	db  *gorm.DB
-	log *zap.Logger
+	db          *gorm.DB
+	codeFormat  domain.OrderCodeFormat
+	taxRounding domain.TaxRounding
+	loyalty     domain.LoyaltyPolicy
+	deposit     domain.DepositPolicy
+	log         *zap.Logger
 }
 
-func NewOrderRepository(db *gorm.DB, log *zap.Logger) *OrderRepository {
-	return &OrderRepository{db: db, log: log}
+func NewOrderRepository(db *gorm.DB, codeFormat domain.OrderCodeFormat, taxRounding domain.TaxRounding, loyalty domain.LoyaltyPolicy, deposit domain.DepositPolicy, log *zap.Logger) *OrderRepository {
+	return &OrderRepository{db: db, codeFormat: codeFormat, taxRounding: taxRounding, loyalty: loyalty, deposit: deposit, log: log}
 }
 
-func (repo OrderRepository) Create(order *domain.Order) error {
+// Create saves the order, applies the automatic pricing rules and, when given, the promo code,
+// then calculates its taxes
+func (repo OrderRepository) Create(order *domain.Order, promoCode string) error {
 
 	return repo.db.Transaction(func(tx *gorm.DB) error {
-
-		if err := tx.Create(&order).Error; err != nil {
-			if errors.Is(err, gorm.ErrDuplicatedKey) {
-				repo.log.Error("Duplicate code order", zap.Error(err))
-				return errors.New("order with this code order already exists")
-			}
-			repo.log.Error("Failed to save Order", zap.Error(err))
-			return err
-		}
-
-		repo.log.Info("Order successfully created")
-		return nil
+		return repo.create(tx, order, promoCode)
 	})
 }
 
-func (repo OrderRepository) AllTables(page, limit int) ([]*domain.Table, int64, error) {
-	var tables []*domain.Table
-	var totalItems int64
-
-	err := repo.db.Model(&domain.Table{}).Where("status = ?", true).Count(&totalItems).Error
+// create saves and prices a new order within the transaction of the caller
+func (repo OrderRepository) create(tx *gorm.DB, order *domain.Order, promoCode string) error {
+	now := time.Now()
+	codeOrder, queueNumber, err := repo.codeFormat.Next(tx, now)
 	if err != nil {
-		repo.log.Error("Failed to count total tables", zap.Error(err))
-		return nil, 0, err
+		repo.log.Error("Failed to generate code order", zap.Error(err))
+		return err
 	}
+	order.CodeOrder = codeOrder
+	order.QueueNumber = queueNumber
 
-	err = repo.db.Model(&domain.Table{}).Order("id").Where("status = ?", true).
-		Scopes(helper.Paginate(uint(page), uint(limit))).
-		Find(&tables).Error
+	// Pesanan dengan nomor telepon ditautkan ke pelanggan yang sama
+	customerID, err := domain.LinkCustomer(tx, order.CustomerID, order.Name, order.CustomerPhone, "")
 	if err != nil {
-		repo.log.Error("Failed to fetch tables", zap.Error(err))
-		return nil, 0, err
+		return err
 	}
+	order.CustomerID = customerID
 
-	if totalItems == 0 {
-		repo.log.Warn("No tables found")
-		return nil, 0, errors.New("no tables found")
+	if err := tx.Create(&order).Error; err != nil {
+		if errors.Is(err, gorm.ErrDuplicatedKey) {
+			repo.log.Error("Duplicate code order", zap.Error(err))
+			return errors.New("order with this code order already exists")
+		}
+		repo.log.Error("Failed to save Order", zap.Error(err))
+		return err
+	}
+
+	if err := domain.PriceOrder(tx, order.ID, now, repo.taxRounding); err != nil {
+		repo.log.Error("Failed to price order", zap.Error(err))
+		return err
 	}
 
-	return tables, totalItems, nil
+	if promoCode != "" {
+		if _, err := domain.ApplyPromoCode(tx, order.ID, promoCode, nil, now); err != nil {
+			return err
+		}
+		// taxes are charged on the price after the promo code
+		if err := domain.PriceOrder(tx, order.ID, now, repo.taxRounding); err != nil {
+			return err
+		}
+	}
+
+	repo.log.Info("Order successfully created")
+	return nil
 }
+
 func (repo OrderRepository) AllPayments() ([]*domain.PaymentMethod, error) {
 	var payments []*domain.PaymentMethod
 
@@ -115,15 +133,113 @@ func (repo *OrderRepository) Update(order *domain.Order) error {
 	return repo.db.Transaction(func(tx *gorm.DB) error {
 		
 		// tx.Session(&gorm.Session{FullSaveAssociations: true}).Updates(&order)
-		if err := tx.Model(&order).Where("id = ?", order.ID).Updates(order).Error; err != nil {
-			repo.log.Error("Failed to update Order", zap.Error(err))
+		expectedVersion := order.Version
+		order.Version = expectedVersion + 1
+
+		// listed so switching the order type can clear the table and delivery details
+		result := tx.Model(&order).Where("id = ? AND version = ?", order.ID, expectedVersion).
+			Select("name", "type", "table_id", "party_size", "customer_phone", "delivery_address", "delivery_fee", "promised_at", "version", "updated_at").
+			Updates(order)
+		if result.Error != nil {
+			order.Version = expectedVersion
+			repo.log.Error("Failed to update Order", zap.Error(result.Error))
+			return result.Error
+		}
+
+		if result.RowsAffected == 0 {
+			order.Version = expectedVersion
+			repo.log.Warn("Order version conflict", zap.Uint("order_id", order.ID), zap.Uint("version", expectedVersion))
+			return domain.ErrVersionConflict
+		}
+
+		// items are saved here rather than as an association, which would only re-link them
+		if err := domain.SaveOrderItems(tx, order.ID, order.OrderItems); err != nil {
 			return err
 		}
-		return nil
+
+		return domain.PriceOrder(tx, order.ID, time.Now(), repo.taxRounding)
+	})
+}
+
+// Transition locks the order, runs the state machine and stores the new statuses with a history entry.
+// A paid order earns loyalty points for its customer, a cancelled one gives back the points and gift cards used on it.
+func (repo *OrderRepository) Transition(id uint, action domain.OrderAction, input domain.OrderTransitionInput) (*domain.Order, error) {
+	var order domain.Order
+	err := repo.db.Transaction(func(tx *gorm.DB) error {
+		if err := lockOrder(tx, &order, id); err != nil {
+			return err
+		}
+
+		history, err := order.Transition(tx, action, input)
+		if err != nil {
+			return err
+		}
+
+		result := tx.Model(&domain.Order{}).Where("id = ? AND version = ?", order.ID, order.Version).
+			UpdateColumns(map[string]interface{}{
+				"status_payment":    order.StatusPayment,
+				"status_kitchen":    order.StatusKitchen,
+				"payment_method_id": order.PaymentMethodID,
+				"version":           order.Version + 1,
+				"updated_at":        time.Now(),
+			})
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return domain.ErrVersionConflict
+		}
+		order.Version++
+
+		switch action {
+		case domain.OrderActionPay:
+			if err := domain.EarnLoyaltyPoints(tx, &order, repo.loyalty, time.Now()); err != nil {
+				return err
+			}
+		case domain.OrderActionCancel:
+			if err := domain.RestoreRedeemedPoints(tx, order.ID, 1, repo.loyalty, time.Now()); err != nil {
+				return err
+			}
+			if err := domain.ReturnGiftCardTenders(tx, order.ID, 1, input.UserID); err != nil {
+				return err
+			}
+			if err := domain.CancelSeatedReservations(tx, order.ID, repo.deposit, input, time.Now()); err != nil {
+				return err
+			}
+		}
+
+		return tx.Create(history).Error
 	})
+	if err != nil {
+		repo.log.Error("Failed to transition order", zap.Uint("order_id", id), zap.String("action", string(action)), zap.Error(err))
+		return nil, err
+	}
+
+	repo.log.Info("Order transitioned", zap.Uint("order_id", id), zap.String("action", string(action)))
+	return &order, nil
 }
 
-func (repo OrderRepository) AllOrders(page, limit int, name, codeOrder string, status domain.StatusPayment) ([]*domain.OrderDetail, int64, error) {
+// lockOrder loads an order with a row lock so concurrent changes to it wait for the transaction
+func lockOrder(tx *gorm.DB, order *domain.Order, id uint) error {
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(order, id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return errors.New("order not found")
+		}
+		return err
+	}
+	return nil
+}
+
+func (repo *OrderRepository) History(orderID uint) ([]domain.OrderStatusHistory, error) {
+	var histories []domain.OrderStatusHistory
+	if err := repo.db.Where("order_id = ?", orderID).Order("id").Find(&histories).Error; err != nil {
+		repo.log.Error("Failed to fetch order status history", zap.Error(err))
+		return nil, err
+	}
+	return histories, nil
+}
+
+func (repo OrderRepository) AllOrders(page, limit int, name, codeOrder string, status domain.StatusPayment, orderType domain.OrderType) ([]*domain.OrderDetail, int64, error) {
 	var orders []*domain.OrderDetail
 	var totalItems int64
 
@@ -137,6 +253,9 @@ func (repo OrderRepository) AllOrders(page, limit int, name, codeOrder string, s
 	if status != "" {
 		query = query.Where("status_payment = ?", status)
 	}
+	if orderType != "" {
+		query = query.Where("type = ?", orderType)
+	}
 
 	if err := query.Count(&totalItems).Error; err != nil {
 		repo.log.Error("Failed to count total orders", zap.Error(err))
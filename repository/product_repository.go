@@ -12,12 +12,14 @@ import (
 )
 
 type ProductRepository struct {
-	db  *gorm.DB
-	log *zap.Logger
+	db   *gorm.DB
+	menu MenuRepository
+	log  *zap.Logger
 }
 
-func NewProductRepository(db *gorm.DB, log *zap.Logger) *ProductRepository {
-	return &ProductRepository{db: db, log: log}
+// NewProductRepository drops the cached menu once a product or its modifiers are written
+func NewProductRepository(db *gorm.DB, menu MenuRepository, log *zap.Logger) *ProductRepository {
+	return &ProductRepository{db: db, menu: menu, log: log}
 }
 
 func (repo ProductRepository) All(
@@ -129,11 +131,22 @@ func (repo ProductRepository) Add(product *domain.Product, categoryName string)
 		repo.log.Error("Failed to add product", zap.Error(err))
 		return nil, fmt.Errorf("failed to add product: %v", err)
 	}
+	repo.menu.Invalidate()
 
 	repo.log.Info("product added successfully", zap.Uint("product_id", product.ID))
 	return product, nil
 }
 
+func (repo ProductRepository) GetByID(id uint) (*domain.Product, error) {
+	var product domain.Product
+	if err := repo.db.Preload("Category").First(&product, id).Error; err != nil {
+		repo.log.Error("product not found", zap.Uint("product_id", id), zap.Error(err))
+		return nil, fmt.Errorf("product with ID %d not found", id)
+	}
+	return &product, nil
+}
+
+// Update applies ProductData when ProductData.Version matches the stored version (0 skips the check)
 func (repo ProductRepository) Update(id uint, ProductData *domain.Product, categoryName string) (*domain.Product, error) {
 	var existingProduct domain.Product
 
@@ -143,6 +156,13 @@ func (repo ProductRepository) Update(id uint, ProductData *domain.Product, categ
 		return nil, fmt.Errorf("product with ID %d not found", id)
 	}
 
+	expectedVersion := existingProduct.Version
+	if ProductData.Version != 0 && ProductData.Version != expectedVersion {
+		repo.log.Warn("Product version conflict", zap.Uint("product_id", id), zap.Uint("version", ProductData.Version))
+		return nil, domain.ErrVersionConflict
+	}
+	ProductData.Version = expectedVersion + 1
+
 	// Validasi Category Name jika diberikan
 	if categoryName != "" {
 		var category domain.Category
@@ -164,14 +184,21 @@ func (repo ProductRepository) Update(id uint, ProductData *domain.Product, categ
 		ProductData.Availability = "Out of Stock"
 	}
 
-	// Update field lainnya
-	if err := repo.db.Model(&existingProduct).Select(
-		"CategoryID", "Image", "Name", "CodeProduct", "Stock", "Price", "Status", "Availability",
-	).Updates(ProductData).Error; err != nil {
-		repo.log.Error("Failed to update inventory", zap.Error(err))
-		return nil, fmt.Errorf("failed to update inventory: %v", err)
+	// Update field lainnya, hanya jika versi belum berubah sejak dibaca
+	result := repo.db.Model(&existingProduct).Where("version = ?", expectedVersion).Select(
+		"CategoryID", "Image", "Name", "Description", "Allergens", "CodeProduct", "Stock", "Price", "Status", "Availability", "Version",
+	).Updates(ProductData)
+	if result.Error != nil {
+		repo.log.Error("Failed to update inventory", zap.Error(result.Error))
+		return nil, fmt.Errorf("failed to update inventory: %v", result.Error)
 	}
 
+	if result.RowsAffected == 0 {
+		repo.log.Warn("Product version conflict", zap.Uint("product_id", id), zap.Uint("version", expectedVersion))
+		return nil, domain.ErrVersionConflict
+	}
+	repo.menu.Invalidate()
+
 	repo.log.Info("Inventory updated successfully", zap.Uint("inventory_id", existingProduct.ID))
 	return &existingProduct, nil
 }
@@ -194,7 +221,42 @@ func (repo *ProductRepository) Delete(id uint) error {
 		repo.log.Error("Failed to soft delete Product", zap.Error(err))
 		return fmt.Errorf("failed to soft delete Product: %v", err)
 	}
+	repo.menu.Invalidate()
 
 	repo.log.Info("product soft deleted successfully", zap.Uint("product_id", Product.ID))
 	return nil
 }
+
+// SetModifiers replaces the modifiers of a product, in the order given
+func (repo ProductRepository) SetModifiers(productID uint, modifiers []domain.ProductModifier) ([]domain.ProductModifier, error) {
+	if err := domain.CheckModifiers(modifiers); err != nil {
+		return nil, err
+	}
+
+	err := repo.db.Transaction(func(tx *gorm.DB) error {
+		var product domain.Product
+		if err := tx.Where("deleted_at IS NULL").First(&product, productID).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return errors.New("product not found")
+			}
+			return err
+		}
+
+		if err := tx.Where("product_id = ?", productID).Delete(&domain.ProductModifier{}).Error; err != nil {
+			return err
+		}
+		for i := range modifiers {
+			modifiers[i].ProductID = productID
+		}
+		if len(modifiers) > 0 {
+			return tx.Create(&modifiers).Error
+		}
+		return nil
+	})
+	if err != nil {
+		repo.log.Error("Failed to set product modifiers", zap.Uint("product_id", productID), zap.Error(err))
+		return nil, err
+	}
+	repo.menu.Invalidate()
+	return modifiers, nil
+}
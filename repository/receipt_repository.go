@@ -0,0 +1,86 @@
+package repository
+
+import (
+	"errors"
+	"project/domain"
+
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+type ReceiptRepository struct {
+	db  *gorm.DB
+	log *zap.Logger
+}
+
+func NewReceiptRepository(db *gorm.DB, log *zap.Logger) *ReceiptRepository {
+	return &ReceiptRepository{db: db, log: log}
+}
+
+func (repo ReceiptRepository) OrderDetail(orderID uint) (*domain.OrderDetail, error) {
+	var detail domain.OrderDetail
+	if err := repo.db.First(&detail, "order_id = ?", orderID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errors.New("order not found")
+		}
+		repo.log.Error("Failed to fetch order detail", zap.Uint("order_id", orderID), zap.Error(err))
+		return nil, err
+	}
+	return &detail, nil
+}
+
+// Payments lists the payments and refunds of an order with their method names
+func (repo ReceiptRepository) Payments(orderID uint) ([]domain.ReceiptPaymentRow, error) {
+	var payments []domain.ReceiptPaymentRow
+	err := repo.db.Model(&domain.Payment{}).
+		Select("payments.*, COALESCE(pm.name, '') AS method_name").
+		Joins("LEFT JOIN payment_methods pm ON pm.id = payments.payment_method_id").
+		Where("payments.order_id = ?", orderID).
+		Order("payments.id").
+		Scan(&payments).Error
+	if err != nil {
+		repo.log.Error("Failed to fetch receipt payments", zap.Uint("order_id", orderID), zap.Error(err))
+		return nil, err
+	}
+	return payments, nil
+}
+
+// KitchenItems lists the items of an order with the station of their category, optionally for one station
+func (repo ReceiptRepository) KitchenItems(orderID uint, station string) ([]domain.KitchenTicketItem, error) {
+	var items []domain.KitchenTicketItem
+	query := repo.db.Table("order_items oi").
+		Select("c.station, p.name, oi.quantity").
+		Joins("JOIN products p ON p.id = oi.product_id").
+		Joins("JOIN categories c ON c.id = p.category_id").
+		Where("oi.order_id = ?", orderID)
+	if station != "" {
+		query = query.Where("c.station = ?", station)
+	}
+
+	if err := query.Order("c.station, oi.id").Scan(&items).Error; err != nil {
+		repo.log.Error("Failed to fetch kitchen items", zap.Uint("order_id", orderID), zap.Error(err))
+		return nil, err
+	}
+	return items, nil
+}
+
+// CountPrint records one more printed receipt for the order and returns the new count
+func (repo ReceiptRepository) CountPrint(orderID uint) (int, error) {
+	var prints int
+	err := repo.db.Transaction(func(tx *gorm.DB) error {
+		result := tx.Model(&domain.Order{}).Where("id = ?", orderID).
+			UpdateColumn("receipt_prints", gorm.Expr("receipt_prints + 1"))
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return errors.New("order not found")
+		}
+		return tx.Model(&domain.Order{}).Select("receipt_prints").Where("id = ?", orderID).Scan(&prints).Error
+	})
+	if err != nil {
+		repo.log.Error("Failed to count receipt print", zap.Uint("order_id", orderID), zap.Error(err))
+		return 0, err
+	}
+	return prints, nil
+}
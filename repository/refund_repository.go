@@ -0,0 +1,345 @@
+package repository
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"project/domain"
+	"time"
+
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+// ApproveFunc is called inside the void or refund transaction once the amount is known.
+// It returns the id of the approving manager, or nil when no approval is needed.
+type ApproveFunc func(amount float64) (*uint, error)
+
+type RefundRepository struct {
+	db          *gorm.DB
+	taxRounding domain.TaxRounding
+	loyalty     domain.LoyaltyPolicy
+	log         *zap.Logger
+}
+
+func NewRefundRepository(db *gorm.DB, taxRounding domain.TaxRounding, loyalty domain.LoyaltyPolicy, log *zap.Logger) *RefundRepository {
+	return &RefundRepository{db: db, taxRounding: taxRounding, loyalty: loyalty, log: log}
+}
+
+func (repo RefundRepository) Void(input domain.VoidInput, approve ApproveFunc) (*domain.OrderVoid, error) {
+	var void domain.OrderVoid
+	err := repo.db.Transaction(func(tx *gorm.DB) error {
+		var order domain.Order
+		if err := lockOrder(tx, &order, input.OrderID); err != nil {
+			return err
+		}
+
+		if order.StatusPayment != domain.OrderInProcess {
+			return domain.ErrVoidNotAllowed
+		}
+
+		item, err := findOrderItem(tx, order.ID, input.OrderItemID)
+		if err != nil {
+			return err
+		}
+
+		if input.Quantity <= 0 || input.Quantity > item.Quantity {
+			return domain.ErrInvalidAdjustment
+		}
+
+		amount, err := order.ChargedAmount(tx, float64(input.Quantity)*item.Product.Price)
+		if err != nil {
+			return err
+		}
+		approvedBy, err := approve(amount)
+		if err != nil {
+			return err
+		}
+
+		// hooks are skipped so stock only comes back when the void asks for it
+		if input.Quantity == item.Quantity {
+			err = tx.Session(&gorm.Session{SkipHooks: true}).Delete(item).Error
+		} else {
+			err = tx.Model(item).UpdateColumn("quantity", item.Quantity-input.Quantity).Error
+		}
+		if err != nil {
+			return fmt.Errorf("failed to void order item: %v", err)
+		}
+
+		if input.ReturnStock {
+			if err := domain.AdjustProductStock(tx, item.ProductID, input.Quantity); err != nil {
+				return err
+			}
+		}
+
+		if err := domain.PriceOrder(tx, order.ID, time.Now(), repo.taxRounding); err != nil {
+			return err
+		}
+
+		void = domain.OrderVoid{
+			OrderID:      order.ID,
+			OrderItemID:  item.ID,
+			ProductID:    item.ProductID,
+			Quantity:     input.Quantity,
+			Amount:       amount,
+			ReasonCode:   input.ReasonCode,
+			Note:         input.Note,
+			ReturnStock:  input.ReturnStock,
+			UserID:       input.UserID,
+			ApprovedByID: approvedBy,
+		}
+		if err := tx.Create(&void).Error; err != nil {
+			return err
+		}
+
+		return recordAdjustment(tx, &order, domain.OrderActionVoid, input.UserID, input.ReasonCode, input.Note)
+	})
+	if err != nil {
+		repo.log.Error("Failed to void order item", zap.Uint("order_id", input.OrderID), zap.Error(err))
+		return nil, err
+	}
+
+	repo.log.Info("Order item voided", zap.Uint("order_id", input.OrderID), zap.Uint("order_item_id", input.OrderItemID))
+	return &void, nil
+}
+
+// Refund gives back part or all of a completed order. Without items and amount the whole remaining balance is refunded.
+// The refund is spread over the money, points and gift cards paid in proportion, and the points the order earned are taken back alike.
+func (repo RefundRepository) Refund(input domain.RefundInput, approve ApproveFunc) (*domain.Refund, error) {
+	var refund domain.Refund
+	err := repo.db.Transaction(func(tx *gorm.DB) error {
+		var order domain.Order
+		if err := lockOrder(tx, &order, input.OrderID); err != nil {
+			return err
+		}
+
+		if order.StatusPayment != domain.OrderCompleted {
+			return domain.ErrRefundNotAllowed
+		}
+
+		money, tenders, err := refundBalance(tx, &order)
+		if err != nil {
+			return err
+		}
+		refundable := money + tenders
+
+		fullRefund := len(input.Items) == 0 && input.Amount == 0
+		requested := input.Items
+		if fullRefund {
+			if requested, err = remainingItems(tx, order.ID); err != nil {
+				return err
+			}
+		}
+
+		var items []domain.RefundItem
+		var itemsAmount float64
+		for _, requestedItem := range requested {
+			item, err := findOrderItem(tx, order.ID, requestedItem.OrderItemID)
+			if err != nil {
+				return err
+			}
+
+			if requestedItem.Quantity <= 0 || requestedItem.Quantity > item.Quantity-item.RefundedQuantity {
+				return domain.ErrInvalidAdjustment
+			}
+
+			amount, err := order.ChargedAmount(tx, float64(requestedItem.Quantity)*item.Product.Price)
+			if err != nil {
+				return err
+			}
+			itemsAmount += amount
+			items = append(items, domain.RefundItem{
+				OrderItemID: item.ID,
+				ProductID:   item.ProductID,
+				Quantity:    requestedItem.Quantity,
+				Amount:      amount,
+			})
+		}
+
+		amount := itemsAmount
+		switch {
+		case fullRefund:
+			amount = refundable
+		case input.Amount > 0:
+			amount = input.Amount
+		}
+
+		if amount <= 0 {
+			return domain.ErrInvalidAdjustment
+		}
+		if amount > refundable+0.005 {
+			return fmt.Errorf("%w: %.2f remaining", domain.ErrRefundExceedsPaid, refundable)
+		}
+
+		approvedBy, err := approve(amount)
+		if err != nil {
+			return err
+		}
+
+		split := domain.SplitRefund(amount, money, tenders)
+
+		paymentMethodID := input.PaymentMethodID
+		if paymentMethodID == nil {
+			paymentMethodID = order.PaymentMethodID
+		}
+
+		refund = domain.Refund{
+			OrderID: order.ID,
+			Payment: domain.Payment{
+				OrderID:         &order.ID,
+				PaymentMethodID: paymentMethodID,
+				Type:            domain.PaymentTypeRefund,
+				Amount:          split.Money,
+				UserID:          input.UserID,
+			},
+			Amount:       amount,
+			ReasonCode:   input.ReasonCode,
+			Note:         input.Note,
+			ReturnStock:  input.ReturnStock,
+			UserID:       input.UserID,
+			ApprovedByID: approvedBy,
+			Items:        items,
+		}
+		if err := tx.Create(&refund).Error; err != nil {
+			return err
+		}
+
+		for _, item := range items {
+			if err := tx.Model(&domain.OrderItem{}).Where("id = ?", item.OrderItemID).
+				UpdateColumn("refunded_quantity", gorm.Expr("refunded_quantity + ?", item.Quantity)).Error; err != nil {
+				return fmt.Errorf("failed to update refunded quantity: %v", err)
+			}
+
+			if input.ReturnStock {
+				if err := domain.AdjustProductStock(tx, item.ProductID, item.Quantity); err != nil {
+					return err
+				}
+			}
+		}
+
+		if err := domain.ReverseLoyaltyPoints(tx, order.ID, split.Share, time.Now()); err != nil {
+			return err
+		}
+		if err := domain.RestoreRedeemedPoints(tx, order.ID, split.Share, repo.loyalty, time.Now()); err != nil {
+			return err
+		}
+		if err := domain.ReturnGiftCardTenders(tx, order.ID, split.Share, input.UserID); err != nil {
+			return err
+		}
+
+		return recordAdjustment(tx, &order, domain.OrderActionRefund, input.UserID, input.ReasonCode, input.Note)
+	})
+	if err != nil {
+		repo.log.Error("Failed to refund order", zap.Uint("order_id", input.OrderID), zap.Error(err))
+		return nil, err
+	}
+
+	repo.log.Info("Order refunded", zap.Uint("order_id", input.OrderID), zap.Float64("amount", refund.Amount))
+	return &refund, nil
+}
+
+func (repo RefundRepository) Voids(orderID uint) ([]domain.OrderVoid, error) {
+	var voids []domain.OrderVoid
+	if err := repo.db.Where("order_id = ?", orderID).Order("id").Find(&voids).Error; err != nil {
+		repo.log.Error("Failed to fetch order voids", zap.Error(err))
+		return nil, err
+	}
+	return voids, nil
+}
+
+func (repo RefundRepository) Refunds(orderID uint) ([]domain.Refund, error) {
+	var refunds []domain.Refund
+	if err := repo.db.Preload("Items").Preload("Payment").Where("order_id = ?", orderID).Order("id").Find(&refunds).Error; err != nil {
+		repo.log.Error("Failed to fetch order refunds", zap.Error(err))
+		return nil, err
+	}
+	return refunds, nil
+}
+
+func findOrderItem(tx *gorm.DB, orderID, itemID uint) (*domain.OrderItem, error) {
+	var item domain.OrderItem
+	if err := tx.Preload("Product").Where("id = ? AND order_id = ?", itemID, orderID).First(&item).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errors.New("order item not found")
+		}
+		return nil, err
+	}
+	return &item, nil
+}
+
+func remainingItems(tx *gorm.DB, orderID uint) ([]domain.RefundItem, error) {
+	var items []domain.OrderItem
+	if err := tx.Where("order_id = ? AND quantity > refunded_quantity", orderID).Find(&items).Error; err != nil {
+		return nil, err
+	}
+
+	remaining := make([]domain.RefundItem, 0, len(items))
+	for _, item := range items {
+		remaining = append(remaining, domain.RefundItem{OrderItemID: item.ID, Quantity: item.Quantity - item.RefundedQuantity})
+	}
+	return remaining, nil
+}
+
+// refundBalance is what is left to refund on an order: the money paid, with the reservation deposit,
+// minus the money refunded, and the value of the points and gift cards used on it minus the part of
+// earlier refunds that went back to them. Orders paid before payments were recorded fall back to
+// their current total.
+func refundBalance(tx *gorm.DB, order *domain.Order) (money, tenders float64, err error) {
+	var totals struct {
+		Paid     float64
+		Tendered float64
+		Refunded float64
+	}
+	err = tx.Model(&domain.Payment{}).
+		Select(`COALESCE(SUM(CASE WHEN type IN ? THEN amount END), 0) AS paid, COALESCE(SUM(CASE WHEN type IN ? THEN amount END), 0) AS tendered,
+			COALESCE(SUM(CASE WHEN type = ? THEN amount END), 0) AS refunded`,
+			[]domain.PaymentType{domain.PaymentTypePayment, domain.PaymentTypeDeposit},
+			[]domain.PaymentType{domain.PaymentTypePoints, domain.PaymentTypeGiftCard}, domain.PaymentTypeRefund).
+		Where("order_id = ?", order.ID).
+		Scan(&totals).Error
+	if err != nil {
+		return 0, 0, fmt.Errorf("failed to calculate refundable amount: %v", err)
+	}
+
+	// a refund pays back in money only part of its amount, the rest went back to the tenders
+	var returned float64
+	err = tx.Model(&domain.Refund{}).Select("COALESCE(SUM(refunds.amount - p.amount), 0)").
+		Joins("JOIN payments p ON p.id = refunds.payment_id").
+		Where("refunds.order_id = ?", order.ID).
+		Scan(&returned).Error
+	if err != nil {
+		return 0, 0, fmt.Errorf("failed to calculate refunded tenders: %v", err)
+	}
+
+	if totals.Paid == 0 && totals.Tendered == 0 {
+		amountDue, err := order.AmountDue(tx)
+		if err != nil {
+			return 0, 0, err
+		}
+		totals.Paid = amountDue
+	}
+
+	return totals.Paid - totals.Refunded, math.Max(totals.Tendered-returned, 0), nil
+}
+
+func recordAdjustment(tx *gorm.DB, order *domain.Order, action domain.OrderAction, userID *uint, code domain.ReasonCode, note string) error {
+	reason := string(code)
+	if note != "" {
+		reason += ": " + note
+	}
+
+	if err := bumpOrderVersion(tx, order.ID); err != nil {
+		return err
+	}
+
+	return tx.Create(&domain.OrderStatusHistory{
+		OrderID:     order.ID,
+		Action:      action,
+		FromPayment: order.StatusPayment,
+		ToPayment:   order.StatusPayment,
+		FromKitchen: order.StatusKitchen,
+		ToKitchen:   order.StatusKitchen,
+		UserID:      userID,
+		Reason:      reason,
+	}).Error
+}
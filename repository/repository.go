@@ -1,8 +1,11 @@
 package repository
 
 import (
+	"fmt"
 	"project/config"
 	"project/database"
+	"project/domain"
+	"time"
 
 	"go.uber.org/zap"
 	"gorm.io/gorm"
@@ -21,21 +24,113 @@ type Repository struct {
 	UserNotification UserNotificationRepository
 	Dashboard        DashboardRepository
 	Revenue          RevenueRepository
+	Refund           RefundRepository
+	Discount         DiscountRepository
+	Tax              TaxRepository
+	TimeClock        TimeClockRepository
+	Receipt          ReceiptRepository
+	Table            TableRepository
+	Waitlist         WaitlistRepository
+	Customer         CustomerRepository
+	Loyalty          LoyaltyRepository
+	GiftCard         GiftCardRepository
+	Feedback         FeedbackRepository
+	Menu             MenuRepository
 }
 
-func NewRepository(db *gorm.DB, cacher database.Cacher, config config.Config, log *zap.Logger) Repository {
+func NewRepository(db *gorm.DB, cacher database.Cacher, config config.Config, log *zap.Logger) (Repository, error) {
+	loyalty := loyaltyPolicy(config.Loyalty)
+	policy, err := reservationPolicy(config.Reservation)
+	if err != nil {
+		return Repository{}, err
+	}
+	orders := *NewOrderRepository(db, database.OrderCodeFormat(config.OrderCode), taxRounding(config.TaxRounding), loyalty, policy.Deposit, log)
+	menu := *NewMenuRepository(db, cacher, config.MenuCacheTTL, log)
+
 	return Repository{
 		Auth:             *NewAuthRepository(db, cacher, log),
 		PasswordReset:    *NewPasswordResetRepository(db, log),
 		User:             *NewUserRepository(db, log),
-		Reservation:      *NewReservationRepository(db, log),
+		Reservation:      *NewReservationRepository(db, orders, policy, log),
 		Notification:     *NewNotificationRepository(db, log),
-		Category:         *NewCategoryRepository(db, log),
-		Order:            *NewOrderRepository(db, log),
+		Category:         *NewCategoryRepository(db, menu, log),
+		Order:            orders,
 		UserNotification: *NewUserNotificationRepository(db, log),
-		Product:          *NewProductRepository(db, log),
+		Product:          *NewProductRepository(db, menu, log),
 		UserPermission:   *NewUserPermissionRepository(db, log),
 		Dashboard:        *NewDashboardRepository(db, log),
 		Revenue:          *NewRevenueRepository(db, log),
+		Refund:           *NewRefundRepository(db, taxRounding(config.TaxRounding), loyalty, log),
+		Discount:         *NewDiscountRepository(db, taxRounding(config.TaxRounding), log),
+		Tax:              *NewTaxRepository(db, log),
+		TimeClock:        *NewTimeClockRepository(db, log),
+		Receipt:          *NewReceiptRepository(db, log),
+		Table:            *NewTableRepository(db, log),
+		Waitlist:         *NewWaitlistRepository(db, orders, policy, log),
+		Customer:         *NewCustomerRepository(db, log),
+		Loyalty:          *NewLoyaltyRepository(db, loyalty, log),
+		GiftCard:         *NewGiftCardRepository(db, config.GiftCardExpiryDays, log),
+		Feedback:         *NewFeedbackRepository(db, config.FeedbackLowRating, log),
+		Menu:             menu,
+	}, nil
+}
+
+func taxRounding(cfg config.TaxRoundingConfig) domain.TaxRounding {
+	rounding := domain.TaxRounding{
+		Scope: domain.TaxRoundingScope(cfg.Scope),
+		Mode:  domain.TaxRoundingMode(cfg.Mode),
+	}
+	if rounding.Scope == "" {
+		rounding.Scope = domain.DefaultTaxRounding.Scope
+	}
+	if rounding.Mode == "" {
+		rounding.Mode = domain.DefaultTaxRounding.Mode
+	}
+	return rounding
+}
+
+// reservationPolicy reads the booking rules from the config, opening hours that cannot be read
+// are an error rather than silently replaced by the default ones
+func reservationPolicy(cfg config.ReservationConfig) (domain.ReservationPolicy, error) {
+	policy := domain.DefaultReservationPolicy
+	if cfg.OpeningHours != "" {
+		hours, err := domain.ParseOpeningHours(cfg.OpeningHours)
+		if err != nil {
+			return domain.ReservationPolicy{}, fmt.Errorf("RESERVATION_OPENING_HOURS: %w", err)
+		}
+		policy.Hours = hours
+	}
+	if cfg.SlotInterval > 0 {
+		policy.SlotInterval = cfg.SlotInterval
+	}
+	if cfg.TurnTime >= 0 {
+		policy.TurnTime = cfg.TurnTime
+	}
+	if cfg.DepositRefundNotice >= 0 {
+		policy.Deposit.RefundNotice = time.Duration(cfg.DepositRefundNotice) * time.Hour
+	}
+	if cfg.DepositLateCancelForfeit >= 0 && cfg.DepositLateCancelForfeit <= 100 {
+		policy.Deposit.LateCancelForfeit = cfg.DepositLateCancelForfeit
+	}
+	if cfg.DepositNoShowForfeit >= 0 && cfg.DepositNoShowForfeit <= 100 {
+		policy.Deposit.NoShowForfeit = cfg.DepositNoShowForfeit
+	}
+	return policy, nil
+}
+
+func loyaltyPolicy(cfg config.LoyaltyConfig) domain.LoyaltyPolicy {
+	policy := domain.DefaultLoyaltyPolicy
+	if cfg.EarnRate >= 0 {
+		policy.EarnRate = cfg.EarnRate
+	}
+	if cfg.PointValue >= 0 {
+		policy.PointValue = cfg.PointValue
+	}
+	if cfg.ExpiryDays >= 0 {
+		policy.Expiry = time.Duration(cfg.ExpiryDays) * 24 * time.Hour
+	}
+	if cfg.TierPeriodDays >= 0 {
+		policy.TierPeriod = time.Duration(cfg.TierPeriodDays) * 24 * time.Hour
 	}
+	return policy
 }
@@ -2,37 +2,40 @@ package repository
 
 import (
 	"errors"
+	"fmt"
 	"project/domain"
+	"project/helper"
+	"sort"
 	"time"
 
 	"go.uber.org/zap"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 type ReservationRepository struct {
-	db  *gorm.DB
-	log *zap.Logger
+	db     *gorm.DB
+	orders OrderRepository
+	policy domain.ReservationPolicy
+	log    *zap.Logger
 }
 
-func NewReservationRepository(db *gorm.DB, log *zap.Logger) *ReservationRepository {
-	return &ReservationRepository{db: db, log: log}
+func NewReservationRepository(db *gorm.DB, orders OrderRepository, policy domain.ReservationPolicy, log *zap.Logger) *ReservationRepository {
+	return &ReservationRepository{db: db, orders: orders, policy: policy, log: log}
 }
 
 // Create untuk menambahkan reservasi baru
 func (repo *ReservationRepository) Add(reservation *domain.Reservation) error {
-	// Validasi Status
-	if reservation.Status != "Confirmed" {
-		return errors.New("status must be 'Confirmed' ")
+	// Reservasi yang langsung dikonfirmasi dicatat waktu konfirmasinya
+	if reservation.Status == domain.ReservationConfirmed {
+		now := time.Now()
+		reservation.ConfirmedAt = &now
 	}
 
-	// Validasi Pax Number (max 8)
-	if reservation.PaxNumber > 8 {
-		return errors.New("pax number cannot exceed 8")
-	}
-
-	// Validasi Table Number (max 7)
-	if reservation.TableNumber <= 0 || reservation.TableNumber > 7 {
-		return errors.New("table number cannot 0 or exceed 7")
+	// Deposit dibayar terpisah lewat CollectDeposit
+	reservation.DepositStatus = domain.DepositNone
+	if reservation.DepositFee > 0 {
+		reservation.DepositStatus = domain.DepositRequested
 	}
 
 	// Validasi Reservation Date & Time (tidak boleh masa lalu)
@@ -40,20 +43,35 @@ func (repo *ReservationRepository) Add(reservation *domain.Reservation) error {
 	// 	return errors.New("reservation date and time cannot be in the past")
 	// }
 
-	// Validasi Table Number (tidak boleh ada reservasi lain di waktu yang sama)
-	var existingReservation domain.Reservation
-	err := repo.db.Where("reservation_date = ? AND reservation_time = ? AND table_number = ?", reservation.ReservationDate, reservation.ReservationTime, reservation.TableNumber).
-		Find(&existingReservation).Error
+	reservation.ReservationName = reservation.FirstName + " " + reservation.Surname
 
-	if err == nil && existingReservation.Status == "Confirmed" {
-		// Jika ada reservasi dengan status  'Confirmed', blokir reservasi baru
-		return errors.New("this table is already reserved at the selected time")
+	// Jam reservasi harus di dalam jam buka dan tepat pada slot
+	if err := repo.policy.Bookable(reservation); err != nil {
+		repo.log.Warn("Reservation outside the opening hours", zap.Error(err))
+		return err
 	}
 
-	reservation.ReservationName = reservation.FirstName + " " + reservation.Surname
-
-	// Create new reservation
-	err = repo.db.Create(&reservation).Error
+	err := repo.db.Transaction(func(tx *gorm.DB) error {
+		// Meja dikunci agar dua reservasi bersamaan tidak mendapat meja yang sama
+		if err := checkReservationTable(tx, reservation, repo.policy.TurnTime); err != nil {
+			return err
+		}
+		customerID, err := domain.LinkCustomer(tx, reservation.CustomerID, reservation.ReservationName, reservation.PhoneNumber, reservation.EmailAddress)
+		if err != nil {
+			return err
+		}
+		reservation.CustomerID = customerID
+		if err := tx.Create(reservation).Error; err != nil {
+			return err
+		}
+		// Meja tambahan untuk rombongan besar
+		for _, tableID := range reservation.JoinedTableIDs {
+			if err := tx.Create(&domain.ReservationTable{ReservationID: reservation.ID, TableID: tableID}).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
 	if err != nil {
 		repo.log.Error("Failed to create reservation", zap.Error(err))
 		return err
@@ -63,83 +81,77 @@ func (repo *ReservationRepository) Add(reservation *domain.Reservation) error {
 	return nil
 }
 
-// Filter berdasarkan waktu
-func (repo *ReservationRepository) filterByTimeQuery(query *gorm.DB, filter string) *gorm.DB {
-	now := time.Now().UTC() // Pastikan menggunakan UTC untuk konsistensi waktu
-
-	switch filter {
-	case "today":
-		// Mulai dari awal hari (00:00:00) hingga akhir hari (23:59:59) UTC
-		startOfDay := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
-		endOfDay := startOfDay.Add(24 * time.Hour).Add(-time.Second)
-		query = query.Where("reservation_date BETWEEN ? AND ?", startOfDay, endOfDay)
-	case "this_week":
-		// Mulai dari hari pertama minggu ini (Senin) hingga hari terakhir minggu ini (Minggu)
-		// Dimulai dari Senin pada minggu ini hingga Minggu (di UTC)
-		startOfWeek := now.AddDate(0, 0, -int(now.Weekday())+1) // Menggunakan Senin sebagai awal minggu
-		endOfWeek := startOfWeek.AddDate(0, 0, 6)               // Menggunakan Minggu sebagai akhir minggu
-		query = query.Where("reservation_date BETWEEN ? AND ?", startOfWeek, endOfWeek)
-	case "this_month":
-		// Mulai dari awal bulan hingga akhir bulan (UTC)
-		startOfMonth := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.UTC)
-		endOfMonth := startOfMonth.AddDate(0, 1, 0).Add(-time.Second)
-		query = query.Where("reservation_date BETWEEN ? AND ?", startOfMonth, endOfMonth)
-	case "this_year":
-		// Mulai dari awal tahun hingga akhir tahun (UTC)
-		startOfYear := time.Date(now.Year(), 1, 1, 0, 0, 0, 0, time.UTC)
-		endOfYear := time.Date(now.Year(), 12, 31, 23, 59, 59, 999999999, time.UTC)
-		query = query.Where("reservation_date BETWEEN ? AND ?", startOfYear, endOfYear)
-	default:
-		// Jika tidak ada filter yang sesuai, kita tidak menambah filter waktu
-	}
-
-	return query
-}
-
-// All untuk mengambil semua reservasi berdasarkan waktu tertentu tanpa pagination
-func (repo *ReservationRepository) All(timeFilter string) ([]*domain.AllReservation, error) {
-	var reservations []*domain.AllReservation
-
-	// Query awal
-	query := repo.db.Model(&domain.Reservation{})
+// All mengambil reservasi per halaman sesuai filter, daftar kosong bila tidak ada yang cocok
+func (repo *ReservationRepository) All(filter domain.ReservationFilter) ([]domain.AllReservation, int64, error) {
+	reservations := []domain.AllReservation{}
+	var totalItems int64
 
-	// Terapkan filter waktu
-	query = repo.filterByTimeQuery(query, timeFilter)
+	query := repo.db.Model(&domain.Reservation{})
+	if !filter.Start.IsZero() {
+		query = query.Where("reservation_date >= ?", filter.Start.Format("2006-01-02"))
+	}
+	if !filter.End.IsZero() {
+		query = query.Where("reservation_date < ?", filter.End.Format("2006-01-02"))
+	}
+	if len(filter.Statuses) > 0 {
+		query = query.Where("status IN ?", filter.Statuses)
+	}
+	if filter.TableID != 0 {
+		// Meja yang digabung juga dihitung
+		query = query.Where("table_id = ? OR id IN (?)", filter.TableID,
+			repo.db.Model(&domain.ReservationTable{}).Select("reservation_id").Where("table_id = ?", filter.TableID))
+	}
+	if filter.Name != "" {
+		query = query.Where("reservation_name ILIKE ?", "%"+filter.Name+"%")
+	}
+	if filter.Phone != "" {
+		query = query.Where("phone_number LIKE ?", "%"+filter.Phone+"%")
+	}
 
-	// Ambil semua data reservasi yang sudah terurut berdasarkan tanggal reservasi
-	err := query.Order("reservation_date ASC").Find(&reservations).Error
+	if err := query.Count(&totalItems).Error; err != nil {
+		repo.log.Error("Failed to count reservations", zap.Error(err))
+		return nil, 0, err
+	}
+	err := query.Order(filter.Order()).
+		Scopes(helper.Paginate(uint(filter.Page), uint(filter.Limit))).
+		Find(&reservations).Error
 	if err != nil {
 		repo.log.Error("Failed to fetch reservations", zap.Error(err))
-		return nil, err
-	}
-
-	// Jika tidak ada data yang ditemukan
-	if len(reservations) == 0 {
-		repo.log.Warn("No reservations found")
-		return nil, errors.New("no reservations found")
+		return nil, 0, err
 	}
 
-	return reservations, nil
+	return reservations, totalItems, nil
 }
 
 // GetByID mengambil detail reservasi berdasarkan ID
 func (repo *ReservationRepository) GetByID(id uint) (*domain.Reservation, error) {
 	var reservation domain.Reservation
 
-	// Cari reservasi berdasarkan ID
-	err := repo.db.First(&reservation, id).Error
+	// Cari reservasi berdasarkan ID beserta pembayaran depositnya
+	err := repo.db.Preload("Payments", func(db *gorm.DB) *gorm.DB { return db.Order("id") }).First(&reservation, id).Error
 	if errors.Is(err, gorm.ErrRecordNotFound) {
 		repo.log.Warn("Reservation not found", zap.Uint("id", id))
 		return nil, errors.New("reservation not found")
 	}
+	if err == nil {
+		err = joinedTables(repo.db, &reservation)
+	}
+	if err == nil {
+		err = countNoShows(repo.db, &reservation)
+	}
+	if err != nil {
+		repo.log.Error("Failed to fetch reservation", zap.Uint("id", id), zap.Error(err))
+		return nil, err
+	}
 
 	// Jika berhasil ditemukan
 	repo.log.Info("Reservation found", zap.Uint("id", id))
 	return &reservation, nil
 }
 
-// Update hanya untuk mengubah table number atau status
-func (repo *ReservationRepository) Update(reservationID uint, updates map[string]interface{}) error {
+// Update hanya untuk memindahkan meja atau membatalkan reservasi.
+// version adalah versi yang diharapkan client, 0 berarti memakai versi yang baru dibaca
+func (repo *ReservationRepository) Update(reservationID, version uint, userID *uint, updates map[string]interface{}) error {
 	repo.log.Debug("Updating reservation", zap.Uint("id", reservationID), zap.Any("updates", updates))
 	var reservation domain.Reservation
 
@@ -150,31 +162,79 @@ func (repo *ReservationRepository) Update(reservationID uint, updates map[string
 		return errors.New("reservation not found")
 	}
 
-	// Validasi: Hanya status dan table number yang bisa diubah
-	if tableNumber, ok := updates["table_number"]; ok {
-		if tableNum, valid := tableNumber.(int); valid {
-			if tableNum <= 0 || tableNum > 7 {
-				return errors.New("table number cannot 0 or exceed 7")
+	if err := joinedTables(repo.db, &reservation); err != nil {
+		repo.log.Error("Failed to fetch reservation", zap.Uint("id", reservationID), zap.Error(err))
+		return err
+	}
+
+	expectedVersion := reservation.Version
+	if version != 0 && version != expectedVersion {
+		repo.log.Warn("Reservation version conflict", zap.Uint("id", reservationID), zap.Uint("version", version))
+		return domain.ErrVersionConflict
+	}
+
+	err = repo.db.Transaction(func(tx *gorm.DB) error {
+		previousTable := reservation.TableID
+
+		// Meja baru harus cukup besar dan kosong selama waktu reservasi, tamu yang sudah duduk
+		// dipindahkan lewat order-nya
+		if tableID, ok := updates["table_id"]; ok {
+			if id, valid := tableID.(uint); valid {
+				if !reservation.Status.Holds() || reservation.Status == domain.ReservationSeated {
+					return fmt.Errorf("%w: cannot move a reservation that is %s", domain.ErrInvalidReservationTransition, reservation.Status)
+				}
+				reservation.TableID = id
+				if err := checkReservationTable(tx, &reservation, repo.policy.TurnTime); err != nil {
+					return err
+				}
 			}
-			reservation.TableNumber = uint(tableNum)
 		}
-	}
 
-	if status, ok := updates["status"]; ok {
-		if newStatus, valid := status.(string); valid {
-			// Validasi: Hanya 'Canceled' yang diperbolehkan
-			if reservation.Status != "Confirmed" {
-				return errors.New("status can only be changed if it's currently 'Confirmed'")
+		// Status hanya bisa dibatalkan di sini, perubahan lain lewat Transition
+		var history *domain.ReservationStatusHistory
+		if status, ok := updates["status"]; ok {
+			if status != domain.ReservationCanceled {
+				return fmt.Errorf("%w: status can only be updated to 'Canceled'", domain.ErrInvalidReservationTransition)
 			}
-			if newStatus != "Canceled" {
-				return errors.New("status can only be updated to 'Canceled'")
+			var err error
+			if history, err = reservation.Transition(domain.ReservationActionCancel, domain.ReservationTransitionInput{UserID: userID}, time.Now()); err != nil {
+				return err
 			}
-			reservation.Status = newStatus
+			if err := repo.settleDeposit(tx, &reservation, userID, time.Now()); err != nil {
+				return err
+			}
+			updates["status"] = reservation.Status
+			updates["closed_at"] = reservation.ClosedAt
+			updates["deposit_status"] = reservation.DepositStatus
 		}
-	}
 
-	// Simpan perubahan ke database
-	err = repo.db.Model(&reservation).Updates(updates).Error
+		// Simpan perubahan ke database, hanya jika versi belum berubah sejak dibaca
+		updates["version"] = gorm.Expr("version + 1")
+		result := tx.Model(&reservation).Where("version = ?", expectedVersion).Updates(updates)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return domain.ErrVersionConflict
+		}
+
+		// Meja yang sudah ditahan untuk reservasi ini dilepas kembali
+		now := time.Now()
+		if history != nil {
+			if err := tx.Create(history).Error; err != nil {
+				return err
+			}
+			return releaseHeldTables(tx, append([]uint{previousTable}, reservation.JoinedTableIDs...), now)
+		}
+		if reservation.TableID != previousTable {
+			return releaseHeldTable(tx, previousTable, now)
+		}
+		return nil
+	})
+	if errors.Is(err, domain.ErrVersionConflict) {
+		repo.log.Warn("Reservation version conflict", zap.Uint("id", reservationID), zap.Uint("version", expectedVersion))
+		return err
+	}
 	if err != nil {
 		repo.log.Error("Failed to update reservation", zap.Uint("id", reservationID), zap.Error(err))
 		return err
@@ -183,3 +243,518 @@ func (repo *ReservationRepository) Update(reservationID uint, updates map[string
 	repo.log.Info("Reservation updated successfully", zap.Uint("id", reservationID))
 	return nil
 }
+
+// Transition locks the reservation, runs the state machine and stores the new status with a history
+// entry. Seating opens a dine-in order at the booked tables with the deposit credited to it, a
+// cancellation or no-show releases the tables held for the booking.
+func (repo *ReservationRepository) Transition(id uint, action domain.ReservationAction, input domain.ReservationTransitionInput) (*domain.Reservation, error) {
+	var reservation domain.Reservation
+	err := repo.db.Transaction(func(tx *gorm.DB) error {
+		if err := lockReservation(tx, &reservation, id); err != nil {
+			return err
+		}
+		if err := joinedTables(tx, &reservation); err != nil {
+			return err
+		}
+
+		now := time.Now()
+		history, err := reservation.Transition(action, input, now)
+		if err != nil {
+			return err
+		}
+
+		switch action {
+		case domain.ReservationActionSeat:
+			if err := repo.seat(tx, &reservation, input); err != nil {
+				return err
+			}
+		case domain.ReservationActionNoShow, domain.ReservationActionCancel:
+			if err := releaseHeldTables(tx, reservation.TableIDs(), now); err != nil {
+				return err
+			}
+			if err := repo.settleDeposit(tx, &reservation, input.UserID, now); err != nil {
+				return err
+			}
+		}
+
+		result := tx.Model(&domain.Reservation{}).Where("id = ? AND version = ?", reservation.ID, reservation.Version).
+			UpdateColumns(map[string]interface{}{
+				"status":         reservation.Status,
+				"confirmed_at":   reservation.ConfirmedAt,
+				"reminded_at":    reservation.RemindedAt,
+				"arrived_at":     reservation.ArrivedAt,
+				"seated_at":      reservation.SeatedAt,
+				"closed_at":      reservation.ClosedAt,
+				"order_id":       reservation.OrderID,
+				"deposit_status": reservation.DepositStatus,
+				"version":        reservation.Version + 1,
+				"updated_at":     now,
+			})
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return domain.ErrVersionConflict
+		}
+		reservation.Version++
+
+		return tx.Create(history).Error
+	})
+	if err == nil {
+		err = countNoShows(repo.db, &reservation)
+	}
+	if err != nil {
+		repo.log.Error("Failed to transition reservation", zap.Uint("id", id), zap.String("action", string(action)), zap.Error(err))
+		return nil, err
+	}
+
+	repo.log.Info("Reservation transitioned", zap.Uint("id", id), zap.String("action", string(action)))
+	return &reservation, nil
+}
+
+// seat opens a dine-in order for the party at the tables of the reservation and credits the
+// deposit to it
+func (repo *ReservationRepository) seat(tx *gorm.DB, reservation *domain.Reservation, input domain.ReservationTransitionInput) error {
+	order := reservation.NewOrder()
+	if err := repo.orders.create(tx, order, ""); err != nil {
+		return err
+	}
+	if len(reservation.JoinedTableIDs) > 0 {
+		if err := domain.JoinTables(tx, order, reservation.JoinedTableIDs); err != nil {
+			return err
+		}
+	}
+
+	if reservation.DepositStatus == domain.DepositPaid {
+		err := tx.Model(&domain.Payment{}).
+			Where("reservation_id = ? AND type = ? AND order_id IS NULL", reservation.ID, domain.PaymentTypeDeposit).
+			UpdateColumn("order_id", order.ID).Error
+		if err != nil {
+			return fmt.Errorf("failed to credit deposit: %v", err)
+		}
+		reservation.DepositStatus = domain.DepositApplied
+	}
+
+	reservation.OrderID = &order.ID
+	return nil
+}
+
+// CollectDeposit takes the deposit of a reservation with a payment method, the requested deposit fee
+// unless another amount is given
+func (repo *ReservationRepository) CollectDeposit(id uint, input domain.DepositInput) (*domain.Reservation, error) {
+	var reservation domain.Reservation
+	err := repo.db.Transaction(func(tx *gorm.DB) error {
+		if err := lockReservation(tx, &reservation, id); err != nil {
+			return err
+		}
+
+		amount := input.Amount
+		if amount == 0 {
+			amount = reservation.DepositFee
+		}
+		if err := reservation.CheckDeposit(amount); err != nil {
+			return err
+		}
+		var paymentMethod domain.PaymentMethod
+		if err := tx.Where("status = ?", true).First(&paymentMethod, input.PaymentMethodID).Error; err != nil {
+			return fmt.Errorf("%w: payment method %d is not available", domain.ErrInvalidDeposit, input.PaymentMethodID)
+		}
+
+		deposit := domain.Payment{
+			ReservationID:   &reservation.ID,
+			PaymentMethodID: &input.PaymentMethodID,
+			Type:            domain.PaymentTypeDeposit,
+			Amount:          amount,
+			UserID:          input.UserID,
+		}
+		if err := tx.Create(&deposit).Error; err != nil {
+			return err
+		}
+
+		reservation.DepositFee = amount
+		reservation.DepositStatus = domain.DepositPaid
+		return tx.Model(&domain.Reservation{}).Where("id = ?", reservation.ID).UpdateColumns(map[string]interface{}{
+			"deposit_fee":    reservation.DepositFee,
+			"deposit_status": reservation.DepositStatus,
+			"version":        gorm.Expr("version + 1"),
+			"updated_at":     time.Now(),
+		}).Error
+	})
+	if err != nil {
+		repo.log.Error("Failed to collect reservation deposit", zap.Uint("id", id), zap.Error(err))
+		return nil, err
+	}
+
+	repo.log.Info("Reservation deposit collected", zap.Uint("id", id), zap.Float64("amount", reservation.DepositFee))
+	return repo.GetByID(id)
+}
+
+// settleDeposit refunds and forfeits the paid deposit of a reservation that was cancelled or not
+// shown up for, as the deposit policy says
+func (repo *ReservationRepository) settleDeposit(tx *gorm.DB, reservation *domain.Reservation, userID *uint, now time.Time) error {
+	if reservation.DepositStatus != domain.DepositPaid {
+		return nil
+	}
+
+	var deposit domain.Payment
+	err := tx.Where("reservation_id = ? AND type = ? AND order_id IS NULL", reservation.ID, domain.PaymentTypeDeposit).
+		Order("id DESC").First(&deposit).Error
+	if err != nil {
+		return fmt.Errorf("failed to retrieve deposit: %v", err)
+	}
+
+	return domain.SettleDeposit(tx, reservation, deposit.Amount, deposit.PaymentMethodID, repo.policy.Deposit, userID, now)
+}
+
+func (repo *ReservationRepository) History(reservationID uint) ([]domain.ReservationStatusHistory, error) {
+	var histories []domain.ReservationStatusHistory
+	if err := repo.db.Where("reservation_id = ?", reservationID).Order("id").Find(&histories).Error; err != nil {
+		repo.log.Error("Failed to fetch reservation status history", zap.Error(err))
+		return nil, err
+	}
+	return histories, nil
+}
+
+// Calendar reads the reservations booked from start up to end, cancelled ones included so calendar
+// clients drop them, with the names of every table they book
+func (repo *ReservationRepository) Calendar(start, end time.Time) (*domain.ReservationCalendar, error) {
+	calendar := domain.ReservationCalendar{Tables: map[uint]string{}}
+	err := repo.db.Where("reservation_date >= ? AND reservation_date < ?", start.Format("2006-01-02"), end.Format("2006-01-02")).
+		Order("reservation_date, reservation_time, id").
+		Find(&calendar.Reservations).Error
+	if err != nil {
+		repo.log.Error("Failed to fetch reservations for the calendar", zap.Error(err))
+		return nil, err
+	}
+	if len(calendar.Reservations) == 0 {
+		return &calendar, nil
+	}
+
+	ids := make([]uint, len(calendar.Reservations))
+	for i, reservation := range calendar.Reservations {
+		ids[i] = reservation.ID
+	}
+	var joined []domain.ReservationTable
+	if err := repo.db.Where("reservation_id IN ?", ids).Order("table_id").Find(&joined).Error; err != nil {
+		repo.log.Error("Failed to fetch joined reservation tables", zap.Error(err))
+		return nil, err
+	}
+	byReservation := map[uint][]uint{}
+	for _, table := range joined {
+		byReservation[table.ReservationID] = append(byReservation[table.ReservationID], table.TableID)
+	}
+
+	var tableIDs []uint
+	for i := range calendar.Reservations {
+		reservation := &calendar.Reservations[i]
+		reservation.JoinedTableIDs = byReservation[reservation.ID]
+		tableIDs = append(tableIDs, reservation.TableIDs()...)
+	}
+	// Meja yang sudah dihapus tetap ditampilkan namanya
+	var tables []domain.Table
+	if err := repo.db.Unscoped().Select("id", "name").Where("id IN ?", tableIDs).Find(&tables).Error; err != nil {
+		repo.log.Error("Failed to fetch reservation table names", zap.Error(err))
+		return nil, err
+	}
+	for _, table := range tables {
+		calendar.Tables[table.ID] = table.Name
+	}
+	return &calendar, nil
+}
+
+// SaveCalendarFeed stores the feed of a user, replacing the token of an earlier one
+func (repo *ReservationRepository) SaveCalendarFeed(feed *domain.CalendarFeed) error {
+	err := repo.db.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "user_id"}},
+		DoUpdates: clause.AssignmentColumns([]string{"token", "created_at"}),
+	}).Create(feed).Error
+	if err != nil {
+		repo.log.Error("Failed to save calendar feed", zap.Uint("user_id", feed.UserID), zap.Error(err))
+	}
+	return err
+}
+
+func (repo *ReservationRepository) DeleteCalendarFeed(userID uint) error {
+	result := repo.db.Where("user_id = ?", userID).Delete(&domain.CalendarFeed{})
+	if result.Error != nil {
+		repo.log.Error("Failed to delete calendar feed", zap.Uint("user_id", userID), zap.Error(result.Error))
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return domain.ErrCalendarFeedNotFound
+	}
+	return nil
+}
+
+// CalendarFeedUser returns the user of a feed token. The feed stops working once the user is
+// deleted or no longer has access to reservations.
+func (repo *ReservationRepository) CalendarFeedUser(token string) (uint, error) {
+	var feed domain.CalendarFeed
+	err := repo.db.Joins("User").Where("token = ?", token).First(&feed).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) || (err == nil && feed.User.ID == 0) {
+		return 0, domain.ErrCalendarFeedNotFound
+	}
+	if err != nil {
+		repo.log.Error("Failed to fetch calendar feed", zap.Error(err))
+		return 0, err
+	}
+	if feed.User.Role == domain.SuperAdmin {
+		return feed.UserID, nil
+	}
+
+	var permitted int64
+	err = repo.db.Model(&domain.UserPermission{}).
+		Joins("JOIN permissions ON permissions.id = user_permissions.permission_id").
+		Where("user_permissions.user_id = ? AND permissions.name = ?", feed.UserID, "Reservations").
+		Count(&permitted).Error
+	if err != nil {
+		repo.log.Error("Failed to check calendar feed permission", zap.Uint("user_id", feed.UserID), zap.Error(err))
+		return 0, err
+	}
+	if permitted == 0 {
+		return 0, domain.ErrCalendarFeedNotFound
+	}
+	return feed.UserID, nil
+}
+
+// lockReservation loads a reservation with a row lock so concurrent changes to it wait for the transaction
+func lockReservation(tx *gorm.DB, reservation *domain.Reservation, id uint) error {
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(reservation, id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return errors.New("reservation not found")
+		}
+		return err
+	}
+	return nil
+}
+
+// countNoShows fills how many earlier reservations of the same guest, by phone number or email
+// address, did not show up
+func countNoShows(tx *gorm.DB, reservation *domain.Reservation) error {
+	if reservation.PhoneNumber == "" && reservation.EmailAddress == "" {
+		return nil
+	}
+
+	guest := tx.Where("phone_number <> '' AND phone_number = ?", reservation.PhoneNumber).
+		Or("email_address <> '' AND LOWER(email_address) = LOWER(?)", reservation.EmailAddress)
+	var noShows int64
+	err := tx.Model(&domain.Reservation{}).
+		Where("status = ? AND id <> ?", domain.ReservationNoShow, reservation.ID).
+		Where(guest).
+		Count(&noShows).Error
+	reservation.NoShows = int(noShows)
+	return err
+}
+
+// DueReminders are the confirmed reservations with an email address that start within lead from now.
+// Bookings made within the lead time are left out, their confirmation was sent moments before.
+func (repo *ReservationRepository) DueReminders(now time.Time, lead time.Duration) ([]domain.Reservation, error) {
+	var reservations []domain.Reservation
+	err := repo.db.Where("status = ? AND email_address <> ''", domain.ReservationConfirmed).
+		Where("reservation_date + reservation_time > ?", now.Format(reservationTimestamp)).
+		Where("reservation_date + reservation_time <= ?", now.Add(lead).Format(reservationTimestamp)).
+		Order("reservation_date, reservation_time").
+		Find(&reservations).Error
+	if err != nil {
+		repo.log.Error("Failed to fetch reservations due a reminder", zap.Error(err))
+		return nil, err
+	}
+
+	due := reservations[:0]
+	for _, reservation := range reservations {
+		start, err := reservation.Start()
+		if err != nil || reservation.CreatedAt.After(start.Add(-lead)) {
+			continue
+		}
+		due = append(due, reservation)
+	}
+	return due, nil
+}
+
+// HoldTables marks the tables of booked reservations as reserved once their booking time has
+// arrived. Only available tables are held, a table still occupied or out of service is left alone.
+func (repo *ReservationRepository) HoldTables(now time.Time) (int64, error) {
+	active := activeReservations(repo.db, now)
+	result := repo.db.Model(&domain.Table{}).
+		Where("status = ?", domain.TableAvailable).
+		Where("id IN (?) OR id IN (?)", active.Session(&gorm.Session{}).Select("table_id"),
+			repo.db.Model(&domain.ReservationTable{}).Select("table_id").Where("reservation_id IN (?)", active.Session(&gorm.Session{}).Select("id"))).
+		Updates(map[string]interface{}{"status": domain.TableReserved, "status_changed_at": now})
+	if result.Error != nil {
+		repo.log.Error("Failed to hold reserved tables", zap.Error(result.Error))
+		return 0, result.Error
+	}
+
+	if result.RowsAffected > 0 {
+		repo.log.Info("Reserved tables held", zap.Int64("tables", result.RowsAffected))
+	}
+	return result.RowsAffected, nil
+}
+
+// reservationTimestamp is how booking times are compared with reservation_date + reservation_time,
+// a timestamp without time zone
+const reservationTimestamp = "2006-01-02 15:04:05"
+
+// checkReservationTable locks the tables of the reservation and checks that together they seat the
+// party and are not booked by another reservation during the same time window, kept
+// turnTime minutes apart
+func checkReservationTable(tx *gorm.DB, reservation *domain.Reservation, turnTime int) error {
+	tableIDs := reservation.TableIDs()
+	sorted := append([]uint(nil), tableIDs...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+
+	var tables []domain.Table
+	for i, id := range sorted {
+		if i > 0 && id == sorted[i-1] {
+			continue
+		}
+		var table domain.Table
+		if err := lockTable(tx, &table, id); err != nil {
+			return err
+		}
+		tables = append(tables, table)
+	}
+	if err := reservation.Validate(tables...); err != nil {
+		return err
+	}
+
+	start, err := reservation.Start()
+	if err != nil {
+		return err
+	}
+	end, err := reservation.End()
+	if err != nil {
+		return err
+	}
+	turn := time.Duration(turnTime) * time.Minute
+
+	var overlapping int64
+	err = bookingTables(tx, tableIDs).
+		Where("status IN ? AND id <> ?", domain.HoldingReservationStatuses, reservation.ID).
+		Where("reservation_date + reservation_time < ?", end.Add(turn).Format(reservationTimestamp)).
+		Where("reservation_date + reservation_time + duration * interval '1 minute' > ?", start.Add(-turn).Format(reservationTimestamp)).
+		Count(&overlapping).Error
+	if err != nil {
+		return err
+	}
+	if overlapping > 0 {
+		return domain.ErrReservationConflict
+	}
+	return nil
+}
+
+// bookingTables are the reservations booking any of the tables, as their own or a joined table
+func bookingTables(tx *gorm.DB, tableIDs []uint) *gorm.DB {
+	return tx.Model(&domain.Reservation{}).
+		Where("table_id IN ? OR id IN (?)", tableIDs,
+			tx.Model(&domain.ReservationTable{}).Select("reservation_id").Where("table_id IN ?", tableIDs))
+}
+
+// joinedTables fills the tables booked with the reservation besides its own
+func joinedTables(tx *gorm.DB, reservation *domain.Reservation) error {
+	return tx.Model(&domain.ReservationTable{}).Where("reservation_id = ?", reservation.ID).
+		Order("table_id").Pluck("table_id", &reservation.JoinedTableIDs).Error
+}
+
+// ReleaseHolds makes reserved tables available again once no booking window holds them, for
+// parties that never came and were not marked as no-show
+func (repo *ReservationRepository) ReleaseHolds(now time.Time) (int64, error) {
+	active := activeReservations(repo.db, now)
+	result := repo.db.Model(&domain.Table{}).
+		Where("status = ?", domain.TableReserved).
+		Where("id NOT IN (?) AND id NOT IN (?)", active.Session(&gorm.Session{}).Select("table_id"),
+			repo.db.Model(&domain.ReservationTable{}).Select("table_id").Where("reservation_id IN (?)", active.Session(&gorm.Session{}).Select("id"))).
+		Updates(map[string]interface{}{"status": domain.TableAvailable, "status_changed_at": now})
+	if result.Error != nil {
+		repo.log.Error("Failed to release held tables", zap.Error(result.Error))
+		return 0, result.Error
+	}
+
+	if result.RowsAffected > 0 {
+		repo.log.Info("Held tables released", zap.Int64("tables", result.RowsAffected))
+	}
+	return result.RowsAffected, nil
+}
+
+// activeReservations are the booked reservations whose booking window contains now
+func activeReservations(tx *gorm.DB, now time.Time) *gorm.DB {
+	at := now.Format(reservationTimestamp)
+	return tx.Model(&domain.Reservation{}).
+		Where("status IN ?", domain.HoldingReservationStatuses).
+		Where("reservation_date + reservation_time <= ?", at).
+		Where("reservation_date + reservation_time + duration * interval '1 minute' > ?", at)
+}
+
+// releaseHeldTables releases every table of a reservation that no longer books them
+func releaseHeldTables(tx *gorm.DB, tableIDs []uint, now time.Time) error {
+	for _, tableID := range tableIDs {
+		if err := releaseHeldTable(tx, tableID, now); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// releaseHeldTable makes a table held for a reservation available again, unless another
+// reservation holds it right now
+func releaseHeldTable(tx *gorm.DB, tableID uint, now time.Time) error {
+	var held int64
+	active := activeReservations(tx, now).Where("id IN (?)", bookingTables(tx, []uint{tableID}).Select("id"))
+	if err := active.Count(&held).Error; err != nil {
+		return err
+	}
+	if held > 0 {
+		return nil
+	}
+	return tx.Model(&domain.Table{}).
+		Where("id = ? AND status = ?", tableID, domain.TableReserved).
+		Updates(map[string]interface{}{"status": domain.TableAvailable, "status_changed_at": now}).Error
+}
+
+// Availability searches the slots of a day where the party can be booked, against the tables that
+// are not out of service and the reservations booking tables around that day
+func (repo *ReservationRepository) Availability(query domain.AvailabilityQuery, now time.Time) ([]domain.AvailableSlot, error) {
+	tables := []domain.Table{}
+	tableQuery := repo.db.Where("status <> ?", domain.TableOutOfService)
+	if query.Section != "" {
+		tableQuery = tableQuery.Where("section = ?", query.Section)
+	}
+	if query.Outdoor != nil {
+		tableQuery = tableQuery.Where("outdoor = ?", *query.Outdoor)
+	}
+	if err := tableQuery.Order("capacity, name").Find(&tables).Error; err != nil {
+		repo.log.Error("Failed to fetch tables for availability", zap.Error(err))
+		return nil, err
+	}
+
+	// bookings of the day before can run past midnight
+	var reservations []domain.Reservation
+	err := repo.db.Where("status IN ? AND reservation_date BETWEEN ? AND ?", domain.HoldingReservationStatuses,
+		query.Date.AddDate(0, 0, -1).Format("2006-01-02"), query.Date.AddDate(0, 0, 1).Format("2006-01-02")).
+		Find(&reservations).Error
+	if err != nil {
+		repo.log.Error("Failed to fetch reservations for availability", zap.Error(err))
+		return nil, err
+	}
+
+	ids := make([]uint, 0, len(reservations))
+	for _, reservation := range reservations {
+		ids = append(ids, reservation.ID)
+	}
+	var joined []domain.ReservationTable
+	if len(ids) > 0 {
+		if err := repo.db.Where("reservation_id IN ?", ids).Find(&joined).Error; err != nil {
+			repo.log.Error("Failed to fetch joined tables for availability", zap.Error(err))
+			return nil, err
+		}
+	}
+	for i := range reservations {
+		for _, table := range joined {
+			if table.ReservationID == reservations[i].ID {
+				reservations[i].JoinedTableIDs = append(reservations[i].JoinedTableIDs, table.TableID)
+			}
+		}
+	}
+
+	return repo.policy.Availability(query, tables, reservations, now), nil
+}
@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"project/domain"
 	"strings"
+	"time"
 
 	"go.uber.org/zap"
 	"gorm.io/gorm"
@@ -22,27 +23,32 @@ func (repo *RevenueRepository) GetTotalRevenueByStatus() (map[string]interface{}
 	var results []struct {
 		StatusPayment string  `json:"status_payment"`
 		Revenue       float64 `json:"revenue"`
+		Discount      float64 `json:"discount"`
 	}
 
 	err := repo.db.Model(&domain.OrderDetail{}).
-		Select("status_payment, SUM(total) as revenue").
+		Select("status_payment, SUM(net_total) as revenue, SUM(discount) as discount").
 		Group("status_payment").
 		Scan(&results).Error
 	if err != nil {
 		return nil, err
 	}
 
-	var totalRevenue float64
+	var totalRevenue, totalDiscount float64
 	revenueMap := make(map[string]float64)
+	discountMap := make(map[string]float64)
 	for _, result := range results {
 		totalRevenue += result.Revenue
+		totalDiscount += result.Discount
 		revenueMap[result.StatusPayment] = result.Revenue
-
+		discountMap[result.StatusPayment] = result.Discount
 	}
 
 	response := map[string]interface{}{
-		"total_revenue": totalRevenue,
-		"by_status":     revenueMap,
+		"total_revenue":      totalRevenue,
+		"by_status":          revenueMap,
+		"total_discount":     totalDiscount,
+		"discount_by_status": discountMap,
 	}
 
 	return response, nil
@@ -68,7 +74,7 @@ func (repo *RevenueRepository) GetMonthlyRevenue(statusPayment string, year int)
 	var revenues []MonthlyRevenue
 
 	query := repo.db.Table("order_details").
-		Select("TO_CHAR(TO_DATE(date_order, 'FMDay, dd-Mon-yyyy'), 'FMMonth') AS month, SUM(total) AS revenue").
+		Select("TO_CHAR(TO_DATE(date_order, 'FMDay, dd-Mon-yyyy'), 'FMMonth') AS month, SUM(net_total) AS revenue").
 		Where("EXTRACT(YEAR FROM TO_DATE(date_order, 'FMDay, dd-Mon-yyyy')) = ?", year).
 		Group("TO_CHAR(TO_DATE(date_order, 'FMDay, dd-Mon-yyyy'), 'FMMonth'), EXTRACT(MONTH FROM TO_DATE(date_order, 'FMDay, dd-Mon-yyyy'))").
 		Order("EXTRACT(MONTH FROM TO_DATE(date_order, 'FMDay, dd-Mon-yyyy'))")
@@ -90,6 +96,87 @@ func (repo *RevenueRepository) GetMonthlyRevenue(statusPayment string, year int)
 	return result, nil
 }
 
+// GetRevenueByOrderType totals completed orders created in [start, end) per order type
+func (repo *RevenueRepository) GetRevenueByOrderType(start, end time.Time) ([]domain.OrderTypeRevenue, error) {
+	var results []domain.OrderTypeRevenue
+	err := repo.db.Table("order_details od").
+		Select(`od.type, COUNT(*) AS orders, SUM(od.net_total) AS revenue, SUM(od.discount) AS discount,
+			SUM(od.tax) AS tax, SUM(od.service_charge) AS service_charge, SUM(od.delivery_fee) AS delivery_fee,
+			ROUND(AVG(od.net_total), 2) AS average_order`).
+		Joins("JOIN orders o ON o.id = od.order_id").
+		Where("od.status_payment = ?", domain.OrderCompleted).
+		Where("o.created_at >= ? AND o.created_at < ?", start, end).
+		Group("od.type").
+		Order("od.type").
+		Scan(&results).Error
+	if err != nil {
+		repo.log.Error("Failed to fetch revenue by order type", zap.Error(err))
+		return nil, fmt.Errorf("failed to fetch revenue by order type: %w", err)
+	}
+	return results, nil
+}
+
+// GetDepositRevenue sums the reservation deposits collected, refunded and forfeited in [start, end),
+// and the deposits held for bookings that are not seated yet
+func (repo *RevenueRepository) GetDepositRevenue(start, end time.Time) (*domain.DepositRevenue, error) {
+	var revenue domain.DepositRevenue
+	err := repo.db.Model(&domain.Payment{}).
+		Select(`COALESCE(SUM(CASE WHEN type = ? THEN amount END), 0) AS collected,
+			COALESCE(SUM(CASE WHEN type = ? AND order_id IS NOT NULL THEN amount END), 0) AS applied,
+			COALESCE(SUM(CASE WHEN type = ? THEN amount END), 0) AS refunded,
+			COALESCE(SUM(CASE WHEN type = ? THEN amount END), 0) AS forfeited`,
+			domain.PaymentTypeDeposit, domain.PaymentTypeDeposit, domain.PaymentTypeRefund, domain.PaymentTypeForfeit).
+		Where("reservation_id IS NOT NULL AND created_at >= ? AND created_at < ?", start, end).
+		Scan(&revenue).Error
+	if err != nil {
+		repo.log.Error("Failed to fetch deposit revenue", zap.Error(err))
+		return nil, fmt.Errorf("failed to fetch deposit revenue: %w", err)
+	}
+
+	err = repo.db.Model(&domain.Payment{}).
+		Select("COALESCE(SUM(payments.amount), 0)").
+		Joins("JOIN reservations r ON r.id = payments.reservation_id").
+		Where("payments.type = ? AND payments.order_id IS NULL AND r.deposit_status = ?", domain.PaymentTypeDeposit, domain.DepositPaid).
+		Scan(&revenue.Outstanding).Error
+	if err != nil {
+		repo.log.Error("Failed to fetch outstanding deposits", zap.Error(err))
+		return nil, fmt.Errorf("failed to fetch outstanding deposits: %w", err)
+	}
+	return &revenue, nil
+}
+
+// GetGiftCardLiability sums the balances owed on gift cards now, usable and expired, and the gift
+// card movements in [start, end)
+func (repo *RevenueRepository) GetGiftCardLiability(start, end time.Time) (*domain.GiftCardLiability, error) {
+	var liability domain.GiftCardLiability
+	now := time.Now()
+	err := repo.db.Model(&domain.GiftCard{}).
+		Select(`COALESCE(SUM(CASE WHEN expires_at IS NULL OR expires_at > ? THEN balance END), 0) AS outstanding,
+			COUNT(CASE WHEN expires_at IS NULL OR expires_at > ? THEN 1 END) AS outstanding_cards,
+			COALESCE(SUM(CASE WHEN expires_at <= ? THEN balance END), 0) AS expired,
+			COUNT(CASE WHEN expires_at <= ? THEN 1 END) AS expired_cards`, now, now, now, now).
+		Where("status = ? AND balance > 0", domain.GiftCardActive).
+		Scan(&liability).Error
+	if err != nil {
+		repo.log.Error("Failed to fetch gift card balances", zap.Error(err))
+		return nil, fmt.Errorf("failed to fetch gift card balances: %w", err)
+	}
+
+	err = repo.db.Model(&domain.GiftCardTransaction{}).
+		Select(`COALESCE(SUM(CASE WHEN type = ? THEN amount END), 0) AS issued,
+			COALESCE(SUM(CASE WHEN type = ? THEN -amount END), 0) AS redeemed,
+			COALESCE(SUM(CASE WHEN type = ? THEN amount END), 0) AS refunded,
+			COALESCE(SUM(CASE WHEN type = ? THEN -amount END), 0) AS voided`,
+			domain.GiftCardIssue, domain.GiftCardRedeem, domain.GiftCardRefund, domain.GiftCardVoid).
+		Where("created_at >= ? AND created_at < ?", start, end).
+		Scan(&liability).Error
+	if err != nil {
+		repo.log.Error("Failed to fetch gift card movements", zap.Error(err))
+		return nil, fmt.Errorf("failed to fetch gift card movements: %w", err)
+	}
+	return &liability, nil
+}
+
 func (repo *RevenueRepository) GetProductRevenueDetails() ([]*domain.BestSeller, error) {
 	var products []*domain.BestSeller
 	repo.db.Preload("Product.Category").Find(&products)
@@ -4,6 +4,7 @@ import (
 	"errors"
 	"project/repository"
 	"testing"
+	"time"
 
 	"github.com/DATA-DOG/go-sqlmock"
 	"github.com/stretchr/testify/assert"
@@ -40,7 +41,7 @@ func TestGetTotalRevenueByStatus(t *testing.T) {
 		AddRow("Cancelled", 50.25).
 		AddRow("In Process", 75.10)
 
-	mock.ExpectQuery(`SELECT status_payment, SUM\(total\) as revenue`).
+	mock.ExpectQuery(`SELECT status_payment, SUM\(net_total\) as revenue`).
 		WillReturnRows(mockResults)
 
 	response, err := repo.GetTotalRevenueByStatus()
@@ -70,7 +71,7 @@ func TestGetTotalRevenueByStatus_DBError(t *testing.T) {
 
 	repo := repository.NewRevenueRepository(db, log)
 
-	mock.ExpectQuery(`SELECT status_payment, SUM\(total\) as revenue`).
+	mock.ExpectQuery(`SELECT status_payment, SUM\(net_total\) as revenue`).
 		WillReturnError(errors.New("database error"))
 
 	response, err := repo.GetTotalRevenueByStatus()
@@ -80,3 +81,57 @@ func TestGetTotalRevenueByStatus_DBError(t *testing.T) {
 
 	assert.NoError(t, mock.ExpectationsWereMet())
 }
+
+func TestGetRevenueByOrderType(t *testing.T) {
+
+	db, mock, err := setupMockDB()
+	assert.NoError(t, err)
+
+	repo := repository.NewRevenueRepository(db, zap.NewNop())
+
+	mockResults := sqlmock.NewRows([]string{"type", "orders", "revenue", "discount", "tax", "service_charge", "delivery_fee", "average_order"}).
+		AddRow("delivery", 2, 60.0, 0, 5.0, 0, 7.0, 30.0).
+		AddRow("dine_in", 3, 150.0, 10.0, 12.0, 9.0, 0, 50.0)
+
+	mock.ExpectQuery(`SELECT od.type, COUNT\(\*\) AS orders, SUM\(od.net_total\) AS revenue`).
+		WillReturnRows(mockResults)
+
+	start := time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC)
+	results, err := repo.GetRevenueByOrderType(start, start.AddDate(0, 1, 0))
+
+	assert.NoError(t, err)
+	assert.Len(t, results, 2)
+	assert.Equal(t, "delivery", results[0].Type)
+	assert.Equal(t, 7.0, results[0].DeliveryFee)
+	assert.Equal(t, int64(3), results[1].Orders)
+	assert.Equal(t, 9.0, results[1].ServiceCharge)
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestGetDepositRevenue(t *testing.T) {
+
+	db, mock, err := setupMockDB()
+	assert.NoError(t, err)
+
+	repo := repository.NewRevenueRepository(db, zap.NewNop())
+
+	mock.ExpectQuery(`SELECT COALESCE\(SUM\(CASE WHEN type = \$1 THEN amount END\), 0\) AS collected`).
+		WithArgs("deposit", "deposit", "refund", "forfeit", sqlmock.AnyArg(), sqlmock.AnyArg()).
+		WillReturnRows(sqlmock.NewRows([]string{"collected", "applied", "refunded", "forfeited"}).AddRow(80.0, 40.0, 10.0, 20.0))
+	mock.ExpectQuery(`SELECT COALESCE\(SUM\(payments.amount\), 0\) FROM "payments" JOIN reservations r`).
+		WithArgs("deposit", "paid").
+		WillReturnRows(sqlmock.NewRows([]string{"coalesce"}).AddRow(30.0))
+
+	start := time.Date(2024, 12, 1, 0, 0, 0, 0, time.UTC)
+	revenue, err := repo.GetDepositRevenue(start, start.AddDate(0, 1, 0))
+
+	assert.NoError(t, err)
+	assert.Equal(t, 80.0, revenue.Collected)
+	assert.Equal(t, 40.0, revenue.Applied)
+	assert.Equal(t, 10.0, revenue.Refunded)
+	assert.Equal(t, 20.0, revenue.Forfeited)
+	assert.Equal(t, 30.0, revenue.Outstanding)
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
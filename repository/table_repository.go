@@ -0,0 +1,134 @@
+package repository
+
+import (
+	"errors"
+	"project/domain"
+	"project/helper"
+	"time"
+
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type TableRepository struct {
+	db  *gorm.DB
+	log *zap.Logger
+}
+
+func NewTableRepository(db *gorm.DB, log *zap.Logger) *TableRepository {
+	return &TableRepository{db: db, log: log}
+}
+
+// All lists the tables of the floor plan, optionally only those with a status or in a section
+func (repo TableRepository) All(page, limit int, status domain.TableStatus, section string) ([]domain.Table, int64, error) {
+	var tables []domain.Table
+	var totalItems int64
+
+	query := repo.db.Model(&domain.Table{})
+	if status != "" {
+		query = query.Where("status = ?", status)
+	}
+	if section != "" {
+		query = query.Where("section = ?", section)
+	}
+
+	if err := query.Count(&totalItems).Error; err != nil {
+		repo.log.Error("Failed to count total tables", zap.Error(err))
+		return nil, 0, err
+	}
+
+	err := query.Order("section, name").Scopes(helper.Paginate(uint(page), uint(limit))).Find(&tables).Error
+	if err != nil {
+		repo.log.Error("Failed to fetch tables", zap.Error(err))
+		return nil, 0, err
+	}
+	return tables, totalItems, nil
+}
+
+func (repo TableRepository) FindByID(table *domain.Table, id uint) error {
+	if err := repo.db.First(table, id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return domain.ErrTableNotFound
+		}
+		repo.log.Error("Failed to fetch table", zap.Uint("id", id), zap.Error(err))
+		return err
+	}
+	return nil
+}
+
+func (repo TableRepository) Create(table *domain.Table) error {
+	if err := repo.db.Create(table).Error; err != nil {
+		if errors.Is(err, gorm.ErrDuplicatedKey) {
+			return errors.New("table with this name already exists")
+		}
+		repo.log.Error("Failed to save table", zap.Error(err))
+		return err
+	}
+	return nil
+}
+
+// Update changes the layout of a table, its status only changes through UpdateStatus and orders
+func (repo TableRepository) Update(table *domain.Table) error {
+	result := repo.db.Model(table).
+		Select("name", "capacity", "section", "outdoor", "shape", "x", "y", "rotation", "updated_at").
+		Updates(table)
+	if result.Error != nil {
+		if errors.Is(result.Error, gorm.ErrDuplicatedKey) {
+			return errors.New("table with this name already exists")
+		}
+		repo.log.Error("Failed to update table", zap.Uint("id", table.ID), zap.Error(result.Error))
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return domain.ErrTableNotFound
+	}
+	return repo.FindByID(table, table.ID)
+}
+
+func (repo TableRepository) UpdateStatus(id uint, status domain.TableStatus) (*domain.Table, error) {
+	var table domain.Table
+	err := repo.db.Transaction(func(tx *gorm.DB) error {
+		if err := lockTable(tx, &table, id); err != nil {
+			return err
+		}
+		if err := table.ChangeStatus(status, time.Now()); err != nil {
+			return err
+		}
+		return tx.Select("status", "status_changed_at", "seated_at", "updated_at").Save(&table).Error
+	})
+	if err != nil {
+		repo.log.Error("Failed to update table status", zap.Uint("id", id), zap.Error(err))
+		return nil, err
+	}
+	return &table, nil
+}
+
+// Delete removes a table from the floor plan, past orders keep their reference to it
+func (repo TableRepository) Delete(id uint) error {
+	err := repo.db.Transaction(func(tx *gorm.DB) error {
+		var table domain.Table
+		if err := lockTable(tx, &table, id); err != nil {
+			return err
+		}
+		if table.Status == domain.TableOccupied {
+			return domain.ErrTableInUse
+		}
+		return tx.Delete(&table).Error
+	})
+	if err != nil {
+		repo.log.Error("Failed to delete table", zap.Uint("id", id), zap.Error(err))
+		return err
+	}
+	return nil
+}
+
+func lockTable(tx *gorm.DB, table *domain.Table, id uint) error {
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(table, id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return domain.ErrTableNotFound
+		}
+		return err
+	}
+	return nil
+}
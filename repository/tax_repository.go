@@ -0,0 +1,151 @@
+package repository
+
+import (
+	"errors"
+	"fmt"
+	"project/domain"
+	"time"
+
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+type TaxRepository struct {
+	db  *gorm.DB
+	log *zap.Logger
+}
+
+func NewTaxRepository(db *gorm.DB, log *zap.Logger) *TaxRepository {
+	return &TaxRepository{db: db, log: log}
+}
+
+func (repo TaxRepository) All() ([]domain.TaxRate, error) {
+	var rates []domain.TaxRate
+	if err := repo.db.Order("id").Find(&rates).Error; err != nil {
+		repo.log.Error("Failed to fetch tax rates", zap.Error(err))
+		return nil, err
+	}
+	return rates, nil
+}
+
+func (repo TaxRepository) FindByID(rate *domain.TaxRate, id uint) error {
+	if err := repo.db.First(rate, id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return domain.ErrTaxRateNotFound
+		}
+		repo.log.Error("Failed to fetch tax rate", zap.Error(err))
+		return err
+	}
+	return nil
+}
+
+func (repo TaxRepository) Create(rate *domain.TaxRate) error {
+	if err := repo.db.Create(rate).Error; err != nil {
+		if errors.Is(err, gorm.ErrDuplicatedKey) {
+			return errors.New("tax rate with this code already exists")
+		}
+		repo.log.Error("Failed to save tax rate", zap.Error(err))
+		return err
+	}
+	return nil
+}
+
+// Update changes a rate for orders priced from now on, tax lines already stored keep the old rate
+func (repo TaxRepository) Update(rate *domain.TaxRate) error {
+	err := repo.db.Model(rate).Select("*").Omit("id", "created_at", "deleted_at").Updates(rate).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrDuplicatedKey) {
+			return errors.New("tax rate with this code already exists")
+		}
+		repo.log.Error("Failed to update tax rate", zap.Error(err))
+		return err
+	}
+	return nil
+}
+
+func (repo TaxRepository) Delete(id uint) error {
+	result := repo.db.Delete(&domain.TaxRate{}, id)
+	if result.Error != nil {
+		repo.log.Error("Failed to delete tax rate", zap.Error(result.Error))
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return domain.ErrTaxRateNotFound
+	}
+	return nil
+}
+
+// AssignToCategory replaces the tax rates of a category, an empty list falls back to the default rates
+func (repo TaxRepository) AssignToCategory(categoryID uint, rateIDs []uint) error {
+	var category domain.Category
+	if err := repo.db.First(&category, categoryID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return errors.New("category not found")
+		}
+		return err
+	}
+	return repo.replaceRates(&category, rateIDs)
+}
+
+// AssignToProduct replaces the tax rates of a product, an empty list falls back to its category's rates
+func (repo TaxRepository) AssignToProduct(productID uint, rateIDs []uint) error {
+	var product domain.Product
+	if err := repo.db.First(&product, productID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return errors.New("product not found")
+		}
+		return err
+	}
+	return repo.replaceRates(&product, rateIDs)
+}
+
+func (repo TaxRepository) replaceRates(owner interface{}, rateIDs []uint) error {
+	var rates []domain.TaxRate
+	if len(rateIDs) > 0 {
+		if err := repo.db.Find(&rates, rateIDs).Error; err != nil {
+			return err
+		}
+		if len(rates) != len(rateIDs) {
+			return domain.ErrTaxRateNotFound
+		}
+	}
+
+	if err := repo.db.Model(owner).Association("TaxRates").Replace(rates); err != nil {
+		repo.log.Error("Failed to assign tax rates", zap.Error(err))
+		return err
+	}
+	return nil
+}
+
+// Summary totals the tax of completed orders created in [start, end) per rate, optionally for one
+// order type. The refunded share of each order's tax is reported separately so the net amount is
+// what has to be filed.
+func (repo TaxRepository) Summary(start, end time.Time, orderType domain.OrderType) ([]domain.TaxSummary, error) {
+	var summary []domain.TaxSummary
+	query := repo.db.Table("order_tax_lines tl").
+		Select(`tl.code, tl.name, tl.rate, tl.inclusive,
+			COUNT(DISTINCT tl.order_id) AS orders,
+			SUM(tl.taxable_amount) AS taxable_amount,
+			SUM(tl.amount) AS tax_amount,
+			ROUND(SUM(tl.amount * COALESCE(od.refunded / NULLIF(od.grand_total, 0), 0)), 2) AS refunded_tax_amount`).
+		Joins("JOIN orders o ON o.id = tl.order_id").
+		Joins("JOIN order_details od ON od.order_id = tl.order_id").
+		Where("o.status_payment = ? AND o.deleted_at IS NULL", domain.OrderCompleted).
+		Where("o.created_at >= ? AND o.created_at < ?", start, end)
+	if orderType != "" {
+		query = query.Where("o.type = ?", orderType)
+	}
+
+	err := query.Group("tl.code, tl.name, tl.rate, tl.inclusive").
+		Order("tl.code, tl.rate").
+		Scan(&summary).Error
+	if err != nil {
+		repo.log.Error("Failed to fetch tax summary", zap.Error(err))
+		return nil, fmt.Errorf("failed to fetch tax summary: %w", err)
+	}
+
+	for i := range summary {
+		summary[i].NetTaxAmount = summary[i].TaxAmount - summary[i].RefundedTaxAmount
+	}
+	return summary, nil
+}
@@ -0,0 +1,122 @@
+package repository
+
+import (
+	"errors"
+	"project/domain"
+	"project/helper"
+	"time"
+
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+type TimeClockRepository struct {
+	db  *gorm.DB
+	log *zap.Logger
+}
+
+func NewTimeClockRepository(db *gorm.DB, log *zap.Logger) *TimeClockRepository {
+	return &TimeClockRepository{db: db, log: log}
+}
+
+// ClockIn opens a shift for the user, a user has at most one open shift
+func (repo TimeClockRepository) ClockIn(record *domain.TimeClock) error {
+	return repo.db.Transaction(func(tx *gorm.DB) error {
+		var open int64
+		if err := tx.Model(&domain.TimeClock{}).Where("user_id = ? AND clock_out IS NULL", record.UserID).Count(&open).Error; err != nil {
+			return err
+		}
+		if open > 0 {
+			return domain.ErrAlreadyClockedIn
+		}
+
+		if err := tx.Create(record).Error; err != nil {
+			repo.log.Error("Failed to clock in", zap.Uint("user_id", record.UserID), zap.Error(err))
+			return err
+		}
+		return nil
+	})
+}
+
+// ClockOut closes the open shift of the user
+func (repo TimeClockRepository) ClockOut(userID uint, at time.Time, note string) (*domain.TimeClock, error) {
+	var record domain.TimeClock
+	if err := repo.db.Where("user_id = ? AND clock_out IS NULL", userID).Order("clock_in DESC").First(&record).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, domain.ErrNotClockedIn
+		}
+		return nil, err
+	}
+
+	updates := map[string]interface{}{"clock_out": at}
+	if note != "" {
+		updates["note"] = note
+	}
+	if err := repo.db.Model(&record).Updates(updates).Error; err != nil {
+		repo.log.Error("Failed to clock out", zap.Uint("user_id", userID), zap.Error(err))
+		return nil, err
+	}
+	return &record, nil
+}
+
+// All lists time clock records overlapping [start, end), optionally for one user
+func (repo TimeClockRepository) All(page, limit int, userID uint, start, end time.Time) ([]domain.TimeClock, int64, error) {
+	var records []domain.TimeClock
+	var totalItems int64
+
+	query := repo.db.Model(&domain.TimeClock{}).
+		Where("clock_in < ? AND (clock_out IS NULL OR clock_out > ?)", end, start)
+	if userID != 0 {
+		query = query.Where("user_id = ?", userID)
+	}
+
+	if err := query.Count(&totalItems).Error; err != nil {
+		repo.log.Error("Failed to count time clock records", zap.Error(err))
+		return nil, 0, err
+	}
+
+	err := query.Order("clock_in DESC").Scopes(helper.Paginate(uint(page), uint(limit))).Find(&records).Error
+	if err != nil {
+		repo.log.Error("Failed to fetch time clock records", zap.Error(err))
+		return nil, 0, err
+	}
+	return records, totalItems, nil
+}
+
+// Worked returns every time clock record overlapping [start, end) with its user
+func (repo TimeClockRepository) Worked(start, end time.Time) ([]domain.TimeClock, error) {
+	var records []domain.TimeClock
+	err := repo.db.Preload("User").
+		Where("clock_in < ? AND (clock_out IS NULL OR clock_out > ?)", end, start).
+		Order("user_id, clock_in").Find(&records).Error
+	if err != nil {
+		repo.log.Error("Failed to fetch worked shifts", zap.Error(err))
+		return nil, err
+	}
+	return records, nil
+}
+
+// ScheduledStaff returns the users with a shift schedule
+func (repo TimeClockRepository) ScheduledStaff() ([]domain.User, error) {
+	var users []domain.User
+	err := repo.db.Where("shift_start <> '' AND shift_end <> ''").Order("id").Find(&users).Error
+	if err != nil {
+		repo.log.Error("Failed to fetch scheduled staff", zap.Error(err))
+		return nil, err
+	}
+	return users, nil
+}
+
+// TotalTips sums the tips taken with payments in [start, end)
+func (repo TimeClockRepository) TotalTips(start, end time.Time) (float64, error) {
+	var total float64
+	err := repo.db.Model(&domain.Payment{}).
+		Select("COALESCE(SUM(tip), 0)").
+		Where("type = ? AND created_at >= ? AND created_at < ?", domain.PaymentTypePayment, start, end).
+		Scan(&total).Error
+	if err != nil {
+		repo.log.Error("Failed to sum tips", zap.Error(err))
+		return 0, err
+	}
+	return total, nil
+}
@@ -4,6 +4,7 @@ import (
 	"errors"
 	"project/domain"
 	"project/helper"
+	"time"
 
 	"go.uber.org/zap"
 	"gorm.io/gorm"
@@ -84,6 +85,20 @@ func (repo UserRepository) Update(user *domain.User) error {
 	return repo.db.Save(user).Error
 }
 
+// PinFailed counts a wrong manager pin, reaching attempts in a row locks the pin for lockout
+func (repo UserRepository) PinFailed(id uint, attempts int, lockout time.Duration) error {
+	return repo.db.Model(&domain.User{}).Where("id = ?", id).UpdateColumns(map[string]interface{}{
+		"pin_failures":     gorm.Expr("CASE WHEN pin_failures + 1 >= ? THEN 0 ELSE pin_failures + 1 END", attempts),
+		"pin_locked_until": gorm.Expr("CASE WHEN pin_failures + 1 >= ? THEN ? ELSE pin_locked_until END", attempts, time.Now().Add(lockout)),
+	}).Error
+}
+
+// PinPassed clears the wrong manager pins counted so far
+func (repo UserRepository) PinPassed(id uint) error {
+	return repo.db.Model(&domain.User{}).Where("id = ?", id).
+		UpdateColumns(map[string]interface{}{"pin_failures": 0, "pin_locked_until": nil}).Error
+}
+
 func (repo UserRepository) Delete(id uint) error {
 	return repo.db.Delete(&domain.User{}, id).Error
 }
@@ -0,0 +1,260 @@
+package repository
+
+import (
+	"database/sql"
+	"errors"
+	"math"
+	"project/domain"
+	"project/helper"
+	"time"
+
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type WaitlistRepository struct {
+	db     *gorm.DB
+	orders OrderRepository
+	policy domain.ReservationPolicy
+	log    *zap.Logger
+}
+
+func NewWaitlistRepository(db *gorm.DB, orders OrderRepository, policy domain.ReservationPolicy, log *zap.Logger) *WaitlistRepository {
+	return &WaitlistRepository{db: db, orders: orders, policy: policy, log: log}
+}
+
+// All lists the parties still waiting in queue order, or the entries with a status newest first,
+// optionally only those of a phone number
+func (repo WaitlistRepository) All(page, limit int, status domain.WaitlistStatus, phone string) ([]domain.WaitlistEntry, int64, error) {
+	entries := []domain.WaitlistEntry{}
+	var totalItems int64
+
+	query := repo.db.Model(&domain.WaitlistEntry{})
+	if status == "" {
+		query = query.Where("status IN ?", []domain.WaitlistStatus{domain.WaitlistWaiting, domain.WaitlistNotified}).Order("created_at, id")
+	} else {
+		query = query.Where("status = ?", status).Order("created_at DESC, id DESC")
+	}
+	if phone != "" {
+		query = query.Where("phone = ?", phone)
+	}
+
+	if err := query.Count(&totalItems).Error; err != nil {
+		repo.log.Error("Failed to count waitlist", zap.Error(err))
+		return nil, 0, err
+	}
+	if err := query.Scopes(helper.Paginate(uint(page), uint(limit))).Find(&entries).Error; err != nil {
+		repo.log.Error("Failed to fetch waitlist", zap.Error(err))
+		return nil, 0, err
+	}
+
+	if err := repo.fill(entries, time.Now()); err != nil {
+		return nil, 0, err
+	}
+	return entries, totalItems, nil
+}
+
+func (repo WaitlistRepository) FindByID(id uint) (*domain.WaitlistEntry, error) {
+	var entry domain.WaitlistEntry
+	if err := repo.db.First(&entry, id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, domain.ErrWaitlistEntryNotFound
+		}
+		repo.log.Error("Failed to fetch waitlist entry", zap.Uint("id", id), zap.Error(err))
+		return nil, err
+	}
+
+	entries := []domain.WaitlistEntry{entry}
+	if err := repo.fill(entries, time.Now()); err != nil {
+		return nil, err
+	}
+	return &entries[0], nil
+}
+
+// Create puts a party at the end of the queue. Without a quoted wait the estimate is quoted,
+// rounded up to five minutes.
+func (repo WaitlistRepository) Create(entry *domain.WaitlistEntry) error {
+	entry.Status = domain.WaitlistWaiting
+	if err := repo.db.Create(entry).Error; err != nil {
+		repo.log.Error("Failed to add party to waitlist", zap.Error(err))
+		return err
+	}
+
+	entries := []domain.WaitlistEntry{*entry}
+	if err := repo.fill(entries, time.Now()); err != nil {
+		return err
+	}
+	*entry = entries[0]
+
+	if entry.QuotedWait == 0 && entry.EstimatedWait > 0 {
+		entry.QuotedWait = (entry.EstimatedWait + 4) / 5 * 5
+		if err := repo.db.Model(entry).UpdateColumn("quoted_wait", entry.QuotedWait).Error; err != nil {
+			repo.log.Error("Failed to save quoted wait", zap.Uint("id", entry.ID), zap.Error(err))
+			return err
+		}
+	}
+
+	repo.log.Info("Party added to waitlist", zap.Uint("id", entry.ID), zap.Int("party_size", entry.PartySize))
+	return nil
+}
+
+// Notify records that the party was told its table is ready
+func (repo WaitlistRepository) Notify(id uint) (*domain.WaitlistEntry, error) {
+	return repo.change(id, func(tx *gorm.DB, entry *domain.WaitlistEntry) error {
+		return entry.Notify(time.Now())
+	})
+}
+
+// Seat opens a dine-in order for the party at the table and takes it off the list
+func (repo WaitlistRepository) Seat(id, tableID uint) (*domain.WaitlistEntry, error) {
+	return repo.change(id, func(tx *gorm.DB, entry *domain.WaitlistEntry) error {
+		order := entry.NewOrder(tableID)
+		if err := repo.orders.create(tx, order, ""); err != nil {
+			return err
+		}
+		return entry.Seat(tableID, order.ID, time.Now())
+	})
+}
+
+// Close takes the party off the list as a no-show or because it left
+func (repo WaitlistRepository) Close(id uint, status domain.WaitlistStatus) (*domain.WaitlistEntry, error) {
+	return repo.change(id, func(tx *gorm.DB, entry *domain.WaitlistEntry) error {
+		return entry.Close(status, time.Now())
+	})
+}
+
+func (repo WaitlistRepository) change(id uint, change func(tx *gorm.DB, entry *domain.WaitlistEntry) error) (*domain.WaitlistEntry, error) {
+	var entry domain.WaitlistEntry
+	err := repo.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&entry, id).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return domain.ErrWaitlistEntryNotFound
+			}
+			return err
+		}
+		if err := change(tx, &entry); err != nil {
+			return err
+		}
+		return tx.Select("status", "notified_at", "closed_at", "table_id", "order_id", "updated_at").Save(&entry).Error
+	})
+	if err != nil {
+		repo.log.Error("Failed to update waitlist entry", zap.Uint("id", id), zap.Error(err))
+		return nil, err
+	}
+
+	repo.log.Info("Waitlist entry updated", zap.Uint("id", id), zap.String("status", string(entry.Status)))
+	entries := []domain.WaitlistEntry{entry}
+	if err := repo.fill(entries, time.Now()); err != nil {
+		return nil, err
+	}
+	return &entries[0], nil
+}
+
+// fill sets the queue position and estimated wait of the waiting entries, and the no-shows of
+// every entry's phone number
+func (repo WaitlistRepository) fill(entries []domain.WaitlistEntry, now time.Time) error {
+	if len(entries) == 0 {
+		return nil
+	}
+
+	var queue []domain.WaitlistEntry
+	err := repo.db.Select("id", "party_size").
+		Where("status IN ?", []domain.WaitlistStatus{domain.WaitlistWaiting, domain.WaitlistNotified}).
+		Order("created_at, id").Find(&queue).Error
+	if err != nil {
+		repo.log.Error("Failed to fetch waitlist queue", zap.Error(err))
+		return err
+	}
+
+	var tables []domain.Table
+	if err := repo.db.Where("status <> ?", domain.TableOutOfService).Find(&tables).Error; err != nil {
+		repo.log.Error("Failed to fetch tables for waitlist", zap.Error(err))
+		return err
+	}
+
+	stay, err := repo.typicalStay(now)
+	if err != nil {
+		return err
+	}
+	turn := time.Duration(repo.policy.TurnTime) * time.Minute
+
+	phones := make([]string, 0, len(entries))
+	for _, entry := range entries {
+		phones = append(phones, entry.Phone)
+	}
+	var noShows []struct {
+		Phone string
+		Total int
+	}
+	err = repo.db.Model(&domain.WaitlistEntry{}).Select("phone, COUNT(*) AS total").
+		Where("status = ? AND phone IN ?", domain.WaitlistNoShow, phones).Group("phone").Scan(&noShows).Error
+	if err != nil {
+		repo.log.Error("Failed to count waitlist no-shows", zap.Error(err))
+		return err
+	}
+
+	for i := range entries {
+		entry := &entries[i]
+		for _, noShow := range noShows {
+			if noShow.Phone == entry.Phone {
+				entry.NoShows = noShow.Total
+				if entry.Status == domain.WaitlistNoShow {
+					entry.NoShows--
+				}
+			}
+		}
+
+		if !entry.Status.Active() {
+			continue
+		}
+		var ahead []int
+		for position, waiting := range queue {
+			if waiting.ID == entry.ID {
+				entry.Position = position + 1
+				break
+			}
+			ahead = append(ahead, waiting.PartySize)
+		}
+		wait := domain.EstimateWait(entry.PartySize, ahead, preferredTables(tables, entry.Section), stay, turn, now)
+		entry.EstimatedWait = int(math.Ceil(wait.Minutes()))
+	}
+	return nil
+}
+
+// typicalStay is how long dine-in guests stayed over the last 30 days, from opening the order to
+// paying it, the default reservation duration when there is no history yet
+func (repo WaitlistRepository) typicalStay(now time.Time) (time.Duration, error) {
+	var minutes sql.NullFloat64
+	err := repo.db.Table("order_status_history AS h").
+		Select("AVG(EXTRACT(EPOCH FROM (h.created_at - o.created_at)) / 60)").
+		Joins("JOIN orders o ON o.id = h.order_id").
+		Where("h.action = ? AND o.type = ? AND h.created_at >= ?", domain.OrderActionPay, domain.OrderDineIn, now.AddDate(0, 0, -30)).
+		Scan(&minutes).Error
+	if err != nil {
+		repo.log.Error("Failed to calculate typical table stay", zap.Error(err))
+		return 0, err
+	}
+	if !minutes.Valid || minutes.Float64 <= 0 {
+		return time.Duration(domain.DefaultReservationDuration) * time.Minute, nil
+	}
+	return time.Duration(minutes.Float64 * float64(time.Minute)), nil
+}
+
+// preferredTables are the tables in the section the party prefers, or every table when there are
+// none in it
+func preferredTables(tables []domain.Table, section string) []domain.Table {
+	if section == "" {
+		return tables
+	}
+	var preferred []domain.Table
+	for _, table := range tables {
+		if table.Section == section {
+			preferred = append(preferred, table)
+		}
+	}
+	if len(preferred) == 0 {
+		return tables
+	}
+	return preferred
+}
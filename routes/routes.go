@@ -29,15 +29,27 @@ func NewRoutes(ctx infra.ServiceContext) {
 	r.POST("/otp", ctx.Ctl.PasswordResetHandler.Create)
 	r.PUT("/otp/:id", ctx.Ctl.PasswordResetHandler.Update)
 	r.PUT("/user/:id", ctx.Ctl.UserHandler.UpdatePassword)
+	r.GET("/menu", ctx.Ctl.MenuHandler.Menu)
+	r.GET("/receipts/:token", ctx.Ctl.ReceiptHandler.Public)
+	r.GET("/receipts/:token/feedback", ctx.Ctl.FeedbackHandler.Form)
+	r.POST("/receipts/:token/feedback", ctx.Ctl.FeedbackHandler.Submit)
+	r.GET("/reservation-links/:token", ctx.Ctl.ReservationHandler.GuestLink)
+	r.POST("/reservation-links/:token", ctx.Ctl.ReservationHandler.GuestLink)
+	r.GET("/reservation-calendar/:token", ctx.Ctl.ReservationHandler.SubscribedCalendar)
 
 	r.GET("/swagger/*any", ginSwagger.WrapHandler(swaggerFiles.Handler))
 
 	r.Use(ctx.Middleware.Jwt.AuthJWT())
 	r.POST("/logout", ctx.Ctl.ProfileHandler.Logout)
 	r.PUT("/profile", ctx.Ctl.ProfileHandler.Update)
+	r.PUT("/profile/pin", ctx.Ctl.ProfileHandler.UpdatePin)
 	r.GET("/users", ctx.Middleware.OnlySuperAdmin(), ctx.Ctl.UserHandler.All)
 	r.PUT("/users/:id", ctx.Middleware.OnlySuperAdmin(), ctx.Ctl.UserPermissionHandler.Update)
 
+	r.POST("/time-clock/clock-in", ctx.Ctl.TimeClockHandler.ClockIn)
+	r.POST("/time-clock/clock-out", ctx.Ctl.TimeClockHandler.ClockOut)
+	r.GET("/time-clock", ctx.Middleware.CanAccess("Staff"), ctx.Ctl.TimeClockHandler.All)
+
 	staffRoutes := r.Group("/staffs", ctx.Middleware.CanAccess("Staff"))
 	{
 		staffRoutes.GET("/", ctx.Ctl.UserHandler.All)
@@ -51,8 +63,69 @@ func NewRoutes(ctx infra.ServiceContext) {
 	{
 		reservationsRoutes.GET("/", ctx.Ctl.ReservationHandler.All)
 		reservationsRoutes.POST("/", ctx.Ctl.ReservationHandler.Add)
+		reservationsRoutes.GET("/availability", ctx.Ctl.ReservationHandler.Availability)
+		reservationsRoutes.GET("/export.ics", ctx.Ctl.ReservationHandler.Export)
+		reservationsRoutes.POST("/calendar-feed", ctx.Ctl.ReservationHandler.CreateCalendarFeed)
+		reservationsRoutes.DELETE("/calendar-feed", ctx.Ctl.ReservationHandler.DeleteCalendarFeed)
 		reservationsRoutes.GET("/:id", ctx.Ctl.ReservationHandler.GetByID)
 		reservationsRoutes.PUT("/:id", ctx.Ctl.ReservationHandler.Update)
+		reservationsRoutes.POST("/:id/confirm", ctx.Ctl.ReservationHandler.Confirm)
+		reservationsRoutes.POST("/:id/remind", ctx.Ctl.ReservationHandler.Remind)
+		reservationsRoutes.POST("/:id/arrive", ctx.Ctl.ReservationHandler.Arrive)
+		reservationsRoutes.POST("/:id/seat", ctx.Ctl.ReservationHandler.Seat)
+		reservationsRoutes.POST("/:id/complete", ctx.Ctl.ReservationHandler.Complete)
+		reservationsRoutes.POST("/:id/no-show", ctx.Ctl.ReservationHandler.NoShow)
+		reservationsRoutes.POST("/:id/cancel", ctx.Ctl.ReservationHandler.Cancel)
+		reservationsRoutes.GET("/:id/history", ctx.Ctl.ReservationHandler.History)
+		reservationsRoutes.POST("/:id/deposit", ctx.Ctl.ReservationHandler.CollectDeposit)
+	}
+
+	waitlistRoutes := r.Group("/waitlist", ctx.Middleware.CanAccess("Reservations"))
+	{
+		waitlistRoutes.GET("/", ctx.Ctl.WaitlistHandler.All)
+		waitlistRoutes.POST("/", ctx.Ctl.WaitlistHandler.Create)
+		waitlistRoutes.GET("/:id", ctx.Ctl.WaitlistHandler.Get)
+		waitlistRoutes.POST("/:id/notify", ctx.Ctl.WaitlistHandler.Notify)
+		waitlistRoutes.POST("/:id/seat", ctx.Ctl.WaitlistHandler.Seat)
+		waitlistRoutes.POST("/:id/no-show", ctx.Ctl.WaitlistHandler.NoShow)
+		waitlistRoutes.POST("/:id/cancel", ctx.Ctl.WaitlistHandler.Cancel)
+	}
+
+	customersRoutes := r.Group("/customers", ctx.Middleware.CanAccess("Customers"))
+	{
+		customersRoutes.GET("/", ctx.Ctl.CustomerHandler.All)
+		customersRoutes.POST("/", ctx.Ctl.CustomerHandler.Create)
+		customersRoutes.GET("/:id", ctx.Ctl.CustomerHandler.Get)
+		customersRoutes.PUT("/:id", ctx.Ctl.CustomerHandler.Update)
+		customersRoutes.GET("/:id/visits", ctx.Ctl.CustomerHandler.Visits)
+		customersRoutes.POST("/:id/merge", ctx.Ctl.CustomerHandler.Merge)
+		customersRoutes.GET("/:id/loyalty", ctx.Ctl.LoyaltyHandler.Account)
+		customersRoutes.GET("/:id/loyalty/ledger", ctx.Ctl.LoyaltyHandler.Ledger)
+		customersRoutes.POST("/:id/loyalty/adjust", ctx.Ctl.LoyaltyHandler.Adjust)
+	}
+
+	giftCardsRoutes := r.Group("/gift-cards", ctx.Middleware.CanAccess("Gift Cards"))
+	{
+		giftCardsRoutes.GET("/", ctx.Ctl.GiftCardHandler.All)
+		giftCardsRoutes.POST("/", ctx.Ctl.GiftCardHandler.Issue)
+		giftCardsRoutes.GET("/code/:code", ctx.Ctl.GiftCardHandler.GetByCode)
+		giftCardsRoutes.GET("/:id", ctx.Ctl.GiftCardHandler.Get)
+		giftCardsRoutes.POST("/:id/void", ctx.Ctl.GiftCardHandler.Void)
+	}
+
+	feedbackRoutes := r.Group("/feedback", ctx.Middleware.CanAccess("Reports"))
+	{
+		feedbackRoutes.GET("/", ctx.Ctl.FeedbackHandler.All)
+		feedbackRoutes.GET("/products", ctx.Ctl.FeedbackHandler.ByProduct)
+		feedbackRoutes.GET("/staff", ctx.Ctl.FeedbackHandler.ByStaff)
+		feedbackRoutes.GET("/trend", ctx.Ctl.FeedbackHandler.Trend)
+	}
+
+	loyaltyRoutes := r.Group("/loyalty", ctx.Middleware.CanAccess("Customers"))
+	{
+		loyaltyRoutes.GET("/tiers", ctx.Ctl.LoyaltyHandler.Tiers)
+		loyaltyRoutes.POST("/tiers", ctx.Ctl.LoyaltyHandler.CreateTier)
+		loyaltyRoutes.PUT("/tiers/:id", ctx.Ctl.LoyaltyHandler.UpdateTier)
 	}
 
 	categoriesRoutes := r.Group("/categories", ctx.Middleware.CanAccess("Menu"))
@@ -60,16 +133,57 @@ func NewRoutes(ctx infra.ServiceContext) {
 		categoriesRoutes.GET("/", ctx.Ctl.CategoryHandler.All)
 		categoriesRoutes.POST("/create", ctx.Ctl.CategoryHandler.Create)
 		categoriesRoutes.PUT("/:id", ctx.Ctl.CategoryHandler.Update)
+		categoriesRoutes.PUT("/:id/tax-rates", ctx.Ctl.TaxHandler.AssignToCategory)
+		categoriesRoutes.PUT("/:id/loyalty-earn-rate", ctx.Ctl.LoyaltyHandler.SetCategoryEarnRate)
 	}
 
 	r.GET("/products", ctx.Middleware.CanAccess("Menu"), ctx.Ctl.CategoryHandler.AllProducts)
 
+	taxRatesRoutes := r.Group("/tax-rates", ctx.Middleware.CanAccess("Menu"))
+	{
+		taxRatesRoutes.GET("/", ctx.Ctl.TaxHandler.All)
+		taxRatesRoutes.POST("/", ctx.Ctl.TaxHandler.Create)
+		taxRatesRoutes.GET("/:id", ctx.Ctl.TaxHandler.GetByID)
+		taxRatesRoutes.PUT("/:id", ctx.Ctl.TaxHandler.Update)
+		taxRatesRoutes.DELETE("/:id", ctx.Ctl.TaxHandler.Delete)
+	}
+
+	promoCodesRoutes := r.Group("/promo-codes", ctx.Middleware.CanAccess("Menu"))
+	{
+		promoCodesRoutes.GET("/", ctx.Ctl.DiscountHandler.AllPromoCodes)
+		promoCodesRoutes.POST("/", ctx.Ctl.DiscountHandler.CreatePromoCode)
+		promoCodesRoutes.GET("/:id", ctx.Ctl.DiscountHandler.GetPromoCode)
+		promoCodesRoutes.PUT("/:id", ctx.Ctl.DiscountHandler.UpdatePromoCode)
+		promoCodesRoutes.DELETE("/:id", ctx.Ctl.DiscountHandler.DeletePromoCode)
+	}
+
+	pricingRulesRoutes := r.Group("/pricing-rules", ctx.Middleware.CanAccess("Menu"))
+	{
+		pricingRulesRoutes.GET("/", ctx.Ctl.DiscountHandler.AllPricingRules)
+		pricingRulesRoutes.POST("/", ctx.Ctl.DiscountHandler.CreatePricingRule)
+		pricingRulesRoutes.GET("/:id", ctx.Ctl.DiscountHandler.GetPricingRule)
+		pricingRulesRoutes.PUT("/:id", ctx.Ctl.DiscountHandler.UpdatePricingRule)
+		pricingRulesRoutes.DELETE("/:id", ctx.Ctl.DiscountHandler.DeletePricingRule)
+	}
+
+	serviceChargesRoutes := r.Group("/service-charges", ctx.Middleware.CanAccess("Menu"))
+	{
+		serviceChargesRoutes.GET("/", ctx.Ctl.DiscountHandler.AllServiceChargeRules)
+		serviceChargesRoutes.POST("/", ctx.Ctl.DiscountHandler.CreateServiceChargeRule)
+		serviceChargesRoutes.GET("/:id", ctx.Ctl.DiscountHandler.GetServiceChargeRule)
+		serviceChargesRoutes.PUT("/:id", ctx.Ctl.DiscountHandler.UpdateServiceChargeRule)
+		serviceChargesRoutes.DELETE("/:id", ctx.Ctl.DiscountHandler.DeleteServiceChargeRule)
+	}
+
 	inventoryRoutes := r.Group("/inventory", ctx.Middleware.CanAccess("Inventory"))
 	{
 		inventoryRoutes.GET("/", ctx.Ctl.ProductHandler.All)
 		inventoryRoutes.POST("/", ctx.Ctl.ProductHandler.Add)
+		inventoryRoutes.GET("/:id", ctx.Ctl.ProductHandler.GetByID)
 		inventoryRoutes.PUT("/:id", ctx.Ctl.ProductHandler.Update)
 		inventoryRoutes.DELETE("/:id", ctx.Ctl.ProductHandler.Delete)
+		inventoryRoutes.PUT("/:id/tax-rates", ctx.Ctl.TaxHandler.AssignToProduct)
+		inventoryRoutes.PUT("/:id/modifiers", ctx.Ctl.ProductHandler.SetModifiers)
 	}
 
 	dashboardRoutes := r.Group("/dashboard", ctx.Middleware.CanAccess("Dashboard"))
@@ -79,15 +193,50 @@ func NewRoutes(ctx infra.ServiceContext) {
 		dashboardRoutes.GET("/ws", ctx.Ctl.DashboardHandler.SalesDataWebSocket)
 	}
 
-	r.GET("/tables", ctx.Middleware.CanAccess("Orders"), ctx.Ctl.OrderHandler.AllTables)
+	tablesRoutes := r.Group("/tables", ctx.Middleware.CanAccess("Orders"))
+	{
+		tablesRoutes.GET("/", ctx.Ctl.TableHandler.All)
+		tablesRoutes.GET("/:id", ctx.Ctl.TableHandler.Get)
+		tablesRoutes.POST("/", ctx.Ctl.TableHandler.Create)
+		tablesRoutes.PUT("/:id", ctx.Ctl.TableHandler.Update)
+		tablesRoutes.PATCH("/:id/status", ctx.Ctl.TableHandler.UpdateStatus)
+		tablesRoutes.DELETE("/:id", ctx.Ctl.TableHandler.Delete)
+	}
+
 	r.GET("/payments", ctx.Middleware.CanAccess("Orders"), ctx.Ctl.OrderHandler.AllPayments)
+	r.GET("/reason-codes", ctx.Middleware.CanAccess("Orders"), ctx.Ctl.RefundHandler.ReasonCodes)
 
 	ordersRoutes := r.Group("/orders", ctx.Middleware.CanAccess("Orders"))
 	{
 		ordersRoutes.GET("/", ctx.Ctl.OrderHandler.AllOrders)
 		ordersRoutes.POST("/", ctx.Ctl.OrderHandler.Create)
+		ordersRoutes.GET("/:id", ctx.Ctl.OrderHandler.GetByID)
 		ordersRoutes.PUT("/:id", ctx.Ctl.OrderHandler.Update)
 		ordersRoutes.DELETE("/:id", ctx.Ctl.OrderHandler.Delete)
+		ordersRoutes.POST("/:id/fire", ctx.Ctl.OrderHandler.Fire)
+		ordersRoutes.POST("/:id/ready", ctx.Ctl.OrderHandler.Ready)
+		ordersRoutes.POST("/:id/serve", ctx.Ctl.OrderHandler.Serve)
+		ordersRoutes.POST("/:id/pay", ctx.Ctl.OrderHandler.Pay)
+		ordersRoutes.POST("/:id/cancel", ctx.Ctl.OrderHandler.Cancel)
+		ordersRoutes.POST("/:id/redeem-points", ctx.Ctl.LoyaltyHandler.Redeem)
+		ordersRoutes.POST("/:id/gift-card", ctx.Ctl.GiftCardHandler.Redeem)
+		ordersRoutes.GET("/:id/history", ctx.Ctl.OrderHandler.History)
+		ordersRoutes.POST("/:id/merge", ctx.Ctl.OrderHandler.Merge)
+		ordersRoutes.POST("/:id/items/move", ctx.Ctl.OrderHandler.MoveItems)
+		ordersRoutes.POST("/:id/transfer", ctx.Ctl.OrderHandler.Transfer)
+		ordersRoutes.POST("/:id/tables", ctx.Ctl.OrderHandler.JoinTables)
+		ordersRoutes.DELETE("/:id/tables/:table_id", ctx.Ctl.OrderHandler.LeaveTable)
+		ordersRoutes.POST("/:id/items/:item_id/void", ctx.Ctl.RefundHandler.Void)
+		ordersRoutes.GET("/:id/voids", ctx.Ctl.RefundHandler.Voids)
+		ordersRoutes.POST("/:id/refunds", ctx.Ctl.RefundHandler.Refund)
+		ordersRoutes.GET("/:id/refunds", ctx.Ctl.RefundHandler.Refunds)
+		ordersRoutes.GET("/:id/discounts", ctx.Ctl.DiscountHandler.OrderDiscounts)
+		ordersRoutes.POST("/:id/discounts", ctx.Ctl.DiscountHandler.ApplyDiscount)
+		ordersRoutes.DELETE("/:id/discounts/:discount_id", ctx.Ctl.DiscountHandler.RemoveDiscount)
+		ordersRoutes.GET("/:id/receipt", ctx.Ctl.ReceiptHandler.Receipt)
+		ordersRoutes.POST("/:id/receipt/print", ctx.Ctl.ReceiptHandler.Print)
+		ordersRoutes.POST("/:id/receipt/email", ctx.Ctl.ReceiptHandler.Email)
+		ordersRoutes.GET("/:id/kitchen-tickets", ctx.Ctl.ReceiptHandler.KitchenTickets)
 	}
 
 	notificationRoutes := r.Group("/notifications")
@@ -103,6 +252,11 @@ func NewRoutes(ctx infra.ServiceContext) {
 		revenueRoutes.GET("/status", ctx.Ctl.RevenueHandler.GetTotalRevenueByStatus)
 		revenueRoutes.GET("/bestsellers", ctx.Ctl.RevenueHandler.GetProductRevenueDetails)
 		revenueRoutes.GET("/monthly_revenue", ctx.Ctl.RevenueHandler.GetMonthlyRevenue)
+		revenueRoutes.GET("/tax-summary", ctx.Ctl.TaxHandler.Summary)
+		revenueRoutes.GET("/tip-pool", ctx.Ctl.TimeClockHandler.TipPool)
+		revenueRoutes.GET("/order-types", ctx.Ctl.RevenueHandler.GetRevenueByOrderType)
+		revenueRoutes.GET("/deposits", ctx.Ctl.RevenueHandler.GetDepositRevenue)
+		revenueRoutes.GET("/gift-cards", ctx.Ctl.RevenueHandler.GetGiftCardLiability)
 
 	}
 
@@ -0,0 +1,53 @@
+package service
+
+import (
+	"project/domain"
+	"project/repository"
+
+	"go.uber.org/zap"
+)
+
+type CustomerService interface {
+	All(page, limit int, search string) ([]domain.Customer, int64, error)
+	FindByID(id uint) (*domain.Customer, error)
+	Visits(id uint, page, limit int) ([]domain.VisitEntry, int64, error)
+	Create(customer *domain.Customer) error
+	Update(id, version uint, details domain.Customer) (*domain.Customer, error)
+	Merge(id uint, duplicateIDs []uint) (*domain.Customer, error)
+}
+
+type customerService struct {
+	repo repository.CustomerRepository
+	log  *zap.Logger
+}
+
+func NewCustomerService(repo repository.CustomerRepository, log *zap.Logger) CustomerService {
+	return &customerService{repo, log}
+}
+
+func (s *customerService) All(page, limit int, search string) ([]domain.Customer, int64, error) {
+	return s.repo.All(page, limit, search)
+}
+
+func (s *customerService) FindByID(id uint) (*domain.Customer, error) {
+	return s.repo.FindByID(id)
+}
+
+func (s *customerService) Visits(id uint, page, limit int) ([]domain.VisitEntry, int64, error) {
+	if _, err := s.repo.FindByID(id); err != nil {
+		return nil, 0, err
+	}
+	return s.repo.Visits(id, page, limit)
+}
+
+func (s *customerService) Create(customer *domain.Customer) error {
+	return s.repo.Create(customer)
+}
+
+func (s *customerService) Update(id, version uint, details domain.Customer) (*domain.Customer, error) {
+	return s.repo.Update(id, version, details)
+}
+
+func (s *customerService) Merge(id uint, duplicateIDs []uint) (*domain.Customer, error) {
+	return s.repo.Merge(id, duplicateIDs)
+}
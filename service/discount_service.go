@@ -0,0 +1,171 @@
+package service
+
+import (
+	"fmt"
+	"project/domain"
+	"project/repository"
+	"strings"
+
+	"go.uber.org/zap"
+)
+
+type DiscountService interface {
+	AllPromoCodes(page, limit int) ([]domain.PromoCode, int64, error)
+	FindPromoCode(id uint) (*domain.PromoCode, error)
+	CreatePromoCode(promoCode *domain.PromoCode) error
+	UpdatePromoCode(promoCode *domain.PromoCode) error
+	DeletePromoCode(id uint) error
+	AllPricingRules() ([]domain.PricingRule, error)
+	FindPricingRule(id uint) (*domain.PricingRule, error)
+	CreatePricingRule(rule *domain.PricingRule) error
+	UpdatePricingRule(rule *domain.PricingRule) error
+	DeletePricingRule(id uint) error
+	AllServiceChargeRules() ([]domain.ServiceChargeRule, error)
+	FindServiceChargeRule(id uint) (*domain.ServiceChargeRule, error)
+	CreateServiceChargeRule(rule *domain.ServiceChargeRule) error
+	UpdateServiceChargeRule(rule *domain.ServiceChargeRule) error
+	DeleteServiceChargeRule(id uint) error
+	OrderDiscounts(orderID uint) ([]domain.OrderDiscount, error)
+	ApplyDiscount(orderID uint, discount domain.OrderDiscount, promoCode string) (*domain.OrderDiscount, error)
+	RemoveDiscount(orderID, discountID uint) error
+}
+
+type discountService struct {
+	repo repository.DiscountRepository
+	log  *zap.Logger
+}
+
+func NewDiscountService(repo repository.DiscountRepository, log *zap.Logger) DiscountService {
+	return &discountService{repo, log}
+}
+
+func (s *discountService) AllPromoCodes(page, limit int) ([]domain.PromoCode, int64, error) {
+	return s.repo.AllPromoCodes(page, limit)
+}
+
+func (s *discountService) FindPromoCode(id uint) (*domain.PromoCode, error) {
+	var promoCode domain.PromoCode
+	if err := s.repo.FindPromoCode(&promoCode, id); err != nil {
+		return nil, err
+	}
+	return &promoCode, nil
+}
+
+func (s *discountService) CreatePromoCode(promoCode *domain.PromoCode) error {
+	if err := validatePromoCode(promoCode); err != nil {
+		return err
+	}
+	return s.repo.CreatePromoCode(promoCode)
+}
+
+func (s *discountService) UpdatePromoCode(promoCode *domain.PromoCode) error {
+	if err := validatePromoCode(promoCode); err != nil {
+		return err
+	}
+	if err := s.repo.FindPromoCode(&domain.PromoCode{}, promoCode.ID); err != nil {
+		return err
+	}
+	return s.repo.UpdatePromoCode(promoCode)
+}
+
+func (s *discountService) DeletePromoCode(id uint) error {
+	return s.repo.DeletePromoCode(id)
+}
+
+func (s *discountService) AllPricingRules() ([]domain.PricingRule, error) {
+	return s.repo.AllPricingRules()
+}
+
+func (s *discountService) FindPricingRule(id uint) (*domain.PricingRule, error) {
+	var rule domain.PricingRule
+	if err := s.repo.FindPricingRule(&rule, id); err != nil {
+		return nil, err
+	}
+	return &rule, nil
+}
+
+func (s *discountService) CreatePricingRule(rule *domain.PricingRule) error {
+	if err := rule.Validate(); err != nil {
+		return err
+	}
+	return s.repo.CreatePricingRule(rule)
+}
+
+func (s *discountService) UpdatePricingRule(rule *domain.PricingRule) error {
+	if err := rule.Validate(); err != nil {
+		return err
+	}
+	if err := s.repo.FindPricingRule(&domain.PricingRule{}, rule.ID); err != nil {
+		return err
+	}
+	return s.repo.UpdatePricingRule(rule)
+}
+
+func (s *discountService) DeletePricingRule(id uint) error {
+	return s.repo.DeletePricingRule(id)
+}
+
+func (s *discountService) AllServiceChargeRules() ([]domain.ServiceChargeRule, error) {
+	return s.repo.AllServiceChargeRules()
+}
+
+func (s *discountService) FindServiceChargeRule(id uint) (*domain.ServiceChargeRule, error) {
+	var rule domain.ServiceChargeRule
+	if err := s.repo.FindServiceChargeRule(&rule, id); err != nil {
+		return nil, err
+	}
+	return &rule, nil
+}
+
+func (s *discountService) CreateServiceChargeRule(rule *domain.ServiceChargeRule) error {
+	if err := rule.Type.Validate(rule.Value); err != nil {
+		return err
+	}
+	return s.repo.CreateServiceChargeRule(rule)
+}
+
+func (s *discountService) UpdateServiceChargeRule(rule *domain.ServiceChargeRule) error {
+	if err := rule.Type.Validate(rule.Value); err != nil {
+		return err
+	}
+	if err := s.repo.FindServiceChargeRule(&domain.ServiceChargeRule{}, rule.ID); err != nil {
+		return err
+	}
+	return s.repo.UpdateServiceChargeRule(rule)
+}
+
+func (s *discountService) DeleteServiceChargeRule(id uint) error {
+	return s.repo.DeleteServiceChargeRule(id)
+}
+
+func (s *discountService) OrderDiscounts(orderID uint) ([]domain.OrderDiscount, error) {
+	return s.repo.OrderDiscounts(orderID)
+}
+
+func (s *discountService) ApplyDiscount(orderID uint, discount domain.OrderDiscount, promoCode string) (*domain.OrderDiscount, error) {
+	promoCode = strings.TrimSpace(promoCode)
+	if promoCode == "" {
+		if err := discount.Type.Validate(discount.Value); err != nil {
+			return nil, err
+		}
+		if discount.Name == "" {
+			discount.Name = "Manual discount"
+		}
+	}
+	return s.repo.ApplyDiscount(orderID, discount, promoCode)
+}
+
+func (s *discountService) RemoveDiscount(orderID, discountID uint) error {
+	return s.repo.RemoveDiscount(orderID, discountID)
+}
+
+func validatePromoCode(promoCode *domain.PromoCode) error {
+	promoCode.Code = strings.ToUpper(strings.TrimSpace(promoCode.Code))
+	if err := promoCode.Type.Validate(promoCode.Value); err != nil {
+		return err
+	}
+	if promoCode.StartsAt != nil && promoCode.EndsAt != nil && promoCode.EndsAt.Before(*promoCode.StartsAt) {
+		return fmt.Errorf("%w: ends_at must be after starts_at", domain.ErrInvalidDiscount)
+	}
+	return nil
+}
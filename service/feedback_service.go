@@ -0,0 +1,77 @@
+package service
+
+import (
+	"errors"
+	"project/config"
+	"project/domain"
+	"project/repository"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+type FeedbackService interface {
+	// Form reads what the guest of a signed receipt link can rate
+	Form(token string) (*domain.FeedbackForm, error)
+	// Submit saves the ratings of the guest of a signed receipt link
+	Submit(token string, request domain.FeedbackRequest) (*domain.Feedback, error)
+	All(page, limit int, start, end time.Time, low bool) ([]domain.Feedback, int64, error)
+	ByProduct(start, end time.Time) ([]domain.FeedbackProductRating, error)
+	ByStaff(start, end time.Time) ([]domain.FeedbackStaffRating, error)
+	Trend(start, end time.Time, interval domain.FeedbackInterval) ([]domain.FeedbackTrend, error)
+}
+
+type feedbackService struct {
+	repo   repository.FeedbackRepository
+	secret []byte
+	log    *zap.Logger
+}
+
+// NewFeedbackService checks the guest links with the secret of the receipt links they come from
+func NewFeedbackService(repo repository.FeedbackRepository, cfg config.ReceiptConfig, log *zap.Logger) FeedbackService {
+	return &feedbackService{repo: repo, secret: []byte(cfg.Secret), log: log}
+}
+
+func (s *feedbackService) Form(token string) (*domain.FeedbackForm, error) {
+	orderID, err := domain.ParseReceiptToken(token, s.secret, time.Now())
+	if err != nil {
+		return nil, err
+	}
+	return s.repo.Form(orderID)
+}
+
+func (s *feedbackService) Submit(token string, request domain.FeedbackRequest) (*domain.Feedback, error) {
+	orderID, err := domain.ParseReceiptToken(token, s.secret, time.Now())
+	if err != nil {
+		return nil, err
+	}
+	return s.repo.Submit(orderID, request)
+}
+
+func (s *feedbackService) All(page, limit int, start, end time.Time, low bool) ([]domain.Feedback, int64, error) {
+	if !end.After(start) {
+		return nil, 0, errors.New("end date must be after start date")
+	}
+	return s.repo.All(page, limit, start, end, low)
+}
+
+func (s *feedbackService) ByProduct(start, end time.Time) ([]domain.FeedbackProductRating, error) {
+	if !end.After(start) {
+		return nil, errors.New("end date must be after start date")
+	}
+	return s.repo.ByProduct(start, end)
+}
+
+func (s *feedbackService) ByStaff(start, end time.Time) ([]domain.FeedbackStaffRating, error) {
+	if !end.After(start) {
+		return nil, errors.New("end date must be after start date")
+	}
+	return s.repo.ByStaff(start, end)
+}
+
+func (s *feedbackService) Trend(start, end time.Time, interval domain.FeedbackInterval) ([]domain.FeedbackTrend, error) {
+	if !end.After(start) {
+		return nil, errors.New("end date must be after start date")
+	}
+	return s.repo.Trend(start, end, interval)
+}
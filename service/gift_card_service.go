@@ -0,0 +1,63 @@
+package service
+
+import (
+	"project/domain"
+	"project/repository"
+
+	"go.uber.org/zap"
+)
+
+type GiftCardService interface {
+	All(page, limit int, search string, status domain.GiftCardStatus) ([]domain.GiftCard, int64, error)
+	FindByID(id uint) (*domain.GiftCard, error)
+	FindByCode(code string) (*domain.GiftCard, error)
+	Issue(request domain.IssueGiftCardRequest, userID *uint) (*domain.GiftCard, error)
+	Void(id uint, note string, userID *uint) (*domain.GiftCard, error)
+	Redeem(orderID uint, request domain.RedeemGiftCardRequest, userID *uint) (*domain.Payment, error)
+}
+
+type giftCardService struct {
+	repo  repository.GiftCardRepository
+	email EmailService
+	log   *zap.Logger
+}
+
+func NewGiftCardService(repo repository.GiftCardRepository, email EmailService, log *zap.Logger) GiftCardService {
+	return &giftCardService{repo: repo, email: email, log: log}
+}
+
+func (s *giftCardService) All(page, limit int, search string, status domain.GiftCardStatus) ([]domain.GiftCard, int64, error) {
+	return s.repo.All(page, limit, search, status)
+}
+
+func (s *giftCardService) FindByID(id uint) (*domain.GiftCard, error) {
+	return s.repo.FindByID(id)
+}
+
+func (s *giftCardService) FindByCode(code string) (*domain.GiftCard, error) {
+	return s.repo.FindByCode(code)
+}
+
+// Issue sells a gift card and emails its code to the recipient when there is one. The card is
+// already saved, so a failed email is only logged.
+func (s *giftCardService) Issue(request domain.IssueGiftCardRequest, userID *uint) (*domain.GiftCard, error) {
+	card, err := s.repo.Issue(request, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	if card.RecipientEmail != "" {
+		if _, err := s.email.Send(card.RecipientEmail, "You received a gift card", "gift_card", card); err != nil {
+			s.log.Error("Failed to send gift card email", zap.Uint("id", card.ID), zap.Error(err))
+		}
+	}
+	return card, nil
+}
+
+func (s *giftCardService) Void(id uint, note string, userID *uint) (*domain.GiftCard, error) {
+	return s.repo.Void(id, note, userID)
+}
+
+func (s *giftCardService) Redeem(orderID uint, request domain.RedeemGiftCardRequest, userID *uint) (*domain.Payment, error) {
+	return s.repo.Redeem(orderID, request, userID)
+}
@@ -0,0 +1,78 @@
+package service
+
+import (
+	"fmt"
+	"project/domain"
+	"project/repository"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+type LoyaltyService interface {
+	Account(customerID uint) (*domain.LoyaltyAccount, error)
+	Ledger(customerID uint, page, limit int) ([]domain.LoyaltyEntry, int64, error)
+	Adjust(customerID uint, adjustment domain.LoyaltyAdjustment, userID *uint) (*domain.LoyaltyEntry, error)
+	Redeem(orderID uint, points int, userID *uint) (*domain.Payment, error)
+	Expire() (int, error)
+	Tiers() ([]domain.LoyaltyTier, error)
+	CreateTier(tier *domain.LoyaltyTier) error
+	UpdateTier(id uint, details domain.LoyaltyTier) (*domain.LoyaltyTier, error)
+	SetCategoryEarnRate(categoryID uint, rate *float64) (*domain.Category, error)
+}
+
+type loyaltyService struct {
+	repo repository.LoyaltyRepository
+	log  *zap.Logger
+}
+
+func NewLoyaltyService(repo repository.LoyaltyRepository, log *zap.Logger) LoyaltyService {
+	return &loyaltyService{repo, log}
+}
+
+func (s *loyaltyService) Account(customerID uint) (*domain.LoyaltyAccount, error) {
+	return s.repo.Account(customerID)
+}
+
+func (s *loyaltyService) Ledger(customerID uint, page, limit int) ([]domain.LoyaltyEntry, int64, error) {
+	return s.repo.Ledger(customerID, page, limit)
+}
+
+func (s *loyaltyService) Adjust(customerID uint, adjustment domain.LoyaltyAdjustment, userID *uint) (*domain.LoyaltyEntry, error) {
+	return s.repo.Adjust(customerID, adjustment, userID)
+}
+
+func (s *loyaltyService) Redeem(orderID uint, points int, userID *uint) (*domain.Payment, error) {
+	return s.repo.Redeem(orderID, points, userID)
+}
+
+// Expire is run by the cron to write off the points past their expiry
+func (s *loyaltyService) Expire() (int, error) {
+	expired, err := s.repo.Expire(time.Now())
+	if err != nil {
+		return 0, err
+	}
+	if expired > 0 {
+		s.log.Info("Loyalty points expired", zap.Int("points", expired))
+	}
+	return expired, nil
+}
+
+func (s *loyaltyService) Tiers() ([]domain.LoyaltyTier, error) {
+	return s.repo.Tiers()
+}
+
+func (s *loyaltyService) CreateTier(tier *domain.LoyaltyTier) error {
+	return s.repo.CreateTier(tier)
+}
+
+func (s *loyaltyService) UpdateTier(id uint, details domain.LoyaltyTier) (*domain.LoyaltyTier, error) {
+	return s.repo.UpdateTier(id, details)
+}
+
+func (s *loyaltyService) SetCategoryEarnRate(categoryID uint, rate *float64) (*domain.Category, error) {
+	if rate != nil && *rate < 0 {
+		return nil, fmt.Errorf("%w: the earn rate cannot be negative", domain.ErrInvalidLoyalty)
+	}
+	return s.repo.SetCategoryEarnRate(categoryID, rate)
+}
@@ -0,0 +1,30 @@
+package service
+
+import (
+	"project/helper"
+	"project/repository"
+
+	"go.uber.org/zap"
+)
+
+type MenuService interface {
+	// Menu returns the public menu as JSON with its ETag
+	Menu() (body []byte, etag string, err error)
+}
+
+type menuService struct {
+	repo repository.MenuRepository
+	log  *zap.Logger
+}
+
+func NewMenuService(repo repository.MenuRepository, log *zap.Logger) MenuService {
+	return &menuService{repo: repo, log: log}
+}
+
+func (s *menuService) Menu() ([]byte, string, error) {
+	body, err := s.repo.Menu()
+	if err != nil {
+		return nil, "", err
+	}
+	return body, helper.ContentETag(body), nil
+}
@@ -0,0 +1,23 @@
+package service
+
+import "project/domain"
+
+func (s *orderService) Merge(targetID uint, sourceIDs []uint, userID *uint) (*domain.Order, error) {
+	return s.repo.Merge(targetID, sourceIDs, userID)
+}
+
+func (s *orderService) MoveItems(fromID uint, toID *uint, moves []domain.OrderItemMove, userID *uint) (*domain.Order, error) {
+	return s.repo.MoveItems(fromID, toID, moves, userID)
+}
+
+func (s *orderService) Transfer(id, tableID uint, userID *uint) (*domain.Order, error) {
+	return s.repo.Transfer(id, tableID, userID)
+}
+
+func (s *orderService) JoinTables(id uint, tableIDs []uint, userID *uint) (*domain.Order, error) {
+	return s.repo.JoinTables(id, tableIDs, userID)
+}
+
+func (s *orderService) LeaveTable(id, tableID uint, userID *uint) (*domain.Order, error) {
+	return s.repo.LeaveTable(id, tableID, userID)
+}
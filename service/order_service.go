@@ -4,20 +4,28 @@ import (
 	"errors"
 	"project/domain"
 	"project/repository"
+	"strings"
 
 	"go.uber.org/zap"
 )
 
 type OrderService interface {
-	AllTables(page, limit int) ([]*domain.Table, int64, error)
 	AllPayments() ([]*domain.PaymentMethod, error)
-	CreateOrder(name string, tableID uint, orderItems []domain.OrderItem) error
+	CreateOrder(order *domain.Order, promoCode string) error
 	FindByIDOrder(order *domain.Order, id string) error
 	// FindByIDTable(table *domain.Table, id string) error
 	FindByIDOrderDetail(order *domain.OrderDetail, id string) error
 	Update(order *domain.Order) error
-	AllOrders(page, limit int, name, codeOrder string, status domain.StatusPayment) ([]*domain.OrderDetail, int64, error)
+	Transition(id uint, action domain.OrderAction, input domain.OrderTransitionInput) (*domain.Order, error)
+	History(orderID uint) ([]domain.OrderStatusHistory, error)
+	AllOrders(page, limit int, name, codeOrder string, status domain.StatusPayment, orderType domain.OrderType) ([]*domain.OrderDetail, int64, error)
 	Delete(order *domain.Order) error
+	Merge(targetID uint, sourceIDs []uint, userID *uint) (*domain.Order, error)
+	// MoveItems moves items to another open order, or splits them off to a new order when toID is nil
+	MoveItems(fromID uint, toID *uint, moves []domain.OrderItemMove, userID *uint) (*domain.Order, error)
+	Transfer(id, tableID uint, userID *uint) (*domain.Order, error)
+	JoinTables(id uint, tableIDs []uint, userID *uint) (*domain.Order, error)
+	LeaveTable(id, tableID uint, userID *uint) (*domain.Order, error)
 }
 
 type orderService struct {
@@ -29,17 +37,6 @@ func NewOrderService(repo repository.OrderRepository, log *zap.Logger) OrderServ
 	return &orderService{repo, log}
 }
 
-func (s *orderService) AllTables(page, limit int) ([]*domain.Table, int64, error) {
-	tables, totalItems, err := s.repo.AllTables(page, limit)
-	if err != nil {
-		return nil, 0, err
-	}
-	if len(tables) == 0 {
-		return nil, int64(totalItems), errors.New("tables not found")
-	}
-
-	return tables, int64(totalItems), nil
-}
 func (s *orderService) AllPayments() ([]*domain.PaymentMethod, error) {
 	payments, err := s.repo.AllPayments()
 	if len(payments) == 0 {
@@ -51,21 +48,23 @@ func (s *orderService) AllPayments() ([]*domain.PaymentMethod, error) {
 
 	return payments, nil
 }
-func (s *orderService) CreateOrder(name string, tableID uint, orderItems []domain.OrderItem) error {
-	if len(orderItems) == 0 {
+// CreateOrder saves a new order, pricing rules such as happy hour are evaluated on its items
+// and the promo code, when given, is checked and applied on what is left
+func (s *orderService) CreateOrder(order *domain.Order, promoCode string) error {
+	if len(order.OrderItems) == 0 {
 		return errors.New("order items cannot be empty")
 	}
-	order := &domain.Order{
-		Name:       name,
-		TableID:    tableID,
-		OrderItems: orderItems,
+	if order.PartySize == 0 {
+		order.PartySize = 1
 	}
-
-	if err := s.repo.Create(order); err != nil {
+	if order.Type == "" {
+		order.Type = domain.OrderDineIn
+	}
+	if err := order.Validate(); err != nil {
 		return err
 	}
 
-	return nil
+	return s.repo.Create(order, strings.TrimSpace(promoCode))
 }
 
 func (s *orderService) FindByIDOrder(order *domain.Order, id string) error {
@@ -102,8 +101,21 @@ func (s *orderService) Update(order *domain.Order) error {
 	}
 	return nil
 }
-func (s *orderService) AllOrders(page, limit int, name, codeOrder string, status domain.StatusPayment) ([]*domain.OrderDetail, int64, error) {
-	orders, totalItems, err := s.repo.AllOrders(page, limit, name, codeOrder, status)
+func (s *orderService) Transition(id uint, action domain.OrderAction, input domain.OrderTransitionInput) (*domain.Order, error) {
+	order, err := s.repo.Transition(id, action, input)
+	if err != nil {
+		s.log.Error("Failed to transition order", zap.String("action", string(action)), zap.Error(err))
+		return nil, err
+	}
+	return order, nil
+}
+
+func (s *orderService) History(orderID uint) ([]domain.OrderStatusHistory, error) {
+	return s.repo.History(orderID)
+}
+
+func (s *orderService) AllOrders(page, limit int, name, codeOrder string, status domain.StatusPayment, orderType domain.OrderType) ([]*domain.OrderDetail, int64, error) {
+	orders, totalItems, err := s.repo.AllOrders(page, limit, name, codeOrder, status, orderType)
 	if err != nil {
 		return nil, 0, err
 	}
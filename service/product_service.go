@@ -11,8 +11,11 @@ import (
 type ProductService interface {
 	All(page, limit int, productStatus, categoryName, stock string, quantity int, minPrice, maxPrice float64) ([]*domain.Product, int64, error)
 	Add(input *domain.Product, categoryName string) (*domain.Product, error)
+	GetByID(id uint) (*domain.Product, error)
 	Update(id uint, ProductData *domain.Product, categoryName string) (*domain.Product, error)
 	Delete(id uint) error
+	// SetModifiers replaces the options offered with a product on the public menu
+	SetModifiers(id uint, modifiers []domain.ProductModifier) ([]domain.ProductModifier, error)
 }
 
 type productService struct {
@@ -64,6 +67,8 @@ func (s *productService) Add(input *domain.Product, categoryName string) (*domai
 		return nil, errors.New("price must be greater than 0")
 	}
 
+	input.Allergens = domain.NormalizeAllergens(input.Allergens)
+
 	// Panggil repository untuk menyimpan data
 	result, err := s.repo.Add(input, categoryName)
 	if err != nil {
@@ -75,8 +80,14 @@ func (s *productService) Add(input *domain.Product, categoryName string) (*domai
 	return result, nil
 }
 
+func (s *productService) GetByID(id uint) (*domain.Product, error) {
+	return s.repo.GetByID(id)
+}
+
 func (s *productService) Update(id uint, inventoryData *domain.Product, categoryName string) (*domain.Product, error) {
 
+	inventoryData.Allergens = domain.NormalizeAllergens(inventoryData.Allergens)
+
 	// Panggil repository untuk update inventory
 	updatedInventory, err := s.repo.Update(id, inventoryData, categoryName)
 	if err != nil {
@@ -97,3 +108,7 @@ func (s *productService) Delete(id uint) error {
 
 	return nil
 }
+
+func (s *productService) SetModifiers(id uint, modifiers []domain.ProductModifier) ([]domain.ProductModifier, error) {
+	return s.repo.SetModifiers(id, modifiers)
+}
@@ -0,0 +1,422 @@
+package service
+
+import (
+	"bytes"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"html/template"
+	"image"
+	_ "image/jpeg"
+	_ "image/png"
+	"net/http"
+	"os"
+	"project/config"
+	"project/domain"
+	"project/helper"
+	"project/repository"
+	"strings"
+	"time"
+
+	"github.com/go-pdf/fpdf"
+	"github.com/skip2/go-qrcode"
+	"go.uber.org/zap"
+)
+
+var ErrInvalidReceiptFormat = errors.New("format must be escpos, pdf or html")
+
+type ReceiptService interface {
+	// Receipt renders the customer receipt of an order without counting it, print is the number of prints so far
+	Receipt(orderID uint, format domain.ReceiptFormat) (content []byte, contentType string, print int, err error)
+	// Print renders the customer receipt of an order for the printer and counts it as printed, print is the new count
+	Print(orderID uint, format domain.ReceiptFormat) (content []byte, contentType string, print int, err error)
+	// KitchenTickets renders one ticket per station, or only the ticket of the given station
+	KitchenTickets(orderID uint, station string, format domain.ReceiptFormat) (content []byte, contentType string, err error)
+	// Public renders the HTML receipt of a signed public link
+	Public(token string) ([]byte, error)
+	// Email sends the HTML receipt of an order to the customer
+	Email(orderID uint, to string) error
+}
+
+type receiptService struct {
+	repo        repository.ReceiptRepository
+	email       EmailService
+	layout      domain.ReceiptLayout
+	url         string
+	feedbackUrl string
+	secret      []byte
+	linkTTL     time.Duration
+	log         *zap.Logger
+}
+
+func NewReceiptService(repo repository.ReceiptRepository, email EmailService, cfg config.ReceiptConfig, log *zap.Logger) ReceiptService {
+	layout := domain.ReceiptLayout{
+		Header:     splitLines(cfg.Header),
+		Footer:     splitLines(cfg.Footer),
+		Logo:       cfg.Logo,
+		PaperWidth: cfg.PaperWidth,
+	}
+	if layout.PaperWidth != 58 {
+		layout.PaperWidth = 80
+	}
+	linkTTL := time.Duration(cfg.LinkTTL) * 24 * time.Hour
+	if linkTTL <= 0 {
+		linkTTL = 30 * 24 * time.Hour
+	}
+	return &receiptService{
+		repo:        repo,
+		email:       email,
+		layout:      layout,
+		url:         cfg.Url,
+		feedbackUrl: cfg.FeedbackUrl,
+		secret:      []byte(cfg.Secret),
+		linkTTL:     linkTTL,
+		log:         log,
+	}
+}
+
+// receiptPage is the data of the receipt template, images are embedded as data URIs
+type receiptPage struct {
+	Receipt  *domain.Receipt
+	Reprints int
+	Logo     template.URL
+	QRCode   template.URL
+	Link     string
+}
+
+type kitchenPage struct {
+	Tickets [][]string
+}
+
+func (s *receiptService) Receipt(orderID uint, format domain.ReceiptFormat) ([]byte, string, int, error) {
+	if !validReceiptFormat(format) {
+		return nil, "", 0, ErrInvalidReceiptFormat
+	}
+
+	receipt, detail, err := s.build(orderID, "")
+	if err != nil {
+		return nil, "", 0, err
+	}
+
+	// a preview shows the receipt as it was last printed
+	receipt.Print = detail.ReceiptPrints
+	return s.render(receipt, format)
+}
+
+func (s *receiptService) Print(orderID uint, format domain.ReceiptFormat) ([]byte, string, int, error) {
+	if !validReceiptFormat(format) {
+		return nil, "", 0, ErrInvalidReceiptFormat
+	}
+
+	receipt, _, err := s.build(orderID, "")
+	if err != nil {
+		return nil, "", 0, err
+	}
+
+	receipt.Print, err = s.repo.CountPrint(orderID)
+	if err != nil {
+		return nil, "", 0, err
+	}
+	return s.render(receipt, format)
+}
+
+func (s *receiptService) render(receipt *domain.Receipt, format domain.ReceiptFormat) ([]byte, string, int, error) {
+	switch format {
+	case domain.ReceiptESCPOS:
+		return s.receiptESCPOS(receipt), "application/octet-stream", receipt.Print, nil
+	case domain.ReceiptPDF:
+		content, err := s.pdf(receipt.Lines(s.layout.Columns()), receipt.Link)
+		return content, "application/pdf", receipt.Print, err
+	default:
+		content, err := s.receiptHTML(receipt)
+		return content, "text/html; charset=utf-8", receipt.Print, err
+	}
+}
+
+func (s *receiptService) Public(token string) ([]byte, error) {
+	orderID, err := domain.ParseReceiptToken(token, s.secret, time.Now())
+	if err != nil {
+		return nil, err
+	}
+
+	// the page links back to the token it was opened with, so viewing it never extends the link
+	receipt, _, err := s.build(orderID, token)
+	if err != nil {
+		return nil, err
+	}
+	return s.receiptHTML(receipt)
+}
+
+func (s *receiptService) Email(orderID uint, to string) error {
+	receipt, _, err := s.build(orderID, "")
+	if err != nil {
+		return err
+	}
+	page, err := s.page(receipt)
+	if err != nil {
+		return err
+	}
+
+	if _, err := s.email.Send(to, "Your receipt for order "+receipt.CodeOrder, "receipt", page); err != nil {
+		s.log.Error("Failed to send receipt email", zap.Uint("order_id", orderID), zap.Error(err))
+		return err
+	}
+	return nil
+}
+
+// build reads the receipt of an order without counting it as printed. Without a token the public
+// link is signed to expire linkTTL after the order was placed, however often it is signed again.
+func (s *receiptService) build(orderID uint, token string) (*domain.Receipt, *domain.OrderDetail, error) {
+	detail, err := s.repo.OrderDetail(orderID)
+	if err != nil {
+		return nil, nil, err
+	}
+	// the receipt link needs no login, so it never shows how to reach the guest
+	detail.CustomerPhone, detail.DeliveryAddress = "", ""
+	payments, err := s.repo.Payments(orderID)
+	if err != nil {
+		return nil, nil, err
+	}
+	receipt, err := domain.NewReceipt(*detail, payments, s.layout)
+	if err != nil {
+		s.log.Error("Failed to build receipt", zap.Uint("order_id", orderID), zap.Error(err))
+		return nil, nil, err
+	}
+	if token == "" {
+		if token, err = domain.SignReceiptToken(orderID, detail.CreatedAt.Add(s.linkTTL), s.secret); err != nil {
+			s.log.Error("Failed to sign receipt link", zap.Uint("order_id", orderID), zap.Error(err))
+			return nil, nil, err
+		}
+	}
+	if s.url != "" {
+		receipt.Link = s.url + token
+	}
+	if s.feedbackUrl != "" && domain.StatusPayment(detail.StatusPayment) == domain.OrderCompleted {
+		receipt.FeedbackLink = s.feedbackUrl + token
+	}
+	return receipt, detail, nil
+}
+
+func (s *receiptService) KitchenTickets(orderID uint, station string, format domain.ReceiptFormat) ([]byte, string, error) {
+	if !validReceiptFormat(format) {
+		return nil, "", ErrInvalidReceiptFormat
+	}
+
+	detail, err := s.repo.OrderDetail(orderID)
+	if err != nil {
+		return nil, "", err
+	}
+	items, err := s.repo.KitchenItems(orderID, station)
+	if err != nil {
+		return nil, "", err
+	}
+	tickets := domain.NewKitchenTickets(*detail, items)
+	columns := s.layout.Columns()
+
+	switch format {
+	case domain.ReceiptESCPOS:
+		printer := helper.NewESCPOS()
+		for _, ticket := range tickets {
+			lines := ticket.Lines(columns)
+			printer.Align(helper.ESCPOSCenter).Bold(true).DoubleSize(true).Line(strings.TrimSpace(lines[0])).DoubleSize(false).Bold(false)
+			printer.Align(helper.ESCPOSLeft)
+			for _, line := range lines[1:] {
+				printer.Line(line)
+			}
+			printer.Feed(3).Cut()
+		}
+		return printer.Bytes(), "application/octet-stream", nil
+	case domain.ReceiptPDF:
+		var lines []string
+		for i, ticket := range tickets {
+			if i > 0 {
+				lines = append(lines, "")
+			}
+			lines = append(lines, ticket.Lines(columns)...)
+		}
+		content, err := s.pdf(lines, "")
+		return content, "application/pdf", err
+	default:
+		page := kitchenPage{}
+		for _, ticket := range tickets {
+			page.Tickets = append(page.Tickets, ticket.Lines(columns))
+		}
+		var body bytes.Buffer
+		if err := kitchenTemplate.Execute(&body, page); err != nil {
+			s.log.Error("Failed to render kitchen tickets", zap.Uint("order_id", orderID), zap.Error(err))
+			return nil, "", err
+		}
+		return body.Bytes(), "text/html; charset=utf-8", nil
+	}
+}
+
+func (s *receiptService) receiptESCPOS(receipt *domain.Receipt) []byte {
+	printer := helper.NewESCPOS().Align(helper.ESCPOSCenter)
+	if logo := s.logoImage(); logo != nil {
+		printer.Image(logo, s.layout.Dots()/2)
+	}
+	for _, line := range receipt.Layout.Header {
+		printer.Bold(true).Line(line).Bold(false)
+	}
+
+	// the header lines are printed centred by the printer itself
+	printer.Align(helper.ESCPOSLeft)
+	lines := receipt.Lines(s.layout.Columns())
+	for _, line := range lines[len(receipt.Layout.Header):] {
+		printer.Line(line)
+	}
+
+	if receipt.Link != "" {
+		printer.Align(helper.ESCPOSCenter).Feed(1).QRCode(receipt.Link, 6)
+	}
+	return printer.Feed(3).Cut().Bytes()
+}
+
+// page prepares the data of the receipt template, shared by the HTML receipt and the email
+func (s *receiptService) page(receipt *domain.Receipt) (receiptPage, error) {
+	page := receiptPage{Receipt: receipt, Reprints: receipt.Print - 1, Link: receipt.Link}
+	if logo, err := os.ReadFile(s.layout.Logo); s.layout.Logo != "" && err == nil {
+		page.Logo = dataURI(logo)
+	}
+	if receipt.Link != "" {
+		qr, err := qrcode.Encode(receipt.Link, qrcode.Medium, 256)
+		if err != nil {
+			return page, err
+		}
+		page.QRCode = dataURI(qr)
+	}
+	return page, nil
+}
+
+func (s *receiptService) receiptHTML(receipt *domain.Receipt) ([]byte, error) {
+	page, err := s.page(receipt)
+	if err != nil {
+		return nil, err
+	}
+
+	tmpl, err := template.ParseFiles("../email/receipt.html")
+	if err != nil {
+		s.log.Error("Failed to parse receipt template", zap.Error(err))
+		return nil, err
+	}
+	var body bytes.Buffer
+	if err := tmpl.Execute(&body, page); err != nil {
+		s.log.Error("Failed to render receipt", zap.String("code_order", receipt.CodeOrder), zap.Error(err))
+		return nil, err
+	}
+	return body.Bytes(), nil
+}
+
+// pdf lays the lines out in a monospaced font on a page as wide as the thermal paper
+func (s *receiptService) pdf(lines []string, link string) ([]byte, error) {
+	const (
+		margin     = 3.0
+		lineHeight = 3.2
+		qrSize     = 30.0
+	)
+	width := float64(s.layout.PaperWidth)
+	logoHeight := 0.0
+	logo, logoErr := os.ReadFile(s.layout.Logo)
+	if s.layout.Logo != "" && logoErr == nil {
+		logoHeight = width / 4
+	}
+	height := 2*margin + logoHeight + float64(len(lines))*lineHeight
+	if link != "" {
+		height += qrSize + margin
+	}
+
+	pdf := fpdf.NewCustom(&fpdf.InitType{UnitStr: "mm", Size: fpdf.SizeType{Wd: width, Ht: height}})
+	pdf.SetMargins(margin, margin, margin)
+	pdf.SetAutoPageBreak(false, 0)
+	pdf.AddPage()
+	pdf.SetFont("Courier", "", 7)
+	y := margin
+
+	if logoHeight > 0 {
+		options := fpdf.ImageOptions{ImageType: imageType(logo), ReadDpi: true}
+		pdf.RegisterImageOptionsReader("logo", options, bytes.NewReader(logo))
+		pdf.ImageOptions("logo", (width-logoHeight)/2, y, 0, logoHeight, false, options, 0, "")
+		y += logoHeight
+	}
+
+	for _, line := range lines {
+		pdf.SetXY(margin, y)
+		pdf.CellFormat(width-2*margin, lineHeight, pdf.UnicodeTranslatorFromDescriptor("")(line), "", 0, "L", false, 0, "")
+		y += lineHeight
+	}
+
+	if link != "" {
+		qr, err := qrcode.Encode(link, qrcode.Medium, 256)
+		if err != nil {
+			return nil, err
+		}
+		options := fpdf.ImageOptions{ImageType: "PNG"}
+		pdf.RegisterImageOptionsReader("qr", options, bytes.NewReader(qr))
+		pdf.ImageOptions("qr", (width-qrSize)/2, y+margin, qrSize, qrSize, false, options, 0, link)
+	}
+
+	var out bytes.Buffer
+	if err := pdf.Output(&out); err != nil {
+		s.log.Error("Failed to render receipt PDF", zap.Error(err))
+		return nil, err
+	}
+	return out.Bytes(), nil
+}
+
+// logoImage decodes the configured logo, nil when there is none or it cannot be read
+func (s *receiptService) logoImage() image.Image {
+	if s.layout.Logo == "" {
+		return nil
+	}
+	file, err := os.Open(s.layout.Logo)
+	if err != nil {
+		s.log.Warn("Failed to open receipt logo", zap.String("logo", s.layout.Logo), zap.Error(err))
+		return nil
+	}
+	defer file.Close()
+
+	img, _, err := image.Decode(file)
+	if err != nil {
+		s.log.Warn("Failed to decode receipt logo", zap.String("logo", s.layout.Logo), zap.Error(err))
+		return nil
+	}
+	return img
+}
+
+func validReceiptFormat(format domain.ReceiptFormat) bool {
+	switch format {
+	case domain.ReceiptESCPOS, domain.ReceiptPDF, domain.ReceiptHTML:
+		return true
+	}
+	return false
+}
+
+func splitLines(text string) []string {
+	if text == "" {
+		return nil
+	}
+	return strings.Split(text, "|")
+}
+
+func dataURI(content []byte) template.URL {
+	return template.URL(fmt.Sprintf("data:%s;base64,%s", http.DetectContentType(content), base64.StdEncoding.EncodeToString(content)))
+}
+
+func imageType(content []byte) string {
+	if http.DetectContentType(content) == "image/jpeg" {
+		return "JPG"
+	}
+	return "PNG"
+}
+
+var kitchenTemplate = template.Must(template.New("kitchen").Parse(`<!DOCTYPE html>
+<html lang="en">
+<head><meta charset="UTF-8"><title>Kitchen tickets</title>
+<style>pre { font-family: monospace; font-size: 14px; page-break-after: always; }</style>
+</head>
+<body>
+{{ range .Tickets }}<pre>{{ range . }}{{ . }}
+{{ end }}</pre>
+{{ end }}</body>
+</html>
+`))
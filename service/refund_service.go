@@ -0,0 +1,101 @@
+package service
+
+import (
+	"project/domain"
+	"project/helper"
+	"project/repository"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+type RefundService interface {
+	ReasonCodes() map[domain.ReasonCode]string
+	Void(input domain.VoidInput) (*domain.OrderVoid, error)
+	Refund(input domain.RefundInput) (*domain.Refund, error)
+	Voids(orderID uint) ([]domain.OrderVoid, error)
+	Refunds(orderID uint) ([]domain.Refund, error)
+}
+
+type refundService struct {
+	repo           repository.Repository
+	approvalAmount float64
+	log            *zap.Logger
+}
+
+func NewRefundService(repo repository.Repository, approvalAmount float64, log *zap.Logger) RefundService {
+	return &refundService{repo: repo, approvalAmount: approvalAmount, log: log}
+}
+
+func (s *refundService) ReasonCodes() map[domain.ReasonCode]string {
+	return domain.ReasonCodes
+}
+
+func (s *refundService) Void(input domain.VoidInput) (*domain.OrderVoid, error) {
+	if err := input.ReasonCode.Validate(input.Note); err != nil {
+		return nil, err
+	}
+
+	return s.repo.Refund.Void(input, s.approver(input.Approval))
+}
+
+func (s *refundService) Refund(input domain.RefundInput) (*domain.Refund, error) {
+	if err := input.ReasonCode.Validate(input.Note); err != nil {
+		return nil, err
+	}
+
+	return s.repo.Refund.Refund(input, s.approver(input.Approval))
+}
+
+func (s *refundService) Voids(orderID uint) ([]domain.OrderVoid, error) {
+	return s.repo.Refund.Voids(orderID)
+}
+
+func (s *refundService) Refunds(orderID uint) ([]domain.Refund, error) {
+	return s.repo.Refund.Refunds(orderID)
+}
+
+// approver checks the manager PIN only when the amount is above the configured approval amount.
+// Wrong PINs are counted on the manager and lock the PIN for a while once there are too many.
+func (s *refundService) approver(approval domain.ManagerApproval) repository.ApproveFunc {
+	return func(amount float64) (*uint, error) {
+		if amount <= s.approvalAmount {
+			return nil, nil
+		}
+
+		if approval.ManagerID == 0 || approval.Pin == "" {
+			return nil, domain.ErrManagerApprovalRequired
+		}
+
+		manager, err := s.repo.User.Get(domain.User{ID: approval.ManagerID})
+		if err != nil {
+			s.log.Warn("Approving manager not found", zap.Uint("manager_id", approval.ManagerID))
+			return nil, domain.ErrManagerApprovalInvalid
+		}
+
+		if manager.Role != domain.Admin && manager.Role != domain.SuperAdmin {
+			return nil, domain.ErrManagerApprovalInvalid
+		}
+
+		if manager.PinLocked(time.Now()) {
+			s.log.Warn("Manager pin locked", zap.Uint("manager_id", approval.ManagerID))
+			return nil, domain.ErrManagerPinLocked
+		}
+
+		if manager.Pin == "" || !helper.CheckPassword(approval.Pin, manager.Pin) {
+			s.log.Warn("Invalid manager pin", zap.Uint("manager_id", approval.ManagerID))
+			if err := s.repo.User.PinFailed(manager.ID, domain.MaxPinAttempts, domain.PinLockout); err != nil {
+				s.log.Error("Failed to count wrong manager pin", zap.Uint("manager_id", manager.ID), zap.Error(err))
+			}
+			return nil, domain.ErrManagerApprovalInvalid
+		}
+
+		if manager.PinFailures > 0 {
+			if err := s.repo.User.PinPassed(manager.ID); err != nil {
+				s.log.Error("Failed to clear wrong manager pins", zap.Uint("manager_id", manager.ID), zap.Error(err))
+			}
+		}
+
+		return &manager.ID, nil
+	}
+}
@@ -1,55 +1,104 @@
 package service
 
 import (
+	"bytes"
 	"errors"
+	"fmt"
+	"html/template"
+	"net/url"
+	"project/config"
 	"project/domain"
 	"project/repository"
+	"strings"
+	"time"
 
 	"go.uber.org/zap"
 )
 
 type ReservationService interface {
-	All(timeFilter string) ([]*domain.AllReservation, error)
+	All(filter domain.ReservationFilter) ([]domain.AllReservation, int64, error)
 	Add(reservationRequest *domain.Reservation) error
 	GetReservationByID(id uint) (*domain.Reservation, error)
-	Update(reservationID uint, updates map[string]interface{}) error
+	Update(reservationID, version uint, userID *uint, updates map[string]interface{}) error
+	Transition(id uint, action domain.ReservationAction, input domain.ReservationTransitionInput) (*domain.Reservation, error)
+	History(reservationID uint) ([]domain.ReservationStatusHistory, error)
+	CollectDeposit(id uint, input domain.DepositInput) (*domain.Reservation, error)
+	HoldTables() (int64, error)
+	Availability(query domain.AvailabilityQuery) ([]domain.AvailableSlot, error)
+	// SendReminders emails the guests whose booking starts within the reminder lead time, sent is how many were reminded
+	SendReminders() (sent int, err error)
+	// GuestLink renders the page of a signed guest link, the reservation is confirmed or cancelled when submit is set
+	GuestLink(token string, submit bool) ([]byte, error)
+	// Calendar exports the reservations from start up to end as an iCalendar file
+	Calendar(start, end time.Time) ([]byte, error)
+	// CalendarFeed creates a new secret feed URL for the user, an earlier one stops working
+	CalendarFeed(userID uint) (*domain.CalendarFeed, error)
+	DeleteCalendarFeed(userID uint) error
+	// SubscribedCalendar is the calendar of a feed token, from a month back to half a year ahead
+	SubscribedCalendar(token string) ([]byte, error)
 }
 
 type reservationService struct {
-	repo repository.ReservationRepository
-	log  *zap.Logger
+	repo         repository.ReservationRepository
+	email        EmailService
+	url          string
+	secret       []byte
+	calendarUrl  string
+	calendarHost string
+	reminderLead time.Duration
+	log          *zap.Logger
 }
 
-func NewReservationService(repo repository.ReservationRepository, log *zap.Logger) ReservationService {
-	return &reservationService{repo, log}
+func NewReservationService(repo repository.ReservationRepository, email EmailService, cfg config.ReservationConfig, log *zap.Logger) ReservationService {
+	reminderLead := time.Duration(cfg.ReminderLeadTime) * time.Minute
+	if reminderLead <= 0 {
+		reminderLead = 24 * time.Hour
+	}
+	return &reservationService{
+		repo:         repo,
+		email:        email,
+		url:          cfg.Url,
+		secret:       []byte(cfg.Secret),
+		calendarUrl:  cfg.CalendarUrl,
+		calendarHost: calendarHost(cfg),
+		reminderLead: reminderLead,
+		log:          log,
+	}
 }
 
-// All untuk mengambil semua reservasi berdasarkan filter waktu tertentu
-func (s *reservationService) All(timeFilter string) ([]*domain.AllReservation, error) {
-	reservations, err := s.repo.All(timeFilter)
-	if err != nil {
-		return nil, err
-	}
-	if len(reservations) == 0 {
-		return nil, errors.New("no reservations found")
+// reservationPage is the data of the reservation email and guest link templates
+type reservationPage struct {
+	Reservation *domain.Reservation
+	Date        string
+	Time        string
+	ConfirmLink string
+	CancelLink  string
+	// Action is what the guest link does, Done once it is done and Message when it cannot be done
+	Action  domain.ReservationAction
+	Done    bool
+	Message string
+}
+
+// All mengambil reservasi per halaman sesuai filter
+func (s *reservationService) All(filter domain.ReservationFilter) ([]domain.AllReservation, int64, error) {
+	if err := filter.Validate(); err != nil {
+		return nil, 0, err
 	}
-	return reservations, nil
+	return s.repo.All(filter)
 }
 
 func (s *reservationService) Add(reservationRequest *domain.Reservation) error {
-	// Validasi status hanya boleh Confirmed atau Canceled
-	if reservationRequest.Status != "Confirmed" {
-		return errors.New("status must be 'Confirmed' ")
+	// Reservasi baru menunggu konfirmasi atau langsung dikonfirmasi
+	if reservationRequest.Status == "" {
+		reservationRequest.Status = domain.ReservationConfirmed
 	}
-
-	// Validasi Pax Number (maksimal 8 orang)
-	if reservationRequest.PaxNumber > 8 {
-		return errors.New("pax number cannot exceed 8")
+	if reservationRequest.Status != domain.ReservationPending && reservationRequest.Status != domain.ReservationConfirmed {
+		return fmt.Errorf("%w: status must be 'Pending' or 'Confirmed'", domain.ErrInvalidReservation)
 	}
 
-	// Validasi Table Number (maksimal 7 table)
-	if reservationRequest.TableNumber > 7 {
-		return errors.New("table number cannot exceed 7")
+	// Kapasitas meja dan bentrok jadwal diperiksa repository terhadap meja yang dipesan
+	if reservationRequest.TableID == 0 {
+		return fmt.Errorf("%w: table_id is required", domain.ErrInvalidReservation)
 	}
 
 	// Validasi Reservation Date & Time (tidak boleh masa lalu)
@@ -65,6 +114,11 @@ func (s *reservationService) Add(reservationRequest *domain.Reservation) error {
 	}
 
 	s.log.Info("Reservation added successfully")
+	if reservationRequest.Status == domain.ReservationPending {
+		s.notify(reservationRequest, "Please confirm your reservation", "reservation_confirmation")
+	} else {
+		s.notify(reservationRequest, "Your reservation is confirmed", "reservation_confirmation")
+	}
 	return nil
 }
 
@@ -78,32 +132,248 @@ func (s *reservationService) GetReservationByID(id uint) (*domain.Reservation, e
 	return reservation, nil
 }
 
-func (s *reservationService) Update(reservationID uint, updates map[string]interface{}) error {
+func (s *reservationService) Update(reservationID, version uint, userID *uint, updates map[string]interface{}) error {
 	// Validasi input updates
 	if len(updates) == 0 {
-		return errors.New("only table number and status can edit")
+		return errors.New("only table and status can edit")
 	}
 
 	// Validasi data sebelum mengirim ke repository
 	if status, ok := updates["status"]; ok {
-		if status != "Canceled" {
-			return errors.New("status can only be updated to 'Canceled'")
-		}
-	}
-
-	if tableNumber, ok := updates["table_number"]; ok {
-		if tableNum, valid := tableNumber.(int); valid && tableNum > 7 {
-			return errors.New("table number cannot exceed 7")
+		if status != domain.ReservationCanceled {
+			return fmt.Errorf("%w: status can only be updated to 'Canceled'", domain.ErrInvalidReservationTransition)
 		}
 	}
 
 	// Panggil repository untuk update data
-	err := s.repo.Update(reservationID, updates)
+	err := s.repo.Update(reservationID, version, userID, updates)
 	if err != nil {
 		s.log.Error("Failed to update reservation", zap.Uint("id", reservationID), zap.Error(err))
 		return err
 	}
 
 	s.log.Info("Reservation updated successfully", zap.Uint("id", reservationID))
+	if _, ok := updates["status"]; ok {
+		if reservation, err := s.repo.GetByID(reservationID); err == nil {
+			s.notify(reservation, "Your reservation is cancelled", "reservation_cancellation")
+		}
+	}
 	return nil
 }
+
+func (s *reservationService) Transition(id uint, action domain.ReservationAction, input domain.ReservationTransitionInput) (*domain.Reservation, error) {
+	reservation, err := s.repo.Transition(id, action, input)
+	if err != nil {
+		s.log.Error("Failed to transition reservation", zap.String("action", string(action)), zap.Error(err))
+		return nil, err
+	}
+
+	switch action {
+	case domain.ReservationActionConfirm:
+		s.notify(reservation, "Your reservation is confirmed", "reservation_confirmation")
+	case domain.ReservationActionCancel:
+		s.notify(reservation, "Your reservation is cancelled", "reservation_cancellation")
+	}
+	return reservation, nil
+}
+
+func (s *reservationService) History(reservationID uint) ([]domain.ReservationStatusHistory, error) {
+	return s.repo.History(reservationID)
+}
+
+func (s *reservationService) CollectDeposit(id uint, input domain.DepositInput) (*domain.Reservation, error) {
+	if input.Amount < 0 {
+		return nil, fmt.Errorf("%w: amount must be positive", domain.ErrInvalidDeposit)
+	}
+	return s.repo.CollectDeposit(id, input)
+}
+
+// HoldTables marks the tables of reservations whose booking time has arrived as reserved, after
+// releasing the tables whose booking window has passed
+func (s *reservationService) HoldTables() (int64, error) {
+	now := time.Now()
+	if _, err := s.repo.ReleaseHolds(now); err != nil {
+		return 0, err
+	}
+	return s.repo.HoldTables(now)
+}
+
+func (s *reservationService) Availability(query domain.AvailabilityQuery) ([]domain.AvailableSlot, error) {
+	if query.Pax == 0 {
+		return nil, fmt.Errorf("%w: pax must be at least 1", domain.ErrInvalidReservation)
+	}
+	if query.Duration < 0 {
+		return nil, fmt.Errorf("%w: duration must be positive", domain.ErrInvalidReservation)
+	}
+	return s.repo.Availability(query, time.Now())
+}
+
+// SendReminders emails the guests of the confirmed reservations starting within the reminder lead time
+// and marks them reminded. A reservation whose email fails stays confirmed and is tried again on the next run.
+func (s *reservationService) SendReminders() (int, error) {
+	reservations, err := s.repo.DueReminders(time.Now(), s.reminderLead)
+	if err != nil {
+		return 0, err
+	}
+
+	sent := 0
+	for i := range reservations {
+		reservation := &reservations[i]
+		page := s.page(reservation)
+		if _, err := s.email.Send(reservation.EmailAddress, "Reminder: your reservation on "+page.Date, "reservation_reminder", page); err != nil {
+			s.log.Error("Failed to send reservation reminder", zap.Uint("id", reservation.ID), zap.Error(err))
+			continue
+		}
+		input := domain.ReservationTransitionInput{Reason: "reminder email sent"}
+		if _, err := s.repo.Transition(reservation.ID, domain.ReservationActionRemind, input); err != nil {
+			s.log.Error("Failed to mark reservation reminded", zap.Uint("id", reservation.ID), zap.Error(err))
+			continue
+		}
+		sent++
+	}
+
+	if sent > 0 {
+		s.log.Info("Reservation reminders sent", zap.Int("sent", sent))
+	}
+	return sent, nil
+}
+
+// GuestLink renders the page a guest opens from a reservation email. The reservation is only changed
+// when the page is submitted, so a mail client opening the link ahead of the guest changes nothing.
+func (s *reservationService) GuestLink(token string, submit bool) ([]byte, error) {
+	reservationID, action, err := domain.ParseReservationToken(token, s.secret, time.Now())
+	if err != nil {
+		return nil, err
+	}
+	reservation, err := s.repo.GetByID(reservationID)
+	if err != nil {
+		return nil, err
+	}
+
+	done := (action == domain.ReservationActionConfirm && reservation.Status != domain.ReservationPending && reservation.Status.Holds()) ||
+		(action == domain.ReservationActionCancel && reservation.Status == domain.ReservationCanceled)
+	var message string
+	switch {
+	case done:
+	case !reservation.Can(action):
+		message = fmt.Sprintf("This reservation is %s and can no longer be changed online, please contact us.", reservation.Status)
+	case submit:
+		input := domain.ReservationTransitionInput{Reason: "by the guest through the email link"}
+		if reservation, err = s.Transition(reservationID, action, input); err != nil {
+			return nil, err
+		}
+		done = true
+	}
+
+	page := s.page(reservation)
+	page.Action = action
+	page.Done = done
+	page.Message = message
+
+	tmpl, err := template.ParseFiles("../email/reservation_link.html")
+	if err != nil {
+		s.log.Error("Failed to parse reservation link template", zap.Error(err))
+		return nil, err
+	}
+	var body bytes.Buffer
+	if err := tmpl.Execute(&body, page); err != nil {
+		s.log.Error("Failed to render reservation link page", zap.Uint("id", reservationID), zap.Error(err))
+		return nil, err
+	}
+	return body.Bytes(), nil
+}
+
+func (s *reservationService) Calendar(start, end time.Time) ([]byte, error) {
+	if !end.After(start) {
+		return nil, fmt.Errorf("%w: end date must be after start date", domain.ErrInvalidReservation)
+	}
+	calendar, err := s.repo.Calendar(start, end)
+	if err != nil {
+		return nil, err
+	}
+	calendar.Name = "Reservations"
+	calendar.Host = s.calendarHost
+	return calendar.ICS(time.Now()), nil
+}
+
+func (s *reservationService) CalendarFeed(userID uint) (*domain.CalendarFeed, error) {
+	feed, err := domain.NewCalendarFeed(userID)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.repo.SaveCalendarFeed(feed); err != nil {
+		return nil, err
+	}
+	if s.calendarUrl != "" {
+		feed.Url = s.calendarUrl + feed.Token + ".ics"
+	}
+	return feed, nil
+}
+
+func (s *reservationService) DeleteCalendarFeed(userID uint) error {
+	return s.repo.DeleteCalendarFeed(userID)
+}
+
+func (s *reservationService) SubscribedCalendar(token string) ([]byte, error) {
+	if _, err := s.repo.CalendarFeedUser(strings.TrimSuffix(token, ".ics")); err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+	return s.Calendar(today.Add(-domain.CalendarFeedPast), today.Add(domain.CalendarFeedAhead))
+}
+
+// calendarHost is the host of the calendar or guest link URL, it keeps the event UIDs of this
+// restaurant apart from those of other calendars
+func calendarHost(cfg config.ReservationConfig) string {
+	for _, link := range []string{cfg.CalendarUrl, cfg.Url} {
+		if u, err := url.Parse(link); err == nil && u.Hostname() != "" {
+			return u.Hostname()
+		}
+	}
+	return "reservations.local"
+}
+
+// page fills the template data of a reservation with signed links for the changes the guest can
+// still make, valid until the booking starts
+func (s *reservationService) page(reservation *domain.Reservation) reservationPage {
+	page := reservationPage{Reservation: reservation}
+	start, err := reservation.Start()
+	if err != nil {
+		return page
+	}
+	page.Date = start.Format("Monday, 2 January 2006")
+	page.Time = start.Format("15:04")
+
+	if s.url == "" {
+		return page
+	}
+	if reservation.Can(domain.ReservationActionConfirm) {
+		page.ConfirmLink = s.link(reservation.ID, domain.ReservationActionConfirm, start)
+	}
+	if reservation.Can(domain.ReservationActionCancel) {
+		page.CancelLink = s.link(reservation.ID, domain.ReservationActionCancel, start)
+	}
+	return page
+}
+
+// link is the signed guest link for the action, left out of the email when it cannot be signed
+func (s *reservationService) link(reservationID uint, action domain.ReservationAction, expiresAt time.Time) string {
+	token, err := domain.SignReservationToken(reservationID, action, expiresAt, s.secret)
+	if err != nil {
+		s.log.Error("Failed to sign reservation link", zap.Uint("id", reservationID), zap.Error(err))
+		return ""
+	}
+	return s.url + token
+}
+
+// notify emails the guest about the reservation. The change is already saved, so a failed email is
+// only logged.
+func (s *reservationService) notify(reservation *domain.Reservation, subject, name string) {
+	if reservation.EmailAddress == "" {
+		return
+	}
+	if _, err := s.email.Send(reservation.EmailAddress, subject, name, s.page(reservation)); err != nil {
+		s.log.Error("Failed to send reservation email", zap.Uint("id", reservation.ID), zap.String("template", name), zap.Error(err))
+	}
+}
@@ -1,8 +1,10 @@
 package service
 
 import (
+	"errors"
 	"project/domain"
 	"project/repository"
+	"time"
 
 	"go.uber.org/zap"
 )
@@ -10,6 +12,9 @@ import (
 type RevenueService interface {
 	GetTotalRevenueByStatus() (map[string]interface{}, error)
 	GetMonthlyRevenue(statusPayment string, year int) (map[string]float64, error)
+	GetRevenueByOrderType(start, end time.Time) ([]domain.OrderTypeRevenue, error)
+	GetDepositRevenue(start, end time.Time) (*domain.DepositRevenue, error)
+	GetGiftCardLiability(start, end time.Time) (*domain.GiftCardLiability, error)
 	GetProductRevenueDetails() ([]*domain.BestSeller, error)
 	AddDailyBestSeller(profitMargin float64)
 }
@@ -31,6 +36,27 @@ func (s *revenueService) GetMonthlyRevenue(statusPayment string, year int) (map[
 	return s.repo.GetMonthlyRevenue(statusPayment, year)
 }
 
+func (s *revenueService) GetRevenueByOrderType(start, end time.Time) ([]domain.OrderTypeRevenue, error) {
+	if !end.After(start) {
+		return nil, errors.New("end date must be after start date")
+	}
+	return s.repo.GetRevenueByOrderType(start, end)
+}
+
+func (s *revenueService) GetDepositRevenue(start, end time.Time) (*domain.DepositRevenue, error) {
+	if !end.After(start) {
+		return nil, errors.New("end date must be after start date")
+	}
+	return s.repo.GetDepositRevenue(start, end)
+}
+
+func (s *revenueService) GetGiftCardLiability(start, end time.Time) (*domain.GiftCardLiability, error) {
+	if !end.After(start) {
+		return nil, errors.New("end date must be after start date")
+	}
+	return s.repo.GetGiftCardLiability(start, end)
+}
+
 func (s *revenueService) GetProductRevenueDetails() ([]*domain.BestSeller, error) {
 	return s.repo.GetProductRevenueDetails()
 }
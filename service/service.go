@@ -21,6 +21,18 @@ type Service struct {
  	Dashboard     DashboardService
 	UserPermission UserPermissionService
 	Revenue        RevenueService
+	Refund         RefundService
+	Discount       DiscountService
+	Tax            TaxService
+	TimeClock      TimeClockService
+	Receipt        ReceiptService
+	Table          TableService
+	Waitlist       WaitlistService
+	Customer       CustomerService
+	Loyalty        LoyaltyService
+	GiftCard       GiftCardService
+	Feedback       FeedbackService
+	Menu           MenuService
 }
 
 func NewService(repo repository.Repository, appConfig config.Config, log *zap.Logger) Service {
@@ -31,12 +43,24 @@ func NewService(repo repository.Repository, appConfig config.Config, log *zap.Lo
 		PasswordReset:  NewPasswordResetService(repo.PasswordReset, log),
 		User:           NewUserService(repo, log),
 		Notification:   NewNotificationService(repo, log),
-		Reservation:    NewReservationService(repo.Reservation, log),
+		Reservation:    NewReservationService(repo.Reservation, NewEmailService(appConfig.Email, log), appConfig.Reservation, log),
 		Category:       NewCategoryService(repo.Category, log),
 		Product:        NewProductService(repo.Product, log),
 		Order:          NewOrderService(repo.Order, log),
  		Dashboard:     NewDashboardService(repo.Dashboard, log),
 		UserPermission: NewUserPermissionService(repo.UserPermission, log),
 		Revenue:        NewRevenueService(repo.Revenue, log),
+		Refund:         NewRefundService(repo, appConfig.ManagerApprovalAmount, log),
+		Discount:       NewDiscountService(repo.Discount, log),
+		Tax:            NewTaxService(repo.Tax, log),
+		TimeClock:      NewTimeClockService(repo.TimeClock, appConfig.TipPoolPoints, log),
+		Table:          NewTableService(repo.Table, log),
+		Waitlist:       NewWaitlistService(repo.Waitlist, log),
+		Customer:       NewCustomerService(repo.Customer, log),
+		Loyalty:        NewLoyaltyService(repo.Loyalty, log),
+		GiftCard:       NewGiftCardService(repo.GiftCard, NewEmailService(appConfig.Email, log), log),
+		Feedback:       NewFeedbackService(repo.Feedback, appConfig.Receipt, log),
+		Menu:           NewMenuService(repo.Menu, log),
+		Receipt:        NewReceiptService(repo.Receipt, NewEmailService(appConfig.Email, log), appConfig.Receipt, log),
 	}
 }
@@ -0,0 +1,79 @@
+package service
+
+import (
+	"project/domain"
+	"project/repository"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+type TableService interface {
+	All(page, limit int, status domain.TableStatus, section string) ([]domain.Table, int64, error)
+	FindByID(id uint) (*domain.Table, error)
+	Create(table *domain.Table) error
+	Update(table *domain.Table) error
+	UpdateStatus(id uint, status domain.TableStatus) (*domain.Table, error)
+	Delete(id uint) error
+}
+
+type tableService struct {
+	repo repository.TableRepository
+	log  *zap.Logger
+}
+
+func NewTableService(repo repository.TableRepository, log *zap.Logger) TableService {
+	return &tableService{repo, log}
+}
+
+func (s *tableService) All(page, limit int, status domain.TableStatus, section string) ([]domain.Table, int64, error) {
+	tables, totalItems, err := s.repo.All(page, limit, status, section)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	now := time.Now()
+	for i := range tables {
+		seatedMinutes(&tables[i], now)
+	}
+	return tables, totalItems, nil
+}
+
+func (s *tableService) FindByID(id uint) (*domain.Table, error) {
+	var table domain.Table
+	if err := s.repo.FindByID(&table, id); err != nil {
+		return nil, err
+	}
+	seatedMinutes(&table, time.Now())
+	return &table, nil
+}
+
+func (s *tableService) Create(table *domain.Table) error {
+	if table.Shape == "" {
+		table.Shape = domain.TableSquare
+	}
+	return s.repo.Create(table)
+}
+
+func (s *tableService) Update(table *domain.Table) error {
+	if table.Shape == "" {
+		table.Shape = domain.TableSquare
+	}
+	if err := s.repo.Update(table); err != nil {
+		return err
+	}
+	seatedMinutes(table, time.Now())
+	return nil
+}
+
+func (s *tableService) UpdateStatus(id uint, status domain.TableStatus) (*domain.Table, error) {
+	return s.repo.UpdateStatus(id, status)
+}
+
+func (s *tableService) Delete(id uint) error {
+	return s.repo.Delete(id)
+}
+
+func seatedMinutes(table *domain.Table, now time.Time) {
+	table.SeatedMinutes = int(table.SeatedFor(now).Minutes())
+}
@@ -0,0 +1,81 @@
+package service
+
+import (
+	"errors"
+	"project/domain"
+	"project/repository"
+	"strings"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+type TaxService interface {
+	All() ([]domain.TaxRate, error)
+	FindByID(id uint) (*domain.TaxRate, error)
+	Create(rate *domain.TaxRate) error
+	Update(rate *domain.TaxRate) error
+	Delete(id uint) error
+	AssignToCategory(categoryID uint, rateIDs []uint) error
+	AssignToProduct(productID uint, rateIDs []uint) error
+	Summary(start, end time.Time, orderType domain.OrderType) ([]domain.TaxSummary, error)
+}
+
+type taxService struct {
+	repo repository.TaxRepository
+	log  *zap.Logger
+}
+
+func NewTaxService(repo repository.TaxRepository, log *zap.Logger) TaxService {
+	return &taxService{repo, log}
+}
+
+func (s *taxService) All() ([]domain.TaxRate, error) {
+	return s.repo.All()
+}
+
+func (s *taxService) FindByID(id uint) (*domain.TaxRate, error) {
+	var rate domain.TaxRate
+	if err := s.repo.FindByID(&rate, id); err != nil {
+		return nil, err
+	}
+	return &rate, nil
+}
+
+func (s *taxService) Create(rate *domain.TaxRate) error {
+	if err := rate.Validate(); err != nil {
+		return err
+	}
+	rate.Code = strings.ToUpper(strings.TrimSpace(rate.Code))
+	return s.repo.Create(rate)
+}
+
+func (s *taxService) Update(rate *domain.TaxRate) error {
+	if err := rate.Validate(); err != nil {
+		return err
+	}
+	if err := s.repo.FindByID(&domain.TaxRate{}, rate.ID); err != nil {
+		return err
+	}
+	rate.Code = strings.ToUpper(strings.TrimSpace(rate.Code))
+	return s.repo.Update(rate)
+}
+
+func (s *taxService) Delete(id uint) error {
+	return s.repo.Delete(id)
+}
+
+func (s *taxService) AssignToCategory(categoryID uint, rateIDs []uint) error {
+	return s.repo.AssignToCategory(categoryID, rateIDs)
+}
+
+func (s *taxService) AssignToProduct(productID uint, rateIDs []uint) error {
+	return s.repo.AssignToProduct(productID, rateIDs)
+}
+
+func (s *taxService) Summary(start, end time.Time, orderType domain.OrderType) ([]domain.TaxSummary, error) {
+	if !end.After(start) {
+		return nil, errors.New("end date must be after start date")
+	}
+	return s.repo.Summary(start, end, orderType)
+}
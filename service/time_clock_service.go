@@ -0,0 +1,125 @@
+package service
+
+import (
+	"errors"
+	"math"
+	"project/domain"
+	"project/repository"
+	"sort"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+type TimeClockService interface {
+	ClockIn(userID uint, note string) (*domain.TimeClock, error)
+	ClockOut(userID uint, note string) (*domain.TimeClock, error)
+	All(page, limit int, userID uint, start, end time.Time) ([]domain.TimeClock, int64, error)
+	TipPool(start, end time.Time, method domain.TipPoolMethod, source domain.TipPoolSource) (*domain.TipPool, error)
+}
+
+type timeClockService struct {
+	repo      repository.TimeClockRepository
+	tipPoints map[domain.UserRole]float64
+	log       *zap.Logger
+}
+
+func NewTimeClockService(repo repository.TimeClockRepository, tipPoints string, log *zap.Logger) TimeClockService {
+	points, err := domain.ParseTipPoints(tipPoints)
+	if err != nil {
+		log.Warn("Invalid tip pool points, every role gets one point", zap.Error(err))
+		points = map[domain.UserRole]float64{}
+	}
+	return &timeClockService{repo: repo, tipPoints: points, log: log}
+}
+
+func (s *timeClockService) ClockIn(userID uint, note string) (*domain.TimeClock, error) {
+	record := &domain.TimeClock{UserID: userID, ClockIn: time.Now(), Note: note}
+	if err := s.repo.ClockIn(record); err != nil {
+		return nil, err
+	}
+	return record, nil
+}
+
+func (s *timeClockService) ClockOut(userID uint, note string) (*domain.TimeClock, error) {
+	return s.repo.ClockOut(userID, time.Now(), note)
+}
+
+func (s *timeClockService) All(page, limit int, userID uint, start, end time.Time) ([]domain.TimeClock, int64, error) {
+	if !end.After(start) {
+		return nil, 0, errors.New("end date must be after start date")
+	}
+	return s.repo.All(page, limit, userID, start, end)
+}
+
+// TipPool shares the tips taken in [start, end) between the staff who worked in that period.
+// The schedule source uses each user's current shift for every day of the period.
+func (s *timeClockService) TipPool(start, end time.Time, method domain.TipPoolMethod, source domain.TipPoolSource) (*domain.TipPool, error) {
+	if !end.After(start) {
+		return nil, errors.New("end date must be after start date")
+	}
+
+	total, err := s.repo.TotalTips(start, end)
+	if err != nil {
+		return nil, err
+	}
+
+	shares := make(map[uint]*domain.TipShare)
+	share := func(user domain.User) *domain.TipShare {
+		if _, ok := shares[user.ID]; !ok {
+			points, ok := s.tipPoints[user.Role]
+			if !ok {
+				points = 1
+			}
+			shares[user.ID] = &domain.TipShare{UserID: user.ID, FullName: user.FullName, Role: user.Role, Points: points}
+		}
+		return shares[user.ID]
+	}
+
+	switch source {
+	case domain.TipPoolFromSchedule:
+		users, err := s.repo.ScheduledStaff()
+		if err != nil {
+			return nil, err
+		}
+		for _, user := range users {
+			hours, err := domain.ScheduledHours(user.ShiftStart, user.ShiftEnd, start, end)
+			if err != nil {
+				s.log.Warn("Skipping unreadable shift", zap.Uint("user_id", user.ID), zap.Error(err))
+				continue
+			}
+			if hours > 0 {
+				share(user).Hours += hours
+			}
+		}
+	default:
+		records, err := s.repo.Worked(start, end)
+		if err != nil {
+			return nil, err
+		}
+		now := time.Now()
+		for _, record := range records {
+			share(record.User).Hours += domain.ClockedHours(record, start, end, now)
+		}
+	}
+
+	list := make([]domain.TipShare, 0, len(shares))
+	for _, item := range shares {
+		list = append(list, *item)
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].UserID < list[j].UserID })
+
+	list = domain.DistributeTips(total, list, method)
+	for i := range list {
+		list[i].Hours = math.Round(list[i].Hours*100) / 100
+	}
+
+	return &domain.TipPool{
+		Start:     start,
+		End:       end,
+		Method:    method,
+		Source:    source,
+		TotalTips: total,
+		Shares:    list,
+	}, nil
+}
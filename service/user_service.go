@@ -21,6 +21,7 @@ type UserService interface {
 	Update(user domain.User) error
 	GetByID(userInput domain.User) (*domain.User, error)
 	UpdateShift() error
+	UpdatePin(id uint, pin string) error
 }
 
 type userService struct {
@@ -203,6 +204,20 @@ func (s *userService) UpdateShift() error {
 	return nil
 }
 
+// UpdatePin stores the hashed PIN an admin uses to approve voids and refunds
+func (s *userService) UpdatePin(id uint, pin string) error {
+	user, err := s.repo.User.Get(domain.User{ID: id})
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return errors.New("user not found")
+	}
+	if err != nil {
+		return err
+	}
+
+	user.Pin = helper.HashPassword(pin)
+	return s.repo.User.Update(user)
+}
+
 type Shift struct {
 	StartTime string
 	EndTime   string
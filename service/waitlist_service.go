@@ -0,0 +1,50 @@
+package service
+
+import (
+	"project/domain"
+	"project/repository"
+
+	"go.uber.org/zap"
+)
+
+type WaitlistService interface {
+	All(page, limit int, status domain.WaitlistStatus, phone string) ([]domain.WaitlistEntry, int64, error)
+	FindByID(id uint) (*domain.WaitlistEntry, error)
+	Create(entry *domain.WaitlistEntry) error
+	Notify(id uint) (*domain.WaitlistEntry, error)
+	Seat(id, tableID uint) (*domain.WaitlistEntry, error)
+	Close(id uint, status domain.WaitlistStatus) (*domain.WaitlistEntry, error)
+}
+
+type waitlistService struct {
+	repo repository.WaitlistRepository
+	log  *zap.Logger
+}
+
+func NewWaitlistService(repo repository.WaitlistRepository, log *zap.Logger) WaitlistService {
+	return &waitlistService{repo, log}
+}
+
+func (s *waitlistService) All(page, limit int, status domain.WaitlistStatus, phone string) ([]domain.WaitlistEntry, int64, error) {
+	return s.repo.All(page, limit, status, phone)
+}
+
+func (s *waitlistService) FindByID(id uint) (*domain.WaitlistEntry, error) {
+	return s.repo.FindByID(id)
+}
+
+func (s *waitlistService) Create(entry *domain.WaitlistEntry) error {
+	return s.repo.Create(entry)
+}
+
+func (s *waitlistService) Notify(id uint) (*domain.WaitlistEntry, error) {
+	return s.repo.Notify(id)
+}
+
+func (s *waitlistService) Seat(id, tableID uint) (*domain.WaitlistEntry, error) {
+	return s.repo.Seat(id, tableID)
+}
+
+func (s *waitlistService) Close(id uint, status domain.WaitlistStatus) (*domain.WaitlistEntry, error) {
+	return s.repo.Close(id, status)
+}